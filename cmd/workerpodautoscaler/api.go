@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	workerpodautoscalercontroller "github.com/practo/k8s-worker-pod-autoscaler/pkg/controller"
+)
+
+// wpaAPIPrefix is the path of the live status api of the WPAs,
+// the status of a WPA is at /api/wpa/<namespace>/<name>
+const wpaAPIPrefix = "/api/wpa/"
+
+// wpaScaleAction is the path of the manual scale of a WPA,
+// it is at /api/wpa/<namespace>/<name>/scale?replicas=<n>
+const wpaScaleAction = "scale"
+
+// liveStatusGetter computes the live status of a WPA
+type liveStatusGetter interface {
+	GetLiveStatus(namespace string, name string) (
+		*workerpodautoscalercontroller.LiveStatus, error)
+}
+
+// namespacedStatusGetter routes the live status of a WPA to the
+// controller of its namespace when many namespaces are watched
+type namespacedStatusGetter map[string]*workerpodautoscalercontroller.Controller
+
+func (n namespacedStatusGetter) GetLiveStatus(namespace string, name string) (
+	*workerpodautoscalercontroller.LiveStatus, error) {
+
+	controller, ok := n[namespace]
+	if !ok {
+		return nil, errors.NewNotFound(v1.Resource("workerpodautoscaler"), name)
+	}
+	return controller.GetLiveStatus(namespace, name)
+}
+
+// manualScaler scales the workload of a WPA manually
+type manualScaler interface {
+	ManualScale(ctx context.Context, namespace string, name string,
+		replicas int32, ttl time.Duration) error
+}
+
+func (n namespacedStatusGetter) ManualScale(ctx context.Context,
+	namespace string, name string, replicas int32, ttl time.Duration) error {
+
+	controller, ok := n[namespace]
+	if !ok {
+		return errors.NewNotFound(v1.Resource("workerpodautoscaler"), name)
+	}
+	return controller.ManualScale(ctx, namespace, name, replicas, ttl)
+}
+
+// wpaScaleFunc handles the manual scale of the WPA
+type wpaScaleFunc func(w http.ResponseWriter, r *http.Request,
+	namespace string, name string)
+
+// wpaAPIHandler returns the live desired, current, backlog and the scale
+// eligibility of the WPA as json. It is read-only and returns the same
+// data as the metrics, so it is not authenticated. The manual scales are
+// handled by scale, they are not found if it is nil.
+func wpaAPIHandler(getter liveStatusGetter, scale wpaScaleFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, wpaAPIPrefix), "/")
+		if len(parts) == 3 && parts[2] == wpaScaleAction &&
+			parts[0] != "" && parts[1] != "" && scale != nil {
+			scale(w, r, parts[0], parts[1])
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			http.Error(w, "expected "+wpaAPIPrefix+"<namespace>/<name>",
+				http.StatusBadRequest)
+			return
+		}
+
+		status, err := getter.GetLiveStatus(parts[0], parts[1])
+		switch {
+		case errors.IsNotFound(err):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err == workerpodautoscalercontroller.ErrQueueNotSynced:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			klog.Errorf("Error writing wpa api response: %v", err)
+		}
+	}
+}
+
+// wpaScaleHandler scales the workload of the WPA to the replicas and
+// pauses its automatic scaling for the ttl. It changes the workload, so
+// the request must have the header: Authorization: Bearer <debugToken>
+func wpaScaleHandler(
+	scaler manualScaler, debugToken string, ttl time.Duration) wpaScaleFunc {
+
+	return func(w http.ResponseWriter, r *http.Request,
+		namespace string, name string) {
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !isAuthorized(r, debugToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		replicas, err := strconv.ParseInt(r.URL.Query().Get("replicas"), 10, 32)
+		if err != nil || replicas < 0 {
+			http.Error(w, "expected replicas=<n> not less than 0",
+				http.StatusBadRequest)
+			return
+		}
+
+		err = scaler.ManualScale(r.Context(), namespace, name, int32(replicas), ttl)
+		switch {
+		case errors.IsNotFound(err):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
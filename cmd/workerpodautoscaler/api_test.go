@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// fakeManualScaler records the manual scales of the existing WPAs
+type fakeManualScaler struct {
+	wpas     map[string]bool
+	scaled   string
+	replicas int32
+	ttl      time.Duration
+}
+
+func (f *fakeManualScaler) ManualScale(ctx context.Context,
+	namespace string, name string, replicas int32, ttl time.Duration) error {
+
+	if !f.wpas[namespace+"/"+name] {
+		return errors.NewNotFound(v1.Resource("workerpodautoscaler"), name)
+	}
+	f.scaled = namespace + "/" + name
+	f.replicas = replicas
+	f.ttl = ttl
+	return nil
+}
+
+func TestWPAScaleHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+		expectedScaled string
+	}{
+		{
+			name:           "wrong method",
+			method:         http.MethodGet,
+			path:           "/api/wpa/testns/otpsender/scale?replicas=2",
+			token:          "secret",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "missing token",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/otpsender/scale?replicas=2",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "wrong token",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/otpsender/scale?replicas=2",
+			token:          "guess",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing replicas",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/otpsender/scale",
+			token:          "secret",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "replicas not a number",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/otpsender/scale?replicas=two",
+			token:          "secret",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative replicas",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/otpsender/scale?replicas=-1",
+			token:          "secret",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "wpa not found",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/mailer/scale?replicas=2",
+			token:          "secret",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "scaled",
+			method:         http.MethodPost,
+			path:           "/api/wpa/testns/otpsender/scale?replicas=2",
+			token:          "secret",
+			expectedStatus: http.StatusNoContent,
+			expectedScaled: "testns/otpsender",
+		},
+	}
+
+	for _, test := range tests {
+		scaler := &fakeManualScaler{wpas: map[string]bool{"testns/otpsender": true}}
+		handler := wpaAPIHandler(nil, wpaScaleHandler(scaler, "secret", time.Minute))
+
+		request := httptest.NewRequest(test.method, test.path, nil)
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.expectedStatus {
+			t.Errorf("%s: expected status %d, got=%d\n",
+				test.name, test.expectedStatus, recorder.Code)
+		}
+		if scaler.scaled != test.expectedScaled {
+			t.Errorf("%s: expected %q to be scaled, got=%q\n",
+				test.name, test.expectedScaled, scaler.scaled)
+		}
+		if test.expectedScaled != "" && (scaler.replicas != 2 || scaler.ttl != time.Minute) {
+			t.Errorf("%s: expected 2 replicas for a minute, got=%d for %v\n",
+				test.name, scaler.replicas, scaler.ttl)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/practo/klog/v2"
+
+	queue "github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// debugQueuesHandler returns the in-memory state of the queues as json.
+// The request must have the header: Authorization: Bearer <debugToken>
+func debugQueuesHandler(
+	queues *queue.Queues, debugToken string) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAuthorized(r, debugToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(queues.ListStatus()); err != nil {
+			klog.Errorf("Error writing debug queues response: %v", err)
+		}
+	}
+}
+
+func isAuthorized(r *http.Request, token string) bool {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return false
+	}
+	requestToken := strings.TrimPrefix(authorization, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) == 1
+}
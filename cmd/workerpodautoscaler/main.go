@@ -30,11 +30,15 @@ func init() {
 func main() {
 	versionCommand := (&versionCmd{}).new()
 	runCommand := (&runCmd{}).new()
+	validateCommand := (&validateCmd{}).new()
+	simulateCommand := (&simulateCmd{}).new()
 
 	// add main commands
 	rootCmd.AddCommand(
 		versionCommand,
 		runCommand,
+		validateCommand,
+		simulateCommand,
 	)
 
 	cmdutil.CheckErr(rootCmd.Execute())
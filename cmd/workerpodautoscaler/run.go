@@ -6,24 +6,39 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/practo/klog/v2"
 	"github.com/practo/promlog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/practo/k8s-worker-pod-autoscaler/pkg/cmdutil"
 	"github.com/practo/k8s-worker-pod-autoscaler/pkg/signals"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
 
 	workerpodautoscalercontroller "github.com/practo/k8s-worker-pod-autoscaler/pkg/controller"
 	clientset "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned"
 	informers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/informers/externalversions"
+	wpainformers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/informers/externalversions/workerpodautoscaler/v1"
 	queue "github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/sink"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/webhook"
 )
 
 type runCmd struct {
@@ -51,35 +66,119 @@ func (v *runCmd) new() *cobra.Command {
 		"resync-period",
 		"wpa-threads",
 		"wpa-default-max-disruption",
+		"update-retry-steps",
+		"update-retry-duration",
+		"update-retry-factor",
+		"max-scale-ups-per-minute",
+		"max-queues-per-backend",
+		"scaling-stuck-window",
+		"crash-loop-restarts",
+		"crash-loop-window",
+		"count-terminating-workers",
+		"annotation-driven",
+		"label-targets",
+		"gc-on-target-delete",
+		"scale-failure-threshold",
+		"scale-failure-cooldown",
+		"slow-reconcile-threshold",
+		"processing-time-mismatch-factor",
+		"fast-scale-up-interval",
 		"aws-regions",
+		"aws-endpoint",
 		"kube-config",
 		"sqs-short-poll-interval",
 		"sqs-long-poll-interval",
+		"sqs-queue-attributes",
+		"sqs-queue-discovery-interval",
+		"sqs-max-poll-interval",
+		"sqs-max-calls-per-minute",
+		"sqs-message-weight-sample-interval",
 		"beanstalk-short-poll-interval",
 		"beanstalk-long-poll-interval",
+		"prometheus-poll-interval",
+		"datadog-poll-interval",
 		"queue-services",
 		"metrics-port",
+		"metrics-bind-address",
+		"metrics-path",
+		"metrics-default-collectors",
+		"metrics-cardinality",
 		"k8s-api-qps",
 		"k8s-api-burst",
 		"namespace",
+		"watch-namespaces",
+		"otel-endpoint",
+		"debug-token",
+		"manual-scale-ttl",
+		"webhook-port",
+		"webhook-cert-file",
+		"webhook-key-file",
+		"kafka-brokers",
+		"kafka-topic",
+		"kafka-sasl-mechanism",
+		"kafka-sasl-username",
+		"kafka-sasl-password",
+		"kafka-tls",
 	}
 
 	flags.Int("scale-down-delay-after-last-scale-activity", 600, "scale down delay after last scale up or down in seconds")
 	flags.Int("resync-period", 20, "maximum sync period for the control loop but the control loop can execute sooner if the wpa status object gets updated.")
-	flags.Int("wpa-threads", 10, "wpa threadiness, number of threads to process wpa resources")
+	flags.Int("wpa-threads", 10, "wpa threadiness, number of threads to process wpa resources, they are split between the namespaces of --watch-namespaces")
 	flags.String("wpa-default-max-disruption", "100%", "it is the default value for the maxDisruption in the WPA spec. This specifies how much percentage of pods can be disrupted in a single scale down acitivity. Can be expressed as integers or as a percentage.")
+	flags.Int("update-retry-steps", 5, "maximum number of attempts to update the deployment or replicaset on conflicts")
+	flags.Int("update-retry-duration", 10, "the duration (in milliseconds) to wait before retrying the update of the deployment or replicaset on conflicts")
+	flags.Float64("update-retry-factor", 1.0, "the factor by which the update retry duration is multiplied after every retry")
+	flags.Int("max-scale-ups-per-minute", 0, "maximum number of scale up operations across all the wpa resources in a minute, the rest are deferred until allowed. 0 means no limit")
+	flags.Int("max-queues-per-backend", 0, "maximum number of queues polled at once by every queue service, the rest wait for their turn in the order they were added. 0 means no limit")
+	flags.Int("scaling-stuck-window", 600, "the duration (in seconds) after which the available replicas stalled below the replicas of the workload stop the scale ups of the wpa. 0 means the scale ups are never stopped")
+	flags.Int("crash-loop-restarts", 0, "number of restarts of a container after which its pod is crash looping if it failed within the crash-loop-window, the scale ups of the wpa are stopped while most of its pods are crash looping. 0 means the pods are not checked")
+	flags.Int("crash-loop-window", 600, "the duration (in seconds) within which the last failure of a container counts towards the crash-loop-restarts")
+	flags.Bool("count-terminating-workers", false, "count the terminating pods of the workload in the current workers of the scale decision, so that the scale downs do not disrupt more than the maxDisruption while the pods drain in their termination grace period")
+	flags.Bool("annotation-driven", false, "scale the deployments annotated with workerpodautoscaler.practo.com/queue-uri, target-per-worker and max-replicas without a wpa object")
+	flags.Bool("label-targets", true, "set the label workerpodautoscaler.practo.com/managed-by=<wpa-name> on the deployments, replicasets and statefulsets scaled by the wpa resources")
+	flags.Bool("gc-on-target-delete", false, "set the deployment, replicaset or statefulset scaled by a wpa as the owner of the wpa, so that the wpa is garbage collected when its workload is deleted")
+	flags.Int("scale-failure-threshold", 5, "number of consecutive failures to scale the workload of a wpa after which its scaling is stopped for the scale-failure-cooldown. 0 means the scaling is never stopped")
+	flags.Int("scale-failure-cooldown", 300, "the duration (in seconds) for which the scaling of a wpa is stopped after repeated failures, one scale is tried after it")
+	flags.Int("slow-reconcile-threshold", 5, "the duration (in seconds) after which a reconcile of a wpa is logged as a warning with the time taken by the queue sync, the update of the workload and the update of the status. 0 means the slow reconciles are not logged")
+	flags.Float64("processing-time-mismatch-factor", 10, "the factor by which the secondsToProcessOneJob of a wpa and the processing time observed from its backlog differ before a warning event is recorded and the ProcessingTimeMismatch condition is set. 0 means the processing time is not checked")
+	flags.Int("fast-scale-up-interval", 0, "the duration (in seconds) after which the fast loop polls the queues of the wpas with a fastScaleUpThreshold again and syncs the ones above it for a scale up without the scale up delay. 0 means the fast loop is not run")
 	flags.String("aws-regions", "ap-south-1,ap-southeast-1", "comma separated aws regions of SQS")
+	flags.String("aws-endpoint", "", "overrides the endpoint of the aws apis (sqs and cloudwatch), useful for testing against LocalStack")
 	flags.String("kube-config", "", "path of the kube config file, if not specified in cluster config is used")
 	flags.Int("sqs-short-poll-interval", 20, "the duration (in seconds) after which the next sqs api call is made to fetch the queue length")
-	flags.Int("sqs-long-poll-interval", 20, "the duration (in seconds) for which the sqs receive message call waits for a message to arrive")
+	flags.Int("sqs-long-poll-interval", 20, "the duration (in seconds) for which the sqs receive message call waits for a message to arrive, it is kept below the sqs-short-poll-interval")
+	flags.String("sqs-queue-attributes", "ApproximateNumberOfMessages,ApproximateNumberOfMessagesNotVisible", "comma separated sqs queue attributes requested by every poll, ApproximateNumberOfMessagesDelayed can be added to count the delayed messages in the backlog")
+	flags.Int("sqs-queue-discovery-interval", 300, "the duration (in seconds) after which the sqs queues matching the queuePrefix of a WPA are listed again")
+	flags.Int("sqs-max-poll-interval", 0, "the longest duration (in seconds) between the polls of an sqs queue whose backlog did not change, the wait is doubled with every poll of the stable queue and is reset to the sqs-short-poll-interval when the backlog changes. 0 means the queues are always polled at the sqs-short-poll-interval")
+	flags.Int("sqs-max-calls-per-minute", 0, "maximum number of GetQueueAttributes calls made for an sqs queue in a minute, the polls over it wait for the next minute. 0 means no limit")
+	flags.Int("sqs-message-weight-sample-interval", 300, "the duration (in seconds) after which the messages of an sqs queue with messageWeights are sampled again, every sample increments the ApproximateReceiveCount of the sampled messages. It is at least the sqs-short-poll-interval")
 	flags.Int("beanstalk-short-poll-interval", 20, "the duration (in seconds) after which the next beanstalk api call is made to fetch the queue length")
 	flags.Int("beanstalk-long-poll-interval", 20, "the duration (in seconds) for which the beanstalk receive message call waits for a message to arrive")
-	flags.String("queue-services", "sqs,beanstalkd", "comma separated queue services, the WPA will start with")
-	flags.String("metrics-port", ":8787", "specify where to serve the /metrics and /status endpoint. /metrics serve the prometheus metrics for WPA")
+	flags.Int("prometheus-poll-interval", 20, "the duration (in seconds) after which the next prometheus query is made to fetch the backlog")
+	flags.Int("datadog-poll-interval", 60, "the duration (in seconds) after which the next datadog query is made to fetch the backlog")
+	flags.String("queue-services", "sqs,beanstalkd,prometheus,datadog", "comma separated queue services, the WPA will start with")
+	flags.String("metrics-port", ":8787", "specify where to serve the /metrics and /status endpoint. /metrics serve the prometheus metrics for WPA. Deprecated, use --metrics-bind-address")
+	flags.String("metrics-bind-address", "", "host:port to serve the metrics, /status, /api/wpa and /debug/queues endpoints on, defaults to --metrics-port")
+	flags.String("metrics-path", "/metrics", "path to serve the prometheus metrics of WPA on")
+	flags.Bool("metrics-default-collectors", true, "export the go runtime (go_*) and process (process_*) metrics along with the WPA metrics")
+	flags.String("metrics-cardinality", "high", "high exports the wpa metrics labelled by the queueName and the metrics by the scale reason, algorithm, cooldown direction and schedule. low exports the wpa metrics with an empty queueName and drops the metrics partitioned further than the wpa, for the clusters with many wpas")
 	flags.Float64("k8s-api-qps", 5.0, "qps indicates the maximum QPS to the k8s api from the clients(wpa).")
 	flags.Int("k8s-api-burst", 10, "maximum burst for throttle between requests from clients(wpa) to k8s api")
 
 	flags.String("namespace", "", "specify the namespace to listen to")
+	flags.String("watch-namespaces", "", "comma separated namespaces to watch the wpa resources and their workloads in, every namespace is watched by its own informers. Cannot be used with --namespace, all the namespaces are watched if neither is specified")
+	flags.String("debug-token", "", "bearer token required to access the /debug/queues endpoint, the endpoint is disabled if not specified")
+	flags.Int("manual-scale-ttl", 0, "the duration (in seconds) for which the automatic scaling of a wpa is paused after its workload is scaled with POST /api/wpa/<namespace>/<name>/scale?replicas=<n>, the endpoint requires the debug-token. 0 means the endpoint is disabled, it is meant for the lower environments only")
+	flags.String("webhook-port", ":8443", "specify where to serve the /mutate and /validate endpoints of the admission webhooks which stamp the defaults on the wpa resources and reject the invalid ones")
+	flags.String("webhook-cert-file", "", "path of the TLS certificate of the webhook, the webhook is disabled if not specified")
+	flags.String("webhook-key-file", "", "path of the TLS private key of the webhook")
+	flags.String("kafka-brokers", "", "comma separated kafka brokers to publish a JSON record of every scale operation to, the scale events are not published if not specified")
+	flags.String("kafka-topic", "", "kafka topic of the scale events")
+	flags.String("kafka-sasl-mechanism", "", "sasl mechanism to authenticate with the kafka brokers: plain, scram-sha-256 or scram-sha-512. SASL is not used if not specified")
+	flags.String("kafka-sasl-username", "", "sasl username of the kafka brokers")
+	flags.String("kafka-sasl-password", "", "sasl password of the kafka brokers, prefer setting it with the WORKERPODAUTOSCALER_KAFKA_SASL_PASSWORD environment variable")
+	flags.Bool("kafka-tls", false, "connect to the kafka brokers over TLS")
+	flags.String("otel-endpoint", "", "OTLP http endpoint to export the OpenTelemetry traces to, e.g. http://otel-collector:4318. Tracing is disabled if not specified")
 	for _, flagName := range flagNames {
 		if err := v.BindFlag(flagName); err != nil {
 			fmt.Println(err)
@@ -99,6 +198,37 @@ func parseRegions(regionNames string) []string {
 	return awsRegions
 }
 
+// parseNamespaces returns the namespaces watched by the informers,
+// the empty namespace watches all the namespaces
+func parseNamespaces(namespace string, watchNamespaces string) []string {
+	namespaces := parseQueueAttributes(watchNamespaces)
+	if len(namespaces) == 0 {
+		return []string{namespace}
+	}
+	if namespace != "" {
+		klog.Fatalf("--namespace and --watch-namespaces cannot be used together")
+	}
+	seen := make(map[string]bool)
+	var unique []string
+	for _, ns := range namespaces {
+		if !seen[ns] {
+			seen[ns] = true
+			unique = append(unique, ns)
+		}
+	}
+	return unique
+}
+
+func parseQueueAttributes(attributeNames string) []string {
+	var attributes []string
+	for _, name := range strings.Split(attributeNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			attributes = append(attributes, name)
+		}
+	}
+	return attributes
+}
+
 func (v *runCmd) run(cmd *cobra.Command, args []string) {
 	scaleDownDelay := time.Second * time.Duration(
 		v.Viper.GetInt("scale-down-delay-after-last-scale-activity"),
@@ -108,25 +238,108 @@ func (v *runCmd) run(cmd *cobra.Command, args []string) {
 	)
 	wpaThraeds := v.Viper.GetInt("wpa-threads")
 	wpaDefaultMaxDisruption := v.Viper.GetString("wpa-default-max-disruption")
+	updateRetry := wait.Backoff{
+		Steps: v.Viper.GetInt("update-retry-steps"),
+		Duration: time.Millisecond * time.Duration(
+			v.Viper.GetInt("update-retry-duration")),
+		Factor: v.Viper.GetFloat64("update-retry-factor"),
+		Jitter: 0.1,
+	}
+	maxScaleUpsPerMinute := v.Viper.GetInt("max-scale-ups-per-minute")
+	maxQueuesPerBackend := v.Viper.GetInt("max-queues-per-backend")
+	scalingStuckWindow := time.Second * time.Duration(
+		v.Viper.GetInt("scaling-stuck-window"))
+	crashLoopRestarts := int32(v.Viper.GetInt("crash-loop-restarts"))
+	crashLoopWindow := time.Second * time.Duration(
+		v.Viper.GetInt("crash-loop-window"))
+	countTerminatingWorkers := v.Viper.GetBool("count-terminating-workers")
+	annotationDriven := v.Viper.GetBool("annotation-driven")
+	labelTargets := v.Viper.GetBool("label-targets")
+	gcOnTargetDelete := v.Viper.GetBool("gc-on-target-delete")
+	scaleFailureThreshold := v.Viper.GetInt("scale-failure-threshold")
+	scaleFailureCooldown := time.Second * time.Duration(
+		v.Viper.GetInt("scale-failure-cooldown"))
+	slowReconcileThreshold := time.Second * time.Duration(
+		v.Viper.GetInt("slow-reconcile-threshold"))
+	processingTimeMismatchFactor := v.Viper.GetFloat64("processing-time-mismatch-factor")
+	fastScaleUpInterval := time.Second * time.Duration(
+		v.Viper.GetInt("fast-scale-up-interval"))
 	awsRegions := parseRegions(v.Viper.GetString("aws-regions"))
+	awsEndpoint := v.Viper.GetString("aws-endpoint")
 	kubeConfigPath := v.Viper.GetString("kube-config")
 	sqsShortPollInterval := v.Viper.GetInt("sqs-short-poll-interval")
 	sqsLongPollInterval := v.Viper.GetInt("sqs-long-poll-interval")
+	sqsQueueAttributes := parseQueueAttributes(
+		v.Viper.GetString("sqs-queue-attributes"))
+	sqsQueueDiscoveryInterval := v.Viper.GetInt("sqs-queue-discovery-interval")
+	sqsMaxPollInterval := v.Viper.GetInt("sqs-max-poll-interval")
+	sqsMaxCallsPerMinute := v.Viper.GetInt("sqs-max-calls-per-minute")
+	sqsMessageWeightSampleInterval := v.Viper.GetInt(
+		"sqs-message-weight-sample-interval")
 	beanstalkShortPollInterval := v.Viper.GetInt(
 		"beanstalk-short-poll-interval")
 	beanstalkLongPollInterval := v.Viper.GetInt("beanstalk-long-poll-interval")
+	prometheusPollInterval := v.Viper.GetInt("prometheus-poll-interval")
+	datadogPollInterval := v.Viper.GetInt("datadog-poll-interval")
 	queueServicesToStartWith := v.Viper.GetString("queue-services")
-	metricsPort := v.Viper.GetString("metrics-port")
+	metricsBindAddress := v.Viper.GetString("metrics-bind-address")
+	if metricsBindAddress == "" {
+		metricsBindAddress = v.Viper.GetString("metrics-port")
+	}
+	metricsPath := v.Viper.GetString("metrics-path")
+	if !strings.HasPrefix(metricsPath, "/") {
+		klog.Fatalf("Invalid --metrics-path %q, it should start with /", metricsPath)
+	}
+	metricsDefaultCollectors := v.Viper.GetBool("metrics-default-collectors")
+	metricsCardinality := v.Viper.GetString("metrics-cardinality")
+	if metricsCardinality != workerpodautoscalercontroller.MetricsCardinalityHigh &&
+		metricsCardinality != workerpodautoscalercontroller.MetricsCardinalityLow {
+		klog.Fatalf("Invalid --metrics-cardinality %q, it should be %s or %s",
+			metricsCardinality, workerpodautoscalercontroller.MetricsCardinalityHigh,
+			workerpodautoscalercontroller.MetricsCardinalityLow)
+	}
 	k8sApiQPS := float32(v.Viper.GetFloat64("k8s-api-qps"))
 	k8sApiBurst := v.Viper.GetInt("k8s-api-burst")
-	namespace := v.Viper.GetString("namespace")
+	namespaces := parseNamespaces(v.Viper.GetString("namespace"),
+		v.Viper.GetString("watch-namespaces"))
+	otelEndpoint := v.Viper.GetString("otel-endpoint")
+	debugToken := v.Viper.GetString("debug-token")
+	manualScaleTTL := time.Second * time.Duration(
+		v.Viper.GetInt("manual-scale-ttl"))
+	if manualScaleTTL > 0 && debugToken == "" {
+		klog.Fatalf("--manual-scale-ttl needs the --debug-token to authenticate the manual scales")
+	}
+	webhookPort := v.Viper.GetString("webhook-port")
+	webhookCertFile := v.Viper.GetString("webhook-cert-file")
+	webhookKeyFile := v.Viper.GetString("webhook-key-file")
+	kafkaBrokers := parseQueueAttributes(v.Viper.GetString("kafka-brokers"))
+	kafkaConfig := sink.KafkaConfig{
+		Brokers:       kafkaBrokers,
+		Topic:         v.Viper.GetString("kafka-topic"),
+		SASLMechanism: v.Viper.GetString("kafka-sasl-mechanism"),
+		SASLUsername:  v.Viper.GetString("kafka-sasl-username"),
+		SASLPassword:  v.Viper.GetString("kafka-sasl-password"),
+		TLS:           v.Viper.GetBool("kafka-tls"),
+	}
 
 	hook := promlog.MustNewPrometheusHook("wpa_", klog.WarningSeverityLevel)
 	klog.AddHook(hook)
 
+	if !metricsDefaultCollectors {
+		prometheus.Unregister(prometheus.NewGoCollector())
+		prometheus.Unregister(
+			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := tracing.Init(ctx, otelEndpoint)
+	if err != nil {
+		klog.Fatalf("Error setting up tracing: %s", err.Error())
+	}
+	defer shutdownTracing(context.Background())
+
 	// // set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
@@ -148,57 +361,211 @@ func (v *runCmd) run(cmd *cobra.Command, args []string) {
 		klog.Fatalf("Error building custom clientset: %s", err.Error())
 	}
 
+	// the workloads of the scaleTargetRef are scaled using their scale
+	// subresource, the resources are discovered when they are first used
+	cachedDiscovery := memory.NewMemCacheClient(kubeClient.Discovery())
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery)
+	scaleClient, err := scale.NewForConfig(rest.CopyConfig(cfg), restMapper,
+		dynamic.LegacyAPIPathResolverFunc,
+		scale.NewDiscoveryScaleKindResolver(cachedDiscovery))
+	if err != nil {
+		klog.Fatalf("Error building scale client: %s", err.Error())
+	}
+
+	var scaleEventSink sink.Sink
+	if len(kafkaConfig.Brokers) > 0 {
+		scaleEventSink, err = sink.NewKafka(kafkaConfig)
+		if err != nil {
+			klog.Fatalf("Error creating the kafka sink: %v", err)
+		}
+		defer scaleEventSink.Close()
+	}
+
 	queues := queue.NewQueues()
 	go queues.Sync(stopCh)
 
+	queueServiceConfig := queue.QueueServiceConfig{
+		AWSRegions:                     awsRegions,
+		AWSEndpoint:                    awsEndpoint,
+		SQSShortPollInterval:           sqsShortPollInterval,
+		SQSLongPollInterval:            sqsLongPollInterval,
+		SQSQueueAttributes:             sqsQueueAttributes,
+		SQSQueueDiscoveryInterval:      sqsQueueDiscoveryInterval,
+		SQSMaxPollInterval:             sqsMaxPollInterval,
+		SQSMaxCallsPerMinute:           sqsMaxCallsPerMinute,
+		SQSMessageWeightSampleInterval: sqsMessageWeightSampleInterval,
+		BeanstalkShortPollInterval:     beanstalkShortPollInterval,
+		BeanstalkLongPollInterval:      beanstalkLongPollInterval,
+		PrometheusPollInterval:         prometheusPollInterval,
+		DatadogPollInterval:            datadogPollInterval,
+	}
+
 	var queuingServices []queue.QueuingService
 
 	// Make all the message service providers and start their pollers
 	for _, q := range strings.Split(queueServicesToStartWith, ",") {
 		q = strings.TrimSpace(q)
-		switch q {
-		case queue.SqsQueueService:
-			sqs, err := queue.NewSQS(
-				queue.SqsQueueService,
-				awsRegions, queues, sqsShortPollInterval, sqsLongPollInterval)
-			if err != nil {
-				klog.Fatalf("Error creating sqs Poller: %v", err)
-			}
-
-			queuingServices = append(queuingServices, sqs)
-		case queue.BeanstalkQueueService:
-			bs, err := queue.NewBeanstalk(
-				queue.BeanstalkQueueService,
-				queues, beanstalkShortPollInterval, beanstalkLongPollInterval)
-			if err != nil {
-				klog.Fatalf("Error creating bs Poller: %v", err)
-			}
-
-			queuingServices = append(queuingServices, bs)
-		default:
-			klog.Fatal("Unsupported queue provider: ", q)
+		queuingService, err := queue.NewQueueService(q, queues, queueServiceConfig)
+		if err != nil {
+			klog.Fatalf("Error creating %s Poller: %v", q, err)
 		}
+
+		queuingServices = append(queuingServices, queuingService)
 	}
 
 	for _, queuingService := range queuingServices {
-		poller := queue.NewPoller(queues, queuingService)
+		poller := queue.NewPoller(queues, queuingService, maxQueuesPerBackend)
 		go poller.Sync(stopCh)
 		go poller.Run(stopCh)
 	}
 
+	// every watched namespace has its own informers and controller,
+	// the queues, the clients, the scale up limiter and the cluster
+	// scoped defaults are shared by them
+	scaleUpLimiter := workerpodautoscalercontroller.NewScaleUpLimiter(
+		maxScaleUpsPerMinute)
+	defaultInformerFactory := informers.NewSharedInformerFactory(
+		customClient, resyncPeriod)
+	wpaDefaultInformer := defaultInformerFactory.K8s().V1().WorkerPodAutoScalerDefaults()
+	controllers := make(map[string]*workerpodautoscalercontroller.Controller)
+	for _, namespace := range namespaces {
+		controllers[namespace] = newController(ctx, namespace, kubeClient,
+			customClient, scaleClient, restMapper, resyncPeriod, stopCh,
+			wpaDefaultInformer, wpaDefaultMaxDisruption, scaleDownDelay,
+			updateRetry, scaleUpLimiter, scalingStuckWindow, crashLoopRestarts,
+			crashLoopWindow, countTerminatingWorkers, annotationDriven,
+			labelTargets, gcOnTargetDelete, scaleFailureThreshold,
+			scaleFailureCooldown, slowReconcileThreshold,
+			processingTimeMismatchFactor, fastScaleUpInterval,
+			scaleEventSink,
+			metricsCardinality == workerpodautoscalercontroller.MetricsCardinalityLow,
+			queues)
+	}
+	defaultInformerFactory.Start(stopCh)
+
+	var statusGetter liveStatusGetter = controllers[namespaces[0]]
+	var scaler manualScaler = controllers[namespaces[0]]
+	if len(namespaces) > 1 {
+		statusGetter = namespacedStatusGetter(controllers)
+		scaler = namespacedStatusGetter(controllers)
+	}
+	go serveMetrics(metricsBindAddress, metricsPath, queues, debugToken,
+		statusGetter, scaler, manualScaleTTL, otelEndpoint != "")
+	if webhookCertFile != "" {
+		go serveWebhook(webhookPort, webhookCertFile, webhookKeyFile)
+	}
+
+	// TODO: autoscale the worker threads based on number of
+	// queues registred in WPA
+	threads := getThreadsPerNamespace(wpaThraeds, len(controllers))
+	var wg sync.WaitGroup
+	for namespace, controller := range controllers {
+		wg.Add(1)
+		go func(namespace string,
+			controller *workerpodautoscalercontroller.Controller) {
+			defer wg.Done()
+			if err := controller.Run(threads, stopCh); err != nil {
+				klog.Fatalf("Error running controller of namespace %q: %s",
+					namespace, err.Error())
+			}
+		}(namespace, controller)
+	}
+	wg.Wait()
+}
+
+// getThreadsPerNamespace splits the threads between the controllers
+// of the watched namespaces, every controller runs at least one thread
+func getThreadsPerNamespace(threads int, namespaces int) int {
+	if namespaces <= 1 {
+		return threads
+	}
+	if perNamespace := threads / namespaces; perNamespace > 1 {
+		return perNamespace
+	}
+	return 1
+}
+
+// newController creates the informers of the namespace, the empty
+// namespace watches all the namespaces, and the controller using them.
+// The informers are started.
+func newController(
+	ctx context.Context,
+	namespace string,
+	kubeClient kubernetes.Interface,
+	customClient clientset.Interface,
+	scaleClient scale.ScalesGetter,
+	restMapper meta.RESTMapper,
+	resyncPeriod time.Duration,
+	stopCh <-chan struct{},
+	wpaDefaultInformer wpainformers.WorkerPodAutoScalerDefaultInformer,
+	wpaDefaultMaxDisruption string,
+	scaleDownDelay time.Duration,
+	updateRetry wait.Backoff,
+	scaleUpLimiter *rate.Limiter,
+	scalingStuckWindow time.Duration,
+	crashLoopRestarts int32,
+	crashLoopWindow time.Duration,
+	countTerminatingWorkers bool,
+	annotationDriven bool,
+	labelTargets bool,
+	gcOnTargetDelete bool,
+	scaleFailureThreshold int,
+	scaleFailureCooldown time.Duration,
+	slowReconcileThreshold time.Duration,
+	processingTimeMismatchFactor float64,
+	fastScaleUpInterval time.Duration,
+	scaleEventSink sink.Sink,
+	lowCardinalityMetrics bool,
+	queues *queue.Queues) *workerpodautoscalercontroller.Controller {
+
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
 		kubeClient, resyncPeriod, kubeinformers.WithNamespace(namespace))
 	customInformerFactory := informers.NewSharedInformerFactoryWithOptions(
 		customClient, resyncPeriod, informers.WithNamespace(namespace))
+	// only the secrets labelled as credentials are watched
+	// and kept in memory, not all the secrets of the cluster
+	secretInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
+		kubeClient, resyncPeriod, kubeinformers.WithNamespace(namespace),
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = workerpodautoscalercontroller.CredentialsLabelSelector
+		}))
+
+	// the pods are watched only to check the crash loops
+	// and to count the terminating pods of the workers
+	var podInformer coreinformers.PodInformer
+	if crashLoopRestarts > 0 || countTerminatingWorkers {
+		podInformer = kubeInformerFactory.Core().V1().Pods()
+	}
 
 	controller := workerpodautoscalercontroller.NewController(
-		ctx, kubeClient, customClient,
+		ctx, kubeClient, customClient, scaleClient, restMapper,
 		kubeInformerFactory.Apps().V1().Deployments(),
 		kubeInformerFactory.Apps().V1().ReplicaSets(),
+		kubeInformerFactory.Apps().V1().StatefulSets(),
+		kubeInformerFactory.Autoscaling().V1().HorizontalPodAutoscalers(),
+		secretInformerFactory.Core().V1().Secrets(),
+		podInformer,
 		customInformerFactory.K8s().V1().WorkerPodAutoScalers(),
+		wpaDefaultInformer,
 		wpaDefaultMaxDisruption,
 		resyncPeriod,
 		scaleDownDelay,
+		updateRetry,
+		scaleUpLimiter,
+		scalingStuckWindow,
+		crashLoopRestarts,
+		crashLoopWindow,
+		countTerminatingWorkers,
+		annotationDriven,
+		labelTargets,
+		gcOnTargetDelete,
+		scaleFailureThreshold,
+		scaleFailureCooldown,
+		slowReconcileThreshold,
+		processingTimeMismatchFactor,
+		fastScaleUpInterval,
+		scaleEventSink,
+		lowCardinalityMetrics,
 		queues,
 	)
 
@@ -207,25 +574,50 @@ func (v *runCmd) run(cmd *cobra.Command, args []string) {
 	// Start method is non-blocking and runs all registered
 	// informers in a dedicated goroutine.
 	kubeInformerFactory.Start(stopCh)
+	secretInformerFactory.Start(stopCh)
 	customInformerFactory.Start(stopCh)
-
-	go serveMetrics(metricsPort)
-
-	// TODO: autoscale the worker threads based on number of
-	// queues registred in WPA
-	if err = controller.Run(wpaThraeds, stopCh); err != nil {
-		klog.Fatalf("Error running controller: %s", err.Error())
-	}
+	return controller
 }
 
-func serveMetrics(metricsPort string) {
+// serveMetrics serves the metrics in the OpenMetrics format to the
+// scrapers which accept it when tracing is enabled, the exemplars with
+// the trace ids are exposed only in that format. The manual scales are
+// served only when the manualScaleTTL is set.
+func serveMetrics(metricsBindAddress string, metricsPath string,
+	queues *queue.Queues, debugToken string, statusGetter liveStatusGetter,
+	scaler manualScaler, manualScaleTTL time.Duration, openMetrics bool) {
+
 	http.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
-	http.Handle("/metrics", promhttp.Handler())
-	http.ListenAndServe(metricsPort, nil)
+	if debugToken != "" {
+		http.HandleFunc("/debug/queues", debugQueuesHandler(queues, debugToken))
+	}
+
+	var scale wpaScaleFunc
+	if manualScaleTTL > 0 {
+		scale = wpaScaleHandler(scaler, debugToken, manualScaleTTL)
+	}
+	http.HandleFunc(wpaAPIPrefix, wpaAPIHandler(statusGetter, scale))
+	http.Handle(metricsPath, promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: openMetrics,
+		}),
+	))
+	http.ListenAndServe(metricsBindAddress, nil)
+}
+
+func serveWebhook(webhookPort string, certFile string, keyFile string) {
+	mux := http.NewServeMux()
+	mux.Handle("/mutate", webhook.NewMutateHandler())
+	mux.Handle("/validate", webhook.NewValidateHandler())
+	err := http.ListenAndServeTLS(webhookPort, certFile, keyFile, mux)
+	if err != nil {
+		klog.Fatalf("Error serving the webhook: %v", err)
+	}
 }
 
 func createRestConfig(kubeConfigPath string) (*rest.Config, error) {
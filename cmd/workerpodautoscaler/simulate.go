@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/cmdutil"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/controller"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+type simulateCmd struct {
+	cmdutil.BaseCmd
+}
+
+var (
+	simulateLong = `Replay a recorded backlog through the scaling of a WorkerPodAutoScaler
+offline and print the desired replicas for every sample. This helps to pick
+targetMessagesPerWorker, maxDisruption and the scale delays from real traffic.
+
+The samples are a CSV with a header row. The columns are timestamp (RFC3339)
+and messages, messagesSentPerMinute and idleWorkers are optional.`
+	simulateExample = `  workerpodautoscaler simulate -f wpa.yaml --samples backlog.csv
+  workerpodautoscaler simulate -f wpa.yaml --samples backlog.csv --output json`
+)
+
+func (v *simulateCmd) new() *cobra.Command {
+	v.Init("workerpodautoscaler", &cobra.Command{
+		Use:     "simulate",
+		Short:   "Simulate the scaling of a WorkerPodAutoScaler from a backlog CSV",
+		Long:    simulateLong,
+		Example: simulateExample,
+		Run:     v.run,
+	})
+
+	flags := v.Cmd.Flags()
+	flags.StringP("filename", "f", "", "path of the WorkerPodAutoScaler manifest")
+	flags.String("samples", "", "path of the backlog CSV, use - to read from stdin")
+	flags.String("output", "csv", "output format, csv or json")
+	flags.Int("initial-replicas", -1, "replicas at the first sample, minReplicas is used when negative")
+	flags.Float64("tolerance", 0.1, "relative change of the replicas which is too small to scale for")
+	flags.Int("scale-down-delay-after-last-scale-activity", 600, "scale down delay after last scale up or down in seconds")
+	flags.String("wpa-default-max-disruption", "100%", "the default value for the maxDisruption in the WPA spec")
+
+	for _, flag := range []string{
+		"filename",
+		"samples",
+		"output",
+		"initial-replicas",
+		"tolerance",
+		"scale-down-delay-after-last-scale-activity",
+		"wpa-default-max-disruption",
+	} {
+		if err := v.BindFlag(flag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	return v.Cmd
+}
+
+func (v *simulateCmd) run(cmd *cobra.Command, args []string) {
+	filename := v.Viper.GetString("filename")
+	samplesFilename := v.Viper.GetString("samples")
+	if filename == "" || samplesFilename == "" {
+		fmt.Println("filename and samples must be specified using -f and --samples")
+		os.Exit(1)
+	}
+	output := v.Viper.GetString("output")
+	if output != "csv" && output != "json" {
+		fmt.Printf("output %q is not csv or json\n", output)
+		os.Exit(1)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	wpa, err := readWorkerPodAutoScaler(f)
+	if err != nil {
+		fmt.Printf("%s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	var reader io.Reader
+	if samplesFilename == "-" {
+		reader = os.Stdin
+	} else {
+		s, err := os.Open(samplesFilename)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+		reader = s
+	}
+	samples, err := readSamples(reader)
+	if err != nil {
+		fmt.Printf("%s: %v\n", samplesFilename, err)
+		os.Exit(1)
+	}
+
+	steps, err := controller.Simulate(wpa, samples, controller.SimulationOptions{
+		InitialReplicas:      int32(v.Viper.GetInt("initial-replicas")),
+		DefaultMaxDisruption: v.Viper.GetString("wpa-default-max-disruption"),
+		DefaultScaleDownDelay: time.Second * time.Duration(
+			v.Viper.GetInt("scale-down-delay-after-last-scale-activity"),
+		),
+		Tolerance: v.Viper.GetFloat64("tolerance"),
+	})
+	if err != nil {
+		fmt.Printf("%s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	if err := writeSteps(os.Stdout, steps, output); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// readWorkerPodAutoScaler returns the first WorkerPodAutoScaler
+// document in the reader
+func readWorkerPodAutoScaler(reader io.Reader) (*v1.WorkerPodAutoScaler, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		var wpa v1.WorkerPodAutoScaler
+		if err := decoder.Decode(&wpa); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no WorkerPodAutoScaler found")
+			}
+			return nil, err
+		}
+		if wpa.Kind == "WorkerPodAutoScaler" {
+			return &wpa, nil
+		}
+	}
+}
+
+// readSamples reads the backlog CSV, the columns are found by the
+// names in the header row
+func readSamples(reader io.Reader) ([]controller.SimulationSample, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no samples found, a header row and samples are expected")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"timestamp", "messages"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %q not found in the header", name)
+		}
+	}
+
+	samples := make([]controller.SimulationSample, 0, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+		sample := controller.SimulationSample{
+			IdleWorkers: queue.UnsyncedIdleWorkers,
+		}
+		sample.Time, err = time.Parse(time.RFC3339, record[columns["timestamp"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: timestamp is not RFC3339: %v", line, err)
+		}
+		if i > 0 && sample.Time.Before(samples[i-1].Time) {
+			return nil, fmt.Errorf("line %d: samples are not sorted by timestamp", line)
+		}
+		messages, err := strconv.ParseInt(record[columns["messages"]], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: messages: %v", line, err)
+		}
+		sample.QueueMessages = int32(messages)
+		if column, ok := columns["messagesSentPerMinute"]; ok && record[column] != "" {
+			sample.MessagesSentPerMinute, err = strconv.ParseFloat(record[column], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: messagesSentPerMinute: %v", line, err)
+			}
+		}
+		if column, ok := columns["idleWorkers"]; ok && record[column] != "" {
+			idleWorkers, err := strconv.ParseInt(record[column], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: idleWorkers: %v", line, err)
+			}
+			sample.IdleWorkers = int32(idleWorkers)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// writeSteps writes the timeline of the simulation as csv or json
+func writeSteps(out io.Writer, steps []controller.SimulationStep, output string) error {
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(steps)
+	}
+
+	writer := csv.NewWriter(out)
+	writer.Write([]string{
+		"timestamp",
+		"messages",
+		"messagesSentPerMinute",
+		"currentReplicas",
+		"desiredReplicas",
+		"unclampedDesiredReplicas",
+		"reason",
+		"operation",
+		"replicas",
+	})
+	for _, step := range steps {
+		writer.Write([]string{
+			step.Time.Format(time.RFC3339),
+			strconv.Itoa(int(step.QueueMessages)),
+			strconv.FormatFloat(step.MessagesSentPerMinute, 'f', -1, 64),
+			strconv.Itoa(int(step.CurrentReplicas)),
+			strconv.Itoa(int(step.DesiredReplicas)),
+			strconv.Itoa(int(step.UnclampedDesiredReplicas)),
+			string(step.Reason),
+			step.Operation,
+			strconv.Itoa(int(step.Replicas)),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
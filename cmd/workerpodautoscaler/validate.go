@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/cmdutil"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/validation"
+)
+
+type validateCmd struct {
+	cmdutil.BaseCmd
+}
+
+var (
+	validateLong    = `Validate WorkerPodAutoScaler manifests offline, without connecting to a cluster`
+	validateExample = `  workerpodautoscaler validate -f wpa.yaml`
+)
+
+func (v *validateCmd) new() *cobra.Command {
+	v.Init("workerpodautoscaler", &cobra.Command{
+		Use:     "validate",
+		Short:   "Validate WorkerPodAutoScaler manifests",
+		Long:    validateLong,
+		Example: validateExample,
+		Run:     v.run,
+	})
+
+	flags := v.Cmd.Flags()
+	flags.StringP("filename", "f", "", "path of the manifest to validate, use - to read from stdin")
+
+	if err := v.BindFlag("filename"); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return v.Cmd
+}
+
+func (v *validateCmd) run(cmd *cobra.Command, args []string) {
+	filename := v.Viper.GetString("filename")
+	if filename == "" {
+		fmt.Println("filename must be specified using -f")
+		os.Exit(1)
+	}
+
+	var reader io.Reader
+	if filename == "-" {
+		reader = os.Stdin
+	} else {
+		f, err := os.Open(filename)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	invalid, err := validateManifests(reader, os.Stdout)
+	if err != nil {
+		fmt.Printf("%s: %v\n", filename, err)
+		os.Exit(1)
+	}
+	if invalid {
+		os.Exit(1)
+	}
+}
+
+// validateManifests validates every WorkerPodAutoScaler document in the
+// reader, printing the errors found. It returns true if any of them
+// is invalid.
+func validateManifests(reader io.Reader, out io.Writer) (bool, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
+	invalid := false
+	documents := 0
+	for {
+		var wpa v1.WorkerPodAutoScaler
+		if err := decoder.Decode(&wpa); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return true, err
+		}
+		if wpa.Kind == "" && wpa.APIVersion == "" {
+			// empty document
+			continue
+		}
+		documents++
+
+		name := wpa.Name
+		if wpa.Namespace != "" {
+			name = wpa.Namespace + "/" + wpa.Name
+		}
+
+		if wpa.Kind != "WorkerPodAutoScaler" {
+			fmt.Fprintf(out, "%s: kind %q is not WorkerPodAutoScaler\n",
+				name, wpa.Kind)
+			invalid = true
+			continue
+		}
+
+		errs := validation.ValidateWorkerPodAutoScaler(&wpa)
+		if len(errs) == 0 {
+			fmt.Fprintf(out, "%s: valid\n", name)
+			continue
+		}
+		invalid = true
+		for _, err := range errs {
+			fmt.Fprintf(out, "%s: %v\n", name, err)
+		}
+	}
+
+	if documents == 0 {
+		return true, fmt.Errorf("no WorkerPodAutoScaler found")
+	}
+	return invalid, nil
+}
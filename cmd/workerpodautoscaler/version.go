@@ -32,4 +32,5 @@ func (v *versionCmd) new() *cobra.Command {
 
 func (v *versionCmd) run(cmd *cobra.Command, args []string) {
 	fmt.Println("Version " + version.GetVersion())
+	fmt.Println("Git commit " + version.GetGitCommit())
 }
@@ -22,7 +22,8 @@ export GOOS="${OS}"
 export GO111MODULE=on
 export GOFLAGS="-mod=vendor"
 
-go install                                                      \
-    -installsuffix "static"                                     \
-    -ldflags "-X $(go list -m)/pkg/version.Version=${VERSION}"  \
+go install                                                                  \
+    -installsuffix "static"                                                 \
+    -ldflags "-X $(go list -m)/pkg/version.Version=${VERSION}               \
+              -X $(go list -m)/pkg/version.GitCommit=${GIT_COMMIT:-UNKNOWN}" \
     ./...
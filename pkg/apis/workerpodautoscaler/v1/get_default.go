@@ -1,8 +1,150 @@
 package v1
 
+import "time"
+
 func (w *WorkerPodAutoScaler) GetMaxDisruption(defaultDisruption string) *string {
 	if w.Spec.MaxDisruption == nil {
 		return &defaultDisruption
 	}
 	return w.Spec.MaxDisruption
 }
+
+func (w *WorkerPodAutoScaler) GetDisableVelocityMinWorkers() bool {
+	if w.Spec.DisableVelocityMinWorkers == nil {
+		return false
+	}
+	return *w.Spec.DisableVelocityMinWorkers
+}
+
+func (w *WorkerPodAutoScaler) GetRespectDisruptionOnIdle() bool {
+	if w.Spec.RespectDisruptionOnIdle == nil {
+		return false
+	}
+	return *w.Spec.RespectDisruptionOnIdle
+}
+
+func (w *WorkerPodAutoScaler) GetThroughputMode() bool {
+	if w.Spec.ThroughputMode == nil {
+		return false
+	}
+	return *w.Spec.ThroughputMode
+}
+
+func (w *WorkerPodAutoScaler) GetBalanceAwareScaling() bool {
+	if w.Spec.BalanceAwareScaling == nil {
+		return false
+	}
+	return *w.Spec.BalanceAwareScaling
+}
+
+func (w *WorkerPodAutoScaler) GetSmoothMessagesSentPerMinute() bool {
+	if w.Spec.SmoothMessagesSentPerMinute == nil {
+		return false
+	}
+	return *w.Spec.SmoothMessagesSentPerMinute
+}
+
+func (w *WorkerPodAutoScaler) GetAutoEstimateProcessingTime() bool {
+	if w.Spec.AutoEstimateProcessingTime == nil {
+		return false
+	}
+	return *w.Spec.AutoEstimateProcessingTime
+}
+
+func (w *WorkerPodAutoScaler) GetPreferIdlePodsOnScaleDown() bool {
+	if w.Spec.PreferIdlePodsOnScaleDown == nil {
+		return false
+	}
+	return *w.Spec.PreferIdlePodsOnScaleDown
+}
+
+func (w *WorkerPodAutoScaler) GetPanicWindow() time.Duration {
+	if w.Spec.PanicWindowSeconds == nil {
+		return 60 * time.Second
+	}
+	return time.Duration(*w.Spec.PanicWindowSeconds) * time.Second
+}
+
+func (w *WorkerPodAutoScaler) GetScaleDownDelay(defaultDelay time.Duration) time.Duration {
+	if w.Spec.ScaleDownDelaySeconds == nil {
+		return defaultDelay
+	}
+	return time.Duration(*w.Spec.ScaleDownDelaySeconds) * time.Second
+}
+
+func (w *WorkerPodAutoScaler) GetScaleUpDelay() time.Duration {
+	if w.Spec.ScaleUpDelaySeconds == nil {
+		return 0
+	}
+	return time.Duration(*w.Spec.ScaleUpDelaySeconds) * time.Second
+}
+
+func (w *WorkerPodAutoScaler) GetWarmFloor() int32 {
+	if w.Spec.WarmFloor == nil {
+		return 0
+	}
+	return *w.Spec.WarmFloor
+}
+
+func (w *WorkerPodAutoScaler) GetColdStartReplicas() int32 {
+	if w.Spec.ColdStartReplicas == nil {
+		return 0
+	}
+	return *w.Spec.ColdStartReplicas
+}
+
+func (w *WorkerPodAutoScaler) GetInitialStabilizationSeconds() int32 {
+	if w.Spec.InitialStabilizationSeconds == nil {
+		return 0
+	}
+	return *w.Spec.InitialStabilizationSeconds
+}
+
+func (w *WorkerPodAutoScaler) GetRoundingStrategy() string {
+	if w.Spec.RoundingStrategy == "" {
+		return RoundingStrategyCeil
+	}
+	return w.Spec.RoundingStrategy
+}
+
+func (w *WorkerPodAutoScaler) GetNoBacklogStrategy() string {
+	if w.Spec.NoBacklogStrategy == "" {
+		return NoBacklogStrategyScaleToMin
+	}
+	return w.Spec.NoBacklogStrategy
+}
+
+func (s *SafetyQueue) GetThreshold() int32 {
+	if s.Threshold == nil {
+		return 0
+	}
+	return *s.Threshold
+}
+
+func (m *MessageWeights) GetDefaultWeight() float64 {
+	if m.DefaultWeight == nil {
+		return 1.0
+	}
+	return *m.DefaultWeight
+}
+
+func (i *IdleSource) GetPath() string {
+	if i.Path == "" {
+		return "/idle"
+	}
+	return i.Path
+}
+
+func (i *IdleSource) GetConcurrency() int32 {
+	if i.Concurrency == nil {
+		return 1
+	}
+	return *i.Concurrency
+}
+
+func (c *ConservativeWindow) GetDisableScaleDown() bool {
+	if c.DisableScaleDown == nil {
+		return true
+	}
+	return *c.DisableScaleDown
+}
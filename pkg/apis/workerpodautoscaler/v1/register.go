@@ -37,6 +37,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&WorkerPodAutoScaler{},
 		&WorkerPodAutoScalerList{},
+		&WorkerPodAutoScalerDefault{},
+		&WorkerPodAutoScalerDefaultList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -6,6 +6,17 @@ import (
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=wpa;wpas
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.maxReplicas,statuspath=.status.CurrentReplicas
+// +kubebuilder:printcolumn:name="Min",type=integer,JSONPath=`.spec.minReplicas`
+// +kubebuilder:printcolumn:name="Max",type=integer,JSONPath=`.spec.maxReplicas`
+// +kubebuilder:printcolumn:name="Current",type=integer,JSONPath=`.status.CurrentReplicas`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.DesiredReplicas`
+// +kubebuilder:printcolumn:name="Backlog",type=integer,JSONPath=`.status.CurrentMessages`
+// +kubebuilder:printcolumn:name="Queue-Healthy",type=boolean,JSONPath=`.status.QueueHealthy`
+// +kubebuilder:printcolumn:name="Last-Scale",type=date,JSONPath=`.status.LastScaleTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // WorkerPodAutoScaler is a specification for a WorkerPodAutoScaler resource
 type WorkerPodAutoScaler struct {
@@ -26,8 +37,441 @@ type WorkerPodAutoScalerSpec struct {
 	ReplicaSetName          string   `json:"replicaSetName,omitempty"`
 	TargetMessagesPerWorker *int32   `json:"targetMessagesPerWorker"`
 	SecondsToProcessOneJob  *float64 `json:"secondsToProcessOneJob,omitempty"`
+	// TargetRef selects the workload by kind and name or label selector,
+	// it is used instead of deploymentName and replicaSetName
+	// +optional
+	TargetRef *TargetRef `json:"targetRef,omitempty"`
+	// ScaleTargetRef references a workload by apiVersion, kind and name,
+	// it is scaled using its scale subresource. It is used to scale the
+	// custom workloads like an Argo Rollout, instead of deploymentName,
+	// replicaSetName and targetRef.
+	// +optional
+	ScaleTargetRef *ScaleTargetRef `json:"scaleTargetRef,omitempty"`
+	// WeightedTargets are the workloads consuming the same queue which
+	// share the desired workers by their weight. It is used instead of
+	// deploymentName, replicaSetName, targetRef and scaleTargetRef.
+	// +optional
+	WeightedTargets []WeightedTarget `json:"weightedTargets,omitempty"`
+	// DisableVelocityMinWorkers stops secondsToProcessOneJob from raising
+	// the minReplicas based on the messages sent per minute. The
+	// secondsToProcessOneJob is still used when there is no backlog
+	// but the queue has throughput.
+	// +optional
+	DisableVelocityMinWorkers *bool `json:"disableVelocityMinWorkers,omitempty"`
+	// RespectDisruptionOnIdle caps the scale down of the workers which
+	// are all idle by the maxDisruption, instead of scaling them down
+	// to the minReplicas at once
+	// +optional
+	RespectDisruptionOnIdle *bool `json:"respectDisruptionOnIdle,omitempty"`
+	// ThroughputMode scales the workers on the messages sent per minute
+	// and secondsToProcessOneJob instead of the backlog
+	// +optional
+	ThroughputMode *bool `json:"throughputMode,omitempty"`
+	// BalanceAwareScaling adds the workers required to keep up with the
+	// growth of the backlog when the messages sent per minute exceed the
+	// messages processed per minute
+	// +optional
+	BalanceAwareScaling *bool `json:"balanceAwareScaling,omitempty"`
+	// SmoothMessagesSentPerMinute uses the 5 minute moving average of the
+	// messages sent per minute instead of the last poll for the velocity
+	// minReplicas and the throughput mode
+	// +optional
+	SmoothMessagesSentPerMinute *bool `json:"smoothMessagesSentPerMinute,omitempty"`
+	// CredentialsSecretRef is the secret containing the credentials used
+	// by the queue service to connect to the queue, the secret must have
+	// the label workerpodautoscaler.practo.com/credentials=true
+	// +optional
+	CredentialsSecretRef *SecretReference `json:"credentialsSecretRef,omitempty"`
+	// SafetyQueue is an auxiliary queue like a dead letter or a retry
+	// queue, it does not drive the desired workers
+	// +optional
+	SafetyQueue *SafetyQueue `json:"safetyQueue,omitempty"`
+	// MessageWeights weighs the backlog by the type of the messages,
+	// the backlog is the plain count of the messages if not set.
+	// Only SQS supports it.
+	// +optional
+	MessageWeights *MessageWeights `json:"messageWeights,omitempty"`
+	// PreferIdlePodsOnScaleDown sets a low pod deletion cost on the pods
+	// which the workers have annotated as idle before scaling down, so
+	// that the idle workers are terminated first
+	// +optional
+	PreferIdlePodsOnScaleDown *bool `json:"preferIdlePodsOnScaleDown,omitempty"`
+	// IdleSinceAnnotation is the pod annotation in which the workers
+	// publish the time since which they are idle, the idle pods are
+	// then ranked by it when preferIdlePodsOnScaleDown is set
+	// +optional
+	IdleSinceAnnotation string `json:"idleSinceAnnotation,omitempty"`
+	// IdleSource is the endpoint of the worker pods which tells the
+	// number of idle workers in the pod. The idle workers scraped from
+	// the pods are used instead of the idle workers of the queue.
+	// +optional
+	IdleSource *IdleSource `json:"idleSource,omitempty"`
+	// PanicThreshold is the backlog per worker above which the WPA
+	// panics and scales to maxReplicas bypassing the ramp limits
+	// +optional
+	PanicThreshold *float64 `json:"panicThreshold,omitempty"`
+	// PanicWindowSeconds is the time the WPA stays in panic after the
+	// backlog per worker was last above the threshold, defaults to 60
+	// +optional
+	PanicWindowSeconds *int32 `json:"panicWindowSeconds,omitempty"`
+	// FastScaleUpThreshold is the backlog per worker above which the
+	// fast loop of the controller, --fast-scale-up-interval, syncs the
+	// WPA for a scale up without waiting for the scaleUpDelaySeconds
+	// +optional
+	FastScaleUpThreshold *float64 `json:"fastScaleUpThreshold,omitempty"`
+	// ScalingGroup shares a replica budget between the WPAs of the
+	// group in the same namespace
+	// +optional
+	ScalingGroup *ScalingGroup `json:"scalingGroup,omitempty"`
+	// AutoEstimateProcessingTime estimates the secondsToProcessOneJob
+	// from the throughput of the workers, secondsToProcessOneJob is used
+	// till the first estimate is available
+	// +optional
+	AutoEstimateProcessingTime *bool `json:"autoEstimateProcessingTime,omitempty"`
+	// Query is the PromQL or Datadog query whose result is the backlog
+	// when the queueURI is the base url of prometheus or datadog
+	// +optional
+	Query string `json:"query,omitempty"`
+	// ScaleDownDelaySeconds is the delay after the last scale up or down
+	// before the workers are scaled down, defaults to the controller
+	// flag --scale-down-delay-after-last-scale-activity
+	// +optional
+	ScaleDownDelaySeconds *int32 `json:"scaleDownDelaySeconds,omitempty"`
+	// ScaleUpDelaySeconds is the delay after the last scale up or down
+	// before the workers are scaled up, defaults to 0
+	// +optional
+	ScaleUpDelaySeconds *int32 `json:"scaleUpDelaySeconds,omitempty"`
+	// SQS overrides the controller defaults of the SQS poll of the queue.
+	// Only SQS supports it.
+	// +optional
+	SQS *SQSOptions `json:"sqs,omitempty"`
+	// WarmFloor is the minimum workers kept when there is no backlog,
+	// unlike minReplicas it does not apply when there is a backlog
+	// +optional
+	WarmFloor *int32 `json:"warmFloor,omitempty"`
+	// ColdStartReplicas is the minimum workers of the first scale up
+	// from zero with a backlog, the workers required by the backlog
+	// are used after it
+	// +optional
+	ColdStartReplicas *int32 `json:"coldStartReplicas,omitempty"`
+	// InitialStabilizationSeconds is the time after the WPA is first
+	// seen during which the workload is not scaled, the WPA only
+	// observes the queue and reports the desired workers
+	// +optional
+	InitialStabilizationSeconds *int32 `json:"initialStabilizationSeconds,omitempty"`
+	// BacklogAgeSLOSeconds is the maximum age of the oldest message in
+	// the queue, the SLOViolated condition is set when it is exceeded.
+	// It does not change the scaling. Only SQS supports it.
+	// +optional
+	BacklogAgeSLOSeconds *int32 `json:"backlogAgeSLOSeconds,omitempty"`
+	// RoundingStrategy is how the backlog divided by the
+	// targetMessagesPerWorker is rounded to the workers, one of Ceil,
+	// Round or Floor, defaults to Ceil
+	// +optional
+	RoundingStrategy string `json:"roundingStrategy,omitempty"`
+	// NoBacklogStrategy is what is done with the workers when there is
+	// no backlog but the queue has throughput, one of ScaleToMin or
+	// HoldCurrent, defaults to ScaleToMin
+	// +optional
+	NoBacklogStrategy string `json:"noBacklogStrategy,omitempty"`
+	// Schedules override the minReplicas and maxReplicas during the
+	// windows they are active, the first active schedule is used
+	// +optional
+	Schedules []ScheduleRule `json:"schedules,omitempty"`
+	// ConservativeWindows make the scaling conservative during the
+	// windows they are active, e.g. while the backlog is not reliable
+	// in a maintenance, the first active window is used. Unlike the
+	// schedules they do not change the replica bounds.
+	// +optional
+	ConservativeWindows []ConservativeWindow `json:"conservativeWindows,omitempty"`
+}
+
+// ScheduleRule overrides the replica bounds for a window which starts
+// at every activation of the cron schedule and lasts for the duration
+type ScheduleRule struct {
+	// Name of the rule, reported in the ScheduleActive condition
+	Name string `json:"name"`
+	// Schedule is the standard 5 field cron expression of the starts
+	Schedule string `json:"schedule"`
+	// TimeZone of the schedule in the IANA database, defaults to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// DurationSeconds is how long the window lasts after every start
+	DurationSeconds int32 `json:"durationSeconds"`
+	// MinReplicas overrides the minReplicas during the window
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas overrides the maxReplicas during the window
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// ConservativeWindow makes the scaling conservative for a window which
+// starts at every activation of the cron schedule and lasts for the duration
+type ConservativeWindow struct {
+	// Name of the window, reported in the ConservativeWindowActive condition
+	Name string `json:"name"`
+	// Schedule is the standard 5 field cron expression of the starts
+	Schedule string `json:"schedule"`
+	// TimeZone of the schedule in the IANA database, defaults to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// DurationSeconds is how long the window lasts after every start
+	DurationSeconds int32 `json:"durationSeconds"`
+	// DisableScaleDown holds the scale downs during the window,
+	// defaults to true
+	// +optional
+	DisableScaleDown *bool `json:"disableScaleDown,omitempty"`
+	// Tolerance overrides the relative change of the workers which is
+	// too small to scale for during the window
+	// +optional
+	Tolerance *float64 `json:"tolerance,omitempty"`
+}
+
+// SQSOptions are the options of the SQS poll of the queue
+type SQSOptions struct {
+	// WaitTimeSeconds is the long poll wait time of the ReceiveMessage
+	// call, defaults to the controller flag --sqs-long-poll-interval
+	// +optional
+	WaitTimeSeconds *int32 `json:"waitTimeSeconds,omitempty"`
+	// QueueAttributes are the attributes requested by every poll,
+	// defaults to the controller flag --sqs-queue-attributes
+	// +optional
+	QueueAttributes []string `json:"queueAttributes,omitempty"`
+	// QueuePrefix polls all the queues whose name starts with the prefix,
+	// in the account and region of the queueURI, and sums their backlog.
+	// The queues are listed again every --sqs-queue-discovery-interval.
+	// +optional
+	QueuePrefix string `json:"queuePrefix,omitempty"`
+	// MaxDiscoveredQueues caps the queues polled for the QueuePrefix,
+	// defaults to 100
+	// +optional
+	MaxDiscoveredQueues *int32 `json:"maxDiscoveredQueues,omitempty"`
+	// BacklogStalenessGuardPolls is the number of polls in a row which
+	// must read zero messages before a drop of the backlog to zero is
+	// used, as the approximate number of messages can briefly read zero
+	// after a burst. 1 uses the zero backlog at once, defaults to 1
+	// +optional
+	BacklogStalenessGuardPolls *int32 `json:"backlogStalenessGuardPolls,omitempty"`
+}
+
+// ScalingGroup is a replica budget shared by the WPAs with the same name.
+// The budget is split in proportion to the desired workers of the WPAs
+// when their sum is above the budget.
+type ScalingGroup struct {
+	// Name of the group
+	Name string `json:"name"`
+	// MaxReplicas is the total replicas of all the WPAs in the group,
+	// the smallest value is used if the WPAs of the group disagree
+	MaxReplicas int32 `json:"maxReplicas"`
+}
+
+// MessageWeights is the weight of every message type in the queue
+type MessageWeights struct {
+	// MessageAttributeName is the message attribute holding the message type
+	MessageAttributeName string `json:"messageAttributeName"`
+	// Weights is the weight of the message types
+	Weights map[string]float64 `json:"weights"`
+	// DefaultWeight is the weight of the messages whose type is not in
+	// the weights or which do not have the attribute, defaults to 1
+	// +optional
+	DefaultWeight *float64 `json:"defaultWeight,omitempty"`
+}
+
+// TargetRef is the reference to the workload scaled by the WPA
+type TargetRef struct {
+	// Kind is one of Deployment, ReplicaSet or StatefulSet
+	Kind string `json:"kind"`
+	// Name of the workload, only one of name or labelSelector is allowed
+	// +optional
+	Name string `json:"name,omitempty"`
+	// LabelSelector should match exactly one workload of the kind in the
+	// namespace of the WPA, it is resolved in every reconcile
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// WeightedTarget is a workload of the weightedTargets of the WPA
+type WeightedTarget struct {
+	// Kind is one of Deployment, ReplicaSet or StatefulSet
+	Kind string `json:"kind"`
+	// Name of the workload
+	Name string `json:"name"`
+	// Weight is the share of the desired workers of the workload
+	// out of the weights of all the targets
+	Weight int32 `json:"weight"`
+	// MinReplicas of the workload, defaults to 0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas of the workload, it is not capped if not set
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// IdleSource is the http endpoint of the worker pods which returns
+// the number of workers of the pod waiting for work as plain text
+type IdleSource struct {
+	// Port of the pods serving the endpoint
+	Port int32 `json:"port"`
+	// Path of the endpoint, defaults to /idle
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Concurrency is the number of workers in a pod, the pod is idle
+	// when all of them are idle, defaults to 1
+	// +optional
+	Concurrency *int32 `json:"concurrency,omitempty"`
+}
+
+// ScaleTargetRef is the reference to a workload which has the
+// scale subresource
+type ScaleTargetRef struct {
+	// APIVersion of the workload, like argoproj.io/v1alpha1
+	APIVersion string `json:"apiVersion"`
+	// Kind of the workload, like Rollout
+	Kind string `json:"kind"`
+	// Name of the workload
+	Name string `json:"name"`
 }
 
+const (
+	// TargetKindDeployment is the kind of a Deployment target
+	TargetKindDeployment = "Deployment"
+	// TargetKindReplicaSet is the kind of a ReplicaSet target
+	TargetKindReplicaSet = "ReplicaSet"
+	// TargetKindStatefulSet is the kind of a StatefulSet target
+	TargetKindStatefulSet = "StatefulSet"
+)
+
+const (
+	// RoundingStrategyCeil rounds the workers required by the backlog up
+	RoundingStrategyCeil = "Ceil"
+	// RoundingStrategyRound rounds the workers required by the
+	// backlog to the nearest integer, half away from zero
+	RoundingStrategyRound = "Round"
+	// RoundingStrategyFloor rounds the workers required by the backlog down
+	RoundingStrategyFloor = "Floor"
+)
+
+const (
+	// NoBacklogStrategyScaleToMin scales down to the min workers, raised
+	// by the messages sent per minute, when there is no backlog
+	NoBacklogStrategyScaleToMin = "ScaleToMin"
+	// NoBacklogStrategyHoldCurrent keeps the current workers when there
+	// is no backlog but the queue has throughput, to absorb the next burst
+	NoBacklogStrategyHoldCurrent = "HoldCurrent"
+)
+
+// SafetyQueue is the specification of the auxiliary queue
+type SafetyQueue struct {
+	QueueURI string `json:"queueURI"`
+	// BlockScaleDownWhenNonEmpty blocks the scale down of the workers
+	// while the messages in the safety queue exceed the threshold
+	// +optional
+	BlockScaleDownWhenNonEmpty bool `json:"blockScaleDownWhenNonEmpty,omitempty"`
+	// Threshold is the number of messages in the safety queue
+	// above which the scale down is blocked, defaults to 0
+	// +optional
+	Threshold *int32 `json:"threshold,omitempty"`
+}
+
+// SecretReference points to a secret
+type SecretReference struct {
+	// Namespace of the secret, it must be the namespace of the WPA
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+const (
+	// ConditionCredentialsAvailable tells if the secret in
+	// spec.credentialsSecretRef could be read
+	ConditionCredentialsAvailable = "CredentialsAvailable"
+
+	// ConditionTargetResolved tells if spec.targetRef resolved to
+	// exactly one workload
+	ConditionTargetResolved = "TargetResolved"
+
+	// ConditionInvalidReplicaBounds tells if minReplicas is greater
+	// than maxReplicas, the workers are kept at maxReplicas
+	ConditionInvalidReplicaBounds = "InvalidReplicaBounds"
+
+	// ConditionInvalidTargetMessagesPerWorker tells if
+	// targetMessagesPerWorker is not set or is not greater than 0, the
+	// WPA does not scale the workload till it is fixed
+	ConditionInvalidTargetMessagesPerWorker = "InvalidTargetMessagesPerWorker"
+
+	// ConditionInvalidQueueURI tells if the queueURI does not belong to
+	// any of the queue services or is not of the form of its queue
+	// service, the queue is not polled till it is fixed
+	ConditionInvalidQueueURI = "InvalidQueueURI"
+
+	// ConditionConflictingHPA tells if a HorizontalPodAutoscaler targets
+	// the same workload, the WPA does not scale the workload until
+	// the HPA is removed
+	ConditionConflictingHPA = "ConflictingHPA"
+
+	// ConditionScaleDecision tells what decided the desired workers
+	// in the last reconcile, the reason of the condition is the
+	// scale reason
+	ConditionScaleDecision = "ScaleDecision"
+
+	// ConditionScalingStuck tells if the available replicas of the
+	// workload are stalled below its replicas, the WPA does not scale
+	// up the workload while it is stuck
+	ConditionScalingStuck = "ScalingStuck"
+
+	// ConditionScalingLimited tells if the workers required by the
+	// backlog are above maxReplicas, see status.UnclampedDesiredReplicas
+	ConditionScalingLimited = "ScalingLimited"
+
+	// ConditionScheduleActive tells if one of spec.schedules is active,
+	// the message names the schedule whose replica bounds are used
+	ConditionScheduleActive = "ScheduleActive"
+
+	// ConditionConservativeWindowActive tells if one of
+	// spec.conservativeWindows is active, the message names the window
+	ConditionConservativeWindowActive = "ConservativeWindowActive"
+
+	// ConditionScalingDisabledAfterFailures tells if the scaling of the
+	// workload is stopped after repeated failures to update it, the
+	// update is retried after the scale failure cooldown
+	ConditionScalingDisabledAfterFailures = "ScalingDisabledAfterFailures"
+
+	// ConditionRolloutInProgress tells if a rollout of the workload is
+	// in progress, the WPA does not scale down the workload till it is over
+	ConditionRolloutInProgress = "RolloutInProgress"
+
+	// ConditionQueueAvailable tells if the last poll of the queue
+	// succeeded, the reason tells the kind of the poll failure. The WPA
+	// does not scale the workload while the queue is not found or the
+	// credentials are rejected as its backlog is not known.
+	ConditionQueueAvailable = "QueueAvailable"
+
+	// ConditionQueueUnsynced tells if the backlog of the queue is not
+	// known, the WPA holds the last desired replicas till it is known
+	ConditionQueueUnsynced = "QueueUnsynced"
+
+	// ConditionSLOViolated tells if the oldest message in the queue is
+	// older than spec.backlogAgeSLOSeconds, it is Unknown while the age
+	// of the oldest message is not known
+	ConditionSLOViolated = "SLOViolated"
+
+	// ConditionWorkersCrashLooping tells if most of the pods of the
+	// workload are restarting after failures, e.g. OOMKilled, the WPA
+	// does not scale up the workload while they are crash looping
+	ConditionWorkersCrashLooping = "WorkersCrashLooping"
+
+	// ConditionInitialStabilization tells if the WPA was first seen
+	// less than spec.initialStabilizationSeconds ago, the WPA does not
+	// scale the workload till it is over
+	ConditionInitialStabilization = "InitialStabilization"
+
+	// ConditionProcessingTimeMismatch tells if the secondsToProcessOneJob
+	// differs from the processing time observed from the backlog by more
+	// than the --processing-time-mismatch-factor
+	ConditionProcessingTimeMismatch = "ProcessingTimeMismatch"
+)
+
 // WorkerPodAutoScalerStatus is the status for a WorkerPodAutoScaler resource
 type WorkerPodAutoScalerStatus struct {
 	CurrentMessages   int32 `json:"CurrentMessages"`
@@ -40,6 +484,29 @@ type WorkerPodAutoScalerStatus struct {
 	// how often the number of pods is changed.
 	// +optional
 	LastScaleTime *metav1.Time `json:"LastScaleTime,omitempty"`
+
+	// Conditions are the latest observations of the WPA's state
+	// +optional
+	Conditions []metav1.Condition `json:"Conditions,omitempty"`
+
+	// QueueHealthy tells if the last poll of the queue succeeded
+	// +optional
+	QueueHealthy bool `json:"QueueHealthy"`
+
+	// LastPollError is the error of the last failed poll of the queue,
+	// it is cleared when a poll succeeds
+	// +optional
+	LastPollError string `json:"LastPollError,omitempty"`
+
+	// UnclampedDesiredReplicas is the workers required by the backlog
+	// before the min, max and maxDisruption are applied
+	// +optional
+	UnclampedDesiredReplicas int32 `json:"UnclampedDesiredReplicas"`
+
+	// NextScaleEligibleTime is the time after which the workers can be
+	// scaled again in both the directions, it is not set once they can
+	// +optional
+	NextScaleEligibleTime *metav1.Time `json:"NextScaleEligibleTime,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -51,3 +518,57 @@ type WorkerPodAutoScalerList struct {
 
 	Items []WorkerPodAutoScaler `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,shortName=wpadefault
+
+// WorkerPodAutoScalerDefault provides the defaults of the optional
+// fields for all the WPAs of the cluster. Only the object named
+// WorkerPodAutoScalerDefaultName is used.
+type WorkerPodAutoScalerDefault struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkerPodAutoScalerDefaultSpec `json:"spec"`
+}
+
+// WorkerPodAutoScalerDefaultName is the name of the
+// WorkerPodAutoScalerDefault used by the controller
+const WorkerPodAutoScalerDefaultName = "default"
+
+// WorkerPodAutoScalerDefaultSpec is the spec for a WorkerPodAutoScalerDefault
+// resource. The fields are used for the WPAs which do not set them, the
+// controller flags are used for the fields which are not set here.
+type WorkerPodAutoScalerDefaultSpec struct {
+	// MaxDisruption is the default maxDisruption of the WPAs
+	// +optional
+	MaxDisruption *string `json:"maxDisruption,omitempty"`
+	// ScaleDownDelaySeconds is the default scaleDownDelaySeconds of the WPAs
+	// +optional
+	ScaleDownDelaySeconds *int32 `json:"scaleDownDelaySeconds,omitempty"`
+	// ScaleUpDelaySeconds is the default scaleUpDelaySeconds of the WPAs
+	// +optional
+	ScaleUpDelaySeconds *int32 `json:"scaleUpDelaySeconds,omitempty"`
+	// Tolerance is the relative change of the workers which is too
+	// small to scale for, defaults to 0.1
+	// +optional
+	Tolerance *float64 `json:"tolerance,omitempty"`
+	// SQS is the default of the SQS poll of the WPAs which do not set
+	// the field in spec.sqs. QueuePrefix and MaxDiscoveredQueues are
+	// not used from the defaults.
+	// +optional
+	SQS *SQSOptions `json:"sqs,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WorkerPodAutoScalerDefaultList is a list of WorkerPodAutoScalerDefault resources
+type WorkerPodAutoScalerDefaultList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkerPodAutoScalerDefault `json:"items"`
+}
@@ -22,16 +22,270 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConservativeWindow) DeepCopyInto(out *ConservativeWindow) {
+	*out = *in
+	if in.DisableScaleDown != nil {
+		in, out := &in.DisableScaleDown, &out.DisableScaleDown
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Tolerance != nil {
+		in, out := &in.Tolerance, &out.Tolerance
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConservativeWindow.
+func (in *ConservativeWindow) DeepCopy() *ConservativeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ConservativeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdleSource) DeepCopyInto(out *IdleSource) {
+	*out = *in
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdleSource.
+func (in *IdleSource) DeepCopy() *IdleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(IdleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessageWeights) DeepCopyInto(out *MessageWeights) {
+	*out = *in
+	if in.Weights != nil {
+		in, out := &in.Weights, &out.Weights
+		*out = make(map[string]float64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DefaultWeight != nil {
+		in, out := &in.DefaultWeight, &out.DefaultWeight
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessageWeights.
+func (in *MessageWeights) DeepCopy() *MessageWeights {
+	if in == nil {
+		return nil
+	}
+	out := new(MessageWeights)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SQSOptions) DeepCopyInto(out *SQSOptions) {
+	*out = *in
+	if in.WaitTimeSeconds != nil {
+		in, out := &in.WaitTimeSeconds, &out.WaitTimeSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.QueueAttributes != nil {
+		in, out := &in.QueueAttributes, &out.QueueAttributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxDiscoveredQueues != nil {
+		in, out := &in.MaxDiscoveredQueues, &out.MaxDiscoveredQueues
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BacklogStalenessGuardPolls != nil {
+		in, out := &in.BacklogStalenessGuardPolls, &out.BacklogStalenessGuardPolls
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SQSOptions.
+func (in *SQSOptions) DeepCopy() *SQSOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(SQSOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafetyQueue) DeepCopyInto(out *SafetyQueue) {
+	*out = *in
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafetyQueue.
+func (in *SafetyQueue) DeepCopy() *SafetyQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(SafetyQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleTargetRef) DeepCopyInto(out *ScaleTargetRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleTargetRef.
+func (in *ScaleTargetRef) DeepCopy() *ScaleTargetRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleTargetRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingGroup) DeepCopyInto(out *ScalingGroup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingGroup.
+func (in *ScalingGroup) DeepCopy() *ScalingGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleRule) DeepCopyInto(out *ScheduleRule) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleRule.
+func (in *ScheduleRule) DeepCopy() *ScheduleRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRef) DeepCopyInto(out *TargetRef) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRef.
+func (in *TargetRef) DeepCopy() *TargetRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedTarget) DeepCopyInto(out *WeightedTarget) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedTarget.
+func (in *WeightedTarget) DeepCopy() *WeightedTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkerPodAutoScaler) DeepCopyInto(out *WorkerPodAutoScaler) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -53,6 +307,107 @@ func (in *WorkerPodAutoScaler) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerPodAutoScalerDefault) DeepCopyInto(out *WorkerPodAutoScalerDefault) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerPodAutoScalerDefault.
+func (in *WorkerPodAutoScalerDefault) DeepCopy() *WorkerPodAutoScalerDefault {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerPodAutoScalerDefault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkerPodAutoScalerDefault) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerPodAutoScalerDefaultList) DeepCopyInto(out *WorkerPodAutoScalerDefaultList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkerPodAutoScalerDefault, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerPodAutoScalerDefaultList.
+func (in *WorkerPodAutoScalerDefaultList) DeepCopy() *WorkerPodAutoScalerDefaultList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerPodAutoScalerDefaultList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkerPodAutoScalerDefaultList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerPodAutoScalerDefaultSpec) DeepCopyInto(out *WorkerPodAutoScalerDefaultSpec) {
+	*out = *in
+	if in.MaxDisruption != nil {
+		in, out := &in.MaxDisruption, &out.MaxDisruption
+		*out = new(string)
+		**out = **in
+	}
+	if in.ScaleDownDelaySeconds != nil {
+		in, out := &in.ScaleDownDelaySeconds, &out.ScaleDownDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleUpDelaySeconds != nil {
+		in, out := &in.ScaleUpDelaySeconds, &out.ScaleUpDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Tolerance != nil {
+		in, out := &in.Tolerance, &out.Tolerance
+		*out = new(float64)
+		**out = **in
+	}
+	if in.SQS != nil {
+		in, out := &in.SQS, &out.SQS
+		*out = new(SQSOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerPodAutoScalerDefaultSpec.
+func (in *WorkerPodAutoScalerDefaultSpec) DeepCopy() *WorkerPodAutoScalerDefaultSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerPodAutoScalerDefaultSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkerPodAutoScalerList) DeepCopyInto(out *WorkerPodAutoScalerList) {
 	*out = *in
@@ -114,6 +469,147 @@ func (in *WorkerPodAutoScalerSpec) DeepCopyInto(out *WorkerPodAutoScalerSpec) {
 		*out = new(float64)
 		**out = **in
 	}
+	if in.TargetRef != nil {
+		in, out := &in.TargetRef, &out.TargetRef
+		*out = new(TargetRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleTargetRef != nil {
+		in, out := &in.ScaleTargetRef, &out.ScaleTargetRef
+		*out = new(ScaleTargetRef)
+		**out = **in
+	}
+	if in.WeightedTargets != nil {
+		in, out := &in.WeightedTargets, &out.WeightedTargets
+		*out = make([]WeightedTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DisableVelocityMinWorkers != nil {
+		in, out := &in.DisableVelocityMinWorkers, &out.DisableVelocityMinWorkers
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RespectDisruptionOnIdle != nil {
+		in, out := &in.RespectDisruptionOnIdle, &out.RespectDisruptionOnIdle
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ThroughputMode != nil {
+		in, out := &in.ThroughputMode, &out.ThroughputMode
+		*out = new(bool)
+		**out = **in
+	}
+	if in.BalanceAwareScaling != nil {
+		in, out := &in.BalanceAwareScaling, &out.BalanceAwareScaling
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SmoothMessagesSentPerMinute != nil {
+		in, out := &in.SmoothMessagesSentPerMinute, &out.SmoothMessagesSentPerMinute
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.SafetyQueue != nil {
+		in, out := &in.SafetyQueue, &out.SafetyQueue
+		*out = new(SafetyQueue)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MessageWeights != nil {
+		in, out := &in.MessageWeights, &out.MessageWeights
+		*out = new(MessageWeights)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreferIdlePodsOnScaleDown != nil {
+		in, out := &in.PreferIdlePodsOnScaleDown, &out.PreferIdlePodsOnScaleDown
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IdleSource != nil {
+		in, out := &in.IdleSource, &out.IdleSource
+		*out = new(IdleSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PanicThreshold != nil {
+		in, out := &in.PanicThreshold, &out.PanicThreshold
+		*out = new(float64)
+		**out = **in
+	}
+	if in.PanicWindowSeconds != nil {
+		in, out := &in.PanicWindowSeconds, &out.PanicWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FastScaleUpThreshold != nil {
+		in, out := &in.FastScaleUpThreshold, &out.FastScaleUpThreshold
+		*out = new(float64)
+		**out = **in
+	}
+	if in.ScalingGroup != nil {
+		in, out := &in.ScalingGroup, &out.ScalingGroup
+		*out = new(ScalingGroup)
+		**out = **in
+	}
+	if in.AutoEstimateProcessingTime != nil {
+		in, out := &in.AutoEstimateProcessingTime, &out.AutoEstimateProcessingTime
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ScaleDownDelaySeconds != nil {
+		in, out := &in.ScaleDownDelaySeconds, &out.ScaleDownDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleUpDelaySeconds != nil {
+		in, out := &in.ScaleUpDelaySeconds, &out.ScaleUpDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SQS != nil {
+		in, out := &in.SQS, &out.SQS
+		*out = new(SQSOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WarmFloor != nil {
+		in, out := &in.WarmFloor, &out.WarmFloor
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ColdStartReplicas != nil {
+		in, out := &in.ColdStartReplicas, &out.ColdStartReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InitialStabilizationSeconds != nil {
+		in, out := &in.InitialStabilizationSeconds, &out.InitialStabilizationSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BacklogAgeSLOSeconds != nil {
+		in, out := &in.BacklogAgeSLOSeconds, &out.BacklogAgeSLOSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]ScheduleRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConservativeWindows != nil {
+		in, out := &in.ConservativeWindows, &out.ConservativeWindows
+		*out = make([]ConservativeWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -130,6 +626,21 @@ func (in *WorkerPodAutoScalerSpec) DeepCopy() *WorkerPodAutoScalerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkerPodAutoScalerStatus) DeepCopyInto(out *WorkerPodAutoScalerStatus) {
 	*out = *in
+	if in.LastScaleTime != nil {
+		in, out := &in.LastScaleTime, &out.LastScaleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NextScaleEligibleTime != nil {
+		in, out := &in.NextScaleEligibleTime, &out.NextScaleEligibleTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
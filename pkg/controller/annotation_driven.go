@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/practo/klog/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/validation"
+)
+
+const (
+	// QueueURIAnnotation on a deployment makes the controller scale it
+	// on the queue without a WPA object when --annotation-driven is set
+	QueueURIAnnotation = "workerpodautoscaler.practo.com/queue-uri"
+
+	// TargetPerWorkerAnnotation is the targetMessagesPerWorker of
+	// the deployment scaled using the annotations
+	TargetPerWorkerAnnotation = "workerpodautoscaler.practo.com/target-per-worker"
+
+	// MinReplicasAnnotation is the minReplicas of the deployment
+	// scaled using the annotations, 0 if not set
+	MinReplicasAnnotation = "workerpodautoscaler.practo.com/min-replicas"
+
+	// MaxReplicasAnnotation is the maxReplicas of the deployment
+	// scaled using the annotations
+	MaxReplicasAnnotation = "workerpodautoscaler.practo.com/max-replicas"
+
+	// annotationDrivenAnnotation marks the WPAs synthesized from the
+	// annotations of a deployment, their status is kept in memory
+	annotationDrivenAnnotation = "workerpodautoscaler.practo.com/annotation-driven"
+)
+
+// isAnnotationDriven tells if the WPA was synthesized from the
+// annotations of a deployment and does not exist in the cluster
+func isAnnotationDriven(workerPodAutoScaler *v1.WorkerPodAutoScaler) bool {
+	return workerPodAutoScaler.Annotations[annotationDrivenAnnotation] == "true"
+}
+
+// hasQueueAnnotations tells if the deployment asks to be scaled
+// using its annotations
+func hasQueueAnnotations(deployment *appsv1.Deployment) bool {
+	_, ok := deployment.Annotations[QueueURIAnnotation]
+	return ok
+}
+
+// newAnnotatedWorkerPodAutoScaler synthesizes the WPA of the deployment
+// from its annotations. The WPA has the name of the deployment.
+func newAnnotatedWorkerPodAutoScaler(
+	deployment *appsv1.Deployment) (*v1.WorkerPodAutoScaler, error) {
+
+	annotations := deployment.Annotations
+	parse := func(annotation string, required bool, value *int32) error {
+		s, ok := annotations[annotation]
+		if !ok {
+			if required {
+				return fmt.Errorf("%s is not set", annotation)
+			}
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s=%q is not an integer", annotation, s)
+		}
+		*value = int32(i)
+		return nil
+	}
+
+	var minReplicas, maxReplicas, target int32
+	if err := parse(TargetPerWorkerAnnotation, true, &target); err != nil {
+		return nil, err
+	}
+	if err := parse(MinReplicasAnnotation, false, &minReplicas); err != nil {
+		return nil, err
+	}
+	if err := parse(MaxReplicasAnnotation, true, &maxReplicas); err != nil {
+		return nil, err
+	}
+
+	workerPodAutoScaler := &v1.WorkerPodAutoScaler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1.SchemeGroupVersion.String(),
+			Kind:       "WorkerPodAutoScaler",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deployment.Name,
+			Namespace: deployment.Namespace,
+			Annotations: map[string]string{
+				annotationDrivenAnnotation: "true",
+			},
+		},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			DeploymentName:          deployment.Name,
+			QueueURI:                annotations[QueueURIAnnotation],
+			TargetMessagesPerWorker: &target,
+			MinReplicas:             &minReplicas,
+			MaxReplicas:             &maxReplicas,
+		},
+	}
+	if errs := validation.ValidateWorkerPodAutoScaler(workerPodAutoScaler); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+	return workerPodAutoScaler, nil
+}
+
+// getAnnotatedWorkerPodAutoScaler returns the WPA synthesized from the
+// annotations of the deployment with the status of its last sync
+func (c *Controller) getAnnotatedWorkerPodAutoScaler(
+	key string) (*v1.WorkerPodAutoScaler, bool) {
+
+	obj, ok := c.annotatedWPAs.Load(key)
+	if !ok {
+		return nil, false
+	}
+	workerPodAutoScaler := obj.(*v1.WorkerPodAutoScaler).DeepCopy()
+	if status, ok := c.annotatedStatuses.Load(key); ok {
+		workerPodAutoScaler.Status = status.(v1.WorkerPodAutoScalerStatus)
+	}
+	return workerPodAutoScaler, true
+}
+
+// syncAnnotatedDeployment keeps the WPA synthesized from the annotations
+// of the deployment and enqueues it. The deployments which are targeted
+// by a WPA object, or share its name, are left to the WPA object.
+func (c *Controller) syncAnnotatedDeployment(
+	deployment *appsv1.Deployment, eventName string, annotationsChanged bool) {
+
+	key := getKey(deployment.Namespace, deployment.Name)
+	_, existing := c.annotatedWPAs.Load(key)
+	forget := func() {
+		if existing {
+			c.annotatedWPAs.Delete(key)
+			c.workqueue.Add(WokerPodAutoScalerEvent{
+				key:  key,
+				name: WokerPodAutoScalerEventDelete,
+			})
+		}
+	}
+
+	if !hasQueueAnnotations(deployment) {
+		forget()
+		return
+	}
+	targeted, err := c.workerPodAutoScalersIndexer.ByIndex(deploymentNameIndex, key)
+	if err != nil {
+		klog.Errorf("%s: unable to find the wpa of the deployment, err: %v", key, err)
+		return
+	}
+	_, err = c.workerPodAutoScalersLister.WorkerPodAutoScalers(
+		deployment.Namespace).Get(deployment.Name)
+	if len(targeted) > 0 || err == nil {
+		klog.V(2).Infof("%s: a wpa object manages the deployment, ignoring its annotations", key)
+		forget()
+		return
+	}
+
+	workerPodAutoScaler, err := newAnnotatedWorkerPodAutoScaler(deployment)
+	if err != nil {
+		if annotationsChanged {
+			klog.Warningf("%s: not scaling the deployment, %v", key, err)
+			c.recorder.Eventf(deployment, corev1.EventTypeWarning,
+				"InvalidAnnotations", "Not scaling the deployment: %v", err)
+		}
+		forget()
+		return
+	}
+
+	if !existing {
+		eventName = WokerPodAutoScalerEventAdd
+	}
+	c.annotatedWPAs.Store(key, workerPodAutoScaler)
+	c.workqueue.Add(WokerPodAutoScalerEvent{key: key, name: eventName})
+}
+
+func (c *Controller) handleAnnotatedDeploymentAdd(obj interface{}) {
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+	c.syncAnnotatedDeployment(deployment, WokerPodAutoScalerEventAdd, true)
+}
+
+// handleAnnotatedDeploymentUpdate syncs the annotated deployment on
+// the resync, when its annotations change or it is scaled outside of WPA
+func (c *Controller) handleAnnotatedDeploymentUpdate(old, new interface{}) {
+	oldDeployment, ok := old.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+	newDeployment, ok := new.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+	annotationsChanged := !reflect.DeepEqual(
+		oldDeployment.Annotations, newDeployment.Annotations)
+	if oldDeployment.ResourceVersion != newDeployment.ResourceVersion &&
+		!annotationsChanged &&
+		!replicasChanged(oldDeployment.Spec.Replicas, newDeployment.Spec.Replicas) {
+		return
+	}
+	c.syncAnnotatedDeployment(newDeployment, WokerPodAutoScalerEventUpdate, annotationsChanged)
+}
+
+func (c *Controller) handleAnnotatedDeploymentDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	if _, ok := c.annotatedWPAs.Load(key); !ok {
+		return
+	}
+	c.annotatedWPAs.Delete(key)
+	c.workqueue.Add(WokerPodAutoScalerEvent{
+		key:  key,
+		name: WokerPodAutoScalerEventDelete,
+	})
+}
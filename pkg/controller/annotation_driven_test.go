@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	listers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/listers/workerpodautoscaler/v1"
+)
+
+func annotatedDeployment(annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "otpsender",
+			Namespace:   "testns",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestNewAnnotatedWorkerPodAutoScaler(t *testing.T) {
+	queueURI := "https://sqs.ap-south-1.amazonaws.com/1234/otp"
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectErr   bool
+		minReplicas int32
+	}{
+		{
+			name: "valid",
+			annotations: map[string]string{
+				QueueURIAnnotation:        queueURI,
+				TargetPerWorkerAnnotation: "10",
+				MinReplicasAnnotation:     "1",
+				MaxReplicasAnnotation:     "20",
+			},
+			minReplicas: 1,
+		},
+		{
+			name: "min replicas defaults to 0",
+			annotations: map[string]string{
+				QueueURIAnnotation:        queueURI,
+				TargetPerWorkerAnnotation: "10",
+				MaxReplicasAnnotation:     "20",
+			},
+		},
+		{
+			name: "max replicas not set",
+			annotations: map[string]string{
+				QueueURIAnnotation:        queueURI,
+				TargetPerWorkerAnnotation: "10",
+			},
+			expectErr: true,
+		},
+		{
+			name: "target is not an integer",
+			annotations: map[string]string{
+				QueueURIAnnotation:        queueURI,
+				TargetPerWorkerAnnotation: "ten",
+				MaxReplicasAnnotation:     "20",
+			},
+			expectErr: true,
+		},
+		{
+			name: "min replicas above max replicas",
+			annotations: map[string]string{
+				QueueURIAnnotation:        queueURI,
+				TargetPerWorkerAnnotation: "10",
+				MinReplicasAnnotation:     "30",
+				MaxReplicasAnnotation:     "20",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wpa, err := newAnnotatedWorkerPodAutoScaler(annotatedDeployment(test.annotations))
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got=%+v", wpa.Spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !isAnnotationDriven(wpa) {
+				t.Errorf("expected the wpa to be annotation driven")
+			}
+			if wpa.Spec.DeploymentName != "otpsender" || wpa.Spec.QueueURI != queueURI ||
+				*wpa.Spec.MinReplicas != test.minReplicas {
+				t.Errorf("unexpected spec: %+v", wpa.Spec)
+			}
+		})
+	}
+}
+
+func TestSyncAnnotatedDeployment(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		deploymentNameIndex:  indexByDeploymentName,
+	})
+	c := &Controller{
+		workerPodAutoScalersLister:  listers.NewWorkerPodAutoScalerLister(indexer),
+		workerPodAutoScalersIndexer: indexer,
+		workqueue:                   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		recorder:                    record.NewFakeRecorder(10),
+		annotatedWPAs:               new(sync.Map),
+		annotatedStatuses:           new(sync.Map),
+	}
+	defer c.workqueue.ShutDown()
+
+	expectEvent := func(name string) {
+		t.Helper()
+		item, _ := c.workqueue.Get()
+		c.workqueue.Done(item)
+		event := item.(WokerPodAutoScalerEvent)
+		if event.key != "testns/otpsender" || event.name != name {
+			t.Errorf("expected a %s event of testns/otpsender, got=%+v", name, event)
+		}
+	}
+
+	deployment := annotatedDeployment(map[string]string{
+		QueueURIAnnotation:        "https://sqs.ap-south-1.amazonaws.com/1234/otp",
+		TargetPerWorkerAnnotation: "10",
+		MaxReplicasAnnotation:     "20",
+	})
+	c.syncAnnotatedDeployment(deployment, WokerPodAutoScalerEventUpdate, false)
+	expectEvent(WokerPodAutoScalerEventAdd)
+
+	c.annotatedStatuses.Store("testns/otpsender", v1.WorkerPodAutoScalerStatus{DesiredReplicas: 3})
+	wpa, ok := c.getAnnotatedWorkerPodAutoScaler("testns/otpsender")
+	if !ok || wpa.Status.DesiredReplicas != 3 {
+		t.Errorf("expected the wpa with the status of the last sync, got=%v, %v", wpa, ok)
+	}
+
+	// a wpa object targeting the deployment takes over from the annotations
+	indexer.Add(&v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otp", Namespace: "testns"},
+		Spec:       v1.WorkerPodAutoScalerSpec{DeploymentName: "otpsender"},
+	})
+	c.syncAnnotatedDeployment(deployment, WokerPodAutoScalerEventUpdate, false)
+	expectEvent(WokerPodAutoScalerEventDelete)
+	if _, ok := c.getAnnotatedWorkerPodAutoScaler("testns/otpsender"); ok {
+		t.Errorf("expected the annotated wpa to be dropped")
+	}
+}
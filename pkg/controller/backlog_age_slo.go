@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// checkBacklogAgeSLO reports in the SLOViolated condition if the oldest
+// message in the queue is older than spec.backlogAgeSLOSeconds. It tells
+// if the workload keeps up with the queue, even at maxReplicas, and does
+// not change the scaling.
+func (c *Controller) checkBacklogAgeSLO(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	metricQueueName string,
+	oldestMessageAge float64) *v1.WorkerPodAutoScaler {
+
+	if workerPodAutoScaler.Spec.BacklogAgeSLOSeconds == nil {
+		return workerPodAutoScaler
+	}
+	slo := *workerPodAutoScaler.Spec.BacklogAgeSLOSeconds
+	name := workerPodAutoScaler.Name
+	namespace := workerPodAutoScaler.Namespace
+
+	oldestMessageAgeSeconds.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(oldestMessageAge)
+
+	condition := getBacklogAgeSLOCondition(oldestMessageAge, slo)
+	var violated float64
+	if condition.Status == metav1.ConditionTrue {
+		violated = 1
+		existing := meta.FindStatusCondition(
+			workerPodAutoScaler.Status.Conditions, v1.ConditionSLOViolated)
+		if existing == nil || existing.Status != metav1.ConditionTrue {
+			klog.Warningf("%s: oldest message is %.0fs old, above the backlog age SLO of %ds",
+				key, oldestMessageAge, slo)
+		}
+	}
+	backlogAgeSLOViolated.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(violated)
+
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		condition,
+	)
+}
+
+// getBacklogAgeSLOCondition returns the SLOViolated condition for the
+// age of the oldest message, the message does not have the age so that
+// the status is not updated at every reconcile
+func getBacklogAgeSLOCondition(oldestMessageAge float64, slo int32) metav1.Condition {
+	if oldestMessageAge == queue.UnsyncedOldestMessageAge {
+		return metav1.Condition{
+			Type:    v1.ConditionSLOViolated,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "OldestMessageAgeUnknown",
+			Message: "The age of the oldest message in the queue is not known",
+		}
+	}
+	if oldestMessageAge > float64(slo) {
+		return metav1.Condition{
+			Type:    v1.ConditionSLOViolated,
+			Status:  metav1.ConditionTrue,
+			Reason:  "BacklogAgeAboveSLO",
+			Message: fmt.Sprintf("The oldest message in the queue is older than %ds", slo),
+		}
+	}
+	return metav1.Condition{
+		Type:    v1.ConditionSLOViolated,
+		Status:  metav1.ConditionFalse,
+		Reason:  "BacklogAgeWithinSLO",
+		Message: fmt.Sprintf("The oldest message in the queue is not older than %ds", slo),
+	}
+}
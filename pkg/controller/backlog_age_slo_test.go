@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func TestCheckBacklogAgeSLO(t *testing.T) {
+	slo := int32(300)
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+		Spec:       v1.WorkerPodAutoScalerSpec{BacklogAgeSLOSeconds: &slo},
+	}
+	c := &Controller{customclientset: fake.NewSimpleClientset(wpa)}
+	ctx := context.Background()
+
+	tests := []struct {
+		name             string
+		oldestMessageAge float64
+		expectedStatus   metav1.ConditionStatus
+		expectedReason   string
+	}{
+		{"age not known", queue.UnsyncedOldestMessageAge, metav1.ConditionUnknown, "OldestMessageAgeUnknown"},
+		{"within the slo", 120, metav1.ConditionFalse, "BacklogAgeWithinSLO"},
+		{"at the slo", 300, metav1.ConditionFalse, "BacklogAgeWithinSLO"},
+		{"above the slo", 301, metav1.ConditionTrue, "BacklogAgeAboveSLO"},
+		{"back within the slo", 0, metav1.ConditionFalse, "BacklogAgeWithinSLO"},
+	}
+	for _, test := range tests {
+		wpa = c.checkBacklogAgeSLO(ctx, "testns/wpa", wpa, "otpsender", test.oldestMessageAge)
+		condition := meta.FindStatusCondition(wpa.Status.Conditions, v1.ConditionSLOViolated)
+		if condition == nil || condition.Status != test.expectedStatus || condition.Reason != test.expectedReason {
+			t.Errorf("%s: expected %s/%s, got=%v",
+				test.name, test.expectedStatus, test.expectedReason, condition)
+		}
+	}
+}
+
+func TestCheckBacklogAgeSLONotSet(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+	}
+	c := &Controller{customclientset: fake.NewSimpleClientset(wpa)}
+
+	wpa = c.checkBacklogAgeSLO(context.Background(), "testns/wpa", wpa, "otpsender", 1000)
+	if meta.FindStatusCondition(wpa.Status.Conditions, v1.ConditionSLOViolated) != nil {
+		t.Errorf("expected no SLOViolated condition without backlogAgeSLOSeconds")
+	}
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"math"
+)
+
+// getBalanceWorkers returns the workers to add to the workers required
+// by the backlog when the backlog grows in the balanceAwareScaling. The
+// workers process the growth in messages per minute as fast as the
+// secondsToProcessOneJob tells, the growth of a minute is divided by the
+// target when it is not known. No workers are added when the backlog
+// does not grow.
+func getBalanceWorkers(input ScalingInput) int32 {
+	if !input.BalanceAwareScaling || input.BacklogBalance <= 0 ||
+		input.TargetMessagesPerWorker <= 0 {
+		return 0
+	}
+	if input.SecondsToProcessOneJob > 0.0 {
+		return int32(math.Ceil(
+			input.BacklogBalance * input.SecondsToProcessOneJob / 60))
+	}
+	return int32(math.Ceil(
+		input.BacklogBalance / float64(input.TargetMessagesPerWorker)))
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// BreakerState is the state of the circuit breaker of the scaling of a WPA
+type BreakerState int
+
+const (
+	// BreakerClosed lets the workload be scaled
+	BreakerClosed BreakerState = iota
+	// BreakerOpen stops the scaling after repeated failures
+	BreakerOpen
+	// BreakerHalfOpen lets one scale be tried after the cooldown
+	BreakerHalfOpen
+)
+
+// breaker keeps the consecutive failures to scale the workload of a WPA
+type breaker struct {
+	failures int
+	openedAt time.Time
+	lastErr  error
+}
+
+// getBreakerState returns the state of the breaker of the WPA at now
+func (c *Controller) getBreakerState(key string, now time.Time) BreakerState {
+	if c.scaleFailureThreshold == 0 {
+		return BreakerClosed
+	}
+	obj, ok := c.breakers.Load(key)
+	if !ok {
+		return BreakerClosed
+	}
+	b := obj.(breaker)
+	if b.failures < c.scaleFailureThreshold {
+		return BreakerClosed
+	}
+	if now.Sub(b.openedAt) < c.scaleFailureCooldown {
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
+
+// recordScaleResult counts the consecutive failures to scale the
+// workload, the breaker opens when they reach the scale failure
+// threshold and opens again if the scale tried after the cooldown fails.
+// It returns the state of the breaker after the result.
+func (c *Controller) recordScaleResult(
+	key string, err error, now time.Time) BreakerState {
+
+	if c.scaleFailureThreshold == 0 {
+		return BreakerClosed
+	}
+	if err == nil {
+		c.breakers.Delete(key)
+		return BreakerClosed
+	}
+
+	var b breaker
+	if obj, ok := c.breakers.Load(key); ok {
+		b = obj.(breaker)
+	}
+	b.failures++
+	b.lastErr = err
+	if b.failures >= c.scaleFailureThreshold {
+		b.openedAt = now
+	}
+	c.breakers.Store(key, b)
+	return c.getBreakerState(key, now)
+}
+
+// reportBreaker reports the state of the breaker of the WPA using the
+// ScalingDisabledAfterFailures condition and the wpa_scaling_breaker_state
+// metric, a warning event is recorded when the breaker opens
+func (c *Controller) reportBreaker(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	metricQueueName string,
+	targetKind string,
+	targetName string,
+	now time.Time) *v1.WorkerPodAutoScaler {
+
+	state := c.getBreakerState(key, now)
+	scalingBreakerState.WithLabelValues(
+		workerPodAutoScaler.Name,
+		workerPodAutoScaler.Namespace,
+		metricQueueName,
+	).Set(float64(state))
+
+	existing := meta.FindStatusCondition(workerPodAutoScaler.Status.Conditions,
+		v1.ConditionScalingDisabledAfterFailures)
+	if state == BreakerClosed {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionScalingDisabledAfterFailures,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ScalingSucceeded",
+				Message: fmt.Sprintf("Scaled %s %s", targetKind, targetName),
+			},
+		)
+	}
+
+	obj, _ := c.breakers.Load(key)
+	b := obj.(breaker)
+	message := fmt.Sprintf(
+		"%d consecutive failures to scale %s %s, retrying after %s, last error: %v",
+		b.failures, targetKind, targetName,
+		b.openedAt.Add(c.scaleFailureCooldown).UTC().Format(time.RFC3339),
+		b.lastErr)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		klog.Warningf("%s: %s", key, message)
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionScalingDisabledAfterFailures, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionScalingDisabledAfterFailures,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ScaleFailures",
+			Message: message,
+		},
+	)
+}
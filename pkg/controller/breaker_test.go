@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	c := &Controller{
+		scaleFailureThreshold: 3,
+		scaleFailureCooldown:  5 * time.Minute,
+		breakers:              new(sync.Map),
+	}
+	key := "ns/wpa"
+	start := time.Now()
+	scaleErr := fmt.Errorf("admission webhook denied the request")
+
+	for i := 0; i < 2; i++ {
+		if state := c.recordScaleResult(key, scaleErr, start); state != BreakerClosed {
+			t.Errorf("failure %d: expected the breaker closed, got %v", i+1, state)
+		}
+	}
+	if state := c.recordScaleResult(key, scaleErr, start); state != BreakerOpen {
+		t.Errorf("expected the breaker open after the threshold, got %v", state)
+	}
+	if state := c.getBreakerState(key, start.Add(time.Minute)); state != BreakerOpen {
+		t.Errorf("expected the breaker open in the cooldown, got %v", state)
+	}
+
+	halfOpen := start.Add(5 * time.Minute)
+	if state := c.getBreakerState(key, halfOpen); state != BreakerHalfOpen {
+		t.Errorf("expected the breaker half open after the cooldown, got %v", state)
+	}
+	if state := c.recordScaleResult(key, scaleErr, halfOpen); state != BreakerOpen {
+		t.Errorf("expected the breaker to open again on failure, got %v", state)
+	}
+	if state := c.getBreakerState(key, halfOpen.Add(time.Minute)); state != BreakerOpen {
+		t.Errorf("expected a new cooldown after the failed retry, got %v", state)
+	}
+
+	later := halfOpen.Add(5 * time.Minute)
+	if state := c.recordScaleResult(key, nil, later); state != BreakerClosed {
+		t.Errorf("expected the breaker closed on success, got %v", state)
+	}
+	if _, ok := c.breakers.Load(key); ok {
+		t.Errorf("expected the failures to be forgotten on success")
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	c := &Controller{breakers: new(sync.Map)}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		c.recordScaleResult("ns/wpa", fmt.Errorf("forbidden"), now)
+	}
+	if state := c.getBreakerState("ns/wpa", now); state != BreakerClosed {
+		t.Errorf("expected the breaker closed when disabled, got %v", state)
+	}
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/validation"
+)
+
+// scalingDefaults are the values used for the optional fields which
+// are not set in the WPA spec
+type scalingDefaults struct {
+	maxDisruption  string
+	scaleDownDelay time.Duration
+	scaleUpDelay   time.Duration
+	tolerance      float64
+	sqs            *v1.SQSOptions
+}
+
+// resolveScalingDefaults returns the defaults of the WorkerPodAutoScalerDefault
+// for the fields it sets and the defaults of the controller for the rest
+func resolveScalingDefaults(
+	controllerDefaults scalingDefaults,
+	clusterDefault *v1.WorkerPodAutoScalerDefault) scalingDefaults {
+
+	defaults := controllerDefaults
+	if clusterDefault == nil {
+		return defaults
+	}
+
+	spec := clusterDefault.Spec
+	if spec.MaxDisruption != nil {
+		defaults.maxDisruption = *spec.MaxDisruption
+	}
+	if spec.ScaleDownDelaySeconds != nil {
+		defaults.scaleDownDelay = time.Duration(*spec.ScaleDownDelaySeconds) * time.Second
+	}
+	if spec.ScaleUpDelaySeconds != nil {
+		defaults.scaleUpDelay = time.Duration(*spec.ScaleUpDelaySeconds) * time.Second
+	}
+	if spec.Tolerance != nil {
+		defaults.tolerance = *spec.Tolerance
+	}
+	if spec.SQS != nil {
+		defaults.sqs = spec.SQS.DeepCopy()
+	}
+	return defaults
+}
+
+// getScalingDefaults returns the defaults of the optional fields of the
+// WPA spec, the cluster default takes precedence over the controller flags
+func (c *Controller) getScalingDefaults() scalingDefaults {
+	controllerDefaults := scalingDefaults{
+		maxDisruption:  c.defaultMaxDisruption,
+		scaleDownDelay: c.scaleDownDelay,
+		tolerance:      defaultTolerance,
+	}
+	if c.workerPodAutoScalerDefaultsLister == nil {
+		return controllerDefaults
+	}
+
+	clusterDefault, err := c.workerPodAutoScalerDefaultsLister.Get(
+		v1.WorkerPodAutoScalerDefaultName)
+	if errors.IsNotFound(err) {
+		return controllerDefaults
+	} else if err != nil {
+		klog.Errorf("Error getting the wpa default, err: %v", err)
+		return controllerDefaults
+	}
+	if errs := validation.ValidateWorkerPodAutoScalerDefault(clusterDefault); len(errs) > 0 {
+		// the errors are logged when the default changes
+		return controllerDefaults
+	}
+	return resolveScalingDefaults(controllerDefaults, clusterDefault)
+}
+
+// getMaxDisruption returns the maxDisruption of the WPA
+// or the default if it is not set
+func (d scalingDefaults) getMaxDisruption(workerPodAutoScaler *v1.WorkerPodAutoScaler) *string {
+	return workerPodAutoScaler.GetMaxDisruption(d.maxDisruption)
+}
+
+// getScaleDownDelay returns the scaleDownDelaySeconds of the WPA
+// or the default if it is not set
+func (d scalingDefaults) getScaleDownDelay(workerPodAutoScaler *v1.WorkerPodAutoScaler) time.Duration {
+	return workerPodAutoScaler.GetScaleDownDelay(d.scaleDownDelay)
+}
+
+// getScaleUpDelay returns the scaleUpDelaySeconds of the WPA
+// or the default if it is not set
+func (d scalingDefaults) getScaleUpDelay(workerPodAutoScaler *v1.WorkerPodAutoScaler) time.Duration {
+	if workerPodAutoScaler.Spec.ScaleUpDelaySeconds == nil {
+		return d.scaleUpDelay
+	}
+	return workerPodAutoScaler.GetScaleUpDelay()
+}
+
+// handleWorkerPodAutoScalerDefaultChange enqueues all the WPAs
+// so that they pick up the changed defaults
+func (c *Controller) handleWorkerPodAutoScalerDefaultChange(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil || key != v1.WorkerPodAutoScalerDefaultName {
+		return
+	}
+	if wpaDefault, ok := obj.(*v1.WorkerPodAutoScalerDefault); ok {
+		if errs := validation.ValidateWorkerPodAutoScalerDefault(wpaDefault); len(errs) > 0 {
+			klog.Errorf("Ignoring the wpa default, the controller defaults are used: %v",
+				errs.ToAggregate())
+		}
+	}
+
+	wpas, err := c.workerPodAutoScalersLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Error listing the wpas, err: %v", err)
+		return
+	}
+	klog.V(2).Infof("wpa default changed, enqueuing %d wpas", len(wpas))
+	for _, wpa := range wpas {
+		c.enqueueUpdateWorkerPodAutoScaler(wpa)
+	}
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestResolveScalingDefaults(t *testing.T) {
+	controllerDefaults := scalingDefaults{
+		maxDisruption:  "100%",
+		scaleDownDelay: 10 * time.Minute,
+		tolerance:      defaultTolerance,
+	}
+	if defaults := resolveScalingDefaults(controllerDefaults, nil); defaults != controllerDefaults {
+		t.Errorf("expected the controller defaults without a cluster default, got=%+v", defaults)
+	}
+
+	maxDisruption := "10%"
+	scaleUpDelaySeconds := int32(30)
+	tolerance := 0.2
+	defaults := resolveScalingDefaults(controllerDefaults, &v1.WorkerPodAutoScalerDefault{
+		Spec: v1.WorkerPodAutoScalerDefaultSpec{
+			MaxDisruption:       &maxDisruption,
+			ScaleUpDelaySeconds: &scaleUpDelaySeconds,
+			Tolerance:           &tolerance,
+		},
+	})
+	if defaults.maxDisruption != "10%" || defaults.scaleUpDelay != 30*time.Second ||
+		defaults.tolerance != 0.2 {
+		t.Errorf("expected the cluster default to be used, got=%+v", defaults)
+	}
+	if defaults.scaleDownDelay != 10*time.Minute {
+		t.Errorf("expected the controller default for the fields not set, got=%v",
+			defaults.scaleDownDelay)
+	}
+
+	// the spec of the WPA takes precedence over the cluster default
+	specDisruption := "50%"
+	specScaleUpDelaySeconds := int32(0)
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			MaxDisruption:       &specDisruption,
+			ScaleUpDelaySeconds: &specScaleUpDelaySeconds,
+		},
+	}
+	if got := *defaults.getMaxDisruption(wpa); got != "50%" {
+		t.Errorf("expected the maxDisruption of the spec, got=%v", got)
+	}
+	if got := defaults.getScaleUpDelay(wpa); got != 0 {
+		t.Errorf("expected the scaleUpDelaySeconds of the spec, got=%v", got)
+	}
+	if got := defaults.getScaleUpDelay(&v1.WorkerPodAutoScaler{}); got != 30*time.Second {
+		t.Errorf("expected the scaleUpDelaySeconds of the default, got=%v", got)
+	}
+}
+
+func TestGetSQSOptionsDefaults(t *testing.T) {
+	defaultWaitTime := int32(5)
+	defaultGuardPolls := int32(2)
+	defaults := &v1.SQSOptions{
+		WaitTimeSeconds:            &defaultWaitTime,
+		QueueAttributes:            []string{"ApproximateNumberOfMessages"},
+		BacklogStalenessGuardPolls: &defaultGuardPolls,
+	}
+
+	if options := getSQSOptions(&v1.WorkerPodAutoScaler{}, nil); options != nil {
+		t.Errorf("expected the controller defaults, got=%+v", options)
+	}
+
+	options := getSQSOptions(&v1.WorkerPodAutoScaler{}, defaults)
+	if options == nil || *options.WaitTimeSeconds != 5 || len(options.QueueAttributes) != 1 ||
+		options.BacklogStalenessGuardPolls != 2 {
+		t.Errorf("expected the cluster default, got=%+v", options)
+	}
+
+	waitTime := int32(20)
+	options = getSQSOptions(&v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			SQS: &v1.SQSOptions{WaitTimeSeconds: &waitTime},
+		},
+	}, defaults)
+	if *options.WaitTimeSeconds != 20 || len(options.QueueAttributes) != 1 {
+		t.Errorf("expected the wait time of the spec and the default attributes, got=%+v", options)
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/schedule"
+)
+
+// applyConservativeWindow returns the conservative window of the WPA
+// active at now, nil if none is active. The active window is reported
+// in the ConservativeWindowActive condition.
+func (c *Controller) applyConservativeWindow(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	now time.Time) (*v1.WorkerPodAutoScaler, *v1.ConservativeWindow) {
+
+	windows := workerPodAutoScaler.Spec.ConservativeWindows
+	if len(windows) == 0 && meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionConservativeWindowActive) == nil {
+		return workerPodAutoScaler, nil
+	}
+
+	active, errs := schedule.ActiveWindow(windows, now)
+	for _, err := range errs {
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+	}
+
+	condition := metav1.Condition{
+		Type:    v1.ConditionConservativeWindowActive,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoConservativeWindowActive",
+		Message: "The scaling is not conservative",
+	}
+	if active != nil {
+		condition = metav1.Condition{
+			Type:    v1.ConditionConservativeWindowActive,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ConservativeWindowActive",
+			Message: getConservativeWindowMessage(active),
+		}
+	}
+	workerPodAutoScaler = updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		condition,
+	)
+	return workerPodAutoScaler, active
+}
+
+// getConservativeWindowMessage describes what the window changes
+func getConservativeWindowMessage(window *v1.ConservativeWindow) string {
+	message := fmt.Sprintf("Conservative window %s is active", window.Name)
+	if window.GetDisableScaleDown() {
+		message += ", not scaling down"
+	}
+	if window.Tolerance != nil {
+		message += fmt.Sprintf(", tolerance=%v", *window.Tolerance)
+	}
+	return message
+}
+
+// getConservativeTolerance returns the tolerance of the active
+// window, the tolerance is not changed if the window does not set it
+func getConservativeTolerance(
+	window *v1.ConservativeWindow, tolerance float64) float64 {
+
+	if window == nil || window.Tolerance == nil {
+		return tolerance
+	}
+	return *window.Tolerance
+}
+
+// holdConservativeScaleDown keeps the current workers instead of
+// scaling down while the active window disables the scale downs
+func holdConservativeScaleDown(
+	window *v1.ConservativeWindow,
+	desiredWorkers int32,
+	currentWorkers int32,
+	scaleReason ScaleReason) (int32, ScaleReason) {
+
+	if window == nil || !window.GetDisableScaleDown() ||
+		desiredWorkers >= currentWorkers {
+		return desiredWorkers, scaleReason
+	}
+	return currentWorkers, ScaleReasonConservativeWindow
+}
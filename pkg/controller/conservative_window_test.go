@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+)
+
+func TestApplyConservativeWindow(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			ConservativeWindows: []v1.ConservativeWindow{
+				{Name: "freeze", Schedule: "0 22 * * *", DurationSeconds: 3600},
+			},
+		},
+	}
+	c := &Controller{customclientset: fake.NewSimpleClientset(wpa)}
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		now            time.Time
+		expectedActive bool
+		expectedStatus metav1.ConditionStatus
+	}{
+		{"before the window", time.Date(2021, 3, 1, 21, 59, 0, 0, time.UTC), false, metav1.ConditionFalse},
+		{"in the window", time.Date(2021, 3, 1, 22, 30, 0, 0, time.UTC), true, metav1.ConditionTrue},
+		{"after the window", time.Date(2021, 3, 1, 23, 0, 0, 0, time.UTC), false, metav1.ConditionFalse},
+	}
+	for _, test := range tests {
+		var window *v1.ConservativeWindow
+		wpa, window = c.applyConservativeWindow(ctx, "testns/wpa", wpa, test.now)
+		if (window != nil) != test.expectedActive {
+			t.Errorf("%s: expected active=%v, got=%v", test.name, test.expectedActive, window)
+		}
+		condition := meta.FindStatusCondition(wpa.Status.Conditions, v1.ConditionConservativeWindowActive)
+		if condition == nil || condition.Status != test.expectedStatus {
+			t.Errorf("%s: expected %s, got=%v", test.name, test.expectedStatus, condition)
+		}
+	}
+}
+
+func TestHoldConservativeScaleDown(t *testing.T) {
+	disabled := false
+	tolerance := 0.3
+	window := &v1.ConservativeWindow{Name: "freeze", Tolerance: &tolerance}
+
+	desired, reason := holdConservativeScaleDown(window, 2, 5, ScaleReasonBacklog)
+	if desired != 5 || reason != ScaleReasonConservativeWindow {
+		t.Errorf("expected the scale down to be held, got desired=%d, reason=%s", desired, reason)
+	}
+	desired, reason = holdConservativeScaleDown(window, 8, 5, ScaleReasonBacklog)
+	if desired != 8 || reason != ScaleReasonBacklog {
+		t.Errorf("expected the scale up, got desired=%d, reason=%s", desired, reason)
+	}
+	desired, _ = holdConservativeScaleDown(nil, 2, 5, ScaleReasonBacklog)
+	if desired != 2 {
+		t.Errorf("expected the scale down without a window, got desired=%d", desired)
+	}
+
+	window.DisableScaleDown = &disabled
+	desired, _ = holdConservativeScaleDown(window, 2, 5, ScaleReasonBacklog)
+	if desired != 2 {
+		t.Errorf("expected the scale down when it is not disabled, got desired=%d", desired)
+	}
+
+	if got := getConservativeTolerance(window, 0.1); got != 0.3 {
+		t.Errorf("expected the tolerance of the window, got=%v", got)
+	}
+	if got := getConservativeTolerance(nil, 0.1); got != 0.1 {
+		t.Errorf("expected the tolerance of the controller, got=%v", got)
+	}
+}
@@ -4,21 +4,34 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/practo/klog/v2"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -30,6 +43,9 @@ import (
 	informers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/informers/externalversions/workerpodautoscaler/v1"
 	listers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/listers/workerpodautoscaler/v1"
 	queue "github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/sink"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/version"
 )
 
 const controllerAgentName = "workerpodautoscaler-controller"
@@ -56,9 +72,54 @@ const (
 
 	// WokerPodAutoScalerEventDelete stores the add event name
 	WokerPodAutoScalerEventDelete = "delete"
+
+	// WokerPodAutoScalerEventFastScaleUp stores the name of the event
+	// queued by the fast loop, it is synced like an update but only
+	// scales up and without the scale up delay
+	WokerPodAutoScalerEventFastScaleUp = "fastScaleUp"
+
+	// deploymentNameIndex indexes the WPAs by namespace/spec.deploymentName
+	deploymentNameIndex = "deploymentName"
+
+	// replicaSetNameIndex indexes the WPAs by namespace/spec.replicaSetName
+	replicaSetNameIndex = "replicaSetName"
+
+	// defaultTolerance is the relative change of the workers
+	// which is too small to scale for
+	defaultTolerance = 0.1
+
+	// statefulSetNameIndex indexes the WPAs by namespace/spec.targetRef.name
+	// of the StatefulSet targets
+	statefulSetNameIndex = "statefulSetName"
+
+	// targetSelectorIndex indexes the WPAs which select their target
+	// using a label selector by namespace/spec.targetRef.kind
+	targetSelectorIndex = "targetSelector"
+
+	// hpaTargetIndex indexes the HPAs by the
+	// namespace/kind/name of spec.scaleTargetRef
+	hpaTargetIndex = "hpaTarget"
+
+	// credentialsSecretIndex indexes the WPAs by the
+	// namespace/name of spec.credentialsSecretRef
+	credentialsSecretIndex = "credentialsSecret"
+
+	// scalingGroupIndex indexes the WPAs by
+	// namespace/spec.scalingGroup.name
+	scalingGroupIndex = "scalingGroup"
 )
 
 var (
+	buildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "build_info",
+			Help:      "Always 1, labelled by the version, the git commit and the go version the controller is built with",
+		},
+		[]string{"version", "git_commit", "go_version"},
+	)
+
 	loopDurationSeconds = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "wpa",
@@ -69,6 +130,45 @@ var (
 		[]string{"workerpodautoscaler", "namespace"},
 	)
 
+	lastReconcileTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "last_reconcile_timestamp_seconds",
+			Help:      "Unix time of the last successful control loop, partitioned by wpa name and namespace",
+		},
+		[]string{"workerpodautoscaler", "namespace"},
+	)
+
+	managedWPAs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "managed_wpas",
+			Help:      "Number of wpa resources managed by the controller, partitioned by namespace",
+		},
+		[]string{"namespace"},
+	)
+
+	scaleUpsDeferred = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "scale_ups_deferred",
+			Help:      "How many times the scale up was deferred by the max scale ups per minute limit, partitioned by wpa name and namespace",
+		},
+		[]string{"workerpodautoscaler", "namespace"},
+	)
+
+	scaleSuppressedByTolerance = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Name:      "scale_suppressed_by_tolerance_total",
+			Help:      "How many times a scale was not made as the change in the workers was within the tolerance, partitioned by wpa name and namespace",
+		},
+		[]string{"workerpodautoscaler", "namespace"},
+	)
+
 	loopCountSuccess = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "wpa",
@@ -99,6 +199,16 @@ var (
 		[]string{"workerpodautoscaler", "namespace", "queueName"},
 	)
 
+	qMsgsSPMAvg = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "messages_sent_per_minute_avg",
+			Help:      "Moving average of the number of messages sent to the queue per minute over 5 minutes",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
 	workersIdle = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "wpa",
@@ -129,6 +239,91 @@ var (
 		[]string{"workerpodautoscaler", "namespace", "queueName"},
 	)
 
+	workersMin = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "worker",
+			Name:      "min",
+			Help:      "Number of minimum workers as specified in minReplicas",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	workersMinComputed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "worker",
+			Name:      "min_computed",
+			Help:      "Number of minimum workers after adjusting minReplicas based on the messages sent per minute",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	workersMaxDisruptionPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "worker",
+			Name:      "max_disruption_pods",
+			Help:      "Number of workers which can be scaled down in a single scale down, resolved from maxDisruption. A percentage of the current workers is rounded up",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	secondsToProcessOneJobEstimate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "seconds_to_process_one_job_estimate",
+			Help:      "Estimated seconds to process one job by one worker, set when autoEstimateProcessingTime is enabled",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	scaleReasonGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "scale_reason",
+			Help:      "1 for the reason which decided the desired workers in the last reconcile, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName", "reason"},
+	)
+
+	scalerAlgorithmInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "scaler_algorithm_info",
+			Help:      "1 for the scaling algorithm used by the wpa in the last reconcile, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName", "algorithm"},
+	)
+
+	panicMode = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "panic_mode",
+			Help:      "1 if the wpa is in panic because the backlog per worker exceeded the panicThreshold, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	scaleCooldownRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "scale_cooldown_remaining_seconds",
+			Help:      "seconds left till the wpa can scale again in the direction, derived from the last scale time and the scale up or down delay",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName", "direction"},
+	)
+
+	scalingBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "scaling_breaker_state",
+			Help:      "state of the circuit breaker of the scaling of the wpa, 0 if closed, 1 if open after repeated failures to scale, 2 if half open",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
 	workersAvailable = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "wpa",
@@ -138,17 +333,142 @@ var (
 		},
 		[]string{"workerpodautoscaler", "namespace", "queueName"},
 	)
+
+	atMaxReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "at_max_replicas",
+			Help:      "1 if the workers required by the backlog met or exceeded the maxReplicas and the desired workers are maxReplicas, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	targetOverrideActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "target_override_active",
+			Help:      "1 if the target override annotation overrides the targetMessagesPerWorker of the wpa, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	oldestMessageAgeSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "oldest_message_age_seconds",
+			Help:      "Age of the oldest message in the queue, only known for the wpas with backlogAgeSLOSeconds",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	backlogAgeSLOViolated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "backlog_age_slo_violated",
+			Help:      "1 if the oldest message in the queue is older than the backlogAgeSLOSeconds of the wpa, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	backlogBalance = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "backlog_balance_per_minute",
+			Help:      "Rolling messages sent minus messages processed per minute, positive when the backlog grows",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	queuePollInterval = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "poll_interval_seconds",
+			Help:      "Adaptive wait between the polls of the sqs queue, longer while the backlog is stable",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	queueAPICallsPerMinute = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "api_calls_per_minute",
+			Help:      "Rate of the GetQueueAttributes calls made by the polls of the sqs queue",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	queueUnsynced = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "queue",
+			Name:      "unsynced",
+			Help:      "1 if the backlog of the queue is not known and the last desired workers are held, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName"},
+	)
+
+	scheduleActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Name:      "schedule_active",
+			Help:      "1 if the schedule overrides the replica bounds of the wpa, else 0",
+		},
+		[]string{"workerpodautoscaler", "namespace", "queueName", "schedule"},
+	)
+
+	idleSourceScrapes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Name:      "idle_source_scrapes_total",
+			Help:      "Number of scrapes of the idle workers from the pods of the wpas with an idleSource, partitioned by the result",
+		},
+		[]string{"result"},
+	)
 )
 
 func init() {
+	prometheus.MustRegister(buildInfo)
+	buildInfo.WithLabelValues(
+		version.GetVersion(),
+		version.GetGitCommit(),
+		runtime.Version(),
+	).Set(1)
 	prometheus.MustRegister(loopDurationSeconds)
 	prometheus.MustRegister(loopCountSuccess)
+	prometheus.MustRegister(lastReconcileTimestamp)
+	prometheus.MustRegister(scaleUpsDeferred)
+	prometheus.MustRegister(scaleSuppressedByTolerance)
+	prometheus.MustRegister(managedWPAs)
 	prometheus.MustRegister(qMsgs)
 	prometheus.MustRegister(qMsgsSPM)
+	prometheus.MustRegister(qMsgsSPMAvg)
+	prometheus.MustRegister(oldestMessageAgeSeconds)
+	prometheus.MustRegister(backlogAgeSLOViolated)
+	prometheus.MustRegister(queueUnsynced)
+	prometheus.MustRegister(backlogBalance)
+	prometheus.MustRegister(queuePollInterval)
+	prometheus.MustRegister(queueAPICallsPerMinute)
 	prometheus.MustRegister(workersIdle)
 	prometheus.MustRegister(workersCurrent)
 	prometheus.MustRegister(workersDesired)
 	prometheus.MustRegister(workersAvailable)
+	prometheus.MustRegister(workersMin)
+	prometheus.MustRegister(workersMinComputed)
+	prometheus.MustRegister(workersMaxDisruptionPods)
+	prometheus.MustRegister(panicMode)
+	prometheus.MustRegister(scalingBreakerState)
+	prometheus.MustRegister(scaleCooldownRemaining)
+	prometheus.MustRegister(scaleReasonGauge)
+	prometheus.MustRegister(scalerAlgorithmInfo)
+	prometheus.MustRegister(secondsToProcessOneJobEstimate)
+	prometheus.MustRegister(atMaxReplicas)
+	prometheus.MustRegister(scheduleActive)
+	prometheus.MustRegister(targetOverrideActive)
+	prometheus.MustRegister(idleSourceScrapes)
 }
 
 type WokerPodAutoScalerEvent struct {
@@ -163,19 +483,42 @@ type Controller struct {
 	// kubeclientset is a standard kubernetes clientset
 	kubeclientset kubernetes.Interface
 	// customclientset is a clientset for our own API group
-	customclientset            clientset.Interface
-	deploymentLister           appslisters.DeploymentLister
-	deploymentsSynced          cache.InformerSynced
-	replicaSetLister           appslisters.ReplicaSetLister
-	replicaSetsSynced          cache.InformerSynced
+	customclientset clientset.Interface
+	// scaleNamespacer and restMapper scale the workloads of the
+	// scaleTargetRef using their scale subresource
+	scaleNamespacer    scale.ScalesGetter
+	restMapper         meta.RESTMapper
+	deploymentLister   appslisters.DeploymentLister
+	deploymentsSynced  cache.InformerSynced
+	replicaSetLister   appslisters.ReplicaSetLister
+	replicaSetsSynced  cache.InformerSynced
+	statefulSetLister  appslisters.StatefulSetLister
+	statefulSetsSynced cache.InformerSynced
+	secretLister       corelisters.SecretLister
+	// podLister is nil if the crash loops of the workers are not checked
+	podLister  corelisters.PodLister
+	podsSynced cache.InformerSynced
+	// hpaIndexer is used to find the HPAs which target
+	// the same workload as the WPA
+	hpaIndexer                 cache.Indexer
+	hpasSynced                 cache.InformerSynced
 	workerPodAutoScalersLister listers.WorkerPodAutoScalerLister
 	workerPodAutoScalersSynced cache.InformerSynced
+	// workerPodAutoScalerDefaultsLister is nil if the
+	// WorkerPodAutoScalerDefault is not used
+	workerPodAutoScalerDefaultsLister listers.WorkerPodAutoScalerDefaultLister
+	// workerPodAutoScalersIndexer is used to find the WPAs which
+	// target a deployment or a replicaset
+	workerPodAutoScalersIndexer cache.Indexer
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
 	// time, and makes it easy to ensure we are never processing the same item
 	// simultaneously in two different workers.
 	workqueue workqueue.RateLimitingInterface
+	// syncLocks makes sure that a WPA is not synced by two workers
+	// at once, its events are different items in the workqueue
+	syncLocks keyLocks
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
@@ -192,6 +535,110 @@ type Controller struct {
 	// the no of seconds to wait after the last scale up before scaling down
 	scaleDownDelay time.Duration
 
+	// updateRetry is the backoff used to retry the update of the
+	// deployment or replicaset on conflicts
+	updateRetry wait.Backoff
+
+	// scaleUpLimiter limits the scale ups across all the WPAs,
+	// it is nil if the scale ups are not limited
+	scaleUpLimiter *rate.Limiter
+
+	// panicUntil keeps the time till which the queue of the WPA is in
+	// panic, keyed by the WPA key
+	panicUntil *sync.Map
+
+	// scalingStuckWindow is the time after which the available workers
+	// stalled below the current workers stop the scale ups,
+	// 0 means the scale ups are never stopped
+	scalingStuckWindow time.Duration
+
+	// stalls keeps the stall of the available workers, keyed by the WPA key
+	stalls *sync.Map
+
+	// crashLoopRestarts is the restarts of a container after which its
+	// pod is crash looping if it failed within the crashLoopWindow,
+	// 0 means the crash loops of the workers are not checked
+	crashLoopRestarts int32
+	crashLoopWindow   time.Duration
+
+	// countTerminatingWorkers counts the terminating pods of the
+	// workload in the maxDisruption of the scale downs
+	countTerminatingWorkers bool
+
+	// forceSyncs keeps the last handled force sync annotation,
+	// keyed by the WPA key
+	forceSyncs *sync.Map
+
+	// targetOverrides keeps the active target override annotation,
+	// keyed by the WPA key
+	targetOverrides *sync.Map
+
+	// annotatedWPAs keeps the WPAs synthesized from the annotations of
+	// the deployments in the annotation driven mode, keyed by the WPA key
+	annotatedWPAs *sync.Map
+	// annotatedStatuses keeps the status of the last sync of the
+	// annotated WPAs as it is not written to the cluster
+	annotatedStatuses *sync.Map
+
+	// labelTargets tells if the managed by label
+	// is set on the workloads scaled by WPA
+	labelTargets bool
+
+	// gcOnTargetDelete tells if the workload scaled by the WPA is set as
+	// its owner, so that the WPA is deleted with the workload
+	gcOnTargetDelete bool
+
+	// scaleFailureThreshold is the number of consecutive failures to
+	// scale the workload after which the scaling of the WPA is stopped
+	// for the scaleFailureCooldown, 0 means the scaling is never stopped
+	scaleFailureThreshold int
+	scaleFailureCooldown  time.Duration
+
+	// firstSeen keeps the time the WPA without a creation time was
+	// first synced, keyed by the WPA key
+	firstSeen *sync.Map
+
+	// manualScales keeps the time till which the automatic scaling of
+	// the WPA is paused after a manual scale, keyed by the WPA key
+	manualScales *sync.Map
+
+	// fastScaleUpInterval is the interval of the fast loop which
+	// queues the WPAs above their fastScaleUpThreshold for a scale up,
+	// 0 means the fast loop is not run
+	fastScaleUpInterval time.Duration
+
+	// slowReconcileThreshold is the time after which a reconcile is
+	// logged with the time taken by its phases, 0 means it is not logged
+	slowReconcileThreshold time.Duration
+
+	// processingTimeMismatchFactor is the factor by which the
+	// secondsToProcessOneJob and the observed processing time differ
+	// before it is reported, 0 means it is not checked
+	processingTimeMismatchFactor float64
+
+	// breakers keeps the failures to scale the workload,
+	// keyed by the WPA key
+	breakers *sync.Map
+
+	// groupDemand keeps the desired workers of the WPAs in a scaling
+	// group before the group budget is applied, keyed by the WPA key
+	groupDemand *sync.Map
+
+	// scaleEventSink publishes the scale operations,
+	// it is nil if the scale events are not published
+	scaleEventSink sink.Sink
+
+	// lowCardinalityMetrics drops the queueName label and the metrics
+	// partitioned further than the WPA
+	lowCardinalityMetrics bool
+	// metricSeries keeps the series exported for the WPA,
+	// keyed by the WPA key
+	metricSeries *sync.Map
+
+	// scrapeIdle returns the idle workers served by the
+	// idleSource endpoint of a pod
+	scrapeIdle func(ctx context.Context, url string) (int32, error)
+
 	Queues *queue.Queues
 }
 
@@ -200,12 +647,35 @@ func NewController(
 	ctx context.Context,
 	kubeclientset kubernetes.Interface,
 	customclientset clientset.Interface,
+	scaleNamespacer scale.ScalesGetter,
+	restMapper meta.RESTMapper,
 	deploymentInformer appsinformers.DeploymentInformer,
 	replicaSetInformer appsinformers.ReplicaSetInformer,
+	statefulSetInformer appsinformers.StatefulSetInformer,
+	hpaInformer autoscalinginformers.HorizontalPodAutoscalerInformer,
+	secretInformer coreinformers.SecretInformer,
+	podInformer coreinformers.PodInformer,
 	workerPodAutoScalerInformer informers.WorkerPodAutoScalerInformer,
+	workerPodAutoScalerDefaultInformer informers.WorkerPodAutoScalerDefaultInformer,
 	defaultMaxDisruption string,
 	resyncPeriod time.Duration,
 	scaleDownDelay time.Duration,
+	updateRetry wait.Backoff,
+	scaleUpLimiter *rate.Limiter,
+	scalingStuckWindow time.Duration,
+	crashLoopRestarts int32,
+	crashLoopWindow time.Duration,
+	countTerminatingWorkers bool,
+	annotationDriven bool,
+	labelTargets bool,
+	gcOnTargetDelete bool,
+	scaleFailureThreshold int,
+	scaleFailureCooldown time.Duration,
+	slowReconcileThreshold time.Duration,
+	processingTimeMismatchFactor float64,
+	fastScaleUpInterval time.Duration,
+	scaleEventSink sink.Sink,
+	lowCardinalityMetrics bool,
 	queues *queue.Queues) *Controller {
 
 	// Create event broadcaster
@@ -219,21 +689,62 @@ func NewController(
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
-		ctx:                        ctx,
-		kubeclientset:              kubeclientset,
-		customclientset:            customclientset,
-		deploymentLister:           deploymentInformer.Lister(),
-		deploymentsSynced:          deploymentInformer.Informer().HasSynced,
-		replicaSetLister:           replicaSetInformer.Lister(),
-		replicaSetsSynced:          replicaSetInformer.Informer().HasSynced,
-		workerPodAutoScalersLister: workerPodAutoScalerInformer.Lister(),
-		workerPodAutoScalersSynced: workerPodAutoScalerInformer.Informer().HasSynced,
-		workqueue:                  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "WorkerPodAutoScalers"),
-		recorder:                   recorder,
-		defaultMaxDisruption:       defaultMaxDisruption,
-		scaleDownDelay:             scaleDownDelay,
-		Queues:                     queues,
+		ctx:                          ctx,
+		kubeclientset:                kubeclientset,
+		customclientset:              customclientset,
+		scaleNamespacer:              scaleNamespacer,
+		restMapper:                   restMapper,
+		deploymentLister:             deploymentInformer.Lister(),
+		deploymentsSynced:            deploymentInformer.Informer().HasSynced,
+		replicaSetLister:             replicaSetInformer.Lister(),
+		replicaSetsSynced:            replicaSetInformer.Informer().HasSynced,
+		statefulSetLister:            statefulSetInformer.Lister(),
+		statefulSetsSynced:           statefulSetInformer.Informer().HasSynced,
+		hpaIndexer:                   hpaInformer.Informer().GetIndexer(),
+		hpasSynced:                   hpaInformer.Informer().HasSynced,
+		workerPodAutoScalersLister:   workerPodAutoScalerInformer.Lister(),
+		workerPodAutoScalersSynced:   workerPodAutoScalerInformer.Informer().HasSynced,
+		workerPodAutoScalersIndexer:  workerPodAutoScalerInformer.Informer().GetIndexer(),
+		workqueue:                    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "WorkerPodAutoScalers"),
+		recorder:                     recorder,
+		defaultMaxDisruption:         defaultMaxDisruption,
+		scaleDownDelay:               scaleDownDelay,
+		updateRetry:                  updateRetry,
+		Queues:                       queues,
+		panicUntil:                   new(sync.Map),
+		groupDemand:                  new(sync.Map),
+		scalingStuckWindow:           scalingStuckWindow,
+		stalls:                       new(sync.Map),
+		crashLoopRestarts:            crashLoopRestarts,
+		crashLoopWindow:              crashLoopWindow,
+		countTerminatingWorkers:      countTerminatingWorkers,
+		forceSyncs:                   new(sync.Map),
+		targetOverrides:              new(sync.Map),
+		annotatedWPAs:                new(sync.Map),
+		annotatedStatuses:            new(sync.Map),
+		labelTargets:                 labelTargets,
+		gcOnTargetDelete:             gcOnTargetDelete,
+		scaleFailureThreshold:        scaleFailureThreshold,
+		scaleFailureCooldown:         scaleFailureCooldown,
+		slowReconcileThreshold:       slowReconcileThreshold,
+		processingTimeMismatchFactor: processingTimeMismatchFactor,
+		fastScaleUpInterval:          fastScaleUpInterval,
+		breakers:                     new(sync.Map),
+		scaleEventSink:               scaleEventSink,
+		lowCardinalityMetrics:        lowCardinalityMetrics,
+		scrapeIdle:                   scrapeIdle,
+		metricSeries:                 new(sync.Map),
+		firstSeen:                    new(sync.Map),
+		manualScales:                 new(sync.Map),
+	}
+	if workerPodAutoScalerDefaultInformer != nil {
+		controller.workerPodAutoScalerDefaultsLister = workerPodAutoScalerDefaultInformer.Lister()
 	}
+	if podInformer != nil {
+		controller.podLister = podInformer.Lister()
+		controller.podsSynced = podInformer.Informer().HasSynced
+	}
+	controller.scaleUpLimiter = scaleUpLimiter
 
 	klog.V(4).Info("Setting up event handlers")
 
@@ -245,6 +756,77 @@ func NewController(
 		},
 		DeleteFunc: controller.enqueueDeleteWorkerPodAutoScaler,
 	}, resyncPeriod)
+
+	// Index the WorkerPodAutoScalers by their target so that the changes
+	// in the target can be mapped back to the WorkerPodAutoScalers
+	err := workerPodAutoScalerInformer.Informer().AddIndexers(cache.Indexers{
+		deploymentNameIndex:    indexByDeploymentName,
+		replicaSetNameIndex:    indexByReplicaSetName,
+		statefulSetNameIndex:   indexByStatefulSetName,
+		targetSelectorIndex:    indexByTargetSelector,
+		credentialsSecretIndex: indexByCredentialsSecret,
+		scalingGroupIndex:      indexByScalingGroup,
+	})
+	if err != nil {
+		klog.Fatalf("Error adding indexers to wpa informer: %v", err)
+	}
+
+	// Index the HPAs by their target to find the HPAs
+	// conflicting with the WorkerPodAutoScalers
+	err = hpaInformer.Informer().AddIndexers(cache.Indexers{
+		hpaTargetIndex: indexByHPATarget,
+	})
+	if err != nil {
+		klog.Fatalf("Error adding indexers to hpa informer: %v", err)
+	}
+
+	// Set up an event handler for when the replicas of the target
+	// Deployment, ReplicaSet or StatefulSet is changed outside of WPA, for example
+	// using kubectl scale. The WPA is reconciled to reassert the desired
+	// replicas instead of waiting for the resync.
+	deploymentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: controller.handleDeploymentUpdate,
+	})
+	replicaSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: controller.handleReplicaSetUpdate,
+	})
+	statefulSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: controller.handleStatefulSetUpdate,
+	})
+
+	// Set up an event handler for when the cluster default of the WPAs
+	// changes so that all the WPAs use it. Its cache is not waited upon,
+	// the WPAs use the controller defaults till it is listed.
+	if workerPodAutoScalerDefaultInformer != nil {
+		workerPodAutoScalerDefaultInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: controller.handleWorkerPodAutoScalerDefaultChange,
+			UpdateFunc: func(old, new interface{}) {
+				controller.handleWorkerPodAutoScalerDefaultChange(new)
+			},
+			DeleteFunc: controller.handleWorkerPodAutoScalerDefaultChange,
+		})
+	}
+
+	// Set up an event handler to scale the deployments annotated with
+	// the queue without a WPA object in the annotation driven mode
+	if annotationDriven {
+		deploymentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    controller.handleAnnotatedDeploymentAdd,
+			UpdateFunc: controller.handleAnnotatedDeploymentUpdate,
+			DeleteFunc: controller.handleAnnotatedDeploymentDelete,
+		})
+	}
+
+	// Set up an event handler for when the credentials secret is created
+	// or rotated so that the queue service picks up the new credentials.
+	// The secret cache is not waited upon, as a missing secret is
+	// reported in the WPA status and the WPA is reconciled once it shows up.
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleSecretAdd,
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleSecretUpdate(old, new)
+		},
+	})
 	return controller
 }
 
@@ -261,7 +843,11 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	// Wait for the caches to be synced before starting workers
 	klog.V(1).Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.workerPodAutoScalersSynced); !ok {
+	cacheSyncs := []cache.InformerSynced{c.deploymentsSynced, c.replicaSetsSynced, c.statefulSetsSynced, c.hpasSynced, c.workerPodAutoScalersSynced}
+	if c.podsSynced != nil {
+		cacheSyncs = append(cacheSyncs, c.podsSynced)
+	}
+	if ok := cache.WaitForCacheSync(stopCh, cacheSyncs...); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -271,6 +857,9 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 		// TOOD: move from stopCh to context, use: UntilWithContext()
 		go wait.Until(c.runWorker, time.Second, stopCh)
 	}
+	if c.fastScaleUpInterval > 0 {
+		go wait.Until(c.queueFastScaleUps, c.fastScaleUpInterval, stopCh)
+	}
 	<-stopCh
 	klog.V(1).Info("Shutting down workers")
 
@@ -321,6 +910,8 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// WorkerPodAutoScaler resource to be synced.
+		unlock := c.syncLocks.lock(event.key)
+		defer unlock()
 		if err := c.syncHandler(ctx, event); err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(event)
@@ -346,6 +937,8 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEvent) error {
 	now := time.Now()
 	key := event.key
+	timer := newReconcileTimer(now)
+	defer c.observeReconcile(key, timer)
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -353,48 +946,146 @@ func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEv
 		return nil
 	}
 
+	ctx, span := tracing.Tracer().Start(ctx, "syncHandler",
+		trace.WithAttributes(
+			attribute.String("wpa", name),
+			attribute.String("namespace", namespace),
+			attribute.String("event", event.name),
+		),
+	)
+	defer span.End()
+	timer.traceID, _ = tracing.TraceID(ctx)
+
 	// Get the WorkerPodAutoScaler resource with this namespace/name
 	workerPodAutoScaler, err := c.workerPodAutoScalersLister.WorkerPodAutoScalers(namespace).Get(name)
-	if err != nil {
-		// The WorkerPodAutoScaler resource may no longer exist, in which case we stop processing.
-		if errors.IsNotFound(err) {
-			utilruntime.HandleError(fmt.Errorf("workerPodAutoScaler '%s' in work queue no longer exists", key))
-			c.Queues.Delete(namespace, name)
+	if errors.IsNotFound(err) {
+		if annotated, ok := c.getAnnotatedWorkerPodAutoScaler(key); ok {
+			workerPodAutoScaler, err = annotated, nil
+		}
+	}
+	if errors.IsNotFound(err) {
+		if event.name == WokerPodAutoScalerEventDelete {
+			klog.V(2).Infof("%s: deleted, stopping the poll of its queue", key)
+			c.forgetWorkerPodAutoScaler(key, namespace, name)
 			return nil
 		}
+		// the informer sends a delete event for every deleted object,
+		// the queue is torn down when it is processed
+		klog.V(4).Infof("%s: not found for the %s event, waiting for the delete event",
+			key, event.name)
+		return nil
+	} else if err != nil {
 		return err
 	}
+	if event.name == WokerPodAutoScalerEventDelete {
+		// the WPA was created again before its delete event was processed
+		klog.V(2).Infof("%s: recreated after the delete, syncing it", key)
+		event.name = WokerPodAutoScalerEventUpdate
+	}
+	if event.name == WokerPodAutoScalerEventAdd {
+		c.updateManagedWPAs(namespace)
+	}
+	if isAnnotationDriven(workerPodAutoScaler) {
+		defer func() {
+			c.annotatedStatuses.Store(key, workerPodAutoScaler.Status)
+		}()
+	}
+	logLevel, err := getLogLevel(workerPodAutoScaler)
+	if err != nil {
+		klog.Warningf("%s: ignoring the log level, %v", key, err)
+	}
 
-	var currentWorkers, availableWorkers int32
-	deploymentName := workerPodAutoScaler.Spec.DeploymentName
-	replicaSetName := workerPodAutoScaler.Spec.ReplicaSetName
-	if deploymentName != "" {
-		// Get the Deployment with the name specified in WorkerPodAutoScaler.spec
-		deployment, err := c.deploymentLister.Deployments(workerPodAutoScaler.Namespace).Get(deploymentName)
-		if errors.IsNotFound(err) {
-			return fmt.Errorf("deployment %s not found in namespace %s",
-				deploymentName, workerPodAutoScaler.Namespace)
-		} else if err != nil {
-			return err
-		}
-		currentWorkers = *deployment.Spec.Replicas
-		availableWorkers = deployment.Status.AvailableReplicas
-	} else if replicaSetName != "" {
-		// Get the ReplicaSet with the name specified in WorkerPodAutoScaler.spec
-		replicaSet, err := c.replicaSetLister.ReplicaSets(workerPodAutoScaler.Namespace).Get(replicaSetName)
-		if errors.IsNotFound(err) {
-			return fmt.Errorf("ReplicaSet %s not found in namespace %s",
-				replicaSetName, workerPodAutoScaler.Namespace)
-		} else if err != nil {
-			return err
-		}
-		currentWorkers = *replicaSet.Spec.Replicas
-		availableWorkers = replicaSet.Status.AvailableReplicas
-	} else {
+	// the fields not set in the spec use the cluster default
+	// and then the defaults of the controller
+	defaults := c.getScalingDefaults()
+
+	targetKind, targetName, err := c.resolveTarget(workerPodAutoScaler)
+	if resolutionErr, ok := err.(*targetResolutionError); ok {
+		klog.Errorf("%s: unable to resolve target, err: %v", key, err)
+		updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionTargetResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  resolutionErr.reason,
+				Message: resolutionErr.message,
+			},
+		)
+		// the target is resolved again in the next resync
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if targetName == "" {
 		// We choose to absorb the error here as the worker would requeue the
 		// resource otherwise. Instead, the next time the resource is updated
 		// the resource will be queued again.
-		utilruntime.HandleError(fmt.Errorf("%s: deployment or replicaset name must be specified", key))
+		utilruntime.HandleError(fmt.Errorf("%s: deployment, replicaset name, targetRef, scaleTargetRef or weightedTargets must be specified", key))
+		return nil
+	}
+	if workerPodAutoScaler.Spec.TargetRef != nil {
+		workerPodAutoScaler = updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionTargetResolved,
+				Status:  metav1.ConditionTrue,
+				Reason:  "TargetFound",
+				Message: fmt.Sprintf("Resolved to %s %s", targetKind, targetName),
+			},
+		)
+	}
+
+	workerPodAutoScaler, conflicting := c.checkConflictingHPA(
+		ctx, workerPodAutoScaler, targetKind, targetName)
+	if conflicting {
+		// the conflict is checked again in the next resync
+		return nil
+	}
+
+	weightedTargets := workerPodAutoScaler.Spec.WeightedTargets
+	var currentWorkers, availableWorkers int32
+	if len(weightedTargets) > 0 {
+		currentWorkers, availableWorkers, err = c.getWeightedTargetReplicas(
+			namespace, weightedTargets)
+	} else {
+		currentWorkers, availableWorkers, err = c.getTargetReplicas(
+			namespace, targetKind, targetName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.labelTargets {
+		labelled := []v1.WeightedTarget{{Kind: targetKind, Name: targetName}}
+		if len(weightedTargets) > 0 {
+			labelled = weightedTargets
+		}
+		for _, target := range labelled {
+			err := c.labelTarget(ctx, namespace, target.Kind, target.Name, name)
+			if err != nil {
+				// the workload is scaled without the label
+				klog.Errorf("%s: unable to label the target, err: %v", key, err)
+			}
+		}
+	}
+
+	if c.gcOnTargetDelete {
+		workerPodAutoScaler, err = c.setTargetOwnerReference(
+			ctx, workerPodAutoScaler, targetKind, targetName)
+		if err != nil {
+			// the wpa is scaled and is not deleted with the target
+			klog.Errorf("%s: unable to set the owner reference, err: %v", key, err)
+		}
+	}
+
+	workerPodAutoScaler, credentials, unavailable := c.checkCredentials(
+		ctx, workerPodAutoScaler)
+	if unavailable {
+		// the wpa is queued again when the secret is added or updated
 		return nil
 	}
 
@@ -403,6 +1094,23 @@ func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEv
 		secondsToProcessOneJob = *workerPodAutoScaler.Spec.SecondsToProcessOneJob
 	}
 
+	workerPodAutoScaler, invalidTarget := c.checkTargetMessagesPerWorker(
+		ctx, workerPodAutoScaler)
+	if invalidTarget {
+		// the WPA is reconciled again when its spec is fixed
+		return nil
+	}
+	workerPodAutoScaler = c.checkReplicaBounds(ctx, workerPodAutoScaler)
+	messageWeights := getMessageWeights(workerPodAutoScaler)
+
+	workerPodAutoScaler, invalidQueueURI := c.checkQueueURI(ctx, workerPodAutoScaler)
+	if invalidQueueURI {
+		// the wpa is queued again when the queueURI is fixed
+		return nil
+	}
+
+	_, queueSyncSpan := tracing.Tracer().Start(ctx, "queueSync")
+	endQueueSync := timer.startPhase(reconcilePhaseQueueSync)
 	switch event.name {
 	case WokerPodAutoScalerEventAdd:
 		err = c.Queues.Add(
@@ -411,116 +1119,508 @@ func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEv
 			workerPodAutoScaler.Spec.QueueURI,
 			currentWorkers,
 			secondsToProcessOneJob,
+			workerPodAutoScaler.GetAutoEstimateProcessingTime(),
+			credentials,
+			messageWeights,
+			workerPodAutoScaler.Spec.Query,
+			getSQSOptions(workerPodAutoScaler, defaults.sqs),
 		)
-	case WokerPodAutoScalerEventUpdate:
+	case WokerPodAutoScalerEventUpdate, WokerPodAutoScalerEventFastScaleUp:
 		err = c.Queues.Add(
 			namespace,
 			name,
 			workerPodAutoScaler.Spec.QueueURI,
 			currentWorkers,
 			secondsToProcessOneJob,
+			workerPodAutoScaler.GetAutoEstimateProcessingTime(),
+			credentials,
+			messageWeights,
+			workerPodAutoScaler.Spec.Query,
+			getSQSOptions(workerPodAutoScaler, defaults.sqs),
 		)
-	case WokerPodAutoScalerEventDelete:
-		err = c.Queues.Delete(namespace, name)
 	}
+	if err == nil {
+		err = c.syncSafetyQueue(workerPodAutoScaler, credentials)
+	}
+	queueSyncSpan.End()
+	endQueueSync()
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("unable to sync queue: %s", err.Error()))
+		span.RecordError(err)
 		return err
 	}
 
+	if c.isForceSyncRequested(key, workerPodAutoScaler, now) {
+		klog.Infof("%s: force sync requested, polling the queue", key)
+		endPollNow := timer.startPhase(reconcilePhaseQueueSync)
+		if !c.Queues.PollNow(namespace, name, forceSyncPollTimeout) {
+			klog.Warningf("%s: queue was not polled in %v, using the last poll",
+				key, forceSyncPollTimeout)
+		}
+		endPollNow()
+	}
+
 	queueName, queueMessages, messagesSentPerMinute, idleWorkers := c.Queues.GetQueueInfo(
 		namespace, name)
 	if queueName == "" {
 		return nil
 	}
-
-	if queueMessages == queue.UnsyncedQueueMessageCount {
-		klog.Warningf(
-			"%s qMsgs: %d, q not initialized, waiting for init to complete",
-			queueName,
-			queueMessages,
+	metricQueueName := c.getMetricQueueName(key, name, namespace, queueName)
+	queueHealthy, lastPollError := c.Queues.GetQueueHealth(namespace, name)
+	workerPodAutoScaler, queueAvailable := c.checkQueueAvailable(ctx, key,
+		workerPodAutoScaler, c.Queues.GetPollErrorReason(namespace, name), lastPollError)
+	workerPodAutoScaler = c.checkBacklogAgeSLO(ctx, key, workerPodAutoScaler,
+		metricQueueName, c.Queues.GetOldestMessageAge(namespace, name))
+
+	workerPodAutoScaler = c.checkQueueSynced(ctx, key, workerPodAutoScaler,
+		metricQueueName, queueMessages != queue.UnsyncedQueueMessageCount)
+
+	if queueMessages == queue.UnsyncedQueueMessageCount || !queueAvailable {
+		if queueMessages == queue.UnsyncedQueueMessageCount {
+			klog.Warningf(
+				"%s qMsgs: %d, q not initialized, waiting for init to complete",
+				queueName,
+				queueMessages,
+			)
+		}
+		// the queue may never be initialized if the polls fail and the
+		// last backlog is stale while the queue is not available, the
+		// scaling is frozen on the last desired replicas but the workers
+		// are still reported and the poll error is set in the status
+		desiredWorkers := workerPodAutoScaler.Status.DesiredReplicas
+		workersCurrent.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(float64(currentWorkers))
+		workersDesired.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(float64(desiredWorkers))
+		workersAvailable.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(float64(availableWorkers))
+		endStatus := timer.startPhase(reconcilePhaseStatus)
+		updateWorkerPodAutoScalerStatus(
+			ctx,
+			name,
+			namespace,
+			c.customclientset,
+			desiredWorkers,
+			workerPodAutoScaler,
+			currentWorkers,
+			availableWorkers,
+			workerPodAutoScaler.Status.CurrentMessages,
+			workerPodAutoScaler.Status.LastScaleTime,
+			queueHealthy,
+			lastPollError,
+			workerPodAutoScaler.Status.UnclampedDesiredReplicas,
+			workerPodAutoScaler.Status.NextScaleEligibleTime,
 		)
+		endStatus()
 		return nil
 	}
 
-	desiredWorkers := GetDesiredWorkers(
-		queueName,
-		queueMessages,
-		messagesSentPerMinute,
-		secondsToProcessOneJob,
-		*workerPodAutoScaler.Spec.TargetMessagesPerWorker,
-		currentWorkers,
-		idleWorkers,
-		*workerPodAutoScaler.Spec.MinReplicas,
-		*workerPodAutoScaler.Spec.MaxReplicas,
-		workerPodAutoScaler.GetMaxDisruption(c.defaultMaxDisruption),
+	idleWorkers = c.getScrapedIdleWorkers(
+		ctx, key, workerPodAutoScaler, targetKind, targetName, idleWorkers)
+
+	if workerPodAutoScaler.GetAutoEstimateProcessingTime() {
+		estimate := c.Queues.GetSecondsToProcessOneJobEstimate(namespace, name)
+		if estimate > 0 {
+			secondsToProcessOneJob = estimate
+		}
+		secondsToProcessOneJobEstimate.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(estimate)
+		logV(3, logLevel).Infof("%s secondsToProcessOneJob estimate: %v", queueName, estimate)
+	}
+	workerPodAutoScaler = c.checkProcessingTimeMismatch(ctx, key, workerPodAutoScaler)
+
+	// the metric of the messages sent per minute is of the last poll
+	// even when the scaling uses its average
+	lastMessagesSentPerMinute := messagesSentPerMinute
+	messagesSentPerMinute = c.getMessagesSentPerMinute(
+		workerPodAutoScaler, lastMessagesSentPerMinute)
+
+	balance, balanceKnown := c.Queues.GetBacklogBalance(namespace, name)
+	if balanceKnown {
+		backlogBalance.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(balance)
+		logV(3, logLevel).Infof("%s backlog balance: %v", queueName, balance)
+	}
+
+	pollInterval, callsPerMinute, pollScheduled := c.Queues.GetPollSchedule(namespace, name)
+	if pollScheduled {
+		queuePollInterval.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(pollInterval.Seconds())
+		queueAPICallsPerMinute.WithLabelValues(
+			name,
+			namespace,
+			metricQueueName,
+		).Set(callsPerMinute)
+	}
+
+	panicking := c.isPanicking(
+		key, workerPodAutoScaler, queueMessages, currentWorkers, now)
+
+	workerPodAutoScaler, minWorkers, maxWorkers := c.applySchedules(
+		ctx, key, workerPodAutoScaler, metricQueueName, now)
+	targetMessagesPerWorker, targetOverridden := c.getTargetMessagesPerWorker(
+		key, workerPodAutoScaler, now)
+	workerPodAutoScaler, conservativeWindow := c.applyConservativeWindow(
+		ctx, key, workerPodAutoScaler, now)
+	// the terminating pods count in the current workers so that the
+	// maxDisruption of back to back scale downs is not overshot
+	terminatingWorkers := c.getTerminatingWorkers(key, namespace, targetKind, targetName)
+
+	result := ComputeDesired(ScalingInput{
+		QueueName:                 queueName,
+		QueueMessages:             queueMessages,
+		MessagesSentPerMinute:     messagesSentPerMinute,
+		SecondsToProcessOneJob:    secondsToProcessOneJob,
+		TargetMessagesPerWorker:   targetMessagesPerWorker,
+		CurrentWorkers:            currentWorkers + terminatingWorkers,
+		IdleWorkers:               idleWorkers,
+		MinWorkers:                minWorkers,
+		MaxWorkers:                maxWorkers,
+		MaxDisruption:             *defaults.getMaxDisruption(workerPodAutoScaler),
+		DisableVelocityMinWorkers: workerPodAutoScaler.GetDisableVelocityMinWorkers(),
+		RespectDisruptionOnIdle:   workerPodAutoScaler.GetRespectDisruptionOnIdle(),
+		Panicking:                 panicking,
+		WarmFloor:                 workerPodAutoScaler.GetWarmFloor(),
+		ThroughputMode:            workerPodAutoScaler.GetThroughputMode(),
+		BalanceAwareScaling:       workerPodAutoScaler.GetBalanceAwareScaling(),
+		BacklogBalance:            balance,
+		ColdStartReplicas:         workerPodAutoScaler.GetColdStartReplicas(),
+		RoundingStrategy:          workerPodAutoScaler.GetRoundingStrategy(),
+		NoBacklogStrategy:         workerPodAutoScaler.GetNoBacklogStrategy(),
+		Tolerance:                 getConservativeTolerance(conservativeWindow, defaults.tolerance),
+		LogLevel:                  logLevel,
+	})
+	unclampedDesiredWorkers := result.UnclampedDesiredWorkers
+	if isSuppressedByTolerance(result, currentWorkers+terminatingWorkers) {
+		scaleSuppressedByTolerance.WithLabelValues(name, namespace).Inc()
+	}
+	desiredWorkers, scaleReason := holdTerminatingWorkers(
+		result.DesiredWorkers, currentWorkers, terminatingWorkers, result.Reason)
+	desiredWorkers, scaleReason = capByMessageGroups(
+		desiredWorkers,
+		c.Queues.GetMessageGroups(namespace, name),
+		minWorkers,
+		scaleReason,
 	)
-	klog.V(2).Infof("%s current: %d", queueName, currentWorkers)
-	klog.V(2).Infof("%s qMsgs: %d, desired: %d",
-		queueName, queueMessages, desiredWorkers)
+	desiredWorkers, scaleReason = c.allocateScalingGroup(
+		key, workerPodAutoScaler, desiredWorkers, scaleReason)
+	workerPodAutoScaler, rollingOut := c.checkRollout(ctx, key,
+		workerPodAutoScaler, targetKind, targetName)
+	if rollingOut && desiredWorkers < currentWorkers {
+		desiredWorkers = currentWorkers
+		scaleReason = ScaleReasonRolloutInProgress
+	}
+	desiredWorkers, scaleReason = holdConservativeScaleDown(
+		conservativeWindow, desiredWorkers, currentWorkers, scaleReason)
+	// the available workers dip while the pods are replaced in a
+	// rollout, it is not a stall
+	stallAvailableWorkers := availableWorkers
+	if rollingOut {
+		stallAvailableWorkers = currentWorkers
+	}
+	workerPodAutoScaler, stuck := c.checkScalingStuck(ctx, key,
+		workerPodAutoScaler, targetKind, targetName,
+		currentWorkers, stallAvailableWorkers, now)
+	if stuck && desiredWorkers > currentWorkers {
+		desiredWorkers = currentWorkers
+		scaleReason = ScaleReasonScalingStuck
+	}
+	workerPodAutoScaler, crashLooping := c.checkWorkersCrashLooping(ctx, key,
+		workerPodAutoScaler, targetKind, targetName, now)
+	if crashLooping && desiredWorkers > currentWorkers {
+		desiredWorkers = currentWorkers
+		scaleReason = ScaleReasonWorkersCrashLooping
+	}
+	workerPodAutoScaler, stabilizing := c.checkInitialStabilization(ctx, key,
+		workerPodAutoScaler, desiredWorkers, now)
+	if stabilizing {
+		desiredWorkers = currentWorkers
+		scaleReason = ScaleReasonInitialStabilization
+	}
+	logV(2, logLevel).Infof("%s current: %d, terminating: %d",
+		queueName, currentWorkers, terminatingWorkers)
+	logV(2, logLevel).Infof("%s qMsgs: %d, desired: %d, reason: %s",
+		queueName, queueMessages, desiredWorkers, scaleReason)
 
 	// set metrics
 	qMsgs.WithLabelValues(
 		name,
 		namespace,
-		queueName,
+		metricQueueName,
 	).Set(float64(queueMessages))
 	qMsgsSPM.WithLabelValues(
 		name,
 		namespace,
-		queueName,
-	).Set(messagesSentPerMinute)
+		metricQueueName,
+	).Set(lastMessagesSentPerMinute)
+	qMsgsSPMAvg.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(c.Queues.GetMessagesSentPerMinuteAverage(namespace, name))
 	workersIdle.WithLabelValues(
 		name,
 		namespace,
-		queueName,
+		metricQueueName,
 	).Set(float64(idleWorkers))
 	workersCurrent.WithLabelValues(
 		name,
 		namespace,
-		queueName,
+		metricQueueName,
 	).Set(float64(currentWorkers))
 	workersDesired.WithLabelValues(
 		name,
 		namespace,
-		queueName,
+		metricQueueName,
 	).Set(float64(desiredWorkers))
 	workersAvailable.WithLabelValues(
 		name,
 		namespace,
-		queueName,
+		metricQueueName,
 	).Set(float64(availableWorkers))
+	// the metrics partitioned further than the wpa are not exported
+	// in the low cardinality
+	if !c.lowCardinalityMetrics {
+		for _, reason := range scaleReasons {
+			var value float64
+			if reason == scaleReason {
+				value = 1
+			}
+			scaleReasonGauge.WithLabelValues(
+				name,
+				namespace,
+				metricQueueName,
+				string(reason),
+			).Set(value)
+		}
+		for _, algorithm := range scalingAlgorithms {
+			var value float64
+			if algorithm == result.Algorithm {
+				value = 1
+			}
+			scalerAlgorithmInfo.WithLabelValues(
+				name,
+				namespace,
+				metricQueueName,
+				string(algorithm),
+			).Set(value)
+		}
+	}
+	var panicModeValue float64
+	if panicking {
+		panicModeValue = 1
+	}
+	panicMode.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(panicModeValue)
+	var targetOverrideValue float64
+	if targetOverridden {
+		targetOverrideValue = 1
+	}
+	targetOverrideActive.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(targetOverrideValue)
+	var atMaxReplicasValue float64
+	if unclampedDesiredWorkers >= maxWorkers && desiredWorkers == maxWorkers {
+		atMaxReplicasValue = 1
+	}
+	atMaxReplicas.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(atMaxReplicasValue)
+	workersMin.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(float64(minWorkers))
+	workersMinComputed.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(float64(getMinWorkers(
+		messagesSentPerMinute,
+		minWorkers,
+		secondsToProcessOneJob,
+		workerPodAutoScaler.GetDisableVelocityMinWorkers(),
+	)))
+	workersMaxDisruptionPods.WithLabelValues(
+		name,
+		namespace,
+		metricQueueName,
+	).Set(float64(getMaxDisruptableWorkers(
+		defaults.getMaxDisruption(workerPodAutoScaler),
+		currentWorkers,
+	)))
 
 	lastScaleTime := workerPodAutoScaler.Status.LastScaleTime.DeepCopy()
+	scaleUpDelay := defaults.getScaleUpDelay(workerPodAutoScaler)
+	if panicking {
+		// in panic the workers are scaled up right away
+		scaleUpDelay = 0
+	}
+	scaleDownDelay := defaults.getScaleDownDelay(workerPodAutoScaler)
+	fastScaleUp := event.name == WokerPodAutoScalerEventFastScaleUp
+	if fastScaleUp {
+		// the fast loop found a spike, it does not wait for the delay
+		scaleUpDelay = 0
+	}
 
 	op := GetScaleOperation(
 		queueName,
 		desiredWorkers,
 		currentWorkers,
 		lastScaleTime,
-		c.scaleDownDelay,
+		scaleUpDelay,
+		scaleDownDelay,
+		c.isScaleDownBlocked(workerPodAutoScaler),
+	)
+	if op == ScaleDown && fastScaleUp {
+		// the scale downs are left to the main loop
+		op = ScaleNoop
+	}
+	if op != ScaleNoop && c.getBreakerState(key, now) == BreakerOpen {
+		logV(2, logLevel).Infof("%s: %s skipped, scaling is disabled after failures",
+			key, scaleOpString(op))
+		op = ScaleNoop
+	}
+	if until, paused := c.getManualScaleUntil(key, now); op != ScaleNoop && paused {
+		logV(2, logLevel).Infof("%s: %s skipped, scaling is paused till %v after a manual scale",
+			key, scaleOpString(op), until)
+		op = ScaleNoop
+	}
+	// the scale ups skipped above do not take from the scale ups per minute
+	if op == ScaleUp && !panicking && c.deferScaleUp(event, workerPodAutoScaler) {
+		op = ScaleNoop
+	}
+
+	span.SetAttributes(
+		attribute.Int64("backlog", int64(queueMessages)),
+		attribute.Int64("desired", int64(desiredWorkers)),
+		attribute.String("op", scaleOpString(op)),
+		attribute.String("reason", string(scaleReason)),
 	)
 
+	workerPodAutoScaler = updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionScaleDecision,
+			Status:  metav1.ConditionTrue,
+			Reason:  string(scaleReason),
+			Message: scaleReasonMessages[scaleReason],
+		},
+	)
+	workerPodAutoScaler = updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		scalingLimitedCondition(
+			unclampedDesiredWorkers, maxWorkers),
+	)
+
+	if op == ScaleDown && workerPodAutoScaler.GetPreferIdlePodsOnScaleDown() {
+		err := c.preferIdlePods(
+			ctx,
+			workerPodAutoScaler.Namespace,
+			targetKind,
+			targetName,
+			currentWorkers,
+			idleWorkers,
+			workerPodAutoScaler.Spec.IdleSinceAnnotation,
+		)
+		if err != nil {
+			// the scale down is not blocked, the pods are
+			// deleted without the preference
+			klog.Errorf("%s: unable to prefer idle pods, err: %v", key, err)
+		}
+	}
+
 	if op == ScaleUp || op == ScaleDown {
-		if deploymentName != "" {
-			c.updateDeployment(
+		endUpdate := timer.startPhase(reconcilePhaseUpdate)
+		var err error
+		if len(weightedTargets) > 0 {
+			err = c.updateWeightedTargets(
 				ctx,
-				workerPodAutoScaler.Namespace, deploymentName, &desiredWorkers)
+				workerPodAutoScaler.Namespace,
+				weightedTargets,
+				desiredWorkers,
+			)
 		} else {
-			c.updateReplicaSet(
+			err = c.updateTarget(
 				ctx,
-				workerPodAutoScaler.Namespace, replicaSetName, &desiredWorkers)
+				workerPodAutoScaler.Namespace,
+				targetKind,
+				targetName,
+				&desiredWorkers,
+			)
 		}
+		endUpdate()
+		c.recordScaleResult(key, err, now)
+		c.publishScaleEvent(workerPodAutoScaler, queueName, queueMessages,
+			targetKind, targetName, op, scaleReason,
+			currentWorkers, desiredWorkers, err)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+		} else {
+			c.recorder.Eventf(workerPodAutoScaler, corev1.EventTypeNormal,
+				scaleOpEventReason(op), "Scaled %s %s from %d to %d, reason: %s",
+				targetKind, targetName, currentWorkers, desiredWorkers, scaleReason)
 
-		now := metav1.Now()
-		lastScaleTime = &now
+			now := metav1.Now()
+			lastScaleTime = &now
+		}
+	}
+	workerPodAutoScaler = c.reportBreaker(ctx, key, workerPodAutoScaler,
+		metricQueueName, targetKind, targetName, now)
+
+	logV(2, logLevel).Infof("%s scaleOp: %v", queueName, scaleOpString(op))
+
+	if !c.lowCardinalityMetrics {
+		for direction, delay := range map[string]time.Duration{
+			"up":   scaleUpDelay,
+			"down": scaleDownDelay,
+		} {
+			remaining := getCooldownRemaining(lastScaleTime, delay, time.Now())
+			if remaining < 0 {
+				remaining = 0
+			}
+			scaleCooldownRemaining.WithLabelValues(
+				name,
+				namespace,
+				metricQueueName,
+				direction,
+			).Set(remaining.Seconds())
+		}
 	}
-
-	klog.V(2).Infof("%s scaleOp: %v", queueName, scaleOpString(op))
 
 	// Finally, we update the status block of the WorkerPodAutoScaler resource to reflect the
 	// current state of the world
-	updateWorkerPodAutoScalerStatus(
+	endStatus := timer.startPhase(reconcilePhaseStatus)
+	workerPodAutoScaler = updateWorkerPodAutoScalerStatus(
 		ctx,
 		name,
 		namespace,
@@ -531,8 +1631,15 @@ func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEv
 		availableWorkers,
 		queueMessages,
 		lastScaleTime,
+		queueHealthy,
+		lastPollError,
+		unclampedDesiredWorkers,
+		getNextScaleEligibleTime(
+			lastScaleTime, scaleUpDelay, scaleDownDelay, time.Now()),
 	)
 
+	endStatus()
+
 	loopDurationSeconds.WithLabelValues(
 		name,
 		namespace,
@@ -541,6 +1648,10 @@ func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEv
 		name,
 		namespace,
 	).Inc()
+	lastReconcileTimestamp.WithLabelValues(
+		name,
+		namespace,
+	).SetToCurrentTime()
 
 	// TODO: organize and log events
 	// c.recorder.Event(workerPodAutoScaler, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
@@ -548,8 +1659,13 @@ func (c *Controller) syncHandler(ctx context.Context, event WokerPodAutoScalerEv
 }
 
 // updateDeployment updates the Deployment with the desired number of replicas
-func (c *Controller) updateDeployment(ctx context.Context, namespace string, deploymentName string, replicas *int32) {
-	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+func (c *Controller) updateDeployment(ctx context.Context, namespace string, deploymentName string, replicas *int32) error {
+	ctx, span := tracing.Tracer().Start(ctx, "updateDeployment")
+	defer span.End()
+
+	attempt := 0
+	retryErr := retry.RetryOnConflict(c.updateRetry, func() error {
+		attempt++
 		// Retrieve the latest version of the Deployment before attempting update
 		deployment, getErr := c.deploymentLister.Deployments(namespace).Get(deploymentName)
 		if errors.IsNotFound(getErr) {
@@ -557,24 +1673,33 @@ func (c *Controller) updateDeployment(ctx context.Context, namespace string, dep
 				deploymentName, namespace)
 		}
 		if getErr != nil {
-			klog.Fatalf("Failed to get deployment: %v", getErr)
+			return fmt.Errorf("failed to get deployment: %v", getErr)
 		}
 
 		deployment.Spec.Replicas = replicas
 		_, updateErr := c.kubeclientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
-		if updateErr != nil {
+		if errors.IsConflict(updateErr) {
+			klog.V(2).Infof("%s/%s: conflict updating deployment, attempt: %d, err: %v",
+				namespace, deploymentName, attempt, updateErr)
+		} else if updateErr != nil {
 			klog.Errorf("Failed to update deployment: %v", updateErr)
 		}
 		return updateErr
 	})
 	if retryErr != nil {
-		klog.Fatalf("Failed to update deployment (retry failed): %v", retryErr)
+		return fmt.Errorf("failed to update deployment (retry failed): %v", retryErr)
 	}
+	return nil
 }
 
 // updateReplicaSet updates the ReplicaSet with the desired number of replicas
-func (c *Controller) updateReplicaSet(ctx context.Context, namespace string, replicaSetName string, replicas *int32) {
-	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+func (c *Controller) updateReplicaSet(ctx context.Context, namespace string, replicaSetName string, replicas *int32) error {
+	ctx, span := tracing.Tracer().Start(ctx, "updateReplicaSet")
+	defer span.End()
+
+	attempt := 0
+	retryErr := retry.RetryOnConflict(c.updateRetry, func() error {
+		attempt++
 		// Retrieve the latest version of the ReplicaSet before attempting update
 		replicaSet, getErr := c.replicaSetLister.ReplicaSets(namespace).Get(replicaSetName)
 		if errors.IsNotFound(getErr) {
@@ -582,19 +1707,23 @@ func (c *Controller) updateReplicaSet(ctx context.Context, namespace string, rep
 				replicaSetName, namespace)
 		}
 		if getErr != nil {
-			klog.Fatalf("Failed to get ReplicaSet: %v", getErr)
+			return fmt.Errorf("failed to get ReplicaSet: %v", getErr)
 		}
 
 		replicaSet.Spec.Replicas = replicas
 		_, updateErr := c.kubeclientset.AppsV1().ReplicaSets(namespace).Update(ctx, replicaSet, metav1.UpdateOptions{})
-		if updateErr != nil {
+		if errors.IsConflict(updateErr) {
+			klog.V(2).Infof("%s/%s: conflict updating ReplicaSet, attempt: %d, err: %v",
+				namespace, replicaSetName, attempt, updateErr)
+		} else if updateErr != nil {
 			klog.Errorf("Failed to update ReplicaSet: %v", updateErr)
 		}
 		return updateErr
 	})
 	if retryErr != nil {
-		klog.Fatalf("Failed to update ReplicaSet (retry failed): %v", retryErr)
+		return fmt.Errorf("failed to update ReplicaSet (retry failed): %v", retryErr)
 	}
+	return nil
 }
 
 // getMaxDisruptableWorkers gets the maximum number of workers that can
@@ -615,24 +1744,49 @@ func getMaxDisruptableWorkers(
 	if err != nil {
 		klog.Fatalf("Error calculating maxDisruptable workers, err: %v", err)
 	}
+	// a negative value would turn the scale down into a scale up,
+	// it is rejected by the validation but may be in old WPAs
+	if maxDisruptableWorkers < 0 {
+		return 0
+	}
 
 	return int32(maxDisruptableWorkers)
 }
 
+// getMessagesSentPerMinute returns the moving average of the messages
+// sent per minute when the WPA smooths it and the average is known,
+// else the messages sent per minute of the last poll
+func (c *Controller) getMessagesSentPerMinute(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	messagesSentPerMinute float64) float64 {
+
+	if !workerPodAutoScaler.GetSmoothMessagesSentPerMinute() {
+		return messagesSentPerMinute
+	}
+	average := c.Queues.GetMessagesSentPerMinuteAverage(
+		workerPodAutoScaler.Namespace, workerPodAutoScaler.Name)
+	if average == queue.UnsyncedMessagesSentPerMinute {
+		return messagesSentPerMinute
+	}
+	return average
+}
+
 // getMinWorkers gets the min workers based on the
 // velocity metric: messagesSentPerMinute
 func getMinWorkers(
 	messagesSentPerMinute float64,
 	minWorkers int32,
-	secondsToProcessOneJob float64) int32 {
+	secondsToProcessOneJob float64,
+	disableVelocityMinWorkers bool) int32 {
 
 	// disable this feature for WPA queues which have not specified
-	// processing time
-	if secondsToProcessOneJob == 0.0 {
+	// processing time or have explicitly disabled it
+	if secondsToProcessOneJob == 0.0 || disableVelocityMinWorkers {
 		return minWorkers
 	}
 
-	workersBasedOnMessagesSent := int32(math.Ceil((secondsToProcessOneJob * messagesSentPerMinute) / 60))
+	workersBasedOnMessagesSent := getThroughputWorkers(
+		messagesSentPerMinute, secondsToProcessOneJob)
 	klog.V(4).Infof("%v, workersBasedOnMessagesSent=%v\n", secondsToProcessOneJob, workersBasedOnMessagesSent)
 	if workersBasedOnMessagesSent > minWorkers {
 		return workersBasedOnMessagesSent
@@ -640,10 +1794,91 @@ func getMinWorkers(
 	return minWorkers
 }
 
+// getThroughputWorkers gets the workers required to process
+// the messages sent per minute
+func getThroughputWorkers(
+	messagesSentPerMinute float64,
+	secondsToProcessOneJob float64) int32 {
+
+	return int32(math.Ceil((secondsToProcessOneJob * messagesSentPerMinute) / 60))
+}
+
 func isChangeTooSmall(desired int32, current int32, tolerance float64) bool {
 	return math.Abs(float64(desired-current))/float64(current) <= tolerance
 }
 
+// isSuppressedByTolerance tells if the workers required by the queue
+// differ from the current workers but are not scaled to as the change
+// is within the tolerance
+func isSuppressedByTolerance(result ScalingResult, currentWorkers int32) bool {
+	return result.Reason == ScaleReasonWithinTolerance &&
+		result.UnclampedDesiredWorkers != currentWorkers
+}
+
+// ScalingInput is the state of a queue and its workers
+// from which the desired workers are computed
+type ScalingInput struct {
+	QueueName               string
+	QueueMessages           int32
+	MessagesSentPerMinute   float64
+	SecondsToProcessOneJob  float64
+	TargetMessagesPerWorker int32
+	CurrentWorkers          int32
+	IdleWorkers             int32
+	MinWorkers              int32
+	MaxWorkers              int32
+	// MaxDisruption is the number or the percentage of the
+	// current workers which can be scaled down at once
+	MaxDisruption             string
+	DisableVelocityMinWorkers bool
+	Panicking                 bool
+	// RespectDisruptionOnIdle caps the scale down of the
+	// workers which are all idle by the MaxDisruption
+	RespectDisruptionOnIdle bool
+	// WarmFloor is the minimum workers when there is no backlog
+	WarmFloor int32
+	// ThroughputMode computes the desired workers from the messages
+	// sent per minute instead of the backlog
+	ThroughputMode bool
+	// ColdStartReplicas is the minimum workers of the
+	// scale up from zero workers when there is a demand
+	ColdStartReplicas int32
+	// Tolerance is the relative change of the workers which is ignored,
+	// defaultTolerance is used when it is not set
+	Tolerance float64
+	// RoundingStrategy rounds the workers required by the backlog,
+	// v1.RoundingStrategyCeil is used when it is not set
+	RoundingStrategy string
+	// BalanceAwareScaling adds the workers required by the growth of
+	// the backlog, BacklogBalance is the growth in messages per minute,
+	// inflow minus outflow, 0 if it is not known
+	BalanceAwareScaling bool
+	BacklogBalance      float64
+	// NoBacklogStrategy is what is done with the workers when there is
+	// no backlog but the queue has throughput,
+	// v1.NoBacklogStrategyScaleToMin is used when it is not set
+	NoBacklogStrategy string
+	// LogLevel raises the verbosity of the logs of the computation
+	// over the -v of klog, 0 leaves it to the -v
+	LogLevel klog.Level
+}
+
+// ScalingResult is the desired workers computed from the ScalingInput
+type ScalingResult struct {
+	// DesiredWorkers is the workers after the rules are applied
+	DesiredWorkers int32
+	// UnclampedDesiredWorkers is the workers required by the
+	// backlog before the rules are applied
+	UnclampedDesiredWorkers int32
+	// Reason tells what decided the desired workers
+	Reason ScaleReason
+	// Clamped tells if the min, max or the max disruption
+	// changed the desired workers, the reason tells which
+	Clamped bool
+	// Algorithm tells how the workers required by the queue are computed
+	Algorithm ScalingAlgorithm
+}
+
 // GetDesiredWorkers finds the desired number of workers which are required
 func GetDesiredWorkers(
 	queueName string,
@@ -655,132 +1890,379 @@ func GetDesiredWorkers(
 	idleWorkers int32,
 	minWorkers int32,
 	maxWorkers int32,
-	maxDisruption *string) int32 {
+	maxDisruption *string,
+	disableVelocityMinWorkers bool,
+	panicking bool) int32 {
+
+	desiredWorkers, _, _ := GetDesiredWorkersWithReason(
+		queueName,
+		queueMessages,
+		messagesSentPerMinute,
+		secondsToProcessOneJob,
+		targetMessagesPerWorker,
+		currentWorkers,
+		idleWorkers,
+		minWorkers,
+		maxWorkers,
+		maxDisruption,
+		disableVelocityMinWorkers,
+		panicking,
+	)
+	return desiredWorkers
+}
+
+// GetDesiredWorkersWithReason finds the desired number of workers which
+// are required, the workers required by the backlog before the rules
+// are applied and the reason which decided the desired workers
+func GetDesiredWorkersWithReason(
+	queueName string,
+	queueMessages int32,
+	messagesSentPerMinute float64,
+	secondsToProcessOneJob float64,
+	targetMessagesPerWorker int32,
+	currentWorkers int32,
+	idleWorkers int32,
+	minWorkers int32,
+	maxWorkers int32,
+	maxDisruption *string,
+	disableVelocityMinWorkers bool,
+	panicking bool) (int32, int32, ScaleReason) {
+
+	if maxDisruption == nil {
+		klog.Fatalf("maxDisruption default is not being set. Exiting")
+	}
+
+	result := ComputeDesired(ScalingInput{
+		QueueName:                 queueName,
+		QueueMessages:             queueMessages,
+		MessagesSentPerMinute:     messagesSentPerMinute,
+		SecondsToProcessOneJob:    secondsToProcessOneJob,
+		TargetMessagesPerWorker:   targetMessagesPerWorker,
+		CurrentWorkers:            currentWorkers,
+		IdleWorkers:               idleWorkers,
+		MinWorkers:                minWorkers,
+		MaxWorkers:                maxWorkers,
+		MaxDisruption:             *maxDisruption,
+		DisableVelocityMinWorkers: disableVelocityMinWorkers,
+		Panicking:                 panicking,
+	})
+	return result.DesiredWorkers, result.UnclampedDesiredWorkers, result.Reason
+}
+
+// ComputeDesired finds the desired number of workers which are required,
+// it does not depend on the controller and can be used offline
+func ComputeDesired(input ScalingInput) ScalingResult {
+	desired, reason := computeDesiredWorkers(input)
+	result := ScalingResult{
+		DesiredWorkers: desired,
+		UnclampedDesiredWorkers: getBacklogWorkers(
+			input.QueueMessages,
+			input.TargetMessagesPerWorker,
+			input.RoundingStrategy,
+		) + getBalanceWorkers(input),
+		Reason:    reason,
+		Algorithm: getScalingAlgorithm(input),
+	}
+	if result.Algorithm == ScalingAlgorithmThroughput {
+		result.UnclampedDesiredWorkers = getThroughputWorkers(
+			input.MessagesSentPerMinute, input.SecondsToProcessOneJob)
+	}
+	switch reason {
+	case ScaleReasonMinReplicas, ScaleReasonMaxReplicas, ScaleReasonMaxDisruption,
+		ScaleReasonWarmFloor:
+		result.Clamped = true
+	}
+	return result
+}
+
+// logV is klog.V which is also enabled for the levels within the log
+// level of the WPA. It is kept in this file so that -vmodule still
+// matches the logs of the reconcile.
+func logV(level klog.Level, logLevel klog.Level) klog.Verbose {
+	if level <= logLevel {
+		return klog.V(0)
+	}
+	return klog.V(level)
+}
+
+// computeDesiredWorkers finds the desired number of workers
+// and the reason which decided it
+func computeDesiredWorkers(input ScalingInput) (int32, ScaleReason) {
+	queueName := input.QueueName
+	currentWorkers := input.CurrentWorkers
+	maxWorkers := input.MaxWorkers
+
+	logV(4, input.LogLevel).Infof("%s min=%v, max=%v, targetBacklog=%v \n",
+		queueName, input.MinWorkers, maxWorkers, input.TargetMessagesPerWorker)
+
+	// the backlog can not be divided by a target which is not greater
+	// than 0, the controller does not scale such WPAs
+	if input.TargetMessagesPerWorker <= 0 {
+		klog.Errorf("%s targetMessagesPerWorker=%v must be greater than 0, keeping the current workers",
+			queueName, input.TargetMessagesPerWorker)
+		return currentWorkers, ScaleReasonInvalidTarget
+	}
+
+	// in panic the ramp limits are bypassed and the workers
+	// are scaled straight to the max
+	if input.Panicking {
+		logV(2, input.LogLevel).Infof("%s panic mode, desired=max", queueName)
+		return convertDesiredReplicasWithRules(
+			currentWorkers,
+			maxWorkers,
+			input.MinWorkers,
+			maxWorkers,
+			currentWorkers,
+			ScaleReasonPanic,
+		)
+	}
 
-	klog.V(4).Infof("%s min=%v, max=%v, targetBacklog=%v \n",
-		queueName, minWorkers, maxWorkers, targetMessagesPerWorker)
+	// in the throughput mode the messages sent per minute decide the
+	// desired workers, it falls back to the backlog till
+	// secondsToProcessOneJob is known
+	throughputMode := getScalingAlgorithm(input) == ScalingAlgorithmThroughput
 
 	// overwrite the minimum workers needed based on
 	// messagesSentPerMinute and secondsToProcessOneJob
 	// this feature is disabled if secondsToProcessOneJob is not set or is 0.0
-	minWorkers = getMinWorkers(
-		messagesSentPerMinute,
-		minWorkers,
-		secondsToProcessOneJob,
+	// or if disableVelocityMinWorkers is set
+	minWorkers := getMinWorkers(
+		input.MessagesSentPerMinute,
+		input.MinWorkers,
+		input.SecondsToProcessOneJob,
+		input.DisableVelocityMinWorkers || throughputMode,
 	)
+	minReason := ScaleReasonMinReplicas
+	if minWorkers > input.MinWorkers {
+		minReason = ScaleReasonVelocity
+	}
+
+	// the warm floor keeps the workers warm only when there is no
+	// backlog, with a backlog the workers required by it take over
+	if input.QueueMessages == 0 && input.WarmFloor > minWorkers {
+		minWorkers = input.WarmFloor
+		minReason = ScaleReasonWarmFloor
+	}
 
 	// gets the maximum number of workers that can be scaled down in a
 	// single scale down activity.
 	maxDisruptableWorkers := getMaxDisruptableWorkers(
-		maxDisruption, currentWorkers,
+		&input.MaxDisruption, currentWorkers,
 	)
 
-	tolerance := 0.1
-	desiredWorkers := int32(math.Ceil(
-		float64(queueMessages) / float64(targetMessagesPerWorker)),
+	tolerance := input.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultTolerance
+	}
+	desiredWorkers := getBacklogWorkers(
+		input.QueueMessages,
+		input.TargetMessagesPerWorker,
+		input.RoundingStrategy,
 	)
+	// the backlog which grows needs more workers than it has now
+	backlogReason := ScaleReasonBacklog
+	if balanceWorkers := getBalanceWorkers(input); balanceWorkers > 0 {
+		logV(3, input.LogLevel).Infof("%s balance=%v, balanceWorkers=%v\n",
+			queueName, input.BacklogBalance, balanceWorkers)
+		desiredWorkers += balanceWorkers
+		backlogReason = ScaleReasonBacklogGrowing
+	}
 
-	klog.V(4).Infof("%s qMsgs=%v, qMsgsPerMin=%v \n",
-		queueName, queueMessages, messagesSentPerMinute)
-	klog.V(4).Infof("%s secToProcessJob=%v, maxDisruption=%v \n",
-		queueName, secondsToProcessOneJob, *maxDisruption)
-	klog.V(4).Infof("%s current=%v, idle=%v \n",
-		queueName, currentWorkers, idleWorkers)
-	klog.V(3).Infof("%s minComputed=%v, maxDisruptable=%v\n",
+	logV(4, input.LogLevel).Infof("%s qMsgs=%v, qMsgsPerMin=%v \n",
+		queueName, input.QueueMessages, input.MessagesSentPerMinute)
+	logV(4, input.LogLevel).Infof("%s secToProcessJob=%v, maxDisruption=%v \n",
+		queueName, input.SecondsToProcessOneJob, input.MaxDisruption)
+	logV(4, input.LogLevel).Infof("%s current=%v, idle=%v \n",
+		queueName, currentWorkers, input.IdleWorkers)
+	logV(3, input.LogLevel).Infof("%s minComputed=%v, maxDisruptable=%v\n",
 		queueName, minWorkers, maxDisruptableWorkers)
 
-	if currentWorkers == 0 {
-		return convertDesiredReplicasWithRules(
+	var desired int32
+	var reason ScaleReason
+	if throughputMode {
+		throughputWorkers := getThroughputWorkers(
+			input.MessagesSentPerMinute, input.SecondsToProcessOneJob)
+		logV(3, input.LogLevel).Infof("%s throughputWorkers=%v\n", queueName, throughputWorkers)
+		if currentWorkers > 0 &&
+			isChangeTooSmall(throughputWorkers, currentWorkers, tolerance) {
+			desired, reason = convertDesiredReplicasWithRules(
+				currentWorkers,
+				currentWorkers,
+				minWorkers,
+				maxWorkers,
+				maxDisruptableWorkers,
+				ScaleReasonWithinTolerance,
+			)
+		} else {
+			desired, reason = convertDesiredReplicasWithRules(
+				currentWorkers,
+				throughputWorkers,
+				minWorkers,
+				maxWorkers,
+				maxDisruptableWorkers,
+				ScaleReasonThroughput,
+			)
+		}
+	} else if currentWorkers == 0 {
+		desired, reason = convertDesiredReplicasWithRules(
 			currentWorkers,
 			desiredWorkers,
 			minWorkers,
 			maxWorkers,
 			maxDisruptableWorkers,
+			backlogReason,
 		)
-	}
-
-	if queueMessages > 0 {
+	} else if input.QueueMessages > 0 {
 		if isChangeTooSmall(desiredWorkers, currentWorkers, tolerance) {
 			// desired is same as current in this scenario
-			return convertDesiredReplicasWithRules(
+			desired, reason = convertDesiredReplicasWithRules(
+				currentWorkers,
 				currentWorkers,
+				minWorkers,
+				maxWorkers,
+				maxDisruptableWorkers,
+				ScaleReasonWithinTolerance,
+			)
+		} else {
+			desired, reason = convertDesiredReplicasWithRules(
 				currentWorkers,
+				desiredWorkers,
 				minWorkers,
 				maxWorkers,
 				maxDisruptableWorkers,
+				backlogReason,
 			)
 		}
-
-		return convertDesiredReplicasWithRules(
+	} else if input.MessagesSentPerMinute > 0 &&
+		input.NoBacklogStrategy == v1.NoBacklogStrategyHoldCurrent {
+		// there is no backlog visible but the queue has throughput, the
+		// current workers are held to absorb the next burst
+		desired, reason = convertDesiredReplicasWithRules(
+			currentWorkers,
 			currentWorkers,
-			desiredWorkers,
 			minWorkers,
 			maxWorkers,
 			maxDisruptableWorkers,
+			ScaleReasonHoldCurrent,
 		)
-	} else if messagesSentPerMinute > 0 && secondsToProcessOneJob > 0.0 {
+	} else if input.MessagesSentPerMinute > 0 && input.SecondsToProcessOneJob > 0.0 {
 		// this is the case in which there is no backlog visible.
 		// (mostly because the workers picks up jobs very quickly)
 		// But the queue has throughput, so we return the minWorkers.
 		// Note: minWorkers is updated based on
 		// messagesSentPerMinute and secondsToProcessOneJob
 		// desried is the minReplicas in this scenario
-		return convertDesiredReplicasWithRules(
+		desired, reason = convertDesiredReplicasWithRules(
 			currentWorkers,
 			minWorkers,
 			minWorkers,
 			maxWorkers,
 			maxDisruptableWorkers,
+			minReason,
 		)
-	}
-
-	// Attempt for massive scale down
-	if currentWorkers == idleWorkers {
-		desiredWorkers := int32(0)
+	} else if currentWorkers == input.IdleWorkers {
+		// Attempt for massive scale down
 		// for massive scale down to happen maxDisruptableWorkers
-		// should be ignored
-		return convertDesiredReplicasWithRules(
+		// should be ignored, unless the disruption is respected
+		// even when all the workers are idle
+		idleDisruptableWorkers := currentWorkers
+		if input.RespectDisruptionOnIdle {
+			idleDisruptableWorkers = maxDisruptableWorkers
+		}
+		desired, reason = convertDesiredReplicasWithRules(
 			currentWorkers,
-			desiredWorkers,
+			0,
 			minWorkers,
 			maxWorkers,
+			idleDisruptableWorkers,
+			ScaleReasonAllIdle,
+		)
+	} else {
+		// Attempt partial scale down since there is no backlog or in-processing
+		// messages.
+		desired, reason = convertDesiredReplicasWithRules(
 			currentWorkers,
+			minWorkers,
+			minWorkers,
+			maxWorkers,
+			maxDisruptableWorkers,
+			ScaleReasonNoBacklog,
 		)
 	}
 
-	// Attempt partial scale down since there is no backlog or in-processing
-	// messages.
-	return convertDesiredReplicasWithRules(
-		currentWorkers,
-		minWorkers,
-		minWorkers,
-		maxWorkers,
-		maxDisruptableWorkers,
-	)
+	if reason == ScaleReasonMinReplicas {
+		reason = minReason
+	}
+
+	// the first scale up from zero jumps to the cold start replicas as
+	// one worker would not drain a large backlog, the backlog decides
+	// the workers once they are up
+	hasDemand := input.QueueMessages > 0 ||
+		(throughputMode && input.MessagesSentPerMinute > 0)
+	if currentWorkers == 0 && hasDemand && desired > 0 &&
+		desired < input.ColdStartReplicas {
+		desired = input.ColdStartReplicas
+		reason = ScaleReasonColdStart
+		if desired > maxWorkers {
+			desired = maxWorkers
+			reason = ScaleReasonMaxReplicas
+		}
+		logV(3, input.LogLevel).Infof("%s cold start, desired=%v\n", queueName, desired)
+	}
+	return desired, reason
 }
 
+// scalingLimitedCondition is the ScalingLimited condition, it is true
+// when the workers required by the backlog are above the max workers
+func scalingLimitedCondition(
+	unclampedDesiredWorkers int32, maxWorkers int32) metav1.Condition {
+
+	if unclampedDesiredWorkers > maxWorkers {
+		return metav1.Condition{
+			Type:    v1.ConditionScalingLimited,
+			Status:  metav1.ConditionTrue,
+			Reason:  "TooManyReplicas",
+			Message: "The workers required by the backlog are above maxReplicas",
+		}
+	}
+	return metav1.Condition{
+		Type:    v1.ConditionScalingLimited,
+		Status:  metav1.ConditionFalse,
+		Reason:  "DesiredWithinRange",
+		Message: "The workers required by the backlog are within maxReplicas",
+	}
+}
+
+// convertDesiredReplicasWithRules applies the min, max and the max
+// disruptable workers on the desired workers. The reason is replaced
+// if the desired workers are changed by the rules.
 func convertDesiredReplicasWithRules(
 	current int32,
 	desired int32,
 	min int32,
 	max int32,
-	maxDisruptable int32) int32 {
+	maxDisruptable int32,
+	reason ScaleReason) (int32, ScaleReason) {
 
 	if min >= max {
-		return max
+		if desired == max {
+			return max, reason
+		}
+		return max, ScaleReasonMaxReplicas
 	}
 
 	if (current - desired) > maxDisruptable {
 		desired = current - maxDisruptable
+		reason = ScaleReasonMaxDisruption
 	}
 
 	if desired > max {
-		return max
+		return max, ScaleReasonMaxReplicas
 	}
 	if desired < min {
-		return min
+		return min, ScaleReasonMinReplicas
 	}
-	return desired
+	return desired, reason
 }
 
 func updateWorkerPodAutoScalerStatus(
@@ -793,15 +2275,23 @@ func updateWorkerPodAutoScalerStatus(
 	currentWorkers int32,
 	availableWorkers int32,
 	queueMessages int32,
-	lastScaleTime *metav1.Time) {
+	lastScaleTime *metav1.Time,
+	queueHealthy bool,
+	lastPollError string,
+	unclampedDesiredWorkers int32,
+	nextScaleEligibleTime *metav1.Time) *v1.WorkerPodAutoScaler {
 
 	if workerPodAutoScaler.Status.CurrentReplicas == currentWorkers &&
 		workerPodAutoScaler.Status.AvailableReplicas == availableWorkers &&
 		workerPodAutoScaler.Status.DesiredReplicas == desiredWorkers &&
 		workerPodAutoScaler.Status.CurrentMessages == queueMessages &&
-		workerPodAutoScaler.Status.LastScaleTime.Equal(lastScaleTime) {
+		workerPodAutoScaler.Status.LastScaleTime.Equal(lastScaleTime) &&
+		workerPodAutoScaler.Status.QueueHealthy == queueHealthy &&
+		workerPodAutoScaler.Status.LastPollError == lastPollError &&
+		workerPodAutoScaler.Status.UnclampedDesiredReplicas == unclampedDesiredWorkers &&
+		workerPodAutoScaler.Status.NextScaleEligibleTime.Equal(nextScaleEligibleTime) {
 		klog.V(4).Infof("%s/%s: WPA status is already up to date\n", namespace, name)
-		return
+		return workerPodAutoScaler
 	} else {
 		klog.V(4).Infof("%s/%s: Updating wpa status\n", namespace, name)
 	}
@@ -815,16 +2305,465 @@ func updateWorkerPodAutoScalerStatus(
 	workerPodAutoScalerCopy.Status.DesiredReplicas = desiredWorkers
 	workerPodAutoScalerCopy.Status.CurrentMessages = queueMessages
 	workerPodAutoScalerCopy.Status.LastScaleTime = lastScaleTime
+	workerPodAutoScalerCopy.Status.QueueHealthy = queueHealthy
+	workerPodAutoScalerCopy.Status.LastPollError = lastPollError
+	workerPodAutoScalerCopy.Status.UnclampedDesiredReplicas = unclampedDesiredWorkers
+	workerPodAutoScalerCopy.Status.NextScaleEligibleTime = nextScaleEligibleTime
+	if isAnnotationDriven(workerPodAutoScaler) {
+		// the status of the annotated WPAs is kept in memory
+		return workerPodAutoScalerCopy
+	}
 	// If the CustomResourceSubresources feature gate is not enabled,
 	// we must use Update instead of UpdateStatus to update the Status block of the WorkerPodAutoScaler resource.
 	// UpdateStatus will not allow changes to the Spec of the resource,
 	// which is ideal for ensuring nothing other than resource status has been updated.
-	_, err := customclientset.K8sV1().WorkerPodAutoScalers(workerPodAutoScaler.Namespace).UpdateStatus(ctx, workerPodAutoScalerCopy, metav1.UpdateOptions{})
+	updated, err := customclientset.K8sV1().WorkerPodAutoScalers(workerPodAutoScaler.Namespace).UpdateStatus(ctx, workerPodAutoScalerCopy, metav1.UpdateOptions{})
 	if err != nil {
 		klog.Errorf("Error updating wpa status, err: %v", err)
-		return
+		return workerPodAutoScaler
 	}
 	klog.V(4).Infof("%s/%s: Updated wpa status\n", namespace, name)
+	return updated
+}
+
+// syncSafetyQueue keeps the safety queue of the WPA in sync with the spec
+func (c *Controller) syncSafetyQueue(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	credentials *queue.Credentials) error {
+
+	namespace := workerPodAutoScaler.Namespace
+	name := workerPodAutoScaler.Name
+	safetyQueue := workerPodAutoScaler.Spec.SafetyQueue
+	if safetyQueue == nil {
+		return c.Queues.DeleteSafetyQueue(namespace, name)
+	}
+
+	return c.Queues.AddSafetyQueue(
+		namespace,
+		name,
+		safetyQueue.QueueURI,
+		credentials,
+	)
+}
+
+// isScaleDownBlocked tells if the scale down should not happen
+// because the safety queue has a backlog above its threshold
+func (c *Controller) isScaleDownBlocked(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) bool {
+
+	safetyQueue := workerPodAutoScaler.Spec.SafetyQueue
+	if safetyQueue == nil || !safetyQueue.BlockScaleDownWhenNonEmpty {
+		return false
+	}
+
+	queueName, messages := c.Queues.GetSafetyQueueInfo(
+		workerPodAutoScaler.Namespace, workerPodAutoScaler.Name)
+	if messages == queue.UnsyncedQueueMessageCount {
+		klog.Warningf(
+			"%s safety qMsgs: %d, q not initialized, not blocking scale down",
+			queueName,
+			messages,
+		)
+		return false
+	}
+
+	klog.V(3).Infof("%s safety qMsgs: %d, threshold: %d",
+		queueName, messages, safetyQueue.GetThreshold())
+	return messages > safetyQueue.GetThreshold()
+}
+
+// isPanicking tells if the WPA is in panic. The panic starts when the
+// backlog per worker exceeds the panicThreshold and lasts for the panic
+// window after the backlog per worker was last above the threshold.
+func (c *Controller) isPanicking(
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	queueMessages int32,
+	currentWorkers int32,
+	now time.Time) bool {
+
+	panicThreshold := workerPodAutoScaler.Spec.PanicThreshold
+	if panicThreshold == nil {
+		c.panicUntil.Delete(key)
+		return false
+	}
+
+	if isAbovePanicThreshold(workerPodAutoScaler, queueMessages, currentWorkers) {
+		if !c.isPanickingAt(key, now) {
+			klog.Warningf("%s: entering panic mode, qMsgs: %d, workers: %d, panicThreshold: %v",
+				key, queueMessages, currentWorkers, *panicThreshold)
+		}
+		c.panicUntil.Store(key, now.Add(workerPodAutoScaler.GetPanicWindow()))
+		return true
+	}
+
+	if c.isPanickingAt(key, now) {
+		return true
+	}
+	if _, ok := c.panicUntil.LoadAndDelete(key); ok {
+		klog.V(2).Infof("%s: exiting panic mode", key)
+	}
+	return false
+}
+
+// isAbovePanicThreshold tells if the backlog per worker
+// is above the panicThreshold of the WPA
+func isAbovePanicThreshold(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	queueMessages int32,
+	currentWorkers int32) bool {
+
+	panicThreshold := workerPodAutoScaler.Spec.PanicThreshold
+	if panicThreshold == nil {
+		return false
+	}
+	workers := currentWorkers
+	if workers == 0 {
+		workers = 1
+	}
+	return float64(queueMessages)/float64(workers) > *panicThreshold
+}
+
+func (c *Controller) isPanickingAt(key string, now time.Time) bool {
+	panicUntil, ok := c.panicUntil.Load(key)
+	return ok && now.Before(panicUntil.(time.Time))
+}
+
+// checkReplicaBounds reports minReplicas greater than maxReplicas using a
+// warning event and the InvalidReplicaBounds condition. Such WPAs are not
+// rejected as they may have been created before the validation, the
+// workers are kept at maxReplicas.
+func (c *Controller) checkReplicaBounds(
+	ctx context.Context,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) *v1.WorkerPodAutoScaler {
+
+	minReplicas := *workerPodAutoScaler.Spec.MinReplicas
+	maxReplicas := *workerPodAutoScaler.Spec.MaxReplicas
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionInvalidReplicaBounds)
+
+	if minReplicas <= maxReplicas {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionInvalidReplicaBounds,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ValidReplicaBounds",
+				Message: "minReplicas is less than or equal to maxReplicas",
+			},
+		)
+	}
+
+	message := fmt.Sprintf(
+		"minReplicas %d is greater than maxReplicas %d, using maxReplicas",
+		minReplicas, maxReplicas)
+	klog.Warningf("%s/%s: %s", workerPodAutoScaler.Namespace,
+		workerPodAutoScaler.Name, message)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionInvalidReplicaBounds, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionInvalidReplicaBounds,
+			Status:  metav1.ConditionTrue,
+			Reason:  "MinGreaterThanMax",
+			Message: message,
+		},
+	)
+}
+
+// checkConflictingHPA reports a HorizontalPodAutoscaler targeting the
+// same workload using a warning event and the ConflictingHPA condition.
+// It returns true if the WPA should not scale the workload.
+func (c *Controller) checkConflictingHPA(
+	ctx context.Context,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	targetKind string,
+	targetName string) (*v1.WorkerPodAutoScaler, bool) {
+
+	hpas, err := c.hpaIndexer.ByIndex(hpaTargetIndex, getHPATargetKey(
+		workerPodAutoScaler.Namespace, getTargetKindName(targetKind), targetName))
+	if err != nil {
+		utilruntime.HandleError(err)
+		return workerPodAutoScaler, false
+	}
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionConflictingHPA)
+	if len(hpas) == 0 {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionConflictingHPA,
+				Status:  metav1.ConditionFalse,
+				Reason:  "NoConflictingHPA",
+				Message: fmt.Sprintf("No HPA targets %s %s", targetKind, targetName),
+			},
+		), false
+	}
+
+	hpaName := c.getKeyForWorkerPodAutoScaler(hpas[0])
+	message := fmt.Sprintf(
+		"HPA %s also targets %s %s, not scaling until it is removed",
+		hpaName, targetKind, targetName)
+	klog.Warningf("%s/%s: %s", workerPodAutoScaler.Namespace,
+		workerPodAutoScaler.Name, message)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionConflictingHPA, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionConflictingHPA,
+			Status:  metav1.ConditionTrue,
+			Reason:  "HPATargetsWorkload",
+			Message: message,
+		},
+	), true
+}
+
+// forgetWorkerPodAutoScaler stops the poll of the queues of the deleted
+// WPA and drops the state and the metric series kept for it
+func (c *Controller) forgetWorkerPodAutoScaler(key string, namespace string, name string) {
+	c.Queues.Delete(namespace, name)
+	c.Queues.DeleteSafetyQueue(namespace, name)
+	c.panicUntil.Delete(key)
+	c.groupDemand.Delete(key)
+	c.stalls.Delete(key)
+	c.forceSyncs.Delete(key)
+	c.targetOverrides.Delete(key)
+	c.breakers.Delete(key)
+	c.annotatedStatuses.Delete(key)
+	c.firstSeen.Delete(key)
+	c.manualScales.Delete(key)
+	c.deleteWorkerPodAutoScalerMetrics(key, name, namespace)
+	c.updateManagedWPAs(namespace)
+}
+
+// updateManagedWPAs sets the number of WPAs in the namespace
+// using the informer cache, the series of a namespace without
+// WPAs is deleted
+func (c *Controller) updateManagedWPAs(namespace string) {
+	wpas, err := c.workerPodAutoScalersLister.WorkerPodAutoScalers(
+		namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if len(wpas) == 0 {
+		managedWPAs.DeleteLabelValues(namespace)
+		return
+	}
+	managedWPAs.WithLabelValues(namespace).Set(float64(len(wpas)))
+}
+
+// NewScaleUpLimiter returns the limiter of the scale ups across all the
+// WPAs, the controllers of all the watched namespaces share it.
+// It returns nil if the scale ups are not limited.
+func NewScaleUpLimiter(maxScaleUpsPerMinute int) *rate.Limiter {
+	if maxScaleUpsPerMinute <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(
+		rate.Limit(float64(maxScaleUpsPerMinute)/60),
+		maxScaleUpsPerMinute,
+	)
+}
+
+// deferScaleUp tells if the scale up should not happen now because
+// the max scale ups per minute across all the WPAs is reached.
+// The WPA is requeued to retry the scale up when the limiter allows it.
+func (c *Controller) deferScaleUp(
+	event WokerPodAutoScalerEvent,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) bool {
+
+	if c.scaleUpLimiter == nil {
+		return false
+	}
+
+	reservation := c.scaleUpLimiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return false
+	}
+	reservation.Cancel()
+
+	klog.V(2).Infof("%s: scaleUp deferred by %v, max scale ups per minute reached",
+		event.key, delay)
+	scaleUpsDeferred.WithLabelValues(
+		workerPodAutoScaler.Name,
+		workerPodAutoScaler.Namespace,
+	).Inc()
+	c.workqueue.AddAfter(WokerPodAutoScalerEvent{
+		key:  event.key,
+		name: WokerPodAutoScalerEventUpdate,
+	}, delay)
+	return true
+}
+
+// getCredentials reads the credentials from the secret referenced in
+// the WPA spec. It returns nil if no secret is referenced.
+func (c *Controller) getCredentials(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) (*queue.Credentials, error) {
+
+	secretRef := workerPodAutoScaler.Spec.CredentialsSecretRef
+	if secretRef == nil {
+		return nil, nil
+	}
+
+	namespace := getSecretNamespace(workerPodAutoScaler)
+	if namespace != workerPodAutoScaler.Namespace {
+		return nil, fmt.Errorf("secret %s of namespace %s can not be used, "+
+			"it must be in the namespace of the WPA %s",
+			secretRef.Name, namespace, workerPodAutoScaler.Namespace)
+	}
+	secret, err := c.secretLister.Secrets(namespace).Get(secretRef.Name)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("secret %s with the label %s not found in namespace %s",
+			secretRef.Name, CredentialsLabelSelector, namespace)
+	} else if err != nil {
+		return nil, err
+	}
+	if secret.Labels[CredentialsLabel] != "true" {
+		return nil, fmt.Errorf("secret %s of namespace %s can not be used, "+
+			"it must have the label %s", secretRef.Name, namespace, CredentialsLabelSelector)
+	}
+
+	data := make(map[string][]byte)
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+	return &queue.Credentials{
+		Data:    data,
+		Version: secret.ResourceVersion,
+	}, nil
+}
+
+// getMessageWeights returns the message weights of the queue,
+// it returns nil if the messages are not weighted
+func getMessageWeights(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) *queue.MessageWeights {
+
+	messageWeights := workerPodAutoScaler.Spec.MessageWeights
+	if messageWeights == nil {
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	for k, v := range messageWeights.Weights {
+		weights[k] = v
+	}
+	return &queue.MessageWeights{
+		AttributeName: messageWeights.MessageAttributeName,
+		Weights:       weights,
+		DefaultWeight: messageWeights.GetDefaultWeight(),
+	}
+}
+
+// getSQSOptions returns the options of the SQS poll of the queue,
+// it returns nil if the controller defaults are used. The wait time and
+// the attributes not set in the spec are taken from the cluster default.
+// The age of the oldest message is fetched for the backlog age SLO.
+func getSQSOptions(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	defaults *v1.SQSOptions) *queue.SQSOptions {
+
+	options := workerPodAutoScaler.Spec.SQS
+	oldestMessageAge := workerPodAutoScaler.Spec.BacklogAgeSLOSeconds != nil
+	if options == nil {
+		if !oldestMessageAge && defaults == nil {
+			return nil
+		}
+		options = &v1.SQSOptions{}
+	}
+
+	sqsOptions := &queue.SQSOptions{
+		QueueAttributes:  append([]string(nil), options.QueueAttributes...),
+		QueuePrefix:      options.QueuePrefix,
+		OldestMessageAge: oldestMessageAge,
+	}
+	waitTimeSeconds := options.WaitTimeSeconds
+	if defaults != nil {
+		if waitTimeSeconds == nil {
+			waitTimeSeconds = defaults.WaitTimeSeconds
+		}
+		if len(sqsOptions.QueueAttributes) == 0 {
+			sqsOptions.QueueAttributes = append([]string(nil), defaults.QueueAttributes...)
+		}
+	}
+	if waitTimeSeconds != nil {
+		waitTime := int64(*waitTimeSeconds)
+		sqsOptions.WaitTimeSeconds = &waitTime
+	}
+	if options.MaxDiscoveredQueues != nil {
+		maxDiscoveredQueues := *options.MaxDiscoveredQueues
+		sqsOptions.MaxDiscoveredQueues = &maxDiscoveredQueues
+	}
+	guardPolls := options.BacklogStalenessGuardPolls
+	if guardPolls == nil && defaults != nil {
+		guardPolls = defaults.BacklogStalenessGuardPolls
+	}
+	if guardPolls != nil {
+		sqsOptions.BacklogStalenessGuardPolls = *guardPolls
+	}
+	return sqsOptions
+}
+
+// getSecretNamespace returns the namespace of the credentials secret,
+// it defaults to the namespace of the WPA
+func getSecretNamespace(workerPodAutoScaler *v1.WorkerPodAutoScaler) string {
+	if workerPodAutoScaler.Spec.CredentialsSecretRef.Namespace != "" {
+		return workerPodAutoScaler.Spec.CredentialsSecretRef.Namespace
+	}
+	return workerPodAutoScaler.Namespace
+}
+
+// updateWorkerPodAutoScalerCondition sets the condition in the WPA status
+// It returns the updated WPA, or the passed WPA if no update was made.
+func updateWorkerPodAutoScalerCondition(
+	ctx context.Context,
+	customclientset clientset.Interface,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	condition metav1.Condition) *v1.WorkerPodAutoScaler {
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, condition.Type)
+	if existing != nil &&
+		existing.Status == condition.Status &&
+		existing.Reason == condition.Reason &&
+		existing.Message == condition.Message {
+		return workerPodAutoScaler
+	}
+
+	workerPodAutoScalerCopy := workerPodAutoScaler.DeepCopy()
+	condition.ObservedGeneration = workerPodAutoScaler.Generation
+	meta.SetStatusCondition(&workerPodAutoScalerCopy.Status.Conditions, condition)
+	if isAnnotationDriven(workerPodAutoScaler) {
+		return workerPodAutoScalerCopy
+	}
+	updated, err := customclientset.K8sV1().WorkerPodAutoScalers(workerPodAutoScaler.Namespace).UpdateStatus(ctx, workerPodAutoScalerCopy, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("Error updating wpa condition, err: %v", err)
+		return workerPodAutoScaler
+	}
+	return updated
 }
 
 // getKeyForWorkerPodAutoScaler takes a WorkerPodAutoScaler resource and converts it into a namespace/name
@@ -855,8 +2794,277 @@ func (c *Controller) enqueueUpdateWorkerPodAutoScaler(obj interface{}) {
 }
 
 func (c *Controller) enqueueDeleteWorkerPodAutoScaler(obj interface{}) {
+	// the deleted object is a tombstone if the
+	// informer missed the delete while relisting
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
 	c.workqueue.Add(WokerPodAutoScalerEvent{
-		key:  c.getKeyForWorkerPodAutoScaler(obj),
+		key:  key,
 		name: WokerPodAutoScalerEventDelete,
 	})
 }
+
+// indexByDeploymentName indexes the WPA by namespace/spec.deploymentName
+// or namespace/spec.targetRef.name of the Deployment targets
+func indexByDeploymentName(obj interface{}) ([]string, error) {
+	wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+	if !ok {
+		return []string{}, nil
+	}
+	if wpa.Spec.DeploymentName != "" {
+		return []string{getKey(wpa.Namespace, wpa.Spec.DeploymentName)}, nil
+	}
+	return indexByTargetRefName(wpa, v1.TargetKindDeployment), nil
+}
+
+// indexByReplicaSetName indexes the WPA by namespace/spec.replicaSetName
+// or namespace/spec.targetRef.name of the ReplicaSet targets
+func indexByReplicaSetName(obj interface{}) ([]string, error) {
+	wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+	if !ok {
+		return []string{}, nil
+	}
+	if wpa.Spec.ReplicaSetName != "" {
+		return []string{getKey(wpa.Namespace, wpa.Spec.ReplicaSetName)}, nil
+	}
+	return indexByTargetRefName(wpa, v1.TargetKindReplicaSet), nil
+}
+
+// indexByStatefulSetName indexes the WPA by namespace/spec.targetRef.name
+// of the StatefulSet targets
+func indexByStatefulSetName(obj interface{}) ([]string, error) {
+	wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+	if !ok {
+		return []string{}, nil
+	}
+	return indexByTargetRefName(wpa, v1.TargetKindStatefulSet), nil
+}
+
+// indexByTargetRefName returns the keys of the weightedTargets, the
+// scaleTargetRef or the targetRef name of the kind
+func indexByTargetRefName(wpa *v1.WorkerPodAutoScaler, kind string) []string {
+	if len(wpa.Spec.WeightedTargets) > 0 {
+		keys := []string{}
+		for _, target := range wpa.Spec.WeightedTargets {
+			if target.Kind == kind {
+				keys = append(keys, getKey(wpa.Namespace, target.Name))
+			}
+		}
+		return keys
+	}
+	scaleTargetRef := wpa.Spec.ScaleTargetRef
+	if scaleTargetRef != nil && getScaleTargetKind(scaleTargetRef) == kind {
+		return []string{getKey(wpa.Namespace, scaleTargetRef.Name)}
+	}
+	targetRef := wpa.Spec.TargetRef
+	if targetRef == nil || targetRef.Kind != kind || targetRef.Name == "" {
+		return []string{}
+	}
+	return []string{getKey(wpa.Namespace, targetRef.Name)}
+}
+
+// indexByTargetSelector indexes the WPA by namespace/spec.targetRef.kind
+// if the target is selected using a label selector
+func indexByTargetSelector(obj interface{}) ([]string, error) {
+	wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+	if !ok || wpa.Spec.TargetRef == nil ||
+		wpa.Spec.TargetRef.LabelSelector == nil {
+		return []string{}, nil
+	}
+	return []string{getKey(wpa.Namespace, wpa.Spec.TargetRef.Kind)}, nil
+}
+
+// indexByScalingGroup indexes the WPA by namespace/spec.scalingGroup.name
+func indexByScalingGroup(obj interface{}) ([]string, error) {
+	wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+	if !ok || wpa.Spec.ScalingGroup == nil {
+		return []string{}, nil
+	}
+	return []string{getKey(wpa.Namespace, wpa.Spec.ScalingGroup.Name)}, nil
+}
+
+// indexByCredentialsSecret indexes the WPA by the namespace/name of
+// spec.credentialsSecretRef
+func indexByCredentialsSecret(obj interface{}) ([]string, error) {
+	wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+	if !ok || wpa.Spec.CredentialsSecretRef == nil {
+		return []string{}, nil
+	}
+	return []string{
+		getKey(getSecretNamespace(wpa), wpa.Spec.CredentialsSecretRef.Name),
+	}, nil
+}
+
+// indexByHPATarget indexes the HPA by the namespace/kind/name
+// of spec.scaleTargetRef
+func indexByHPATarget(obj interface{}) ([]string, error) {
+	hpa, ok := obj.(*autoscalingv1.HorizontalPodAutoscaler)
+	if !ok {
+		return []string{}, nil
+	}
+	return []string{getHPATargetKey(
+		hpa.Namespace,
+		hpa.Spec.ScaleTargetRef.Kind,
+		hpa.Spec.ScaleTargetRef.Name,
+	)}, nil
+}
+
+func getHPATargetKey(namespace string, kind string, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+func getKey(namespace string, name string) string {
+	return namespace + "/" + name
+}
+
+// replicasChanged tells if the spec.replicas of the target has changed
+func replicasChanged(old *int32, new *int32) bool {
+	if old == nil || new == nil {
+		return old != new
+	}
+	return *old != *new
+}
+
+func (c *Controller) handleDeploymentUpdate(old, new interface{}) {
+	oldDeployment, ok := old.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+	newDeployment, ok := new.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+	if !replicasChanged(oldDeployment.Spec.Replicas, newDeployment.Spec.Replicas) {
+		return
+	}
+
+	c.enqueueWorkerPodAutoScalersByIndex(
+		deploymentNameIndex,
+		getKey(newDeployment.Namespace, newDeployment.Name),
+	)
+	c.enqueueWorkerPodAutoScalersBySelector(
+		v1.TargetKindDeployment, newDeployment)
+}
+
+func (c *Controller) handleReplicaSetUpdate(old, new interface{}) {
+	oldReplicaSet, ok := old.(*appsv1.ReplicaSet)
+	if !ok {
+		return
+	}
+	newReplicaSet, ok := new.(*appsv1.ReplicaSet)
+	if !ok {
+		return
+	}
+	if !replicasChanged(oldReplicaSet.Spec.Replicas, newReplicaSet.Spec.Replicas) {
+		return
+	}
+
+	c.enqueueWorkerPodAutoScalersByIndex(
+		replicaSetNameIndex,
+		getKey(newReplicaSet.Namespace, newReplicaSet.Name),
+	)
+	c.enqueueWorkerPodAutoScalersBySelector(
+		v1.TargetKindReplicaSet, newReplicaSet)
+}
+
+func (c *Controller) handleStatefulSetUpdate(old, new interface{}) {
+	oldStatefulSet, ok := old.(*appsv1.StatefulSet)
+	if !ok {
+		return
+	}
+	newStatefulSet, ok := new.(*appsv1.StatefulSet)
+	if !ok {
+		return
+	}
+	if !replicasChanged(oldStatefulSet.Spec.Replicas, newStatefulSet.Spec.Replicas) {
+		return
+	}
+
+	c.enqueueWorkerPodAutoScalersByIndex(
+		statefulSetNameIndex,
+		getKey(newStatefulSet.Namespace, newStatefulSet.Name),
+	)
+	c.enqueueWorkerPodAutoScalersBySelector(
+		v1.TargetKindStatefulSet, newStatefulSet)
+}
+
+func (c *Controller) handleSecretAdd(obj interface{}) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+
+	c.enqueueWorkerPodAutoScalersByIndex(
+		credentialsSecretIndex,
+		getKey(secret.Namespace, secret.Name),
+	)
+}
+
+func (c *Controller) handleSecretUpdate(old, new interface{}) {
+	oldSecret, ok := old.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	newSecret, ok := new.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	if oldSecret.ResourceVersion == newSecret.ResourceVersion {
+		return
+	}
+
+	c.enqueueWorkerPodAutoScalersByIndex(
+		credentialsSecretIndex,
+		getKey(newSecret.Namespace, newSecret.Name),
+	)
+}
+
+// enqueueWorkerPodAutoScalersByIndex enqueues an update event for all the
+// WPAs which are indexed by the indexName with the value of targetKey
+func (c *Controller) enqueueWorkerPodAutoScalersByIndex(
+	indexName string, targetKey string) {
+
+	objs, err := c.workerPodAutoScalersIndexer.ByIndex(indexName, targetKey)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	for _, obj := range objs {
+		klog.V(4).Infof("%s changed, enqueuing wpa: %s",
+			targetKey, c.getKeyForWorkerPodAutoScaler(obj))
+		c.enqueueUpdateWorkerPodAutoScaler(obj)
+	}
+}
+
+// enqueueWorkerPodAutoScalersBySelector enqueues an update event for all
+// the WPAs whose targetRef label selector matches the labels of the target
+func (c *Controller) enqueueWorkerPodAutoScalersBySelector(
+	kind string, target metav1.Object) {
+
+	objs, err := c.workerPodAutoScalersIndexer.ByIndex(
+		targetSelectorIndex, getKey(target.GetNamespace(), kind))
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	for _, obj := range objs {
+		wpa, ok := obj.(*v1.WorkerPodAutoScaler)
+		if !ok {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(
+			wpa.Spec.TargetRef.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(target.GetLabels())) {
+			continue
+		}
+		klog.V(4).Infof("%s/%s changed, enqueuing wpa: %s",
+			target.GetNamespace(), target.GetName(),
+			c.getKeyForWorkerPodAutoScaler(obj))
+		c.enqueueUpdateWorkerPodAutoScaler(obj)
+	}
+}
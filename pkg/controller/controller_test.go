@@ -3,6 +3,7 @@ package controller_test
 import (
 	"testing"
 
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
 	"github.com/practo/k8s-worker-pod-autoscaler/pkg/controller"
 )
 
@@ -17,6 +18,15 @@ type desiredWorkerTester struct {
 	minWorkers              int32
 	maxWorkers              int32
 	maxDisruption           string
+	disableVelocityMin      bool
+	respectDisruptionOnIdle bool
+	panicking               bool
+	warmFloor               int32
+	throughputMode          bool
+	coldStartReplicas       int32
+	noBacklogStrategy       string
+	balanceAwareScaling     bool
+	backlogBalance          float64
 }
 
 func (c *desiredWorkerTester) getDesired() int32 {
@@ -31,9 +41,50 @@ func (c *desiredWorkerTester) getDesired() int32 {
 		c.minWorkers,
 		c.maxWorkers,
 		&c.maxDisruption,
+		c.disableVelocityMin,
+		c.panicking,
 	)
 }
 
+func (c *desiredWorkerTester) getDesiredWithReason() (int32, int32, controller.ScaleReason) {
+	result := controller.ComputeDesired(c.scalingInput())
+	return result.DesiredWorkers, result.UnclampedDesiredWorkers, result.Reason
+}
+
+func (c *desiredWorkerTester) scalingInput() controller.ScalingInput {
+	return controller.ScalingInput{
+		QueueName:                 c.queueName,
+		QueueMessages:             c.queueMessages,
+		MessagesSentPerMinute:     c.messagesSentPerMinute,
+		SecondsToProcessOneJob:    c.secondsToProcessOneJob,
+		TargetMessagesPerWorker:   c.targetMessagesPerWorker,
+		CurrentWorkers:            c.currentWorkers,
+		IdleWorkers:               c.idleWorkers,
+		MinWorkers:                c.minWorkers,
+		MaxWorkers:                c.maxWorkers,
+		MaxDisruption:             c.maxDisruption,
+		DisableVelocityMinWorkers: c.disableVelocityMin,
+		RespectDisruptionOnIdle:   c.respectDisruptionOnIdle,
+		Panicking:                 c.panicking,
+		WarmFloor:                 c.warmFloor,
+		ThroughputMode:            c.throughputMode,
+		ColdStartReplicas:         c.coldStartReplicas,
+		NoBacklogStrategy:         c.noBacklogStrategy,
+		BalanceAwareScaling:       c.balanceAwareScaling,
+		BacklogBalance:            c.backlogBalance,
+	}
+}
+
+func (c *desiredWorkerTester) testReason(
+	t *testing.T, expected int32, expectedReason controller.ScaleReason) {
+
+	desired, _, reason := c.getDesiredWithReason()
+	if desired != expected || reason != expectedReason {
+		t.Errorf("desired=%v, reason=%v, expected=%v, expectedReason=%v\n",
+			desired, reason, expected, expectedReason)
+	}
+}
+
 func (c *desiredWorkerTester) test(t *testing.T, expected int32) {
 	desired := c.getDesired()
 	if desired != expected {
@@ -41,6 +92,198 @@ func (c *desiredWorkerTester) test(t *testing.T, expected int32) {
 	}
 }
 
+// TestUnclampedDesiredWorkersAboveMax tests the workers required by
+// the backlog are surfaced when the desired workers are capped by max
+func TestUnclampedDesiredWorkersAboveMax(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           1000,
+		messagesSentPerMinute:   0,
+		secondsToProcessOneJob:  0.0,
+		targetMessagesPerWorker: 10,
+		currentWorkers:          10,
+		idleWorkers:             0,
+		minWorkers:              0,
+		maxWorkers:              20,
+		maxDisruption:           "0%",
+	}
+
+	desired, unclamped, reason := c.getDesiredWithReason()
+	if desired != 20 || unclamped != 100 || reason != controller.ScaleReasonMaxReplicas {
+		t.Errorf("desired=%v, unclamped=%v, reason=%v, expected=20, expectedUnclamped=100, expectedReason=%v\n",
+			desired, unclamped, reason, controller.ScaleReasonMaxReplicas)
+	}
+}
+
+// TestComputeDesiredClamped tests the clamp info of the result
+func TestComputeDesiredClamped(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           1000,
+		targetMessagesPerWorker: 10,
+		currentWorkers:          10,
+		minWorkers:              0,
+		maxWorkers:              20,
+		maxDisruption:           "0%",
+	}
+	result := controller.ComputeDesired(c.scalingInput())
+	if !result.Clamped || result.Reason != controller.ScaleReasonMaxReplicas {
+		t.Errorf("expected clamped by %v, got clamped=%v, reason=%v\n",
+			controller.ScaleReasonMaxReplicas, result.Clamped, result.Reason)
+	}
+
+	c.queueMessages = 150
+	result = controller.ComputeDesired(c.scalingInput())
+	if result.Clamped || result.DesiredWorkers != 15 || result.Reason != controller.ScaleReasonBacklog {
+		t.Errorf("expected desired=15 not clamped, got desired=%v, clamped=%v, reason=%v\n",
+			result.DesiredWorkers, result.Clamped, result.Reason)
+	}
+}
+
+// TestZeroTargetMessagesPerWorker tests a target of zero does not
+// divide the backlog by zero and keeps the current workers
+func TestZeroTargetMessagesPerWorker(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           1000,
+		targetMessagesPerWorker: 0,
+		currentWorkers:          10,
+		minWorkers:              0,
+		maxWorkers:              20,
+		maxDisruption:           "10%",
+	}
+	c.test(t, 10)
+	c.testReason(t, 10, controller.ScaleReasonInvalidTarget)
+
+	_, unclamped, _ := c.getDesiredWithReason()
+	if unclamped != 0 {
+		t.Errorf("unclamped=%v, expected=0\n", unclamped)
+	}
+}
+
+// TestWarmFloor tests the warm floor applies only when there is no backlog
+func TestWarmFloor(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           0,
+		targetMessagesPerWorker: 10,
+		currentWorkers:          5,
+		idleWorkers:             5,
+		minWorkers:              0,
+		maxWorkers:              20,
+		maxDisruption:           "100%",
+		warmFloor:               2,
+	}
+	c.testReason(t, 2, controller.ScaleReasonWarmFloor)
+
+	c.currentWorkers = 0
+	c.idleWorkers = 0
+	c.testReason(t, 2, controller.ScaleReasonWarmFloor)
+
+	// with a backlog the workers required by it take over
+	c.queueMessages = 5
+	c.currentWorkers = 2
+	c.testReason(t, 1, controller.ScaleReasonBacklog)
+
+	// minReplicas above the warm floor decides as before
+	c.queueMessages = 0
+	c.currentWorkers = 5
+	c.idleWorkers = 5
+	c.minWorkers = 3
+	c.testReason(t, 3, controller.ScaleReasonMinReplicas)
+}
+
+// TestThroughputMode tests the messages sent per minute decide the
+// desired workers in the throughput mode and the backlog is ignored
+func TestThroughputMode(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           0,
+		messagesSentPerMinute:   600,
+		secondsToProcessOneJob:  2,
+		targetMessagesPerWorker: 10,
+		currentWorkers:          10,
+		idleWorkers:             0,
+		minWorkers:              0,
+		maxWorkers:              50,
+		maxDisruption:           "100%",
+		throughputMode:          true,
+	}
+	c.testReason(t, 20, controller.ScaleReasonThroughput)
+
+	// steady throughput keeps the workers
+	c.currentWorkers = 20
+	c.testReason(t, 20, controller.ScaleReasonWithinTolerance)
+	c.currentWorkers = 21
+	c.testReason(t, 21, controller.ScaleReasonWithinTolerance)
+
+	// the backlog does not drive the workers
+	c.currentWorkers = 20
+	c.queueMessages = 10000
+	c.testReason(t, 20, controller.ScaleReasonWithinTolerance)
+	_, unclamped, _ := c.getDesiredWithReason()
+	if unclamped != 20 {
+		t.Errorf("unclamped=%v, expected=20\n", unclamped)
+	}
+
+	// the throughput dropping scales down within the max disruption
+	c.messagesSentPerMinute = 300
+	c.testReason(t, 10, controller.ScaleReasonThroughput)
+	c.maxDisruption = "25%"
+	c.testReason(t, 15, controller.ScaleReasonMaxDisruption)
+
+	// the min and the max replicas are applied
+	c.maxDisruption = "100%"
+	c.minWorkers = 12
+	c.testReason(t, 12, controller.ScaleReasonMinReplicas)
+	c.minWorkers = 0
+	c.messagesSentPerMinute = 3000
+	c.testReason(t, 50, controller.ScaleReasonMaxReplicas)
+
+	// the backlog decides till secondsToProcessOneJob is known
+	c.secondsToProcessOneJob = 0
+	c.queueMessages = 300
+	c.testReason(t, 30, controller.ScaleReasonBacklog)
+}
+
+// TestColdStart tests the scale up from zero workers jumps to the
+// cold start replicas and the backlog decides the workers after it
+func TestColdStart(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           10000,
+		targetMessagesPerWorker: 1000,
+		currentWorkers:          0,
+		idleWorkers:             0,
+		minWorkers:              0,
+		maxWorkers:              50,
+		maxDisruption:           "10%",
+		coldStartReplicas:       20,
+	}
+	// the backlog needs 10, the cold start jumps to 20
+	c.testReason(t, 20, controller.ScaleReasonColdStart)
+
+	// once the workers are up the backlog decides again,
+	// the scale down is capped by the disruption
+	c.currentWorkers = 20
+	c.testReason(t, 18, controller.ScaleReasonMaxDisruption)
+
+	// the backlog above the cold start decides
+	c.currentWorkers = 0
+	c.queueMessages = 30000
+	c.testReason(t, 30, controller.ScaleReasonBacklog)
+
+	// the cold start is capped by the max
+	c.queueMessages = 10000
+	c.maxWorkers = 15
+	c.testReason(t, 15, controller.ScaleReasonMaxReplicas)
+
+	// no backlog, no cold start
+	c.maxWorkers = 50
+	c.queueMessages = 0
+	c.testReason(t, 0, controller.ScaleReasonBacklog)
+}
+
 // TestScaleDownWhenQueueMessagesLessThanTarget tests scale down
 // when unprocessed messages is less than targetMessagesPerWorker
 // #89
@@ -158,3 +401,204 @@ func TestRPMScalingForFastRunningWorkers(t *testing.T) {
 
 	c.test(t, 2)
 }
+
+// TestNoBacklogStrategy tests the workers when there is no backlog
+// but the queue has throughput
+func TestNoBacklogStrategy(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           0,
+		messagesSentPerMinute:   float64(120),
+		secondsToProcessOneJob:  float64(1),
+		targetMessagesPerWorker: 60,
+		currentWorkers:          8,
+		idleWorkers:             0,
+		minWorkers:              0,
+		maxWorkers:              100,
+		maxDisruption:           "100%",
+	}
+
+	// the default scales down to the min raised by the velocity
+	c.testReason(t, 2, controller.ScaleReasonVelocity)
+	c.noBacklogStrategy = v1.NoBacklogStrategyScaleToMin
+	c.testReason(t, 2, controller.ScaleReasonVelocity)
+
+	c.noBacklogStrategy = v1.NoBacklogStrategyHoldCurrent
+	c.testReason(t, 8, controller.ScaleReasonHoldCurrent)
+
+	// the held workers are within the bounds
+	c.maxWorkers = 5
+	c.testReason(t, 5, controller.ScaleReasonMaxReplicas)
+	c.maxWorkers = 100
+	c.currentWorkers = 1
+	c.testReason(t, 2, controller.ScaleReasonVelocity)
+
+	// it holds even when secondsToProcessOneJob is not known
+	c.currentWorkers = 8
+	c.secondsToProcessOneJob = 0
+	c.idleWorkers = 8
+	c.testReason(t, 8, controller.ScaleReasonHoldCurrent)
+
+	// without throughput the workers are scaled down
+	c.messagesSentPerMinute = 0
+	c.testReason(t, 0, controller.ScaleReasonAllIdle)
+}
+
+// TestRespectDisruptionOnIdle tests the fully idle workers are scaled
+// down by the maxDisruption in every loop when the disruption is respected
+func TestRespectDisruptionOnIdle(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           0,
+		targetMessagesPerWorker: 10,
+		currentWorkers:          100,
+		idleWorkers:             100,
+		minWorkers:              0,
+		maxWorkers:              100,
+		maxDisruption:           "10%",
+	}
+
+	// the idle workers are scaled down at once by default
+	c.testReason(t, 0, controller.ScaleReasonAllIdle)
+
+	c.respectDisruptionOnIdle = true
+	for _, expected := range []int32{90, 81, 72} {
+		c.testReason(t, expected, controller.ScaleReasonMaxDisruption)
+		c.currentWorkers = expected
+		c.idleWorkers = expected
+	}
+
+	// the last idle worker is scaled down to the min
+	c.maxDisruption = "1"
+	c.currentWorkers = 1
+	c.idleWorkers = 1
+	c.testReason(t, 0, controller.ScaleReasonAllIdle)
+}
+
+// TestBalanceAwareScaling tests the workers required by the growth of
+// the backlog are added to the workers required by the backlog
+func TestBalanceAwareScaling(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           100,
+		messagesSentPerMinute:   float64(600),
+		targetMessagesPerWorker: 10,
+		currentWorkers:          10,
+		idleWorkers:             0,
+		minWorkers:              0,
+		maxWorkers:              100,
+		maxDisruption:           "100%",
+		backlogBalance:          150,
+	}
+
+	// the balance is not used without the balanceAwareScaling
+	c.testReason(t, 10, controller.ScaleReasonWithinTolerance)
+
+	// the growth of a minute is divided by the target
+	c.balanceAwareScaling = true
+	c.testReason(t, 25, controller.ScaleReasonBacklogGrowing)
+	if result := controller.ComputeDesired(c.scalingInput()); result.UnclampedDesiredWorkers != 25 ||
+		result.Algorithm != controller.ScalingAlgorithmBalance {
+		t.Errorf("expected unclamped=25, algorithm=balance, got unclamped=%v, algorithm=%v",
+			result.UnclampedDesiredWorkers, result.Algorithm)
+	}
+
+	// the growth is processed as fast as secondsToProcessOneJob tells
+	c.secondsToProcessOneJob = 2
+	c.disableVelocityMin = true
+	c.testReason(t, 15, controller.ScaleReasonBacklogGrowing)
+
+	// a draining backlog does not remove workers
+	c.backlogBalance = -300
+	c.testReason(t, 10, controller.ScaleReasonWithinTolerance)
+
+	// the added workers are within the bounds
+	c.backlogBalance = 3000
+	c.maxWorkers = 40
+	c.testReason(t, 40, controller.ScaleReasonMaxReplicas)
+}
+
+// TestDisableVelocityMinWorkersDoesNotRaiseMin
+// secondsToProcessOneJob raises the min workers based on the rpm,
+// disableVelocityMinWorkers keeps the configured min workers
+func TestDisableVelocityMinWorkersDoesNotRaiseMin(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           1,
+		messagesSentPerMinute:   float64(2136),
+		secondsToProcessOneJob:  float64(10),
+		targetMessagesPerWorker: 2500,
+		currentWorkers:          10,
+		idleWorkers:             0,
+		minWorkers:              2,
+		maxWorkers:              20,
+		maxDisruption:           "100%",
+	}
+
+	// velocity based min workers raises the min to max
+	c.test(t, 20)
+
+	// backlog based desired workers is used
+	c.disableVelocityMin = true
+	c.test(t, 2)
+}
+
+// TestDisableVelocityMinWorkersKeepsThroughputBranch
+// when there is no backlog but the queue has throughput the workers
+// are not massively scaled down ignoring the maxDisruption even if all
+// are idle, this is kept when velocity min workers is disabled
+func TestDisableVelocityMinWorkersKeepsThroughputBranch(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           0,
+		messagesSentPerMinute:   float64(120),
+		secondsToProcessOneJob:  float64(1),
+		targetMessagesPerWorker: 60,
+		currentWorkers:          2,
+		idleWorkers:             2,
+		minWorkers:              0,
+		maxWorkers:              100,
+		maxDisruption:           "50%",
+	}
+
+	// min is raised to ceil(1*120/60)=2
+	c.test(t, 2)
+
+	// min is not raised, scale down respects maxDisruption
+	c.disableVelocityMin = true
+	c.test(t, 1)
+
+	// without secondsToProcessOneJob the idle workers
+	// are massively scaled down ignoring maxDisruption
+	c.secondsToProcessOneJob = 0.0
+	c.test(t, 0)
+}
+
+// TestPanicScalesToMax tests the panic mode scales straight to max
+// and does not scale down while the panic window lasts
+func TestPanicScalesToMax(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           1000,
+		messagesSentPerMinute:   float64(0),
+		secondsToProcessOneJob:  float64(0),
+		targetMessagesPerWorker: 10,
+		currentWorkers:          10,
+		idleWorkers:             0,
+		minWorkers:              1,
+		maxWorkers:              300,
+		maxDisruption:           "10%",
+	}
+
+	// without panic the backlog decides the desired workers
+	c.test(t, 100)
+
+	c.panicking = true
+	c.test(t, 300)
+
+	// the backlog is drained but the panic window lasts
+	c.queueMessages = 0
+	c.currentWorkers = 300
+	c.idleWorkers = 300
+	c.test(t, 300)
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNextScaleEligibleTime(t *testing.T) {
+	now := time.Now()
+	lastScaleTime := metav1.NewTime(now.Add(-time.Minute))
+
+	if next := getNextScaleEligibleTime(nil, time.Minute, time.Hour, now); next != nil {
+		t.Errorf("expected no next time without a last scale, got %v", next)
+	}
+
+	next := getNextScaleEligibleTime(&lastScaleTime, 30*time.Second, 10*time.Minute, now)
+	expected := lastScaleTime.Add(10 * time.Minute)
+	if next == nil || !next.Time.Equal(expected) {
+		t.Errorf("expected next time %v, got %v", expected, next)
+	}
+
+	if next := getNextScaleEligibleTime(&lastScaleTime, 0, 30*time.Second, now); next != nil {
+		t.Errorf("expected no next time after the delays passed, got %v", next)
+	}
+
+	remaining := getCooldownRemaining(&lastScaleTime, 10*time.Minute, now)
+	if remaining != 9*time.Minute {
+		t.Errorf("expected 9m remaining, got %v", remaining)
+	}
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// crashLoop is the pods of the workload which are crash looping
+type crashLoop struct {
+	crashing int
+	pods     int
+	// reason is the most common reason of the last termination
+	// of the crashing containers, e.g. OOMKilled
+	reason string
+}
+
+// getCrashLoop returns the pods whose containers restarted at least
+// the given number of times and failed the last time within the window.
+// The workload is crash looping when more than half of its pods are.
+func getCrashLoop(
+	pods []*corev1.Pod,
+	restarts int32,
+	window time.Duration,
+	now time.Time) (crashLoop, bool) {
+
+	loop := crashLoop{}
+	reasons := make(map[string]int)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		loop.pods++
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.LastTerminationState.Terminated
+			if status.RestartCount < restarts || terminated == nil ||
+				terminated.ExitCode == 0 ||
+				now.Sub(terminated.FinishedAt.Time) > window {
+				continue
+			}
+			loop.crashing++
+			reason := terminated.Reason
+			if reason == "" {
+				reason = fmt.Sprintf("ExitCode%d", terminated.ExitCode)
+			}
+			reasons[reason]++
+			break
+		}
+	}
+
+	for reason, count := range reasons {
+		if count > reasons[loop.reason] ||
+			(count == reasons[loop.reason] && reason < loop.reason) {
+			loop.reason = reason
+		}
+	}
+	return loop, loop.crashing > 0 && loop.crashing*2 > loop.pods
+}
+
+// checkWorkersCrashLooping reports the pods of the workload crash
+// looping using a warning event and the WorkersCrashLooping condition.
+// It returns true if the WPA should not scale up, as more replicas
+// would only crash as well.
+func (c *Controller) checkWorkersCrashLooping(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	targetKind string,
+	targetName string,
+	now time.Time) (*v1.WorkerPodAutoScaler, bool) {
+
+	if c.crashLoopRestarts == 0 {
+		return workerPodAutoScaler, false
+	}
+
+	namespace := workerPodAutoScaler.Namespace
+	selector, err := c.getTargetSelector(namespace, targetKind, targetName)
+	if err != nil {
+		klog.Warningf("%s: error getting the pods of %s %s, %v",
+			key, targetKind, targetName, err)
+		return workerPodAutoScaler, false
+	}
+	pods, err := c.podLister.Pods(namespace).List(selector)
+	if err != nil {
+		klog.Warningf("%s: error listing the pods of %s %s, %v",
+			key, targetKind, targetName, err)
+		return workerPodAutoScaler, false
+	}
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionWorkersCrashLooping)
+	loop, crashLooping := getCrashLoop(pods, c.crashLoopRestarts, c.crashLoopWindow, now)
+	if !crashLooping {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionWorkersCrashLooping,
+				Status:  metav1.ConditionFalse,
+				Reason:  "WorkersRunning",
+				Message: fmt.Sprintf("Pods of %s %s are not crash looping", targetKind, targetName),
+			},
+		), false
+	}
+
+	message := fmt.Sprintf(
+		"%d of %d pods of %s %s are crash looping (%s), not scaling up",
+		loop.crashing, loop.pods, targetKind, targetName, loop.reason)
+	klog.Warningf("%s: %s", key, message)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionWorkersCrashLooping, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionWorkersCrashLooping,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ContainersTerminated",
+			Message: message,
+		},
+	), true
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func crashingPod(restarts int32, reason string, exitCode int32, finishedAt time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				RestartCount: restarts,
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:     reason,
+						ExitCode:   exitCode,
+						FinishedAt: metav1.NewTime(finishedAt),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestGetCrashLoop(t *testing.T) {
+	now := time.Now()
+	window := 10 * time.Minute
+	running := &corev1.Pod{}
+
+	tests := []struct {
+		name         string
+		pods         []*corev1.Pod
+		crashLooping bool
+		crashing     int
+		reason       string
+	}{
+		{
+			name:         "no pods",
+			crashLooping: false,
+		},
+		{
+			name: "most pods oom killed",
+			pods: []*corev1.Pod{
+				crashingPod(5, "OOMKilled", 137, now.Add(-time.Minute)),
+				crashingPod(3, "OOMKilled", 137, now.Add(-2*time.Minute)),
+				crashingPod(4, "Error", 1, now.Add(-time.Minute)),
+				running,
+			},
+			crashLooping: true,
+			crashing:     3,
+			reason:       "OOMKilled",
+		},
+		{
+			name: "half of the pods crashing",
+			pods: []*corev1.Pod{
+				crashingPod(5, "OOMKilled", 137, now.Add(-time.Minute)),
+				running,
+			},
+			crashLooping: false,
+			crashing:     1,
+			reason:       "OOMKilled",
+		},
+		{
+			name: "restarts below the threshold",
+			pods: []*corev1.Pod{
+				crashingPod(2, "OOMKilled", 137, now.Add(-time.Minute)),
+			},
+			crashLooping: false,
+		},
+		{
+			name: "failure outside the window",
+			pods: []*corev1.Pod{
+				crashingPod(5, "OOMKilled", 137, now.Add(-time.Hour)),
+			},
+			crashLooping: false,
+		},
+		{
+			name: "completed containers",
+			pods: []*corev1.Pod{
+				crashingPod(5, "Completed", 0, now.Add(-time.Minute)),
+			},
+			crashLooping: false,
+		},
+		{
+			name: "exit code without a reason",
+			pods: []*corev1.Pod{
+				crashingPod(5, "", 2, now.Add(-time.Minute)),
+			},
+			crashLooping: true,
+			crashing:     1,
+			reason:       "ExitCode2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loop, crashLooping := getCrashLoop(test.pods, 3, window, now)
+			if crashLooping != test.crashLooping {
+				t.Errorf("expected crash looping=%v, got=%v", test.crashLooping, crashLooping)
+			}
+			if loop.crashing != test.crashing || loop.reason != test.reason {
+				t.Errorf("expected %d crashing pods with reason %q, got=%+v",
+					test.crashing, test.reason, loop)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/practo/klog/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// CredentialsLabel must be set to "true" on the secrets referenced by the
+// credentialsSecretRef of the WPAs, only the secrets with the label are
+// watched by the controller
+const CredentialsLabel = "workerpodautoscaler.practo.com/credentials"
+
+// CredentialsLabelSelector selects the secrets watched by the controller
+const CredentialsLabelSelector = CredentialsLabel + "=true"
+
+// checkCredentials reads the credentials of the WPA and reports if they
+// could be read using the CredentialsAvailable condition. The condition
+// is not set for the WPAs which do not reference a secret. It returns
+// true if the WPA should not be synced as its credentials are unavailable.
+func (c *Controller) checkCredentials(
+	ctx context.Context,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) (*v1.WorkerPodAutoScaler, *queue.Credentials, bool) {
+
+	credentials, err := c.getCredentials(workerPodAutoScaler)
+	if err != nil {
+		klog.Errorf("%s/%s: unable to read credentials, err: %v",
+			workerPodAutoScaler.Namespace, workerPodAutoScaler.Name, err)
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionCredentialsAvailable,
+				Status:  metav1.ConditionFalse,
+				Reason:  "SecretUnavailable",
+				Message: err.Error(),
+			},
+		), nil, true
+	}
+	if workerPodAutoScaler.Spec.CredentialsSecretRef == nil {
+		return workerPodAutoScaler, nil, false
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionCredentialsAvailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  "SecretFound",
+			Message: "Credentials were read from the secret",
+		},
+	), credentials, false
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+)
+
+func credentialsSecret(namespace string, resourceVersion string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "sqs-keys",
+			Namespace:       namespace,
+			ResourceVersion: resourceVersion,
+			Labels:          map[string]string{CredentialsLabel: "true"},
+		},
+		Data: map[string][]byte{
+			"AWS_ACCESS_KEY_ID":     []byte("AKIAOTPSENDER"),
+			"AWS_SECRET_ACCESS_KEY": []byte("secret"),
+		},
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	tests := []struct {
+		name            string
+		secretRef       *v1.SecretReference
+		secrets         []*corev1.Secret
+		expectedSkip    bool
+		expectedVersion string
+		expectedStatus  metav1.ConditionStatus
+	}{
+		{
+			name: "no secret referenced",
+		},
+		{
+			name:           "missing secret",
+			secretRef:      &v1.SecretReference{Name: "sqs-keys"},
+			expectedSkip:   true,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:            "secret in the namespace of the wpa",
+			secretRef:       &v1.SecretReference{Name: "sqs-keys"},
+			secrets:         []*corev1.Secret{credentialsSecret("testns", "1")},
+			expectedVersion: "1",
+			expectedStatus:  metav1.ConditionTrue,
+		},
+		{
+			name:            "rotated secret",
+			secretRef:       &v1.SecretReference{Name: "sqs-keys"},
+			secrets:         []*corev1.Secret{credentialsSecret("testns", "2")},
+			expectedVersion: "2",
+			expectedStatus:  metav1.ConditionTrue,
+		},
+		{
+			name:      "secret without the credentials label",
+			secretRef: &v1.SecretReference{Name: "sqs-keys"},
+			secrets: []*corev1.Secret{
+				{ObjectMeta: metav1.ObjectMeta{Name: "sqs-keys", Namespace: "testns"}},
+			},
+			expectedSkip:   true,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "secret in another namespace",
+			secretRef:      &v1.SecretReference{Name: "sqs-keys", Namespace: "kube-system"},
+			secrets:        []*corev1.Secret{credentialsSecret("kube-system", "1")},
+			expectedSkip:   true,
+			expectedStatus: metav1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		wpa := &v1.WorkerPodAutoScaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+			Spec:       v1.WorkerPodAutoScalerSpec{CredentialsSecretRef: test.secretRef},
+		}
+		secrets := newIndexer()
+		for _, secret := range test.secrets {
+			if err := secrets.Add(secret); err != nil {
+				t.Fatalf("Error adding the secret to the indexer: %v\n", err)
+			}
+		}
+		c := &Controller{
+			customclientset: fake.NewSimpleClientset(wpa),
+			secretLister:    corelisters.NewSecretLister(secrets),
+		}
+
+		wpa, credentials, skip := c.checkCredentials(context.Background(), wpa)
+		if skip != test.expectedSkip {
+			t.Errorf("%s: expected skip=%v, got=%v\n", test.name, test.expectedSkip, skip)
+		}
+		var version string
+		if credentials != nil {
+			version = credentials.Version
+		}
+		if version != test.expectedVersion {
+			t.Errorf("%s: expected credentials version=%q, got=%q\n",
+				test.name, test.expectedVersion, version)
+		}
+		condition := meta.FindStatusCondition(
+			wpa.Status.Conditions, v1.ConditionCredentialsAvailable)
+		if test.expectedStatus == "" {
+			if condition != nil {
+				t.Errorf("%s: expected no condition, got=%v\n", test.name, condition)
+			}
+			continue
+		}
+		if condition == nil || condition.Status != test.expectedStatus {
+			t.Errorf("%s: expected condition status=%v, got=%v\n",
+				test.name, test.expectedStatus, condition)
+		}
+	}
+}
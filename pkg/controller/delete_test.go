@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	listers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/listers/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func newDeleteTestController(t *testing.T) (*Controller, func()) {
+	stopCh := make(chan struct{})
+	queues := queue.NewQueues()
+	go queues.Sync(stopCh)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	c := &Controller{
+		workerPodAutoScalersLister: listers.NewWorkerPodAutoScalerLister(indexer),
+		Queues:                     queues,
+		panicUntil:                 new(sync.Map),
+		groupDemand:                new(sync.Map),
+		stalls:                     new(sync.Map),
+		forceSyncs:                 new(sync.Map),
+		targetOverrides:            new(sync.Map),
+		breakers:                   new(sync.Map),
+		annotatedWPAs:              new(sync.Map),
+		annotatedStatuses:          new(sync.Map),
+		metricSeries:               new(sync.Map),
+		firstSeen:                  new(sync.Map),
+		manualScales:               new(sync.Map),
+	}
+	err := queues.Add("testns", "otpsender",
+		"beanstalk://beanstalkd:11300/otpsender", 1, 0.0, false, nil, nil, "", nil)
+	if err != nil {
+		t.Fatalf("Error adding the queue: %v\n", err)
+	}
+	return c, func() { close(stopCh) }
+}
+
+func TestSyncHandlerDeleteAfterObjectGone(t *testing.T) {
+	c, stop := newDeleteTestController(t)
+	defer stop()
+	c.stalls.Store("testns/otpsender", &stall{})
+
+	err := c.syncHandler(context.Background(), WokerPodAutoScalerEvent{
+		key:  "testns/otpsender",
+		name: WokerPodAutoScalerEventDelete,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	if queueName, _, _, _ := c.Queues.GetQueueInfo("testns", "otpsender"); queueName != "" {
+		t.Errorf("expected the queue to be deleted, got=%v\n", queueName)
+	}
+	if _, ok := c.stalls.Load("testns/otpsender"); ok {
+		t.Errorf("expected the state of the wpa to be dropped\n")
+	}
+}
+
+func TestSyncHandlerDeleteMetricSeries(t *testing.T) {
+	c, stop := newDeleteTestController(t)
+	defer stop()
+	queueName := c.getMetricQueueName("testns/otpsender", "otpsender", "testns", "otpsender")
+	qMsgs.WithLabelValues("otpsender", "testns", queueName).Set(87)
+	workersDesired.WithLabelValues("otpsender", "testns", queueName).Set(5)
+	scaleReasonGauge.WithLabelValues("otpsender", "testns", queueName,
+		string(ScaleReasonBacklog)).Set(1)
+	loopDurationSeconds.WithLabelValues("otpsender", "testns").Set(0.4)
+	lastReconcileTimestamp.WithLabelValues("otpsender", "testns").SetToCurrentTime()
+	managedWPAs.WithLabelValues("testns").Set(1)
+
+	err := c.syncHandler(context.Background(), WokerPodAutoScalerEvent{
+		key:  "testns/otpsender",
+		name: WokerPodAutoScalerEventDelete,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+
+	for metric, deleted := range map[string]bool{
+		"wpa_queue_messages": !qMsgs.DeleteLabelValues(
+			"otpsender", "testns", queueName),
+		"wpa_worker_desired": !workersDesired.DeleteLabelValues(
+			"otpsender", "testns", queueName),
+		"wpa_scale_reason": !scaleReasonGauge.DeleteLabelValues(
+			"otpsender", "testns", queueName, string(ScaleReasonBacklog)),
+		"wpa_controller_loop_duration_seconds": !loopDurationSeconds.DeleteLabelValues(
+			"otpsender", "testns"),
+		"wpa_controller_last_reconcile_timestamp_seconds": !lastReconcileTimestamp.DeleteLabelValues(
+			"otpsender", "testns"),
+		"wpa_controller_managed_wpas": !managedWPAs.DeleteLabelValues("testns"),
+	} {
+		if !deleted {
+			t.Errorf("expected the %s series of the deleted wpa to be gone\n", metric)
+		}
+	}
+}
+
+func TestSyncHandlerUpdateAfterObjectGone(t *testing.T) {
+	c, stop := newDeleteTestController(t)
+	defer stop()
+
+	// a stale update is not a delete, the delete event tears down the queue
+	err := c.syncHandler(context.Background(), WokerPodAutoScalerEvent{
+		key:  "testns/otpsender",
+		name: WokerPodAutoScalerEventUpdate,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	if queueName, _, _, _ := c.Queues.GetQueueInfo("testns", "otpsender"); queueName != "otpsender" {
+		t.Errorf("expected the queue to be kept, got=%q\n", queueName)
+	}
+}
+
+func TestEnqueueDeleteTombstone(t *testing.T) {
+	c := &Controller{
+		workqueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+	defer c.workqueue.ShutDown()
+
+	c.enqueueDeleteWorkerPodAutoScaler(cache.DeletedFinalStateUnknown{
+		Key: "testns/otpsender",
+		Obj: &v1.WorkerPodAutoScaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		},
+	})
+	item, _ := c.workqueue.Get()
+	event := item.(WokerPodAutoScalerEvent)
+	if event.key != "testns/otpsender" || event.name != WokerPodAutoScalerEventDelete {
+		t.Errorf("expected a delete event of testns/otpsender, got=%+v\n", event)
+	}
+}
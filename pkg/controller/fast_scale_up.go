@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// queueFastScaleUps is the fast loop, it runs every fastScaleUpInterval.
+// It requests a poll of the backlog of the WPAs with a
+// fastScaleUpThreshold and queues the ones whose last polled backlog per
+// worker is above it for a scale up. The backlog polled on the request
+// is checked in the next run. The sync of the main loop decides the
+// workers, the fast loop only makes it happen sooner.
+func (c *Controller) queueFastScaleUps() {
+	wpas, err := c.workerPodAutoScalersLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	now := time.Now()
+	for _, wpa := range wpas {
+		if wpa.Spec.FastScaleUpThreshold == nil {
+			continue
+		}
+		if !c.Queues.RequestPoll(wpa.Namespace, wpa.Name) {
+			continue
+		}
+		_, queueMessages, _, _ := c.Queues.GetQueueInfo(wpa.Namespace, wpa.Name)
+		if !isFastScaleUpNeeded(wpa, queueMessages, now) {
+			continue
+		}
+		key := getKey(wpa.Namespace, wpa.Name)
+		klog.V(2).Infof("%s: backlog %d above the fast scale up threshold %v for %d workers",
+			key, queueMessages, *wpa.Spec.FastScaleUpThreshold, wpa.Status.CurrentReplicas)
+		c.workqueue.Add(WokerPodAutoScalerEvent{
+			key:  key,
+			name: WokerPodAutoScalerEventFastScaleUp,
+		})
+	}
+}
+
+// isFastScaleUpNeeded tells if the backlog per worker is above the
+// fastScaleUpThreshold of the WPA and the workers can be scaled up.
+// The workers of the last sync of the WPA are used.
+func isFastScaleUpNeeded(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	queueMessages int32,
+	now time.Time) bool {
+
+	spec := workerPodAutoScaler.Spec
+	threshold := spec.FastScaleUpThreshold
+	if threshold == nil || spec.MinReplicas == nil || spec.MaxReplicas == nil ||
+		queueMessages == queue.UnsyncedQueueMessageCount || queueMessages <= 0 {
+		return false
+	}
+	currentWorkers := workerPodAutoScaler.Status.CurrentReplicas
+	_, _, maxWorkers, _ := getActiveBounds(workerPodAutoScaler, now)
+	if currentWorkers >= maxWorkers {
+		return false
+	}
+	workers := currentWorkers
+	if workers == 0 {
+		workers = 1
+	}
+	return float64(queueMessages)/float64(workers) > *threshold
+}
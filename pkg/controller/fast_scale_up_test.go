@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func TestIsFastScaleUpNeeded(t *testing.T) {
+	minReplicas, maxReplicas := int32(0), int32(10)
+	threshold := 100.0
+	newWPA := func(threshold *float64, currentWorkers int32) *v1.WorkerPodAutoScaler {
+		return &v1.WorkerPodAutoScaler{
+			Spec: v1.WorkerPodAutoScalerSpec{
+				MinReplicas:          &minReplicas,
+				MaxReplicas:          &maxReplicas,
+				FastScaleUpThreshold: threshold,
+			},
+			Status: v1.WorkerPodAutoScalerStatus{CurrentReplicas: currentWorkers},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		wpa           *v1.WorkerPodAutoScaler
+		queueMessages int32
+		expected      bool
+	}{
+		{"above the threshold", newWPA(&threshold, 2), 201, true},
+		{"at the threshold", newWPA(&threshold, 2), 200, false},
+		{"no workers", newWPA(&threshold, 0), 101, true},
+		{"at max", newWPA(&threshold, 10), 5000, false},
+		{"unsynced", newWPA(&threshold, 2), queue.UnsyncedQueueMessageCount, false},
+		{"disabled", newWPA(nil, 2), 5000, false},
+	}
+	for _, test := range tests {
+		got := isFastScaleUpNeeded(test.wpa, test.queueMessages, time.Now())
+		if got != test.expected {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+		}
+	}
+}
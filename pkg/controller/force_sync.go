@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/practo/klog/v2"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+const (
+	// ForceSyncAnnotation is set to a RFC3339 timestamp on the WPA to
+	// poll its queue right away and reconcile, the sync is forced
+	// again whenever the timestamp changes
+	ForceSyncAnnotation = "workerpodautoscaler.practo.com/force-sync"
+
+	// forceSyncMaxAge is the age after which the timestamp is stale,
+	// so that a left over annotation does not force a sync on restarts
+	forceSyncMaxAge = 5 * time.Minute
+
+	// forceSyncPollTimeout is the time the reconcile waits for
+	// the forced poll of the queue
+	forceSyncPollTimeout = 10 * time.Second
+)
+
+// isForceSyncRequested tells if the force sync annotation of the WPA has
+// a timestamp which is not handled yet. The timestamps which are not
+// valid or are older than forceSyncMaxAge are ignored.
+func (c *Controller) isForceSyncRequested(
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	now time.Time) bool {
+
+	value, ok := workerPodAutoScaler.Annotations[ForceSyncAnnotation]
+	if !ok || value == "" {
+		c.forceSyncs.Delete(key)
+		return false
+	}
+	if handled, ok := c.forceSyncs.Load(key); ok && handled.(string) == value {
+		return false
+	}
+	c.forceSyncs.Store(key, value)
+
+	requested, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		klog.Warningf("%s: ignoring %s=%q, it is not a RFC3339 timestamp",
+			key, ForceSyncAnnotation, value)
+		return false
+	}
+	if now.Sub(requested) > forceSyncMaxAge {
+		klog.Warningf("%s: ignoring %s=%q, it is older than %v",
+			key, ForceSyncAnnotation, value, forceSyncMaxAge)
+		return false
+	}
+	return true
+}
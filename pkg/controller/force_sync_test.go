@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestIsForceSyncRequested(t *testing.T) {
+	c := &Controller{forceSyncs: new(sync.Map)}
+	now := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	wpa := func(value string) *v1.WorkerPodAutoScaler {
+		return &v1.WorkerPodAutoScaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{ForceSyncAnnotation: value},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{"new timestamp", "2021-04-01T09:59:00Z", true},
+		{"handled timestamp", "2021-04-01T09:59:00Z", false},
+		{"changed timestamp", "2021-04-01T09:59:30Z", true},
+		{"stale timestamp", "2021-04-01T09:00:00Z", false},
+		{"not a timestamp", "now", false},
+		{"cleared", "", false},
+		{"same timestamp after clear", "2021-04-01T09:59:30Z", true},
+	}
+
+	for _, test := range tests {
+		requested := c.isForceSyncRequested("ns/wpa", wpa(test.value), now)
+		if requested != test.expected {
+			t.Errorf("%s: expected=%v, got=%v\n", test.name, test.expected, requested)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+const (
+	// idleScrapeTimeout is the timeout of the scrape of one pod
+	idleScrapeTimeout = 2 * time.Second
+	// idleScrapeConcurrency is the number of pods scraped at once
+	idleScrapeConcurrency = 10
+)
+
+// scrapeIdle returns the idle workers of the pod served as plain text
+// by the endpoint at the url
+func scrapeIdle(ctx context.Context, url string) (int32, error) {
+	ctx, cancel := context.WithTimeout(ctx, idleScrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	return parseIdle(string(body))
+}
+
+// parseIdle parses the idle workers returned by the endpoint of a pod
+func parseIdle(body string) (int32, error) {
+	idle, err := strconv.ParseInt(strings.TrimSpace(body), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle workers %q: %v", body, err)
+	}
+	if idle < 0 {
+		return 0, fmt.Errorf("invalid idle workers %d, must not be negative", idle)
+	}
+	return int32(idle), nil
+}
+
+// getIdleSourceURL returns the url of the idle endpoint of the pod
+func getIdleSourceURL(pod *corev1.Pod, idleSource *v1.IdleSource) string {
+	host := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(idleSource.Port)))
+	return "http://" + host + idleSource.GetPath()
+}
+
+// isScrapedForIdle tells if the pod serves the idle workers, the pods
+// which are not running or are being deleted are not idle
+func isScrapedForIdle(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil &&
+		pod.Status.Phase == corev1.PodRunning &&
+		pod.Status.PodIP != ""
+}
+
+// getIdlePods returns the number of idle pods using the idle workers
+// scraped from each pod. A pod is idle when all its workers are idle.
+// It fails if any of the pods cannot be scraped, as the idle pods of a
+// partial scrape would let a busy workload be scaled down.
+func getIdlePods(
+	ctx context.Context,
+	pods []*corev1.Pod,
+	idleSource *v1.IdleSource,
+	scrape func(ctx context.Context, url string) (int32, error)) (int32, error) {
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		idlePods int32
+		firstErr error
+	)
+	concurrency := idleSource.GetConcurrency()
+	sem := make(chan struct{}, idleScrapeConcurrency)
+	for _, pod := range pods {
+		if !isScrapedForIdle(pod) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(pod *corev1.Pod) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			idle, err := scrape(ctx, getIdleSourceURL(pod, idleSource))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("error scraping pod %s: %v", pod.Name, err)
+				}
+				return
+			}
+			if idle >= concurrency {
+				idlePods++
+			}
+		}(pod)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return idlePods, nil
+}
+
+// getScrapedIdleWorkers returns the idle workers scraped from the pods of
+// the workload when the WPA has an idleSource. The idle workers of the
+// queue are returned if the WPA has no idleSource or if any pod could
+// not be scraped.
+func (c *Controller) getScrapedIdleWorkers(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	targetKind string,
+	targetName string,
+	queueIdleWorkers int32) int32 {
+
+	idleSource := workerPodAutoScaler.Spec.IdleSource
+	if idleSource == nil {
+		return queueIdleWorkers
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "getScrapedIdleWorkers")
+	defer span.End()
+
+	namespace := workerPodAutoScaler.Namespace
+	selector, err := c.getTargetSelector(namespace, targetKind, targetName)
+	if err != nil {
+		klog.Warningf("%s: error getting the pods of %s %s, using the idle workers of the queue, %v",
+			key, targetKind, targetName, err)
+		idleSourceScrapes.WithLabelValues("error").Inc()
+		return queueIdleWorkers
+	}
+
+	var pods []*corev1.Pod
+	if c.podLister != nil {
+		pods, err = c.podLister.Pods(namespace).List(selector)
+	} else {
+		var podList *corev1.PodList
+		podList, err = c.kubeclientset.CoreV1().Pods(namespace).List(
+			ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err == nil {
+			for i := range podList.Items {
+				pods = append(pods, &podList.Items[i])
+			}
+		}
+	}
+	if err != nil {
+		klog.Warningf("%s: error listing the pods of %s %s, using the idle workers of the queue, %v",
+			key, targetKind, targetName, err)
+		idleSourceScrapes.WithLabelValues("error").Inc()
+		return queueIdleWorkers
+	}
+
+	idleWorkers, err := getIdlePods(ctx, pods, idleSource, c.scrapeIdle)
+	if err != nil {
+		klog.Warningf("%s: %v, using the idle workers of the queue", key, err)
+		idleSourceScrapes.WithLabelValues("error").Inc()
+		return queueIdleWorkers
+	}
+	idleSourceScrapes.WithLabelValues("success").Inc()
+	klog.V(3).Infof("%s: scraped idle workers: %d", key, idleWorkers)
+	return idleWorkers
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func runningPod(name string, podIP string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "testns"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: podIP,
+		},
+	}
+}
+
+func TestParseIdle(t *testing.T) {
+	tests := []struct {
+		body  string
+		idle  int32
+		valid bool
+	}{
+		{body: "3\n", idle: 3, valid: true},
+		{body: " 0 ", idle: 0, valid: true},
+		{body: "-1", valid: false},
+		{body: "idle", valid: false},
+	}
+
+	for _, test := range tests {
+		idle, err := parseIdle(test.body)
+		if test.valid && (err != nil || idle != test.idle) {
+			t.Errorf("%q: expected idle=%d, got=%d, err=%v", test.body, test.idle, idle, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%q: expected an error", test.body)
+		}
+	}
+}
+
+func TestScrapeIdle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/idle" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintln(w, "4")
+		}))
+	defer server.Close()
+
+	idle, err := scrapeIdle(context.Background(), server.URL+"/idle")
+	if err != nil || idle != 4 {
+		t.Errorf("expected idle=4, got=%d, err=%v", idle, err)
+	}
+	if _, err := scrapeIdle(context.Background(), server.URL+"/metrics"); err == nil {
+		t.Errorf("expected an error for a not found endpoint")
+	}
+}
+
+func TestGetIdlePods(t *testing.T) {
+	now := metav1.Now()
+	terminating := runningPod("otpsender-d", "10.0.0.4")
+	terminating.DeletionTimestamp = &now
+	pending := runningPod("otpsender-e", "")
+	pending.Status.Phase = corev1.PodPending
+	pods := []*corev1.Pod{
+		runningPod("otpsender-a", "10.0.0.1"),
+		runningPod("otpsender-b", "10.0.0.2"),
+		runningPod("otpsender-c", "10.0.0.3"),
+		terminating,
+		pending,
+	}
+	idle := map[string]int32{
+		"http://10.0.0.1:9090/idle": 4,
+		"http://10.0.0.2:9090/idle": 2,
+		"http://10.0.0.3:9090/idle": 4,
+	}
+	scrape := func(ctx context.Context, url string) (int32, error) {
+		if workers, ok := idle[url]; ok {
+			return workers, nil
+		}
+		return 0, fmt.Errorf("unexpected scrape of %s", url)
+	}
+
+	concurrency := int32(4)
+	idleSource := &v1.IdleSource{Port: 9090, Concurrency: &concurrency}
+	idlePods, err := getIdlePods(context.Background(), pods, idleSource, scrape)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if idlePods != 2 {
+		t.Errorf("expected 2 idle pods, got=%d", idlePods)
+	}
+
+	// a partial scrape is an error
+	delete(idle, "http://10.0.0.2:9090/idle")
+	if _, err := getIdlePods(context.Background(), pods, idleSource, scrape); err == nil {
+		t.Errorf("expected an error when a pod cannot be scraped")
+	}
+}
+
+func TestGetScrapedIdleWorkersWithoutIdleSource(t *testing.T) {
+	c := &Controller{}
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+	}
+	idleWorkers := c.getScrapedIdleWorkers(context.Background(), "testns/otpsender",
+		wpa, v1.TargetKindDeployment, "otpsender", 3)
+	if idleWorkers != 3 {
+		t.Errorf("expected the idle workers of the queue, got=%d", idleWorkers)
+	}
+}
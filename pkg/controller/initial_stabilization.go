@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// getFirstSeen returns the time the WPA was first seen, it is the
+// creation time of the WPA so that a restart of the controller does not
+// start the stabilization again. The time the controller first synced
+// the WPA is used when the creation time is not set.
+func (c *Controller) getFirstSeen(
+	key string, workerPodAutoScaler *v1.WorkerPodAutoScaler, now time.Time) time.Time {
+
+	if !workerPodAutoScaler.CreationTimestamp.IsZero() {
+		return workerPodAutoScaler.CreationTimestamp.Time
+	}
+	firstSeen, _ := c.firstSeen.LoadOrStore(key, now)
+	return firstSeen.(time.Time)
+}
+
+// getInitialStabilizationRemaining returns the time left in the initial
+// stabilization of the WPA, 0 when it is over or disabled
+func getInitialStabilizationRemaining(
+	firstSeen time.Time, stabilizationSeconds int32, now time.Time) time.Duration {
+
+	if stabilizationSeconds <= 0 {
+		return 0
+	}
+	remaining := firstSeen.Add(
+		time.Duration(stabilizationSeconds) * time.Second).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// checkInitialStabilization reports the initial stabilization of the WPA
+// in the InitialStabilization condition and logs the desired workers it
+// would scale to. It returns true while the stabilization is on, the
+// workload is not scaled then so that adopting a busy workload does not
+// resize it on the first reading of the backlog.
+func (c *Controller) checkInitialStabilization(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	desiredWorkers int32,
+	now time.Time) (*v1.WorkerPodAutoScaler, bool) {
+
+	remaining := getInitialStabilizationRemaining(
+		c.getFirstSeen(key, workerPodAutoScaler, now),
+		workerPodAutoScaler.GetInitialStabilizationSeconds(), now)
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionInitialStabilization)
+	if remaining == 0 {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionInitialStabilization,
+				Status:  metav1.ConditionFalse,
+				Reason:  "StabilizationComplete",
+				Message: "Initial stabilization is complete, scaling the workload",
+			},
+		), false
+	}
+
+	klog.V(2).Infof("%s: initial stabilization ends in %v, not scaling to %d desired workers",
+		key, remaining.Round(time.Second), desiredWorkers)
+	message := fmt.Sprintf("Initial stabilization is on till %s, not scaling",
+		now.Add(remaining).UTC().Format(time.RFC3339))
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionInitialStabilization,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Stabilizing",
+			Message: message,
+		},
+	), true
+}
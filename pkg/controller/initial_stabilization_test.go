@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+)
+
+func TestGetInitialStabilizationRemaining(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		firstSeen time.Time
+		seconds   int32
+		expected  time.Duration
+	}{
+		{"disabled", now, 0, 0},
+		{"just seen", now, 600, 10 * time.Minute},
+		{"seen before", now.Add(-4 * time.Minute), 600, 6 * time.Minute},
+		{"over", now.Add(-11 * time.Minute), 600, 0},
+	}
+	for _, test := range tests {
+		got := getInitialStabilizationRemaining(test.firstSeen, test.seconds, now)
+		if got != test.expected {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestCheckInitialStabilization(t *testing.T) {
+	now := time.Now()
+	stabilizationSeconds := int32(600)
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			InitialStabilizationSeconds: &stabilizationSeconds,
+		},
+	}
+	c := &Controller{
+		customclientset: fake.NewSimpleClientset(wpa),
+		firstSeen:       new(sync.Map),
+	}
+	ctx := context.Background()
+	key := "testns/otpsender"
+
+	// the wpa without a creation time is first seen at the first sync
+	updated, stabilizing := c.checkInitialStabilization(ctx, key, wpa, 10, now)
+	if !stabilizing {
+		t.Fatalf("expected the wpa to be stabilizing")
+	}
+	condition := meta.FindStatusCondition(
+		updated.Status.Conditions, v1.ConditionInitialStabilization)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("expected the condition to be true, got=%v", condition)
+	}
+
+	updated, stabilizing = c.checkInitialStabilization(
+		ctx, key, updated, 10, now.Add(11*time.Minute))
+	if stabilizing {
+		t.Errorf("expected the stabilization to be over")
+	}
+	condition = meta.FindStatusCondition(
+		updated.Status.Conditions, v1.ConditionInitialStabilization)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("expected the condition to be false, got=%v", condition)
+	}
+
+	// the creation time is used when it is set
+	created := wpa.DeepCopy()
+	created.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	if _, stabilizing := c.checkInitialStabilization(
+		ctx, "testns/created", created, 10, now); stabilizing {
+		t.Errorf("expected a wpa created an hour ago not to be stabilizing")
+	}
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// checkQueueURI reports a queueURI which does not belong to any of the
+// queue services or is not of the form of its queue service, using a
+// warning event and the InvalidQueueURI condition. Such a queue is not
+// polled, the WPA would otherwise only log it. The queue of the previous
+// queueURI of the WPA is stopped along with its metric series, so that
+// it is not polled and reported after the queueURI became invalid.
+// It returns true if the WPA should not be synced. Such WPAs may have
+// been created before the validation.
+func (c *Controller) checkQueueURI(
+	ctx context.Context,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) (*v1.WorkerPodAutoScaler, bool) {
+
+	err := queue.ValidateQueueURI(workerPodAutoScaler.Spec.QueueURI)
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionInvalidQueueURI)
+
+	if err == nil {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:   v1.ConditionInvalidQueueURI,
+				Status: metav1.ConditionFalse,
+				Reason: "ValidQueueURI",
+				Message: "queueURI belongs to the " +
+					queue.GetQueueServiceName(workerPodAutoScaler.Spec.QueueURI) + " queue service",
+			},
+		), false
+	}
+
+	message := err.Error() + ", not polling the queue"
+	klog.Warningf("%s/%s: %s", workerPodAutoScaler.Namespace,
+		workerPodAutoScaler.Name, message)
+	c.Queues.Delete(workerPodAutoScaler.Namespace, workerPodAutoScaler.Name)
+	c.deleteQueueMetrics(getKey(workerPodAutoScaler.Namespace, workerPodAutoScaler.Name))
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionInvalidQueueURI, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionInvalidQueueURI,
+			Status:  metav1.ConditionTrue,
+			Reason:  "InvalidQueueURI",
+			Message: message,
+		},
+	), true
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func TestCheckQueueURI(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	queues := queue.NewQueues()
+	go queues.Sync(stopCh)
+	c := &Controller{
+		customclientset: fake.NewSimpleClientset(wpa),
+		recorder:        recorder,
+		Queues:          queues,
+		metricSeries:    new(sync.Map),
+	}
+	ctx := context.Background()
+
+	// a valid queueURI does not add the condition
+	wpa.Spec.QueueURI = "https://sqs.ap-south-1.amazonaws.com/123456789012/otpsender"
+	wpa, invalid := c.checkQueueURI(ctx, wpa)
+	if invalid || meta.FindStatusCondition(
+		wpa.Status.Conditions, v1.ConditionInvalidQueueURI) != nil {
+		t.Errorf("expected no condition for a valid queueURI, got=%v", wpa.Status.Conditions)
+	}
+	err := queues.Add("testns", "wpa", wpa.Spec.QueueURI, 1, 0.0, false, nil, nil, "", nil)
+	if err != nil {
+		t.Fatalf("Error adding the queue: %v\n", err)
+	}
+	c.getMetricQueueName("testns/wpa", "wpa", "testns", "otpsender")
+
+	tests := []struct {
+		name            string
+		uri             string
+		expectedInvalid bool
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+	}{
+		{"unsupported", "ftp://queue.example.com/otpsender", true,
+			metav1.ConditionTrue, "InvalidQueueURI"},
+		{"still unsupported", "ftp://queue.example.com/otpsender", true,
+			metav1.ConditionTrue, "InvalidQueueURI"},
+		{"fixed", "https://sqs.ap-south-1.amazonaws.com/123456789012/otpsender", false,
+			metav1.ConditionFalse, "ValidQueueURI"},
+	}
+	for _, test := range tests {
+		wpa.Spec.QueueURI = test.uri
+		wpa, invalid = c.checkQueueURI(ctx, wpa)
+		if invalid != test.expectedInvalid {
+			t.Errorf("%s: invalid=%v, expected=%v", test.name, invalid, test.expectedInvalid)
+		}
+		if invalid {
+			// the queue of the previous queueURI is not polled anymore
+			if queueName, _, _, _ := queues.GetQueueInfo("testns", "wpa"); queueName != "" {
+				t.Errorf("%s: expected the previous queue to be deleted, got=%q",
+					test.name, queueName)
+			}
+			if _, ok := c.metricSeries.Load("testns/wpa"); ok {
+				t.Errorf("%s: expected the queue series to be deleted", test.name)
+			}
+		}
+		condition := meta.FindStatusCondition(
+			wpa.Status.Conditions, v1.ConditionInvalidQueueURI)
+		if condition == nil || condition.Status != test.expectedStatus ||
+			condition.Reason != test.expectedReason {
+			t.Errorf("%s: expected the condition %s %s, got=%v",
+				test.name, test.expectedStatus, test.expectedReason, condition)
+		}
+	}
+
+	// the warning event is recorded once when the queueURI becomes invalid
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected 1 warning event, got=%d", len(recorder.Events))
+	}
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// checkTargetMessagesPerWorker reports a targetMessagesPerWorker which is
+// not set or is not greater than 0 using a warning event and the
+// InvalidTargetMessagesPerWorker condition. It returns true if the WPA
+// should not scale the workload, as the backlog can not be divided by
+// the target. Such WPAs may have been created before the validation.
+func (c *Controller) checkTargetMessagesPerWorker(
+	ctx context.Context,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) (*v1.WorkerPodAutoScaler, bool) {
+
+	target := workerPodAutoScaler.Spec.TargetMessagesPerWorker
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionInvalidTargetMessagesPerWorker)
+
+	if target != nil && *target > 0 {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionInvalidTargetMessagesPerWorker,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ValidTarget",
+				Message: "targetMessagesPerWorker is greater than 0",
+			},
+		), false
+	}
+
+	reason := "TargetNotSet"
+	message := "targetMessagesPerWorker is not set, not scaling"
+	if target != nil {
+		reason = "TargetNotPositive"
+		message = fmt.Sprintf(
+			"targetMessagesPerWorker %d is not greater than 0, not scaling", *target)
+	}
+	klog.Warningf("%s/%s: %s", workerPodAutoScaler.Namespace,
+		workerPodAutoScaler.Name, message)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionInvalidTargetMessagesPerWorker, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionInvalidTargetMessagesPerWorker,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		},
+	), true
+}
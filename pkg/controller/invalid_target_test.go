@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+)
+
+func TestCheckTargetMessagesPerWorker(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{
+		customclientset: fake.NewSimpleClientset(wpa),
+		recorder:        recorder,
+	}
+	ctx := context.Background()
+
+	zero := int32(0)
+	ten := int32(10)
+	tests := []struct {
+		name            string
+		target          *int32
+		expectedInvalid bool
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+	}{
+		{"not set", nil, true, metav1.ConditionTrue, "TargetNotSet"},
+		{"zero", &zero, true, metav1.ConditionTrue, "TargetNotPositive"},
+		{"fixed", &ten, false, metav1.ConditionFalse, "ValidTarget"},
+	}
+	for _, test := range tests {
+		wpa.Spec.TargetMessagesPerWorker = test.target
+		var invalid bool
+		wpa, invalid = c.checkTargetMessagesPerWorker(ctx, wpa)
+		if invalid != test.expectedInvalid {
+			t.Errorf("%s: invalid=%v, expected=%v", test.name, invalid, test.expectedInvalid)
+		}
+		condition := meta.FindStatusCondition(
+			wpa.Status.Conditions, v1.ConditionInvalidTargetMessagesPerWorker)
+		if condition == nil || condition.Status != test.expectedStatus ||
+			condition.Reason != test.expectedReason {
+			t.Errorf("%s: expected the condition %s %s, got=%v",
+				test.name, test.expectedStatus, test.expectedReason, condition)
+		}
+	}
+
+	// the warning event is recorded once when the target becomes invalid
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected 1 warning event, got=%d", len(recorder.Events))
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import "sync"
+
+// keyLocks serializes the syncs of a WPA. The workqueue dedups the
+// events only when they are equal, the add, update and delete events
+// of a WPA are different items and can be handed to different workers,
+// which would then update the workload of the WPA concurrently.
+// The zero value is ready to use.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is the lock of a key and the number of its holders and
+// waiters, it is dropped when nobody uses it
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until the key is not locked by another worker, the
+// returned func unlocks it
+func (k *keyLocks) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
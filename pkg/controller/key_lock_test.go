@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestKeyLocks(t *testing.T) {
+	var locks keyLocks
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.lock("testns/otpsender")
+			defer unlock()
+			n := atomic.AddInt32(&running, 1)
+			if n > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, n)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+	if maxRunning != 1 {
+		t.Errorf("expected the key to be synced by one worker at once, got=%d", maxRunning)
+	}
+	if len(locks.locks) != 0 {
+		t.Errorf("expected the unused locks to be dropped, got=%d", len(locks.locks))
+	}
+
+	// other keys are not blocked
+	unlock := locks.lock("testns/otpsender")
+	done := make(chan struct{})
+	go func() {
+		locks.lock("testns/smssender")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("expected another key to be locked while the key is locked")
+	}
+	unlock()
+}
+
+func TestProcessEventsOfOneKeyConcurrently(t *testing.T) {
+	c, stop := newDeleteTestController(t)
+	defer stop()
+	c.workqueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer c.workqueue.ShutDown()
+
+	events := []string{
+		WokerPodAutoScalerEventAdd,
+		WokerPodAutoScalerEventUpdate,
+		WokerPodAutoScalerEventDelete,
+	}
+	for _, name := range events {
+		c.workqueue.Add(WokerPodAutoScalerEvent{key: "testns/otpsender", name: name})
+	}
+
+	var wg sync.WaitGroup
+	for range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.processNextWorkItem(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if queueName, _, _, _ := c.Queues.GetQueueInfo("testns", "otpsender"); queueName != "" {
+		t.Errorf("expected the queue to be deleted, got=%v\n", queueName)
+	}
+	if c.workqueue.Len() != 0 {
+		t.Errorf("expected no event to be requeued, got=%d", c.workqueue.Len())
+	}
+}
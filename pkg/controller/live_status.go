@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	queue "github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/schedule"
+)
+
+// ErrQueueNotSynced is returned by GetLiveStatus when the queue of
+// the WPA is not polled yet, the desired workers are not known then
+var ErrQueueNotSynced = fmt.Errorf("queue is not synced yet")
+
+// LiveStatus is the scaling state of a WPA computed from the last poll
+// of its queue and the listers, unlike the WPA status it does not wait
+// for the next reconcile to be updated
+type LiveStatus struct {
+	Name                     string  `json:"name"`
+	Namespace                string  `json:"namespace"`
+	QueueName                string  `json:"queueName"`
+	TargetKind               string  `json:"targetKind"`
+	TargetName               string  `json:"targetName"`
+	QueueMessages            int32   `json:"queueMessages"`
+	MessagesSentPerMinute    float64 `json:"messagesSentPerMinute"`
+	IdleWorkers              int32   `json:"idleWorkers"`
+	CurrentReplicas          int32   `json:"currentReplicas"`
+	AvailableReplicas        int32   `json:"availableReplicas"`
+	DesiredReplicas          int32   `json:"desiredReplicas"`
+	UnclampedDesiredReplicas int32   `json:"unclampedDesiredReplicas"`
+	Reason                   string  `json:"reason"`
+	Panicking                bool    `json:"panicking"`
+	// ScaleOperation is the operation the next reconcile would do:
+	// scale-up, scale-down or no scaling operation
+	ScaleOperation string `json:"scaleOperation"`
+	// ScaleEligible tells if the workers can be scaled now, i.e.
+	// they are not in the cooldown after the last scale and the
+	// scaling is not disabled after failures
+	ScaleEligible                bool         `json:"scaleEligible"`
+	NextScaleEligibleTime        *metav1.Time `json:"nextScaleEligibleTime,omitempty"`
+	ScalingDisabledAfterFailures bool         `json:"scalingDisabledAfterFailures"`
+	// ManualScaleUntil is the time till which the scaling is paused
+	// after a manual scale
+	ManualScaleUntil *metav1.Time `json:"manualScaleUntil,omitempty"`
+}
+
+// GetLiveStatus computes the desired workers of the WPA from the last
+// poll of its queue as the reconcile would, without updating the WPA
+// or the workload. The conditions which need the history of the WPA,
+// i.e. the panic window and the ScalingStuck and WorkersCrashLooping
+// conditions, are read from the state of the last reconcile.
+func (c *Controller) GetLiveStatus(
+	namespace string, name string) (*LiveStatus, error) {
+
+	now := time.Now()
+	key := getKey(namespace, name)
+	workerPodAutoScaler, err := c.workerPodAutoScalersLister.WorkerPodAutoScalers(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := c.getScalingDefaults()
+
+	targetKind, targetName, err := c.resolveTarget(workerPodAutoScaler)
+	if err != nil {
+		return nil, err
+	}
+	currentWorkers, availableWorkers, err := c.getTargetReplicas(
+		namespace, targetKind, targetName)
+	if err != nil {
+		return nil, err
+	}
+
+	queueName, queueMessages, messagesSentPerMinute, idleWorkers := c.Queues.GetQueueInfo(
+		namespace, name)
+	if queueName == "" || queueMessages == queue.UnsyncedQueueMessageCount {
+		return nil, ErrQueueNotSynced
+	}
+	messagesSentPerMinute = c.getMessagesSentPerMinute(
+		workerPodAutoScaler, messagesSentPerMinute)
+	balance, _ := c.Queues.GetBacklogBalance(namespace, name)
+
+	var secondsToProcessOneJob float64
+	if workerPodAutoScaler.Spec.SecondsToProcessOneJob != nil {
+		secondsToProcessOneJob = *workerPodAutoScaler.Spec.SecondsToProcessOneJob
+	}
+	if workerPodAutoScaler.GetAutoEstimateProcessingTime() {
+		if estimate := c.Queues.GetSecondsToProcessOneJobEstimate(namespace, name); estimate > 0 {
+			secondsToProcessOneJob = estimate
+		}
+	}
+
+	panicking := c.isPanickingAt(key, now) ||
+		isAbovePanicThreshold(workerPodAutoScaler, queueMessages, currentWorkers)
+	_, minWorkers, maxWorkers, _ := getActiveBounds(workerPodAutoScaler, now)
+	conservativeWindow, _ := schedule.ActiveWindow(
+		workerPodAutoScaler.Spec.ConservativeWindows, now)
+	// a target which is not set is not greater than 0,
+	// the reason is InvalidTarget then
+	var targetMessagesPerWorker int32
+	if workerPodAutoScaler.Spec.TargetMessagesPerWorker != nil {
+		targetMessagesPerWorker = *workerPodAutoScaler.Spec.TargetMessagesPerWorker
+	}
+	if target, _, active, err := getTargetOverride(workerPodAutoScaler, now); err == nil && active {
+		targetMessagesPerWorker = target
+	}
+	terminatingWorkers := c.getTerminatingWorkers(key, namespace, targetKind, targetName)
+
+	result := ComputeDesired(ScalingInput{
+		QueueName:                 queueName,
+		QueueMessages:             queueMessages,
+		MessagesSentPerMinute:     messagesSentPerMinute,
+		SecondsToProcessOneJob:    secondsToProcessOneJob,
+		TargetMessagesPerWorker:   targetMessagesPerWorker,
+		CurrentWorkers:            currentWorkers + terminatingWorkers,
+		IdleWorkers:               idleWorkers,
+		MinWorkers:                minWorkers,
+		MaxWorkers:                maxWorkers,
+		MaxDisruption:             *defaults.getMaxDisruption(workerPodAutoScaler),
+		DisableVelocityMinWorkers: workerPodAutoScaler.GetDisableVelocityMinWorkers(),
+		RespectDisruptionOnIdle:   workerPodAutoScaler.GetRespectDisruptionOnIdle(),
+		Panicking:                 panicking,
+		WarmFloor:                 workerPodAutoScaler.GetWarmFloor(),
+		ThroughputMode:            workerPodAutoScaler.GetThroughputMode(),
+		BalanceAwareScaling:       workerPodAutoScaler.GetBalanceAwareScaling(),
+		BacklogBalance:            balance,
+		ColdStartReplicas:         workerPodAutoScaler.GetColdStartReplicas(),
+		RoundingStrategy:          workerPodAutoScaler.GetRoundingStrategy(),
+		NoBacklogStrategy:         workerPodAutoScaler.GetNoBacklogStrategy(),
+		Tolerance:                 getConservativeTolerance(conservativeWindow, defaults.tolerance),
+	})
+	desiredWorkers, scaleReason := holdTerminatingWorkers(
+		result.DesiredWorkers, currentWorkers, terminatingWorkers, result.Reason)
+	desiredWorkers, scaleReason = capByMessageGroups(
+		desiredWorkers,
+		c.Queues.GetMessageGroups(namespace, name),
+		minWorkers,
+		result.Reason,
+	)
+	if workerPodAutoScaler.Spec.ScalingGroup != nil {
+		budget, totalDemand, _, err := c.getScalingGroupDemand(
+			key, workerPodAutoScaler, desiredWorkers)
+		if err != nil {
+			return nil, err
+		}
+		share := getScalingGroupShare(desiredWorkers, totalDemand, budget,
+			*workerPodAutoScaler.Spec.MinReplicas)
+		if share < desiredWorkers {
+			desiredWorkers, scaleReason = share, ScaleReasonScalingGroup
+		}
+	}
+	rollingOut, err := c.isRollingOut(namespace, targetKind, targetName)
+	if err != nil {
+		return nil, err
+	}
+	if rollingOut && desiredWorkers < currentWorkers {
+		desiredWorkers, scaleReason = currentWorkers, ScaleReasonRolloutInProgress
+	}
+	desiredWorkers, scaleReason = holdConservativeScaleDown(
+		conservativeWindow, desiredWorkers, currentWorkers, scaleReason)
+	if desiredWorkers > currentWorkers && meta.IsStatusConditionTrue(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionScalingStuck) {
+		desiredWorkers, scaleReason = currentWorkers, ScaleReasonScalingStuck
+	}
+	if desiredWorkers > currentWorkers && meta.IsStatusConditionTrue(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionWorkersCrashLooping) {
+		desiredWorkers, scaleReason = currentWorkers, ScaleReasonWorkersCrashLooping
+	}
+
+	lastScaleTime := workerPodAutoScaler.Status.LastScaleTime
+	scaleUpDelay := defaults.getScaleUpDelay(workerPodAutoScaler)
+	if panicking {
+		scaleUpDelay = 0
+	}
+	scaleDownDelay := defaults.getScaleDownDelay(workerPodAutoScaler)
+	breakerOpen := c.getBreakerState(key, now) == BreakerOpen
+	op := GetScaleOperation(
+		queueName,
+		desiredWorkers,
+		currentWorkers,
+		lastScaleTime,
+		scaleUpDelay,
+		scaleDownDelay,
+		c.isScaleDownBlocked(workerPodAutoScaler),
+	)
+	manualScaleUntil, paused := c.getManualScaleUntil(key, now)
+	if breakerOpen || paused {
+		op = ScaleNoop
+	}
+	var manualScaleUntilTime *metav1.Time
+	if paused {
+		manualScaleUntilTime = &metav1.Time{Time: manualScaleUntil}
+	}
+	nextScaleEligibleTime := getNextScaleEligibleTime(
+		lastScaleTime, scaleUpDelay, scaleDownDelay, now)
+
+	return &LiveStatus{
+		Name:                         name,
+		Namespace:                    namespace,
+		QueueName:                    queueName,
+		TargetKind:                   targetKind,
+		TargetName:                   targetName,
+		QueueMessages:                queueMessages,
+		MessagesSentPerMinute:        messagesSentPerMinute,
+		IdleWorkers:                  idleWorkers,
+		CurrentReplicas:              currentWorkers,
+		AvailableReplicas:            availableWorkers,
+		DesiredReplicas:              desiredWorkers,
+		UnclampedDesiredReplicas:     result.UnclampedDesiredWorkers,
+		Reason:                       string(scaleReason),
+		Panicking:                    panicking,
+		ScaleOperation:               scaleOpString(op),
+		ScaleEligible:                !breakerOpen && !paused && nextScaleEligibleTime == nil,
+		NextScaleEligibleTime:        nextScaleEligibleTime,
+		ScalingDisabledAfterFailures: breakerOpen,
+		ManualScaleUntil:             manualScaleUntilTime,
+	}, nil
+}
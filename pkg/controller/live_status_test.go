@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	listers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/listers/workerpodautoscaler/v1"
+)
+
+func TestGetLiveStatus(t *testing.T) {
+	c, stop := newDeleteTestController(t)
+	defer stop()
+
+	replicas := int32(2)
+	c.deploymentLister = newTargetTestController(t,
+		&appsv1.Deployment{
+			ObjectMeta: objectMeta("otpsender", "otpsender"),
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+	).deploymentLister
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	err := indexer.Add(&v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec:       v1.WorkerPodAutoScalerSpec{DeploymentName: "otpsender"},
+	})
+	if err != nil {
+		t.Fatalf("Error adding the wpa: %v\n", err)
+	}
+	c.workerPodAutoScalersLister = listers.NewWorkerPodAutoScalerLister(indexer)
+
+	if _, err := c.GetLiveStatus("testns", "missing"); !errors.IsNotFound(err) {
+		t.Errorf("expected not found for a missing wpa, got=%v\n", err)
+	}
+	// the queue is added but not polled yet
+	if _, err := c.GetLiveStatus("testns", "otpsender"); err != ErrQueueNotSynced {
+		t.Errorf("expected=%v, got=%v\n", ErrQueueNotSynced, err)
+	}
+}
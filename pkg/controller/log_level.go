@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/practo/klog/v2"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// LogLevelAnnotation raises the log verbosity of the reconcile of the
+// WPA over the -v of the controller, to debug one WPA without the logs
+// of every other WPA
+const LogLevelAnnotation = "workerpodautoscaler.practo.com/log-level"
+
+// getLogLevel returns the log level of the WPA, it is 0 if the WPA has
+// no log level. An error is returned if the annotation is not valid.
+func getLogLevel(workerPodAutoScaler *v1.WorkerPodAutoScaler) (klog.Level, error) {
+	value, ok := workerPodAutoScaler.Annotations[LogLevelAnnotation]
+	if !ok || value == "" {
+		return 0, nil
+	}
+	level, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || level < 0 {
+		return 0, fmt.Errorf("%s=%q is not a non negative integer",
+			LogLevelAnnotation, value)
+	}
+	return klog.Level(level), nil
+}
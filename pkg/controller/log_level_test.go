@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/practo/klog/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestGetLogLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    klog.Level
+		err         bool
+	}{
+		{"not set", nil, 0, false},
+		{"empty", map[string]string{LogLevelAnnotation: ""}, 0, false},
+		{"set", map[string]string{LogLevelAnnotation: "4"}, 4, false},
+		{"negative", map[string]string{LogLevelAnnotation: "-1"}, 0, true},
+		{"not a number", map[string]string{LogLevelAnnotation: "debug"}, 0, true},
+	}
+	for _, test := range tests {
+		wpa := &v1.WorkerPodAutoScaler{
+			ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations},
+		}
+		level, err := getLogLevel(wpa)
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error=%v, got=%v", test.name, test.err, err)
+		}
+		if level != test.expected {
+			t.Errorf("%s: expected level=%d, got=%d", test.name, test.expected, level)
+		}
+	}
+}
+
+func TestLogV(t *testing.T) {
+	// the tests run with the default -v of 0
+	tests := []struct {
+		name     string
+		level    klog.Level
+		logLevel klog.Level
+		expected bool
+	}{
+		{"within -v", 0, 0, true},
+		{"above -v", 4, 0, false},
+		{"within the log level of the wpa", 4, 4, true},
+		{"above the log level of the wpa", 4, 3, false},
+	}
+	for _, test := range tests {
+		if got := logV(test.level, test.logLevel).Enabled(); got != test.expected {
+			t.Errorf("%s: expected enabled=%v, got=%v", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/practo/klog/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+// ManagedByLabel is set on the workload scaled by the WPA,
+// its value is the name of the WPA
+const ManagedByLabel = "workerpodautoscaler.practo.com/managed-by"
+
+// labelTarget sets the managed by label on the workload if it is not
+// set to the name of the WPA. Only the label owned by WPA is patched so
+// that the labels set by others are left alone.
+func (c *Controller) labelTarget(
+	ctx context.Context,
+	namespace string,
+	targetKind string,
+	targetName string,
+	wpaName string) error {
+
+	if _, ok := parseScaleTargetKind(targetKind); ok {
+		klog.V(4).Infof("%s/%s: %s is scaled using the scale subresource, not labelling it",
+			namespace, targetName, getTargetKindName(targetKind))
+		return nil
+	}
+	targetLabels, err := c.getTargetLabels(namespace, targetKind, targetName)
+	if err != nil {
+		return err
+	}
+	patch, ok, err := getManagedByLabelPatch(targetLabels, wpaName)
+	if err != nil || !ok {
+		return err
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "labelTarget")
+	defer span.End()
+
+	switch targetKind {
+	case v1.TargetKindDeployment:
+		_, err = c.kubeclientset.AppsV1().Deployments(namespace).Patch(
+			ctx, targetName, types.MergePatchType, patch, metav1.PatchOptions{})
+	case v1.TargetKindReplicaSet:
+		_, err = c.kubeclientset.AppsV1().ReplicaSets(namespace).Patch(
+			ctx, targetName, types.MergePatchType, patch, metav1.PatchOptions{})
+	case v1.TargetKindStatefulSet:
+		_, err = c.kubeclientset.AppsV1().StatefulSets(namespace).Patch(
+			ctx, targetName, types.MergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error labelling %s %s: %v", targetKind, targetName, err)
+	}
+	klog.V(2).Infof("%s/%s: labelled %s %s=%s",
+		namespace, targetName, targetKind, ManagedByLabel, wpaName)
+	return nil
+}
+
+// getTargetLabels returns the labels of the workload
+func (c *Controller) getTargetLabels(
+	namespace string, kind string, name string) (map[string]string, error) {
+
+	switch kind {
+	case v1.TargetKindDeployment:
+		deployment, err := c.deploymentLister.Deployments(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return deployment.Labels, nil
+	case v1.TargetKindReplicaSet:
+		replicaSet, err := c.replicaSetLister.ReplicaSets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return replicaSet.Labels, nil
+	case v1.TargetKindStatefulSet:
+		statefulSet, err := c.statefulSetLister.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return statefulSet.Labels, nil
+	}
+	return nil, fmt.Errorf("unsupported target kind %q", kind)
+}
+
+// getManagedByLabelPatch returns the merge patch to set the managed by
+// label, it returns false if the label is up to date. The WPA name can
+// be longer than a label value allows, it is an error then.
+func getManagedByLabelPatch(
+	targetLabels map[string]string, wpaName string) ([]byte, bool, error) {
+
+	if targetLabels[ManagedByLabel] == wpaName {
+		return nil, false, nil
+	}
+	if errs := validation.IsValidLabelValue(wpaName); len(errs) > 0 {
+		return nil, false, fmt.Errorf("wpa name %q is not a valid label value: %s",
+			wpaName, strings.Join(errs, ", "))
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				ManagedByLabel: wpaName,
+			},
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return patch, true, nil
+}
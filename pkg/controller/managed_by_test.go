@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetManagedByLabelPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wpaName string
+		patch   string
+		ok      bool
+		err     bool
+	}{
+		{
+			name:    "no labels",
+			labels:  nil,
+			wpaName: "otpsender",
+			patch:   `{"metadata":{"labels":{"workerpodautoscaler.practo.com/managed-by":"otpsender"}}}`,
+			ok:      true,
+		},
+		{
+			name:    "labelled by another wpa",
+			labels:  map[string]string{"app": "otpsender", ManagedByLabel: "mailer"},
+			wpaName: "otpsender",
+			patch:   `{"metadata":{"labels":{"workerpodautoscaler.practo.com/managed-by":"otpsender"}}}`,
+			ok:      true,
+		},
+		{
+			name:    "up to date",
+			labels:  map[string]string{ManagedByLabel: "otpsender"},
+			wpaName: "otpsender",
+		},
+		{
+			name:    "name too long for a label",
+			wpaName: strings.Repeat("a", 64),
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		patch, ok, err := getManagedByLabelPatch(test.labels, test.wpaName)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error\n", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got=%v\n", test.name, err)
+			continue
+		}
+		if ok != test.ok {
+			t.Errorf("%s: expected ok=%v, got=%v\n", test.name, test.ok, ok)
+		}
+		if string(patch) != test.patch {
+			t.Errorf("%s: expected patch=%s, got=%s\n", test.name, test.patch, patch)
+		}
+	}
+}
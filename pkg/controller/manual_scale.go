@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ManualScale scales the workload of the WPA to the replicas and pauses
+// the automatic scaling of the WPA for the ttl, the WPA is synced again
+// when the pause ends. It is meant to test the downstream behaviour of a
+// replica count in the lower environments without disabling the WPA.
+func (c *Controller) ManualScale(
+	ctx context.Context,
+	namespace string,
+	name string,
+	replicas int32,
+	ttl time.Duration) error {
+
+	if replicas < 0 {
+		return fmt.Errorf("replicas should not be less than 0, got %d", replicas)
+	}
+	workerPodAutoScaler, err := c.workerPodAutoScalersLister.WorkerPodAutoScalers(namespace).Get(name)
+	if err != nil {
+		return err
+	}
+	if len(workerPodAutoScaler.Spec.WeightedTargets) > 0 {
+		return fmt.Errorf("manual scale of the weightedTargets is not supported")
+	}
+	targetKind, targetName, err := c.resolveTarget(workerPodAutoScaler)
+	if err != nil {
+		return err
+	}
+
+	// the sync of the WPA waits for the manual scale, the pause starts
+	// before the update so that the next sync does not scale it back
+	key := getKey(namespace, name)
+	unlock := c.syncLocks.lock(key)
+	defer unlock()
+	until := time.Now().Add(ttl)
+	c.manualScales.Store(key, until)
+	err = c.updateTarget(ctx, namespace, targetKind, targetName, &replicas)
+	if err != nil {
+		c.manualScales.Delete(key)
+		return fmt.Errorf("error scaling %s %s manually: %v", targetKind, targetName, err)
+	}
+
+	klog.Infof("%s: scaled %s %s to %d manually, the scaling is paused till %v",
+		key, targetKind, targetName, replicas, until)
+	c.recorder.Eventf(workerPodAutoScaler, corev1.EventTypeNormal, "ScaledManually",
+		"Scaled %s %s to %d manually, the scaling is paused for %v",
+		targetKind, targetName, replicas, ttl)
+	c.workqueue.AddAfter(WokerPodAutoScalerEvent{
+		key:  key,
+		name: WokerPodAutoScalerEventUpdate,
+	}, ttl)
+	return nil
+}
+
+// getManualScaleUntil returns the time till which the automatic scaling
+// of the WPA is paused after a manual scale, it is false if not paused
+func (c *Controller) getManualScaleUntil(key string, now time.Time) (time.Time, bool) {
+	value, ok := c.manualScales.Load(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	until := value.(time.Time)
+	if !now.Before(until) {
+		c.manualScales.Delete(key)
+		return time.Time{}, false
+	}
+	return until, true
+}
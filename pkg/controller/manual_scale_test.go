@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	scalefake "k8s.io/client-go/scale/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	listers "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/listers/workerpodautoscaler/v1"
+)
+
+func TestManualScale(t *testing.T) {
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(schema.GroupVersionKind{
+		Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}, meta.RESTScopeNamespace)
+
+	scales := &scalefake.FakeScaleClient{}
+	var updated *autoscalingv1.Scale
+	scales.AddReactor("get", "rollouts", func(
+		action k8stesting.Action) (bool, runtime.Object, error) {
+
+		return true, &autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: 4},
+		}, nil
+	})
+	scales.AddReactor("update", "rollouts", func(
+		action k8stesting.Action) (bool, runtime.Object, error) {
+
+		updated = action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		return true, updated, nil
+	})
+
+	wpas := newIndexer()
+	err := wpas.Add(&v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			ScaleTargetRef: &v1.ScaleTargetRef{
+				APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "otpsender"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error adding the wpa to the indexer: %v\n", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	c := newTargetTestController(t)
+	c.workerPodAutoScalersLister = listers.NewWorkerPodAutoScalerLister(wpas)
+	c.scaleNamespacer = scales
+	c.restMapper = restMapper
+	c.updateRetry = retry.DefaultRetry
+	c.recorder = recorder
+	c.workqueue = queue
+	c.manualScales = new(sync.Map)
+	ctx := context.Background()
+	key := "testns/otpsender"
+
+	if _, paused := c.getManualScaleUntil(key, time.Now()); paused {
+		t.Errorf("expected the scaling not to be paused before a manual scale")
+	}
+	if err := c.ManualScale(ctx, "testns", "otpsender", -1, time.Minute); err == nil {
+		t.Errorf("expected an error for negative replicas")
+	}
+	if err := c.ManualScale(ctx, "testns", "mailer", 2, time.Minute); err == nil {
+		t.Errorf("expected an error for a missing wpa")
+	}
+
+	if err := c.ManualScale(ctx, "testns", "otpsender", 2, time.Minute); err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if updated == nil || updated.Spec.Replicas != 2 {
+		t.Fatalf("expected the scale to be updated to 2 replicas, got=%v", updated)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected 1 event, got=%d", len(recorder.Events))
+	}
+
+	// the scaling is paused for the ttl and resumes after it
+	now := time.Now()
+	until, paused := c.getManualScaleUntil(key, now)
+	if !paused || until.Sub(now) > time.Minute {
+		t.Errorf("expected the scaling to be paused for a minute, got=%v, %v", until, paused)
+	}
+	if _, paused := c.getManualScaleUntil(key, now.Add(2*time.Minute)); paused {
+		t.Errorf("expected the scaling to resume after the ttl")
+	}
+	if _, ok := c.manualScales.Load(key); ok {
+		t.Errorf("expected the ended pause to be forgotten")
+	}
+	// the manual scale waits for the sync of the WPA in progress
+	unlock := c.syncLocks.lock(key)
+	done := make(chan error)
+	go func() {
+		done <- c.ManualScale(ctx, "testns", "otpsender", 3, time.Minute)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expected the manual scale to wait for the sync")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if updated.Spec.Replicas != 2 {
+		t.Errorf("expected the scale not to be updated during the sync, got=%d", updated.Spec.Replicas)
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if updated.Spec.Replicas != 3 {
+		t.Errorf("expected the scale to be updated to 3 replicas, got=%d", updated.Spec.Replicas)
+	}
+}
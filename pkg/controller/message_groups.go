@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// capByMessageGroups caps the desired workers of the FIFO queues at the
+// message groups which can be processed at once, as the workers above it
+// would not get any message. The min workers are kept. The messageGroups
+// is queue.UnsyncedMessageGroups for the standard queues.
+func capByMessageGroups(
+	desiredWorkers int32,
+	messageGroups int32,
+	minWorkers int32,
+	reason ScaleReason) (int32, ScaleReason) {
+
+	if messageGroups == queue.UnsyncedMessageGroups {
+		return desiredWorkers, reason
+	}
+
+	groupsCap := messageGroups
+	if groupsCap < minWorkers {
+		groupsCap = minWorkers
+	}
+	if desiredWorkers > groupsCap {
+		return groupsCap, ScaleReasonMessageGroups
+	}
+	return desiredWorkers, reason
+}
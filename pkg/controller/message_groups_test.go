@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func TestCapByMessageGroups(t *testing.T) {
+	tests := []struct {
+		name           string
+		desired        int32
+		messageGroups  int32
+		minWorkers     int32
+		expected       int32
+		expectedReason ScaleReason
+	}{
+		{"standard queue", 20, queue.UnsyncedMessageGroups, 0, 20, ScaleReasonBacklog},
+		{"fifo below groups", 3, 5, 0, 3, ScaleReasonBacklog},
+		{"fifo capped by groups", 20, 5, 0, 5, ScaleReasonMessageGroups},
+		{"fifo cap keeps min", 20, 1, 2, 2, ScaleReasonMessageGroups},
+		{"fifo no groups", 4, 0, 0, 0, ScaleReasonMessageGroups},
+	}
+
+	for _, test := range tests {
+		desired, reason := capByMessageGroups(
+			test.desired, test.messageGroups, test.minWorkers, ScaleReasonBacklog)
+		if desired != test.expected || reason != test.expectedReason {
+			t.Errorf("%s: desired=%v, reason=%v, expected=%v, expectedReason=%v\n",
+				test.name, desired, reason, test.expected, test.expectedReason)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/practo/klog/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+// setTargetOwnerReference makes the workload scaled by the WPA an owner
+// of the WPA, so that the WPA is garbage collected when its workload is
+// deleted. The workload is not the controller of the WPA and does not
+// block its own deletion. The owner references of the other workloads
+// are dropped as the WPA scales only one, e.g. after its target changed.
+// The scale targets are not owners, their uid is not known.
+func (c *Controller) setTargetOwnerReference(
+	ctx context.Context,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	targetKind string,
+	targetName string) (*v1.WorkerPodAutoScaler, error) {
+
+	if isAnnotationDriven(workerPodAutoScaler) {
+		return workerPodAutoScaler, nil
+	}
+	if _, ok := parseScaleTargetKind(targetKind); ok {
+		klog.V(4).Infof("%s/%s: %s is scaled using the scale subresource, not owning the wpa",
+			workerPodAutoScaler.Namespace, targetName, getTargetKindName(targetKind))
+		return workerPodAutoScaler, nil
+	}
+	uid, err := c.getTargetUID(workerPodAutoScaler.Namespace, targetKind, targetName)
+	if err != nil {
+		return workerPodAutoScaler, err
+	}
+	ownerReferences, ok := getTargetOwnerReferences(
+		workerPodAutoScaler.OwnerReferences, targetKind, targetName, uid)
+	if !ok {
+		return workerPodAutoScaler, nil
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "setTargetOwnerReference")
+	defer span.End()
+
+	workerPodAutoScalerCopy := workerPodAutoScaler.DeepCopy()
+	workerPodAutoScalerCopy.OwnerReferences = ownerReferences
+	updated, err := c.customclientset.K8sV1().WorkerPodAutoScalers(
+		workerPodAutoScaler.Namespace).Update(ctx, workerPodAutoScalerCopy, metav1.UpdateOptions{})
+	if err != nil {
+		return workerPodAutoScaler, fmt.Errorf("error setting the owner reference to %s %s: %v",
+			targetKind, targetName, err)
+	}
+	klog.V(2).Infof("%s/%s: owned by %s %s",
+		workerPodAutoScaler.Namespace, workerPodAutoScaler.Name, targetKind, targetName)
+	return updated, nil
+}
+
+// getTargetUID returns the uid of the workload
+func (c *Controller) getTargetUID(
+	namespace string, kind string, name string) (types.UID, error) {
+
+	switch kind {
+	case v1.TargetKindDeployment:
+		deployment, err := c.deploymentLister.Deployments(namespace).Get(name)
+		if err != nil {
+			return "", err
+		}
+		return deployment.UID, nil
+	case v1.TargetKindReplicaSet:
+		replicaSet, err := c.replicaSetLister.ReplicaSets(namespace).Get(name)
+		if err != nil {
+			return "", err
+		}
+		return replicaSet.UID, nil
+	case v1.TargetKindStatefulSet:
+		statefulSet, err := c.statefulSetLister.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return "", err
+		}
+		return statefulSet.UID, nil
+	}
+	return "", fmt.Errorf("unsupported target kind %q", kind)
+}
+
+// getTargetOwnerReferences returns the owner references of the WPA with
+// the reference to the workload set like controllerutil.SetOwnerReference
+// does, and without the references to the other workloads. The owners
+// which are not workloads are kept. It returns false if the owner
+// references are up to date.
+func getTargetOwnerReferences(
+	existing []metav1.OwnerReference,
+	targetKind string,
+	targetName string,
+	uid types.UID) ([]metav1.OwnerReference, bool) {
+
+	owner := metav1.OwnerReference{
+		APIVersion: appsv1.SchemeGroupVersion.String(),
+		Kind:       targetKind,
+		Name:       targetName,
+		UID:        uid,
+	}
+	ownerReferences := []metav1.OwnerReference{}
+	changed := true
+	for _, ref := range existing {
+		if !isWorkloadOwnerReference(ref) {
+			ownerReferences = append(ownerReferences, ref)
+			continue
+		}
+		if ref.Kind == owner.Kind && ref.Name == owner.Name &&
+			ref.UID == owner.UID && ref.APIVersion == owner.APIVersion {
+			changed = false
+		}
+	}
+	if !changed && len(ownerReferences)+1 == len(existing) {
+		return nil, false
+	}
+	return append(ownerReferences, owner), true
+}
+
+// isWorkloadOwnerReference tells if the owner is a workload which can be
+// scaled by a WPA, they are the owners set by setTargetOwnerReference
+func isWorkloadOwnerReference(ref metav1.OwnerReference) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil || gv.Group != appsv1.GroupName {
+		return false
+	}
+	return ref.Kind == v1.TargetKindDeployment ||
+		ref.Kind == v1.TargetKindReplicaSet ||
+		ref.Kind == v1.TargetKindStatefulSet
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+)
+
+func TestGetTargetOwnerReferences(t *testing.T) {
+	owner := metav1.OwnerReference{
+		APIVersion: "apps/v1", Kind: "Deployment", Name: "otpsender", UID: "uid-1"}
+	other := metav1.OwnerReference{
+		APIVersion: "apps/v1", Kind: "Deployment", Name: "mailer", UID: "uid-2"}
+	notWorkload := metav1.OwnerReference{
+		APIVersion: "argoproj.io/v1alpha1", Kind: "Application", Name: "workers", UID: "uid-3"}
+
+	tests := []struct {
+		name     string
+		existing []metav1.OwnerReference
+		expected []metav1.OwnerReference
+		changed  bool
+	}{
+		{"no owners", nil, []metav1.OwnerReference{owner}, true},
+		{"up to date", []metav1.OwnerReference{notWorkload, owner}, nil, false},
+		{"target changed", []metav1.OwnerReference{other, notWorkload},
+			[]metav1.OwnerReference{notWorkload, owner}, true},
+		{"target recreated", []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "otpsender", UID: "uid-0"}},
+			[]metav1.OwnerReference{owner}, true},
+		{"duplicate workload owners", []metav1.OwnerReference{owner, other},
+			[]metav1.OwnerReference{owner}, true},
+	}
+	for _, test := range tests {
+		got, changed := getTargetOwnerReferences(
+			test.existing, v1.TargetKindDeployment, "otpsender", "uid-1")
+		if changed != test.changed {
+			t.Errorf("%s: expected changed=%v, got=%v", test.name, test.changed, changed)
+			continue
+		}
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+			}
+		}
+	}
+}
+
+func TestSetTargetOwnerReference(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender")}
+	deployment.UID = "uid-1"
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+	}
+	c := newTargetTestController(t, deployment)
+	c.customclientset = fake.NewSimpleClientset(wpa)
+	ctx := context.Background()
+
+	updated, err := c.setTargetOwnerReference(ctx, wpa, v1.TargetKindDeployment, "otpsender")
+	if err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	stored, err := c.customclientset.K8sV1().WorkerPodAutoScalers("testns").Get(
+		ctx, "otpsender", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the wpa, got=%v", err)
+	}
+	for _, obj := range []*v1.WorkerPodAutoScaler{updated, stored} {
+		refs := obj.OwnerReferences
+		if len(refs) != 1 || refs[0].UID != "uid-1" || refs[0].Kind != "Deployment" ||
+			refs[0].Controller != nil || refs[0].BlockOwnerDeletion != nil {
+			t.Errorf("expected the deployment to own the wpa, got=%v", refs)
+		}
+	}
+
+	// the target which is not found is an error, the wpa is not changed
+	updated, err = c.setTargetOwnerReference(ctx, stored, v1.TargetKindStatefulSet, "otpsender")
+	if err == nil {
+		t.Errorf("expected an error for a missing target")
+	}
+	if len(updated.OwnerReferences) != 1 {
+		t.Errorf("expected the owner references to be kept, got=%v", updated.OwnerReferences)
+	}
+
+	// the scale targets do not own the wpa
+	updated, err = c.setTargetOwnerReference(ctx, stored, "Rollout.v1alpha1.argoproj.io", "otpsender")
+	if err != nil || updated != stored {
+		t.Errorf("expected the wpa not to be changed, got=%v, %v", updated.OwnerReferences, err)
+	}
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+const (
+	// PodDeletionCostAnnotation is used by the ReplicaSet controller to
+	// pick the pods to delete on scale down, the pods with the lower
+	// cost are deleted first. It needs kubernetes 1.21+.
+	PodDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+	// WorkerIdleAnnotation is set to "true" by the worker on its pod
+	// when it is not processing a job, and removed when it picks a job.
+	WorkerIdleAnnotation = "k8s.practo.dev/worker-idle"
+
+	// idlePodDeletionCost is the deletion cost set on the idle pods
+	idlePodDeletionCost = "-1"
+)
+
+// preferIdlePods sets the idle pod deletion cost on the pods annotated
+// idle by the workers and removes it from the pods which are busy again,
+// so that the idle workers are deleted first on scale down.
+// It is skipped when the queue tells all the workers are idle, as
+// any pod can then be deleted. When the idle since annotation is set the
+// idle pods are ranked by the time since which they are idle instead.
+func (c *Controller) preferIdlePods(
+	ctx context.Context,
+	namespace string,
+	targetKind string,
+	targetName string,
+	currentWorkers int32,
+	idleWorkers int32,
+	idleSinceAnnotation string) error {
+
+	if targetKind == v1.TargetKindStatefulSet {
+		klog.V(4).Infof("%s/%s: StatefulSet deletes the highest ordinal, not preferring idle pods",
+			namespace, targetName)
+		return nil
+	}
+	if idleSinceAnnotation == "" && idleWorkers == currentWorkers {
+		return nil
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "preferIdlePods")
+	defer span.End()
+
+	selector, err := c.getTargetSelector(namespace, targetKind, targetName)
+	if err != nil {
+		return err
+	}
+	pods, err := c.kubeclientset.CoreV1().Pods(namespace).List(
+		ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+
+	var rankedCosts map[string]string
+	if idleSinceAnnotation != "" {
+		rankedCosts = getIdleRankCosts(pods.Items, idleSinceAnnotation)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		var patch []byte
+		var ok bool
+		if idleSinceAnnotation != "" {
+			patch, ok, err = getRankedPodDeletionCostPatch(pod, rankedCosts[pod.Name])
+		} else {
+			patch, ok, err = getPodDeletionCostPatch(pod)
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		_, err = c.kubeclientset.CoreV1().Pods(namespace).Patch(
+			ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("error patching pod %s: %v", pod.Name, err)
+		}
+		klog.V(3).Infof("%s/%s: updated pod deletion cost, idle: %s, rank cost: %s",
+			namespace, pod.Name, pod.Annotations[WorkerIdleAnnotation],
+			rankedCosts[pod.Name])
+	}
+	return nil
+}
+
+// getPodDeletionCostPatch returns the merge patch to set or remove the
+// idle pod deletion cost, it returns false if the pod is up to date.
+// Only the cost set by WPA is removed, a cost set by others is kept.
+func getPodDeletionCostPatch(pod *corev1.Pod) ([]byte, bool, error) {
+	idle := pod.Annotations[WorkerIdleAnnotation] == "true"
+	cost, hasCost := pod.Annotations[PodDeletionCostAnnotation]
+
+	var value interface{}
+	switch {
+	case idle && cost != idlePodDeletionCost:
+		value = idlePodDeletionCost
+	case !idle && hasCost && cost == idlePodDeletionCost:
+		// null removes the annotation in a merge patch
+		value = nil
+	default:
+		return nil, false, nil
+	}
+	return podDeletionCostPatch(value)
+}
+
+// getIdleRankCosts returns the deletion cost of the pods which have the
+// idle since annotation, keyed by the pod name. The pod idle for the
+// longest gets the lowest cost so that it is deleted first, the costs
+// go from -<idle pods> to -1. Pods with an invalid time are ignored.
+func getIdleRankCosts(
+	pods []corev1.Pod, idleSinceAnnotation string) map[string]string {
+
+	type idlePod struct {
+		name  string
+		since time.Time
+	}
+	var idlePods []idlePod
+	for _, pod := range pods {
+		value, ok := pod.Annotations[idleSinceAnnotation]
+		if !ok || value == "" {
+			continue
+		}
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			klog.V(4).Infof("%s/%s: ignoring invalid %s=%q, err: %v",
+				pod.Namespace, pod.Name, idleSinceAnnotation, value, err)
+			continue
+		}
+		idlePods = append(idlePods, idlePod{name: pod.Name, since: since})
+	}
+	sort.SliceStable(idlePods, func(i, j int) bool {
+		return idlePods[i].since.Before(idlePods[j].since)
+	})
+
+	costs := make(map[string]string, len(idlePods))
+	for i, pod := range idlePods {
+		costs[pod.name] = strconv.Itoa(i - len(idlePods))
+	}
+	return costs
+}
+
+// getRankedPodDeletionCostPatch returns the merge patch to set the
+// ranked cost of the idle pod or to remove the negative cost from the
+// busy pod, it returns false if the pod is up to date. The negative
+// costs are owned by WPA in this mode, other costs are kept.
+func getRankedPodDeletionCostPatch(
+	pod *corev1.Pod, rankedCost string) ([]byte, bool, error) {
+
+	cost, hasCost := pod.Annotations[PodDeletionCostAnnotation]
+	if rankedCost != "" {
+		if cost == rankedCost {
+			return nil, false, nil
+		}
+		return podDeletionCostPatch(rankedCost)
+	}
+
+	if !hasCost {
+		return nil, false, nil
+	}
+	if value, err := strconv.Atoi(cost); err != nil || value >= 0 {
+		return nil, false, nil
+	}
+	// null removes the annotation in a merge patch
+	return podDeletionCostPatch(nil)
+}
+
+// podDeletionCostPatch returns the merge patch setting
+// the pod deletion cost to the value
+func podDeletionCostPatch(value interface{}) ([]byte, bool, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				PodDeletionCostAnnotation: value,
+			},
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return patch, true, nil
+}
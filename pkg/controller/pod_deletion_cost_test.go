@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func annotatedPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "otpsender-abc",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestGetPodDeletionCostPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected string
+	}{
+		{
+			name:     "idle pod without cost",
+			pod:      annotatedPod(map[string]string{WorkerIdleAnnotation: "true"}),
+			expected: `{"metadata":{"annotations":{"controller.kubernetes.io/pod-deletion-cost":"-1"}}}`,
+		},
+		{
+			name: "idle pod with cost",
+			pod: annotatedPod(map[string]string{
+				WorkerIdleAnnotation:      "true",
+				PodDeletionCostAnnotation: idlePodDeletionCost,
+			}),
+			expected: "",
+		},
+		{
+			name:     "busy pod with idle cost",
+			pod:      annotatedPod(map[string]string{PodDeletionCostAnnotation: idlePodDeletionCost}),
+			expected: `{"metadata":{"annotations":{"controller.kubernetes.io/pod-deletion-cost":null}}}`,
+		},
+		{
+			name:     "busy pod with cost not set by wpa",
+			pod:      annotatedPod(map[string]string{PodDeletionCostAnnotation: "100"}),
+			expected: "",
+		},
+		{
+			name:     "pod without annotations",
+			pod:      annotatedPod(nil),
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		patch, ok, err := getPodDeletionCostPatch(test.pod)
+		if err != nil {
+			t.Errorf("%s: expected no error, got=%v\n", test.name, err)
+			continue
+		}
+		if ok != (test.expected != "") || string(patch) != test.expected {
+			t.Errorf("%s: expected patch=%s, got=%s\n",
+				test.name, test.expected, string(patch))
+		}
+	}
+}
+
+func TestGetIdleRankCosts(t *testing.T) {
+	idleSince := "example.com/idle-since"
+	pod := func(name string, since string) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if since != "" {
+			p.Annotations = map[string]string{idleSince: since}
+		}
+		return p
+	}
+	pods := []corev1.Pod{
+		pod("recent", "2021-06-01T10:05:00Z"),
+		pod("busy", ""),
+		pod("oldest", "2021-06-01T10:00:00Z"),
+		pod("invalid", "yesterday"),
+		pod("middle", "2021-06-01T10:03:00Z"),
+	}
+
+	costs := getIdleRankCosts(pods, idleSince)
+	expected := map[string]string{
+		"oldest": "-3",
+		"middle": "-2",
+		"recent": "-1",
+	}
+	if len(costs) != len(expected) {
+		t.Fatalf("expected costs=%v, got=%v\n", expected, costs)
+	}
+	for name, cost := range expected {
+		if costs[name] != cost {
+			t.Errorf("%s: expected cost=%s, got=%s\n", name, cost, costs[name])
+		}
+	}
+}
+
+func TestGetRankedPodDeletionCostPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		rankedCost string
+		expected   string
+	}{
+		{
+			name:       "idle pod without cost",
+			pod:        annotatedPod(nil),
+			rankedCost: "-2",
+			expected:   `{"metadata":{"annotations":{"controller.kubernetes.io/pod-deletion-cost":"-2"}}}`,
+		},
+		{
+			name:       "idle pod with same cost",
+			pod:        annotatedPod(map[string]string{PodDeletionCostAnnotation: "-2"}),
+			rankedCost: "-2",
+			expected:   "",
+		},
+		{
+			name:       "idle pod with cost not set by wpa",
+			pod:        annotatedPod(map[string]string{PodDeletionCostAnnotation: "100"}),
+			rankedCost: "-1",
+			expected:   `{"metadata":{"annotations":{"controller.kubernetes.io/pod-deletion-cost":"-1"}}}`,
+		},
+		{
+			name:     "busy pod with ranked cost",
+			pod:      annotatedPod(map[string]string{PodDeletionCostAnnotation: "-3"}),
+			expected: `{"metadata":{"annotations":{"controller.kubernetes.io/pod-deletion-cost":null}}}`,
+		},
+		{
+			name:     "busy pod with cost not set by wpa",
+			pod:      annotatedPod(map[string]string{PodDeletionCostAnnotation: "0"}),
+			expected: "",
+		},
+		{
+			name:     "busy pod without cost",
+			pod:      annotatedPod(nil),
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		patch, ok, err := getRankedPodDeletionCostPatch(test.pod, test.rankedCost)
+		if err != nil {
+			t.Errorf("%s: expected no error, got=%v\n", test.name, err)
+			continue
+		}
+		if ok != (test.expected != "") || string(patch) != test.expected {
+			t.Errorf("%s: expected patch=%s, got=%s\n",
+				test.name, test.expected, string(patch))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// checkProcessingTimeMismatch reports the secondsToProcessOneJob which
+// differs from the processing time observed from the backlog by more
+// than the processingTimeMismatchFactor, using a warning event and the
+// ProcessingTimeMismatch condition. The scaling is not changed, a stale
+// secondsToProcessOneJob is only made visible before it miscalibrates it.
+func (c *Controller) checkProcessingTimeMismatch(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) *v1.WorkerPodAutoScaler {
+
+	if c.processingTimeMismatchFactor <= 1 {
+		return workerPodAutoScaler
+	}
+
+	configured, estimate, mismatch := c.Queues.GetProcessingTimeMismatch(
+		workerPodAutoScaler.Namespace, workerPodAutoScaler.Name,
+		c.processingTimeMismatchFactor)
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionProcessingTimeMismatch)
+	if !mismatch {
+		// the condition is kept till there is an estimate to compare
+		if estimate == 0 || existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionProcessingTimeMismatch,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ProcessingTimeMatches",
+				Message: "secondsToProcessOneJob matches the observed processing time",
+			},
+		)
+	}
+
+	klog.Warningf("%s: secondsToProcessOneJob=%v, observed=%.2f, differ by more than %vx",
+		key, configured, estimate, c.processingTimeMismatchFactor)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionProcessingTimeMismatch,
+			fmt.Sprintf("secondsToProcessOneJob=%v differs from the observed %.2f seconds by more than %vx",
+				configured, estimate, c.processingTimeMismatchFactor))
+	}
+	// the estimate is not in the condition, it would update the
+	// status with every poll
+	message := fmt.Sprintf(
+		"secondsToProcessOneJob=%v differs from the observed processing time by more than %vx",
+		configured, c.processingTimeMismatchFactor)
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionProcessingTimeMismatch,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ProcessingTimeMismatch",
+			Message: message,
+		},
+	)
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+// checkQueueAvailable reports the last poll of the queue in the
+// QueueAvailable condition. It returns false when the poll keeps failing
+// till the queue or its credentials are fixed, the backlog is not known
+// then and the workload is not scaled. The throttled and the transient
+// failures are backed off by the poller and the last backlog is used.
+func (c *Controller) checkQueueAvailable(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	pollErrorReason string,
+	lastPollError string) (*v1.WorkerPodAutoScaler, bool) {
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionQueueAvailable)
+	if pollErrorReason == "" {
+		if existing == nil || existing.Status == metav1.ConditionTrue {
+			return workerPodAutoScaler, true
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionQueueAvailable,
+				Status:  metav1.ConditionTrue,
+				Reason:  "QueuePolled",
+				Message: "The queue was polled successfully",
+			},
+		), true
+	}
+
+	available := !queue.IsTerminalPollErrorReason(pollErrorReason)
+	if !available {
+		klog.Warningf("%s: queue poll failed with %s, not scaling, err: %s",
+			key, pollErrorReason, lastPollError)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionQueueAvailable,
+			Status:  metav1.ConditionFalse,
+			Reason:  pollErrorReason,
+			Message: lastPollError,
+		},
+	), available
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func TestCheckQueueAvailable(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+	}
+	c := &Controller{customclientset: fake.NewSimpleClientset(wpa)}
+	ctx := context.Background()
+
+	tests := []struct {
+		name              string
+		reason            string
+		expectedAvailable bool
+		expectedStatus    metav1.ConditionStatus
+	}{
+		{"queue not found", queue.PollErrorReasonQueueNotFound, false, metav1.ConditionFalse},
+		{"auth failed", queue.PollErrorReasonQueueAuthFailed, false, metav1.ConditionFalse},
+		{"throttled", queue.PollErrorReasonQueueThrottled, true, metav1.ConditionFalse},
+		{"unavailable", queue.PollErrorReasonQueueUnavailable, true, metav1.ConditionFalse},
+		{"polled", "", true, metav1.ConditionTrue},
+	}
+	for _, test := range tests {
+		var available bool
+		wpa, available = c.checkQueueAvailable(ctx, "testns/wpa", wpa, test.reason, "poll failed")
+		if available != test.expectedAvailable {
+			t.Errorf("%s: available=%v, expected=%v", test.name, available, test.expectedAvailable)
+		}
+		condition := meta.FindStatusCondition(wpa.Status.Conditions, v1.ConditionQueueAvailable)
+		if condition == nil || condition.Status != test.expectedStatus {
+			t.Errorf("%s: expected the condition %s, got=%v", test.name, test.expectedStatus, condition)
+			continue
+		}
+		if test.reason != "" && condition.Reason != test.reason {
+			t.Errorf("%s: expected the reason %s, got=%s", test.name, test.reason, condition.Reason)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/practo/klog/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// checkQueueSynced reports in the QueueUnsynced condition and in the
+// wpa_queue_unsynced metric if the backlog of the queue is not known,
+// e.g. before the first poll or while the queue backend is down. The
+// scaling is frozen then but the status is still updated.
+func (c *Controller) checkQueueSynced(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	metricQueueName string,
+	synced bool) *v1.WorkerPodAutoScaler {
+
+	var unsynced float64
+	if !synced {
+		unsynced = 1
+	}
+	queueUnsynced.WithLabelValues(
+		workerPodAutoScaler.Name,
+		workerPodAutoScaler.Namespace,
+		metricQueueName,
+	).Set(unsynced)
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionQueueUnsynced)
+	if synced {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionQueueUnsynced,
+				Status:  metav1.ConditionFalse,
+				Reason:  "QueueSynced",
+				Message: "The backlog of the queue is known",
+			},
+		)
+	}
+
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		klog.Warningf("%s: backlog of the queue is not known, holding the desired replicas %d",
+			key, workerPodAutoScaler.Status.DesiredReplicas)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionQueueUnsynced,
+			Status:  metav1.ConditionTrue,
+			Reason:  "QueueNotSynced",
+			Message: "The backlog of the queue is not known, the last desired replicas are held",
+		},
+	)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/fake"
+)
+
+func getQueueUnsynced(t *testing.T) float64 {
+	var metric dto.Metric
+	err := queueUnsynced.WithLabelValues("wpa", "testns", "otpsender").Write(&metric)
+	if err != nil {
+		t.Fatalf("error reading wpa_queue_unsynced: %v", err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func TestCheckQueueSynced(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "wpa", Namespace: "testns"},
+		Status:     v1.WorkerPodAutoScalerStatus{DesiredReplicas: 4},
+	}
+	c := &Controller{customclientset: fake.NewSimpleClientset(wpa)}
+	ctx := context.Background()
+	defer queueUnsynced.DeleteLabelValues("wpa", "testns", "otpsender")
+
+	// the condition is not set till the queue is unsynced once
+	wpa = c.checkQueueSynced(ctx, "testns/wpa", wpa, "otpsender", true)
+	if condition := meta.FindStatusCondition(
+		wpa.Status.Conditions, v1.ConditionQueueUnsynced); condition != nil {
+		t.Errorf("expected no condition while synced, got=%v", condition)
+	}
+
+	tests := []struct {
+		name           string
+		synced         bool
+		expectedStatus metav1.ConditionStatus
+		expectedMetric float64
+	}{
+		{"unsynced", false, metav1.ConditionTrue, 1},
+		{"still unsynced", false, metav1.ConditionTrue, 1},
+		{"synced again", true, metav1.ConditionFalse, 0},
+	}
+	for _, test := range tests {
+		wpa = c.checkQueueSynced(ctx, "testns/wpa", wpa, "otpsender", test.synced)
+		condition := meta.FindStatusCondition(wpa.Status.Conditions, v1.ConditionQueueUnsynced)
+		if condition == nil || condition.Status != test.expectedStatus {
+			t.Errorf("%s: expected %s, got=%v", test.name, test.expectedStatus, condition)
+		}
+		if got := getQueueUnsynced(t); got != test.expectedMetric {
+			t.Errorf("%s: expected wpa_queue_unsynced=%v, got=%v",
+				test.name, test.expectedMetric, got)
+		}
+	}
+	if wpa.Status.DesiredReplicas != 4 {
+		t.Errorf("expected the desired replicas to be held, got=%d", wpa.Status.DesiredReplicas)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/practo/klog/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The phases of the reconcile which call out of the controller,
+// the rest of the reconcile is reported as reconcilePhaseOther
+const (
+	reconcilePhaseQueueSync = "queueSync"
+	reconcilePhaseUpdate    = "update"
+	reconcilePhaseStatus    = "status"
+	reconcilePhaseOther     = "other"
+)
+
+var reconcileDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: "wpa",
+		Subsystem: "controller",
+		Name:      "reconcile_duration_seconds",
+		Help:      "How long in seconds the reconcile of a wpa takes, the failed reconciles included",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	},
+)
+
+func init() {
+	prometheus.MustRegister(reconcileDurationSeconds)
+}
+
+// reconcileTimer times the reconcile of a WPA and its phases
+type reconcileTimer struct {
+	start  time.Time
+	phases map[string]time.Duration
+	// traceID is the trace of the reconcile, it is empty if not traced
+	traceID string
+}
+
+func newReconcileTimer(start time.Time) *reconcileTimer {
+	return &reconcileTimer{
+		start:  start,
+		phases: make(map[string]time.Duration),
+	}
+}
+
+// startPhase starts timing the phase, the returned func ends it.
+// A phase can be timed more than once in a reconcile.
+func (t *reconcileTimer) startPhase(phase string) func() {
+	start := time.Now()
+	return func() {
+		t.phases[phase] += time.Since(start)
+	}
+}
+
+// getPhases returns the time taken by the phases and by the rest of
+// the reconcile out of the total time taken, in a fixed order
+func (t *reconcileTimer) getPhases(total time.Duration) string {
+	other := total
+	phases := []string{}
+	for _, phase := range []string{
+		reconcilePhaseQueueSync,
+		reconcilePhaseUpdate,
+		reconcilePhaseStatus,
+	} {
+		other -= t.phases[phase]
+		phases = append(phases, fmt.Sprintf("%s=%v", phase, t.phases[phase]))
+	}
+	phases = append(phases, fmt.Sprintf("%s=%v", reconcilePhaseOther, other))
+	return strings.Join(phases, " ")
+}
+
+// observeReconcile records the time taken by the reconcile of the WPA
+// and warns with the time of every phase when it takes longer than the
+// slowReconcileThreshold. The reconcile blocks a thread till then.
+// The trace of the reconcile is attached as an exemplar to link the
+// slow reconciles to their trace.
+func (c *Controller) observeReconcile(key string, timer *reconcileTimer) {
+	total := time.Since(timer.start)
+	observeWithTraceID(reconcileDurationSeconds, total.Seconds(), timer.traceID)
+	if !c.isSlowReconcile(total) {
+		return
+	}
+	klog.Warningf("%s: reconcile took %v, longer than %v: %s",
+		key, total, c.slowReconcileThreshold, timer.getPhases(total))
+}
+
+// observeWithTraceID observes the value with the trace id as the exemplar,
+// the value is observed without it if the trace id is empty
+func observeWithTraceID(observer prometheus.Observer, value float64, traceID string) {
+	exemplarObserver, ok := observer.(prometheus.ExemplarObserver)
+	if traceID == "" || !ok {
+		observer.Observe(value)
+		return
+	}
+	exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": traceID})
+}
+
+// isSlowReconcile tells if the reconcile took longer than the
+// slowReconcileThreshold
+func (c *Controller) isSlowReconcile(total time.Duration) bool {
+	return c.slowReconcileThreshold > 0 && total > c.slowReconcileThreshold
+}
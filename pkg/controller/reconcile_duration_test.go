@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+func TestReconcileTimerPhases(t *testing.T) {
+	timer := newReconcileTimer(time.Now())
+	timer.phases[reconcilePhaseQueueSync] = 2 * time.Second
+	timer.phases[reconcilePhaseStatus] = time.Second
+
+	// a phase timed twice adds up
+	end := timer.startPhase(reconcilePhaseUpdate)
+	end()
+	first := timer.phases[reconcilePhaseUpdate]
+	timer.startPhase(reconcilePhaseUpdate)()
+	if timer.phases[reconcilePhaseUpdate] < first {
+		t.Errorf("expected the update phase to add up, got=%v", timer.phases[reconcilePhaseUpdate])
+	}
+	timer.phases[reconcilePhaseUpdate] = 500 * time.Millisecond
+
+	got := timer.getPhases(5 * time.Second)
+	expected := "queueSync=2s update=500ms status=1s other=1.5s"
+	if got != expected {
+		t.Errorf("expected %q, got=%q", expected, got)
+	}
+}
+
+func getReconcileCount(t *testing.T) uint64 {
+	var metric dto.Metric
+	if err := reconcileDurationSeconds.Write(&metric); err != nil {
+		t.Fatalf("error reading wpa_controller_reconcile_duration_seconds: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestObserveReconcile(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		elapsed   time.Duration
+		slow      bool
+	}{
+		{"fast", time.Second, 10 * time.Millisecond, false},
+		{"slow", time.Second, 2 * time.Second, true},
+		{"disabled", 0, 2 * time.Second, false},
+	}
+	for _, test := range tests {
+		c := &Controller{slowReconcileThreshold: test.threshold}
+		if slow := c.isSlowReconcile(test.elapsed); slow != test.slow {
+			t.Errorf("%s: expected slow=%v, got=%v", test.name, test.slow, slow)
+		}
+
+		// the reconcile is observed either way
+		count := getReconcileCount(t)
+		c.observeReconcile("testns/otpsender", newReconcileTimer(time.Now().Add(-test.elapsed)))
+		if got := getReconcileCount(t); got != count+1 {
+			t.Errorf("%s: expected the reconcile to be observed, got=%d", test.name, got-count)
+		}
+	}
+}
+
+func TestObserveWithTraceID(t *testing.T) {
+	// the reconciles are not traced when tracing is disabled
+	if _, ok := tracing.TraceID(context.Background()); ok {
+		t.Errorf("expected no trace id without a span")
+	}
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "syncHandler")
+	defer span.End()
+	traceID, ok := tracing.TraceID(ctx)
+	if !ok || traceID != span.SpanContext().TraceID().String() {
+		t.Fatalf("expected the trace id of the span, got=%q", traceID)
+	}
+
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_reconcile_duration_seconds",
+		Buckets: []float64{1, 10},
+	})
+	observeWithTraceID(histogram, 0.5, "")
+	observeWithTraceID(histogram, 5, traceID)
+
+	var metric dto.Metric
+	if err := histogram.Write(&metric); err != nil {
+		t.Fatalf("error reading the histogram: %v", err)
+	}
+	if count := metric.GetHistogram().GetSampleCount(); count != 2 {
+		t.Errorf("expected 2 observations, got=%d", count)
+	}
+	buckets := metric.GetHistogram().GetBucket()
+	if buckets[0].GetExemplar() != nil {
+		t.Errorf("expected no exemplar without a trace id, got=%v", buckets[0].GetExemplar())
+	}
+	exemplar := buckets[1].GetExemplar()
+	if exemplar == nil || len(exemplar.GetLabel()) != 1 ||
+		exemplar.GetLabel()[0].GetName() != "trace_id" ||
+		exemplar.GetLabel()[0].GetValue() != traceID {
+		t.Errorf("expected the trace id as the exemplar, got=%v", exemplar)
+	}
+}
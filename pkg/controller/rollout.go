@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/practo/klog/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// isRollingOut tells if a rollout of the workload is in progress,
+// a ReplicaSet is never rolled out
+func (c *Controller) isRollingOut(
+	namespace string, kind string, name string) (bool, error) {
+
+	switch kind {
+	case v1.TargetKindDeployment:
+		deployment, err := c.deploymentLister.Deployments(namespace).Get(name)
+		if err != nil {
+			return false, err
+		}
+		return isDeploymentRollingOut(deployment), nil
+	case v1.TargetKindStatefulSet:
+		statefulSet, err := c.statefulSetLister.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return false, err
+		}
+		return isStatefulSetRollingOut(statefulSet), nil
+	}
+	return false, nil
+}
+
+// isDeploymentRollingOut tells if the deployment has pods
+// which are not updated to its latest template
+func isDeploymentRollingOut(deployment *appsv1.Deployment) bool {
+	return deployment.Status.UpdatedReplicas != deployment.Status.Replicas
+}
+
+// isStatefulSetRollingOut tells if the statefulset has pods
+// which are not updated to its latest revision
+func isStatefulSetRollingOut(statefulSet *appsv1.StatefulSet) bool {
+	return statefulSet.Status.UpdateRevision != "" &&
+		statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision
+}
+
+// checkRollout reports the rollout of the workload in the
+// RolloutInProgress condition. It returns true while the rollout is in
+// progress, the workers are not scaled down then as the available
+// workers dip while the pods are replaced.
+func (c *Controller) checkRollout(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	targetKind string,
+	targetName string) (*v1.WorkerPodAutoScaler, bool) {
+
+	rollingOut, err := c.isRollingOut(
+		workerPodAutoScaler.Namespace, targetKind, targetName)
+	if err != nil {
+		klog.Errorf("%s: unable to check the rollout of %s %s, err: %v",
+			key, targetKind, targetName, err)
+		return workerPodAutoScaler, false
+	}
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionRolloutInProgress)
+	if !rollingOut {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionRolloutInProgress,
+				Status:  metav1.ConditionFalse,
+				Reason:  "RolloutComplete",
+				Message: fmt.Sprintf("Rollout of %s %s is complete", targetKind, targetName),
+			},
+		), false
+	}
+
+	klog.V(2).Infof("%s: rollout of %s %s in progress, not scaling down",
+		key, targetKind, targetName)
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionRolloutInProgress,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RolloutInProgress",
+			Message: fmt.Sprintf("Rollout of %s %s is in progress, not scaling down", targetKind, targetName),
+		},
+	), true
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestIsRollingOut(t *testing.T) {
+	c := newTargetTestController(t,
+		&appsv1.Deployment{
+			ObjectMeta: objectMeta("settled", "settled"),
+			Status:     appsv1.DeploymentStatus{Replicas: 5, UpdatedReplicas: 5},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: objectMeta("rolling", "rolling"),
+			Status:     appsv1.DeploymentStatus{Replicas: 7, UpdatedReplicas: 2},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: objectMeta("settled", "settled"),
+			Status: appsv1.StatefulSetStatus{
+				CurrentRevision: "settled-1", UpdateRevision: "settled-1"},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: objectMeta("rolling", "rolling"),
+			Status: appsv1.StatefulSetStatus{
+				CurrentRevision: "rolling-1", UpdateRevision: "rolling-2"},
+		},
+		&appsv1.ReplicaSet{ObjectMeta: objectMeta("rs", "rs")},
+	)
+
+	tests := []struct {
+		kind     string
+		name     string
+		expected bool
+	}{
+		{v1.TargetKindDeployment, "settled", false},
+		{v1.TargetKindDeployment, "rolling", true},
+		{v1.TargetKindStatefulSet, "settled", false},
+		{v1.TargetKindStatefulSet, "rolling", true},
+		{v1.TargetKindReplicaSet, "rs", false},
+	}
+	for _, test := range tests {
+		rollingOut, err := c.isRollingOut("testns", test.kind, test.name)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", test.kind, test.name, err)
+			continue
+		}
+		if rollingOut != test.expected {
+			t.Errorf("%s %s: rollingOut=%v, expected=%v",
+				test.kind, test.name, rollingOut, test.expected)
+		}
+	}
+
+	if _, err := c.isRollingOut("testns", v1.TargetKindDeployment, "missing"); err == nil {
+		t.Errorf("expected an error for a missing deployment")
+	}
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"math"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// getBacklogWorkers returns the workers required by the backlog, i.e.
+// the backlog divided by the target rounded with the strategy. Ceil is
+// used when the strategy is not set. A backlog is never left without a
+// worker, even if it is rounded down to zero. No workers are required
+// by a target which is not greater than 0.
+func getBacklogWorkers(
+	queueMessages int32,
+	targetMessagesPerWorker int32,
+	roundingStrategy string) int32 {
+
+	if targetMessagesPerWorker <= 0 {
+		return 0
+	}
+	workers := float64(queueMessages) / float64(targetMessagesPerWorker)
+	switch roundingStrategy {
+	case v1.RoundingStrategyFloor:
+		workers = math.Floor(workers)
+	case v1.RoundingStrategyRound:
+		workers = math.Round(workers)
+	default:
+		workers = math.Ceil(workers)
+	}
+	if workers == 0 && queueMessages > 0 {
+		return 1
+	}
+	return int32(workers)
+}
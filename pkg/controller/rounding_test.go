@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestGetBacklogWorkers(t *testing.T) {
+	tests := []struct {
+		name          string
+		queueMessages int32
+		strategy      string
+		expected      int32
+	}{
+		{"ceil exactly divisible", 100, v1.RoundingStrategyCeil, 10},
+		{"ceil just over", 101, v1.RoundingStrategyCeil, 11},
+		{"ceil by default", 101, "", 11},
+		{"round exactly divisible", 100, v1.RoundingStrategyRound, 10},
+		{"round just over", 101, v1.RoundingStrategyRound, 10},
+		{"round half", 105, v1.RoundingStrategyRound, 11},
+		{"floor exactly divisible", 100, v1.RoundingStrategyFloor, 10},
+		{"floor just over", 101, v1.RoundingStrategyFloor, 10},
+		{"floor just under", 99, v1.RoundingStrategyFloor, 9},
+		{"floor keeps a worker for a small backlog", 5, v1.RoundingStrategyFloor, 1},
+		{"round keeps a worker for a small backlog", 1, v1.RoundingStrategyRound, 1},
+		{"floor without a backlog", 0, v1.RoundingStrategyFloor, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			workers := getBacklogWorkers(test.queueMessages, 10, test.strategy)
+			if workers != test.expected {
+				t.Errorf("expected %d workers, got=%d", test.expected, workers)
+			}
+		})
+	}
+}
+
+func TestGetBacklogWorkersWithZeroTarget(t *testing.T) {
+	if workers := getBacklogWorkers(100, 0, v1.RoundingStrategyCeil); workers != 0 {
+		t.Errorf("expected 0 workers, got=%d", workers)
+	}
+}
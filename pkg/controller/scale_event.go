@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/sink"
+)
+
+// publishScaleEvent publishes the scale operation of the WPA to the
+// scale event sink, the failed updates of the workload are published
+// with their error. It is a no-op if there is no sink.
+func (c *Controller) publishScaleEvent(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	queueName string,
+	queueMessages int32,
+	targetKind string,
+	targetName string,
+	op ScaleOperation,
+	reason ScaleReason,
+	currentWorkers int32,
+	desiredWorkers int32,
+	scaleErr error) {
+
+	if c.scaleEventSink == nil {
+		return
+	}
+
+	event := sink.ScaleEvent{
+		Time:            time.Now().UTC(),
+		Name:            workerPodAutoScaler.Name,
+		Namespace:       workerPodAutoScaler.Namespace,
+		QueueName:       queueName,
+		TargetKind:      targetKind,
+		TargetName:      targetName,
+		Operation:       scaleOpString(op),
+		Reason:          string(reason),
+		QueueMessages:   queueMessages,
+		CurrentReplicas: currentWorkers,
+		DesiredReplicas: desiredWorkers,
+	}
+	if scaleErr != nil {
+		event.Error = scaleErr.Error()
+	}
+	c.scaleEventSink.Publish(event)
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/sink"
+)
+
+type fakeSink struct {
+	events []sink.ScaleEvent
+}
+
+func (s *fakeSink) Publish(event sink.ScaleEvent) {
+	s.events = append(s.events, event)
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func TestPublishScaleEvent(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "default"},
+	}
+
+	// no sink
+	c := &Controller{}
+	c.publishScaleEvent(wpa, "otpsender", 100, v1.TargetKindDeployment,
+		"otpsender", ScaleUp, ScaleReasonBacklog, 2, 10, nil)
+
+	s := &fakeSink{}
+	c = &Controller{scaleEventSink: s}
+	c.publishScaleEvent(wpa, "otpsender", 100, v1.TargetKindDeployment,
+		"otpsender", ScaleUp, ScaleReasonBacklog, 2, 10, nil)
+	c.publishScaleEvent(wpa, "otpsender", 0, v1.TargetKindDeployment,
+		"otpsender", ScaleDown, ScaleReasonNoBacklog, 10, 0,
+		fmt.Errorf("forbidden"))
+
+	if len(s.events) != 2 {
+		t.Fatalf("expected 2 events, got=%d\n", len(s.events))
+	}
+	up := s.events[0]
+	if up.Name != "otpsender" || up.Namespace != "default" ||
+		up.Operation != "scale-up" || up.Reason != string(ScaleReasonBacklog) ||
+		up.QueueMessages != 100 || up.CurrentReplicas != 2 ||
+		up.DesiredReplicas != 10 || up.Error != "" {
+		t.Errorf("unexpected scale up event=%+v\n", up)
+	}
+	down := s.events[1]
+	if down.Operation != "scale-down" || down.Error != "forbidden" {
+		t.Errorf("unexpected scale down event=%+v\n", down)
+	}
+}
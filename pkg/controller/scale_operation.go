@@ -20,56 +20,112 @@ func GetScaleOperation(
 	desiredWorkers int32,
 	currentWorkers int32,
 	lastScaleTime *metav1.Time,
-	scaleDownDelay time.Duration) ScaleOperation {
+	scaleUpDelay time.Duration,
+	scaleDownDelay time.Duration,
+	scaleDownBlocked bool) ScaleOperation {
+
+	return getScaleOperationAt(q, desiredWorkers, currentWorkers, lastScaleTime,
+		scaleUpDelay, scaleDownDelay, scaleDownBlocked, time.Now())
+}
+
+// getScaleOperationAt is GetScaleOperation at the time now,
+// the simulation replays the samples at their time
+func getScaleOperationAt(
+	q string,
+	desiredWorkers int32,
+	currentWorkers int32,
+	lastScaleTime *metav1.Time,
+	scaleUpDelay time.Duration,
+	scaleDownDelay time.Duration,
+	scaleDownBlocked bool,
+	now time.Time) ScaleOperation {
 
 	if desiredWorkers > currentWorkers {
-		return ScaleUp
+		if canScale(q, "scaleUp", lastScaleTime, scaleUpDelay, now) {
+			return ScaleUp
+		}
+		return ScaleNoop
 	}
 
 	if desiredWorkers == currentWorkers {
 		return ScaleNoop
 	}
 
-	if canScaleDown(
-		q, desiredWorkers, currentWorkers, lastScaleTime, scaleDownDelay) {
+	if scaleDownBlocked {
+		klog.V(2).Infof("%s scaleDown forbidden, blocked by safety queue", q)
+		return ScaleNoop
+	}
+
+	if canScale(q, "scaleDown", lastScaleTime, scaleDownDelay, now) {
 		return ScaleDown
 	}
 
 	return ScaleNoop
 }
 
-// canScaleDown checks the scaleDownDelay and the lastScaleTime to decide
+// canScale checks the delay and the lastScaleTime to decide
 // if scaling is required. Checks coolOff!
-func canScaleDown(
+func canScale(
 	q string,
-	desiredWorkers int32,
-	currentWorkers int32,
-	lastScaleTime *metav1.Time, scaleDownDelay time.Duration) bool {
+	op string,
+	lastScaleTime *metav1.Time,
+	delay time.Duration,
+	now time.Time) bool {
 
 	if lastScaleTime == nil {
-		klog.V(2).Infof("%s scaleDownDelay ignored, lastScaleTime is nil", q)
+		klog.V(2).Infof("%s %s delay ignored, lastScaleTime is nil", q, op)
 		return true
 	}
 
-	nextScaleDownTime := metav1.NewTime(
-		lastScaleTime.Time.Add(scaleDownDelay),
-	)
-	now := metav1.Now()
-
-	if nextScaleDownTime.Before(&now) {
-		klog.V(2).Infof("%s scaleDown is allowed, cooloff passed", q)
+	remaining := getCooldownRemaining(lastScaleTime, delay, now)
+	if remaining <= 0 {
+		klog.V(2).Infof("%s %s is allowed, cooloff passed", q, op)
 		return true
 	}
 
 	klog.V(2).Infof(
-		"%s scaleDown forbidden, nextScaleDownTime: %v",
+		"%s %s forbidden, next %s time: %v",
 		q,
-		nextScaleDownTime,
+		op,
+		op,
+		lastScaleTime.Time.Add(delay),
 	)
 
 	return false
 }
 
+// getCooldownRemaining returns the time left till the delay after the
+// last scale passes, it is 0 or negative once it has passed
+func getCooldownRemaining(
+	lastScaleTime *metav1.Time,
+	delay time.Duration,
+	now time.Time) time.Duration {
+
+	if lastScaleTime == nil {
+		return 0
+	}
+	return lastScaleTime.Time.Add(delay).Sub(now)
+}
+
+// getNextScaleEligibleTime returns the time after which the workers can
+// be scaled again in both the directions, nil if they can be scaled now
+func getNextScaleEligibleTime(
+	lastScaleTime *metav1.Time,
+	scaleUpDelay time.Duration,
+	scaleDownDelay time.Duration,
+	now time.Time) *metav1.Time {
+
+	delay := scaleUpDelay
+	if scaleDownDelay > delay {
+		delay = scaleDownDelay
+	}
+	if getCooldownRemaining(lastScaleTime, delay, now) <= 0 {
+		return nil
+	}
+	next := metav1.NewTime(lastScaleTime.Time.Add(delay))
+	return &next
+}
+
 func scaleOpString(op ScaleOperation) string {
 	switch op {
 	case ScaleUp:
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
 	"github.com/practo/k8s-worker-pod-autoscaler/pkg/controller"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -19,8 +20,10 @@ func timeBeforeSeconds(before int) *metav1.Time {
 type opTestCase struct {
 	desired           int32
 	current           int32
+	scaleUpDelay      time.Duration
 	scaleDownDelay    time.Duration
 	lastScaleTime     *metav1.Time
+	scaleDownBlocked  bool
 	expectedOperation controller.ScaleOperation
 }
 
@@ -61,6 +64,22 @@ func TestScaleOperation(t *testing.T) {
 			lastScaleTime:     timeBeforeSeconds(1),
 			expectedOperation: controller.ScaleNoop,
 		},
+		{
+			current:           10,
+			desired:           5,
+			scaleDownDelay:    time.Second * time.Duration(5),
+			lastScaleTime:     timeBeforeSeconds(10),
+			scaleDownBlocked:  true,
+			expectedOperation: controller.ScaleNoop,
+		},
+		{
+			current:           10,
+			desired:           15,
+			scaleDownDelay:    time.Second * time.Duration(5),
+			lastScaleTime:     timeBeforeSeconds(10),
+			scaleDownBlocked:  true,
+			expectedOperation: controller.ScaleUp,
+		},
 	}
 
 	for _, optc := range opTestCases {
@@ -70,7 +89,117 @@ func TestScaleOperation(t *testing.T) {
 			tc.desired,
 			tc.current,
 			tc.lastScaleTime,
+			tc.scaleUpDelay,
+			tc.scaleDownDelay,
+			tc.scaleDownBlocked,
+		)
+		if op != tc.expectedOperation {
+			t.Errorf("expected op=%v, got=%v", tc.expectedOperation, op)
+		}
+	}
+}
+
+// TestScaleOperationWithWPAScaleDownDelay tests the scaleDownDelaySeconds
+// of the WPA overrides the scale down delay of the controller
+func TestScaleOperationWithWPAScaleDownDelay(t *testing.T) {
+	defaultDelay := 10 * time.Minute
+	delaySeconds := int32(30)
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			ScaleDownDelaySeconds: &delaySeconds,
+		},
+	}
+
+	var opTestCases = []opTestCase{
+		{
+			current:           10,
+			desired:           5,
+			scaleDownDelay:    wpa.GetScaleDownDelay(defaultDelay),
+			lastScaleTime:     timeBeforeSeconds(10),
+			expectedOperation: controller.ScaleNoop,
+		},
+		{
+			current:           10,
+			desired:           5,
+			scaleDownDelay:    wpa.GetScaleDownDelay(defaultDelay),
+			lastScaleTime:     timeBeforeSeconds(40),
+			expectedOperation: controller.ScaleDown,
+		},
+		{
+			current:           10,
+			desired:           5,
+			scaleDownDelay:    (&v1.WorkerPodAutoScaler{}).GetScaleDownDelay(defaultDelay),
+			lastScaleTime:     timeBeforeSeconds(40),
+			expectedOperation: controller.ScaleNoop,
+		},
+	}
+
+	for _, tc := range opTestCases {
+		op := controller.GetScaleOperation(
+			"q",
+			tc.desired,
+			tc.current,
+			tc.lastScaleTime,
+			tc.scaleUpDelay,
+			tc.scaleDownDelay,
+			tc.scaleDownBlocked,
+		)
+		if op != tc.expectedOperation {
+			t.Errorf("expected op=%v, got=%v", tc.expectedOperation, op)
+		}
+	}
+}
+
+// TestScaleOperationWithScaleUpDelay tests the scale up waits for the
+// scaleUpDelaySeconds of the WPA after the last scale
+func TestScaleOperationWithScaleUpDelay(t *testing.T) {
+	delaySeconds := int32(30)
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			ScaleUpDelaySeconds: &delaySeconds,
+		},
+	}
+
+	var opTestCases = []opTestCase{
+		{
+			current:           10,
+			desired:           15,
+			scaleUpDelay:      wpa.GetScaleUpDelay(),
+			lastScaleTime:     timeBeforeSeconds(10),
+			expectedOperation: controller.ScaleNoop,
+		},
+		{
+			current:           10,
+			desired:           15,
+			scaleUpDelay:      wpa.GetScaleUpDelay(),
+			lastScaleTime:     timeBeforeSeconds(40),
+			expectedOperation: controller.ScaleUp,
+		},
+		{
+			current:           10,
+			desired:           15,
+			scaleUpDelay:      wpa.GetScaleUpDelay(),
+			lastScaleTime:     nil,
+			expectedOperation: controller.ScaleUp,
+		},
+		{
+			current:           10,
+			desired:           15,
+			scaleUpDelay:      (&v1.WorkerPodAutoScaler{}).GetScaleUpDelay(),
+			lastScaleTime:     timeBeforeSeconds(1),
+			expectedOperation: controller.ScaleUp,
+		},
+	}
+
+	for _, tc := range opTestCases {
+		op := controller.GetScaleOperation(
+			"q",
+			tc.desired,
+			tc.current,
+			tc.lastScaleTime,
+			tc.scaleUpDelay,
 			tc.scaleDownDelay,
+			tc.scaleDownBlocked,
 		)
 		if op != tc.expectedOperation {
 			t.Errorf("expected op=%v, got=%v", tc.expectedOperation, op)
@@ -0,0 +1,137 @@
+package controller
+
+// ScaleReason tells what decided the desired workers
+type ScaleReason string
+
+const (
+	// ScaleReasonBacklog is when the backlog decides the desired workers
+	ScaleReasonBacklog ScaleReason = "Backlog"
+	// ScaleReasonWithinTolerance is when the change in the workers
+	// required by the backlog is too small to scale
+	ScaleReasonWithinTolerance ScaleReason = "WithinTolerance"
+	// ScaleReasonVelocity is when the min workers raised based on the
+	// messages sent per minute decides the desired workers
+	ScaleReasonVelocity ScaleReason = "Velocity"
+	// ScaleReasonAllIdle is when all the workers are idle and
+	// are scaled down ignoring the maxDisruption
+	ScaleReasonAllIdle ScaleReason = "AllIdle"
+	// ScaleReasonNoBacklog is when there is no backlog and
+	// the workers are scaled down to the min
+	ScaleReasonNoBacklog ScaleReason = "NoBacklog"
+	// ScaleReasonMaxDisruption is when the scale down is
+	// capped by the maxDisruption
+	ScaleReasonMaxDisruption ScaleReason = "MaxDisruption"
+	// ScaleReasonMinReplicas is when the desired workers are
+	// raised to the minReplicas
+	ScaleReasonMinReplicas ScaleReason = "MinReplicas"
+	// ScaleReasonMaxReplicas is when the desired workers are
+	// capped by the maxReplicas
+	ScaleReasonMaxReplicas ScaleReason = "MaxReplicas"
+	// ScaleReasonPanic is when the backlog per worker exceeded
+	// the panicThreshold and the workers are scaled to the max
+	ScaleReasonPanic ScaleReason = "Panic"
+	// ScaleReasonScalingGroup is when the desired workers are capped
+	// by the share of the WPA in the budget of its scaling group
+	ScaleReasonScalingGroup ScaleReason = "ScalingGroup"
+	// ScaleReasonScalingStuck is when the scale up is stopped as the
+	// available workers are stalled below the current workers
+	ScaleReasonScalingStuck ScaleReason = "ScalingStuck"
+	// ScaleReasonMessageGroups is when the desired workers are capped
+	// by the message groups of the FIFO queue which can be processed at once
+	ScaleReasonMessageGroups ScaleReason = "MessageGroups"
+	// ScaleReasonWarmFloor is when there is no backlog and
+	// the desired workers are raised to the warmFloor
+	ScaleReasonWarmFloor ScaleReason = "WarmFloor"
+	// ScaleReasonThroughput is when the messages sent per minute
+	// decide the desired workers in the throughputMode
+	ScaleReasonThroughput ScaleReason = "Throughput"
+	// ScaleReasonRolloutInProgress is when the scale down is held
+	// while a rollout of the workload is in progress
+	ScaleReasonRolloutInProgress ScaleReason = "RolloutInProgress"
+	// ScaleReasonColdStart is when the scale up from zero
+	// workers is raised to the coldStartReplicas
+	ScaleReasonColdStart ScaleReason = "ColdStart"
+	// ScaleReasonWorkersCrashLooping is when the scale up is stopped
+	// as the pods of the workload are crash looping
+	ScaleReasonWorkersCrashLooping ScaleReason = "WorkersCrashLooping"
+	// ScaleReasonWorkersTerminating is when the workers are held as the
+	// pods of the earlier scale downs are still terminating
+	ScaleReasonWorkersTerminating ScaleReason = "WorkersTerminating"
+	// ScaleReasonInvalidTarget is when the targetMessagesPerWorker is
+	// not greater than 0 and the current workers are kept
+	ScaleReasonInvalidTarget ScaleReason = "InvalidTarget"
+	// ScaleReasonConservativeWindow is when the scale down is held
+	// while a conservative window of the WPA is active
+	ScaleReasonConservativeWindow ScaleReason = "ConservativeWindow"
+	// ScaleReasonHoldCurrent is when there is no backlog but the queue
+	// has throughput and the current workers are held
+	ScaleReasonHoldCurrent ScaleReason = "HoldCurrent"
+	// ScaleReasonBacklogGrowing is when the backlog and the workers
+	// required by its growth decide the desired workers
+	ScaleReasonBacklogGrowing ScaleReason = "BacklogGrowing"
+	// ScaleReasonInitialStabilization is when the workers are not
+	// scaled as the WPA was first seen within initialStabilizationSeconds
+	ScaleReasonInitialStabilization ScaleReason = "InitialStabilization"
+)
+
+// scaleOpEventReason returns the reason of the event recorded on scaling
+func scaleOpEventReason(op ScaleOperation) string {
+	if op == ScaleUp {
+		return "ScaledUp"
+	}
+	return "ScaledDown"
+}
+
+// scaleReasons is the list of all the reasons
+var scaleReasons = []ScaleReason{
+	ScaleReasonBacklog,
+	ScaleReasonWithinTolerance,
+	ScaleReasonVelocity,
+	ScaleReasonAllIdle,
+	ScaleReasonNoBacklog,
+	ScaleReasonMaxDisruption,
+	ScaleReasonMinReplicas,
+	ScaleReasonMaxReplicas,
+	ScaleReasonPanic,
+	ScaleReasonScalingGroup,
+	ScaleReasonScalingStuck,
+	ScaleReasonMessageGroups,
+	ScaleReasonWarmFloor,
+	ScaleReasonThroughput,
+	ScaleReasonRolloutInProgress,
+	ScaleReasonColdStart,
+	ScaleReasonWorkersCrashLooping,
+	ScaleReasonWorkersTerminating,
+	ScaleReasonInvalidTarget,
+	ScaleReasonConservativeWindow,
+	ScaleReasonHoldCurrent,
+	ScaleReasonBacklogGrowing,
+	ScaleReasonInitialStabilization,
+}
+
+// scaleReasonMessages describe the reasons, used in the condition
+var scaleReasonMessages = map[ScaleReason]string{
+	ScaleReasonBacklog:              "The backlog decides the desired workers",
+	ScaleReasonWithinTolerance:      "The change in workers required by the backlog is within the tolerance",
+	ScaleReasonVelocity:             "The min workers raised by the messages sent per minute decides the desired workers",
+	ScaleReasonAllIdle:              "All the workers are idle, scaling down ignoring maxDisruption",
+	ScaleReasonNoBacklog:            "There is no backlog, scaling down to the min workers",
+	ScaleReasonMaxDisruption:        "The scale down is capped by maxDisruption",
+	ScaleReasonMinReplicas:          "The desired workers are raised to minReplicas",
+	ScaleReasonMaxReplicas:          "The desired workers are capped by maxReplicas",
+	ScaleReasonPanic:                "The backlog per worker exceeded panicThreshold, scaling to maxReplicas",
+	ScaleReasonScalingGroup:         "The desired workers are capped by the share in the scaling group budget",
+	ScaleReasonScalingStuck:         "The available workers are stalled below the current workers, not scaling up",
+	ScaleReasonMessageGroups:        "The desired workers are capped by the message groups of the FIFO queue",
+	ScaleReasonWarmFloor:            "There is no backlog, the desired workers are raised to warmFloor",
+	ScaleReasonThroughput:           "The messages sent per minute decide the desired workers",
+	ScaleReasonRolloutInProgress:    "A rollout of the workload is in progress, not scaling down",
+	ScaleReasonColdStart:            "The scale up from zero workers is raised to coldStartReplicas",
+	ScaleReasonWorkersCrashLooping:  "The pods of the workload are crash looping, not scaling up",
+	ScaleReasonWorkersTerminating:   "The pods of the earlier scale downs are terminating, not scaling down further",
+	ScaleReasonInvalidTarget:        "The targetMessagesPerWorker is not greater than 0, keeping the current workers",
+	ScaleReasonConservativeWindow:   "A conservative window is active, not scaling down",
+	ScaleReasonHoldCurrent:          "There is no backlog but the queue has throughput, keeping the current workers",
+	ScaleReasonBacklogGrowing:       "The backlog is growing, scaling for the backlog and its growth",
+	ScaleReasonInitialStabilization: "The WPA was first seen within initialStabilizationSeconds, not scaling",
+}
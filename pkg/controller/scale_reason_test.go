@@ -0,0 +1,60 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/controller"
+)
+
+func TestScaleReason(t *testing.T) {
+	c := desiredWorkerTester{
+		queueName:               "q",
+		queueMessages:           100,
+		targetMessagesPerWorker: 10,
+		currentWorkers:          0,
+		idleWorkers:             0,
+		minWorkers:              0,
+		maxWorkers:              50,
+		maxDisruption:           "10%",
+	}
+
+	// scale up from zero based on the backlog
+	c.testReason(t, 10, controller.ScaleReasonBacklog)
+
+	// backlog needs 10, 10 are running
+	c.currentWorkers = 10
+	c.testReason(t, 10, controller.ScaleReasonWithinTolerance)
+
+	// backlog needs 100, capped by the max
+	c.queueMessages = 1000
+	c.testReason(t, 50, controller.ScaleReasonMaxReplicas)
+
+	// backlog needs 1, scale down is capped by the disruption
+	c.queueMessages = 10
+	c.testReason(t, 9, controller.ScaleReasonMaxDisruption)
+
+	// no backlog and not all idle, partial scale down
+	c.queueMessages = 0
+	c.idleWorkers = 5
+	c.testReason(t, 9, controller.ScaleReasonMaxDisruption)
+
+	c.maxDisruption = "100%"
+	c.testReason(t, 0, controller.ScaleReasonNoBacklog)
+
+	// all idle, massive scale down to the min
+	c.idleWorkers = 10
+	c.minWorkers = 2
+	c.testReason(t, 2, controller.ScaleReasonMinReplicas)
+
+	c.minWorkers = 0
+	c.testReason(t, 0, controller.ScaleReasonAllIdle)
+
+	// no backlog but throughput raises the min
+	c.messagesSentPerMinute = 120
+	c.secondsToProcessOneJob = 2
+	c.testReason(t, 4, controller.ScaleReasonVelocity)
+
+	// panic scales to the max
+	c.panicking = true
+	c.testReason(t, 50, controller.ScaleReasonPanic)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestNewScaleUpLimiter(t *testing.T) {
+	if limiter := NewScaleUpLimiter(0); limiter != nil {
+		t.Errorf("expected no limiter when the scale ups are not limited\n")
+	}
+	if limiter := NewScaleUpLimiter(6); limiter == nil || limiter.Burst() != 6 {
+		t.Errorf("expected a limiter with a burst of 6, got=%v\n", limiter)
+	}
+}
+
+func TestScaleUpLimiterIsSharedByTheNamespaces(t *testing.T) {
+	limiter := NewScaleUpLimiter(1)
+	newNamespaceController := func() *Controller {
+		return &Controller{
+			workqueue: workqueue.NewRateLimitingQueue(
+				workqueue.DefaultControllerRateLimiter()),
+			scaleUpLimiter: limiter,
+		}
+	}
+	payments := newNamespaceController()
+	notifications := newNamespaceController()
+	defer payments.workqueue.ShutDown()
+	defer notifications.workqueue.ShutDown()
+
+	newWPA := func(namespace string) *v1.WorkerPodAutoScaler {
+		return &v1.WorkerPodAutoScaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: namespace},
+		}
+	}
+	if payments.deferScaleUp(WokerPodAutoScalerEvent{key: "payments/otpsender"},
+		newWPA("payments")) {
+		t.Errorf("expected the first scale up to not be deferred\n")
+	}
+	if !notifications.deferScaleUp(WokerPodAutoScalerEvent{key: "notifications/otpsender"},
+		newWPA("notifications")) {
+		t.Errorf("expected the scale up of the other namespace to be deferred\n")
+	}
+}
@@ -0,0 +1,36 @@
+package controller
+
+// ScalingAlgorithm tells how the workers required by the queue are computed
+type ScalingAlgorithm string
+
+const (
+	// ScalingAlgorithmDefault computes the workers from the backlog and
+	// the targetMessagesPerWorker
+	ScalingAlgorithmDefault ScalingAlgorithm = "default"
+	// ScalingAlgorithmThroughput computes the workers from the messages
+	// sent per minute and the secondsToProcessOneJob in the throughputMode
+	ScalingAlgorithmThroughput ScalingAlgorithm = "throughput"
+	// ScalingAlgorithmBalance computes the workers from the backlog and
+	// adds the workers required by its growth in the balanceAwareScaling
+	ScalingAlgorithmBalance ScalingAlgorithm = "balance"
+)
+
+// scalingAlgorithms is the list of all the algorithms
+var scalingAlgorithms = []ScalingAlgorithm{
+	ScalingAlgorithmDefault,
+	ScalingAlgorithmThroughput,
+	ScalingAlgorithmBalance,
+}
+
+// getScalingAlgorithm returns the algorithm used for the input, the
+// throughput mode falls back to the backlog till secondsToProcessOneJob
+// is known
+func getScalingAlgorithm(input ScalingInput) ScalingAlgorithm {
+	if input.ThroughputMode && input.SecondsToProcessOneJob > 0.0 {
+		return ScalingAlgorithmThroughput
+	}
+	if input.BalanceAwareScaling {
+		return ScalingAlgorithmBalance
+	}
+	return ScalingAlgorithmDefault
+}
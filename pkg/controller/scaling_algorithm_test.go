@@ -0,0 +1,45 @@
+package controller
+
+import "testing"
+
+func TestGetScalingAlgorithm(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    ScalingInput
+		expected ScalingAlgorithm
+	}{
+		{
+			name:     "backlog",
+			input:    ScalingInput{SecondsToProcessOneJob: 2},
+			expected: ScalingAlgorithmDefault,
+		},
+		{
+			name:     "throughput mode",
+			input:    ScalingInput{ThroughputMode: true, SecondsToProcessOneJob: 2},
+			expected: ScalingAlgorithmThroughput,
+		},
+		{
+			name:     "throughput mode without the processing time",
+			input:    ScalingInput{ThroughputMode: true},
+			expected: ScalingAlgorithmDefault,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if algorithm := getScalingAlgorithm(test.input); algorithm != test.expected {
+				t.Errorf("expected %s, got=%s", test.expected, algorithm)
+			}
+			if result := ComputeDesired(withBounds(test.input)); result.Algorithm != test.expected {
+				t.Errorf("expected the result to use %s, got=%s", test.expected, result.Algorithm)
+			}
+		})
+	}
+}
+
+func withBounds(input ScalingInput) ScalingInput {
+	input.TargetMessagesPerWorker = 10
+	input.MaxWorkers = 10
+	input.MaxDisruption = "100%"
+	return input
+}
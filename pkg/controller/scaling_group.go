@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"github.com/practo/klog/v2"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// allocateScalingGroup caps the desired workers of the WPA to its share
+// of the scaling group budget. The desired workers of every WPA is kept
+// as its demand and the budget is split in proportion to the demands
+// when their sum is above the budget.
+func (c *Controller) allocateScalingGroup(
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	desiredWorkers int32,
+	reason ScaleReason) (int32, ScaleReason) {
+
+	scalingGroup := workerPodAutoScaler.Spec.ScalingGroup
+	if scalingGroup == nil {
+		c.groupDemand.Delete(key)
+		return desiredWorkers, reason
+	}
+
+	previous, loaded := c.groupDemand.Load(key)
+	demandChanged := !loaded || previous.(int32) != desiredWorkers
+	c.groupDemand.Store(key, desiredWorkers)
+
+	budget, totalDemand, memberKeys, err := c.getScalingGroupDemand(
+		key, workerPodAutoScaler, desiredWorkers)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return desiredWorkers, reason
+	}
+	// the other members reconcile to take their new share
+	if demandChanged {
+		for _, memberKey := range memberKeys {
+			c.workqueue.Add(WokerPodAutoScalerEvent{
+				key:  memberKey,
+				name: WokerPodAutoScalerEventUpdate,
+			})
+		}
+	}
+
+	share := getScalingGroupShare(
+		desiredWorkers,
+		totalDemand,
+		budget,
+		*workerPodAutoScaler.Spec.MinReplicas,
+	)
+	if share >= desiredWorkers {
+		return desiredWorkers, reason
+	}
+
+	klog.V(2).Infof("%s: scaling group %s demand: %d, budget: %d, desired: %d, share: %d",
+		key, scalingGroup.Name, totalDemand, budget, desiredWorkers, share)
+	return share, ScaleReasonScalingGroup
+}
+
+// getScalingGroupDemand returns the budget and the total demand of the
+// scaling group of the WPA, the desired workers are used as the demand of
+// the WPA. It returns the keys of the other members of the group.
+func (c *Controller) getScalingGroupDemand(
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	desiredWorkers int32) (int32, int32, []string, error) {
+
+	scalingGroup := workerPodAutoScaler.Spec.ScalingGroup
+	members, err := c.workerPodAutoScalersIndexer.ByIndex(scalingGroupIndex,
+		getKey(workerPodAutoScaler.Namespace, scalingGroup.Name))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	budget := scalingGroup.MaxReplicas
+	totalDemand := desiredWorkers
+	var memberKeys []string
+	for _, obj := range members {
+		member := obj.(*v1.WorkerPodAutoScaler)
+		memberKey := c.getKeyForWorkerPodAutoScaler(member)
+		if memberKey == key {
+			continue
+		}
+		if member.Spec.ScalingGroup.MaxReplicas < budget {
+			budget = member.Spec.ScalingGroup.MaxReplicas
+		}
+		if demand, ok := c.groupDemand.Load(memberKey); ok {
+			totalDemand += demand.(int32)
+		}
+		memberKeys = append(memberKeys, memberKey)
+	}
+	return budget, totalDemand, memberKeys, nil
+}
+
+// getScalingGroupShare returns the share of the demand in the budget,
+// the share is never below the min workers
+func getScalingGroupShare(
+	demand int32,
+	totalDemand int32,
+	budget int32,
+	minWorkers int32) int32 {
+
+	if totalDemand <= budget {
+		return demand
+	}
+
+	share := int32(int64(budget) * int64(demand) / int64(totalDemand))
+	if share < minWorkers {
+		return minWorkers
+	}
+	return share
+}
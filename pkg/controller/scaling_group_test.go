@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestGetScalingGroupShare(t *testing.T) {
+	tests := []struct {
+		name        string
+		demand      int32
+		totalDemand int32
+		budget      int32
+		minWorkers  int32
+		expected    int32
+	}{
+		{"within budget", 30, 50, 100, 0, 30},
+		{"proportional share", 30, 150, 100, 0, 20},
+		{"share is floored", 10, 30, 20, 0, 6},
+		{"share is not below min", 1, 100, 50, 2, 2},
+		{"zero budget", 10, 20, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		share := getScalingGroupShare(
+			test.demand, test.totalDemand, test.budget, test.minWorkers)
+		if share != test.expected {
+			t.Errorf("%s: expected share=%d, got=%d\n",
+				test.name, test.expected, share)
+		}
+	}
+}
+
+func scalingGroupWorkerPodAutoScaler(
+	name string, group string, maxReplicas int32) *v1.WorkerPodAutoScaler {
+
+	minReplicas := int32(0)
+	return &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "testns"},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			MinReplicas: &minReplicas,
+			ScalingGroup: &v1.ScalingGroup{
+				Name:        group,
+				MaxReplicas: maxReplicas,
+			},
+		},
+	}
+}
+
+func TestAllocateScalingGroup(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		scalingGroupIndex: indexByScalingGroup,
+	})
+	videos := scalingGroupWorkerPodAutoScaler("videos", "encoders", 100)
+	images := scalingGroupWorkerPodAutoScaler("images", "encoders", 80)
+	mailer := scalingGroupWorkerPodAutoScaler("mailer", "mailers", 10)
+	for _, wpa := range []*v1.WorkerPodAutoScaler{videos, images, mailer} {
+		if err := indexer.Add(wpa); err != nil {
+			t.Fatalf("Error adding wpa to the indexer: %v\n", err)
+		}
+	}
+
+	c := &Controller{
+		workerPodAutoScalersIndexer: indexer,
+		workqueue:                   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		groupDemand:                 new(sync.Map),
+	}
+	defer c.workqueue.ShutDown()
+
+	// the demand of images is not known yet
+	desired, reason := c.allocateScalingGroup(
+		"testns/videos", videos, 60, ScaleReasonBacklog)
+	if desired != 60 || reason != ScaleReasonBacklog {
+		t.Errorf("expected 60 Backlog, got=%d %s\n", desired, reason)
+	}
+
+	// 60+100 is above the smallest budget 80
+	desired, reason = c.allocateScalingGroup(
+		"testns/images", images, 100, ScaleReasonBacklog)
+	if desired != 50 || reason != ScaleReasonScalingGroup {
+		t.Errorf("expected 50 ScalingGroup, got=%d %s\n", desired, reason)
+	}
+	// the change in the demand of both queued the other
+	if c.workqueue.Len() != 2 {
+		t.Errorf("expected videos and images to be queued, queue len=%d\n",
+			c.workqueue.Len())
+	}
+
+	desired, reason = c.allocateScalingGroup(
+		"testns/videos", videos, 60, ScaleReasonBacklog)
+	if desired != 30 || reason != ScaleReasonScalingGroup {
+		t.Errorf("expected 30 ScalingGroup, got=%d %s\n", desired, reason)
+	}
+
+	// other groups do not share the budget
+	desired, reason = c.allocateScalingGroup(
+		"testns/mailer", mailer, 10, ScaleReasonMaxReplicas)
+	if desired != 10 || reason != ScaleReasonMaxReplicas {
+		t.Errorf("expected 10 MaxReplicas, got=%d %s\n", desired, reason)
+	}
+}
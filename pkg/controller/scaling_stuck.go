@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// stall is the time since which the available replicas of the
+// workload are below its replicas without increasing
+type stall struct {
+	since     time.Time
+	available int32
+}
+
+// getStall returns the time since which the available workers are
+// stalled below the current workers. The stall starts over whenever
+// the available workers increase. It returns false if not stalled.
+func (c *Controller) getStall(
+	key string,
+	currentWorkers int32,
+	availableWorkers int32,
+	now time.Time) (time.Time, bool) {
+
+	if availableWorkers >= currentWorkers {
+		c.stalls.Delete(key)
+		return time.Time{}, false
+	}
+
+	if obj, ok := c.stalls.Load(key); ok {
+		existing := obj.(stall)
+		if availableWorkers <= existing.available {
+			return existing.since, true
+		}
+	}
+	c.stalls.Store(key, stall{since: now, available: availableWorkers})
+	return now, true
+}
+
+// checkScalingStuck reports the available workers stalled below the
+// current workers for longer than the scaling stuck window using a
+// warning event and the ScalingStuck condition. It returns true if the
+// WPA should not scale up, as more replicas would not become available.
+func (c *Controller) checkScalingStuck(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	targetKind string,
+	targetName string,
+	currentWorkers int32,
+	availableWorkers int32,
+	now time.Time) (*v1.WorkerPodAutoScaler, bool) {
+
+	if c.scalingStuckWindow == 0 {
+		return workerPodAutoScaler, false
+	}
+
+	existing := meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionScalingStuck)
+	since, stalled := c.getStall(key, currentWorkers, availableWorkers, now)
+	if !stalled || now.Sub(since) < c.scalingStuckWindow {
+		if existing == nil || existing.Status == metav1.ConditionFalse {
+			return workerPodAutoScaler, false
+		}
+		return updateWorkerPodAutoScalerCondition(
+			ctx,
+			c.customclientset,
+			workerPodAutoScaler,
+			metav1.Condition{
+				Type:    v1.ConditionScalingStuck,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ReplicasAvailable",
+				Message: fmt.Sprintf("Replicas of %s %s are becoming available", targetKind, targetName),
+			},
+		), false
+	}
+
+	message := fmt.Sprintf(
+		"%d of %d replicas of %s %s available since %s, not scaling up",
+		availableWorkers, currentWorkers, targetKind, targetName,
+		since.UTC().Format(time.RFC3339))
+	klog.Warningf("%s: %s", key, message)
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		c.recorder.Event(workerPodAutoScaler, corev1.EventTypeWarning,
+			v1.ConditionScalingStuck, message)
+	}
+	return updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		metav1.Condition{
+			Type:    v1.ConditionScalingStuck,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ReplicasUnavailable",
+			Message: message,
+		},
+	), true
+}
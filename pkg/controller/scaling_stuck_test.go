@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetStall(t *testing.T) {
+	c := &Controller{stalls: new(sync.Map)}
+	start := time.Now()
+
+	if _, stalled := c.getStall("ns/wpa", 5, 5, start); stalled {
+		t.Errorf("expected not stalled when all workers are available")
+	}
+
+	since, stalled := c.getStall("ns/wpa", 5, 2, start)
+	if !stalled || !since.Equal(start) {
+		t.Errorf("expected stall since %v, got %v, %v", start, since, stalled)
+	}
+
+	since, _ = c.getStall("ns/wpa", 8, 2, start.Add(time.Minute))
+	if !since.Equal(start) {
+		t.Errorf("expected the stall to continue since %v, got %v", start, since)
+	}
+
+	later := start.Add(2 * time.Minute)
+	since, _ = c.getStall("ns/wpa", 8, 3, later)
+	if !since.Equal(later) {
+		t.Errorf("expected the stall to start over at %v, got %v", later, since)
+	}
+
+	if _, stalled := c.getStall("ns/wpa", 8, 8, later); stalled {
+		t.Errorf("expected the stall to end when all workers are available")
+	}
+	if _, ok := c.stalls.Load("ns/wpa"); ok {
+		t.Errorf("expected the stall to be deleted")
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/schedule"
+)
+
+// applySchedules returns the min and max workers of the WPA at now, the
+// bounds of the first active schedule override the bounds of the spec.
+// The active schedule is reported in the ScheduleActive condition and
+// the wpa_schedule_active metric.
+func (c *Controller) applySchedules(
+	ctx context.Context,
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	metricQueueName string,
+	now time.Time) (*v1.WorkerPodAutoScaler, int32, int32) {
+
+	minWorkers := *workerPodAutoScaler.Spec.MinReplicas
+	maxWorkers := *workerPodAutoScaler.Spec.MaxReplicas
+	rules := workerPodAutoScaler.Spec.Schedules
+	if len(rules) == 0 && meta.FindStatusCondition(
+		workerPodAutoScaler.Status.Conditions, v1.ConditionScheduleActive) == nil {
+		return workerPodAutoScaler, minWorkers, maxWorkers
+	}
+
+	active, minWorkers, maxWorkers, errs := getActiveBounds(workerPodAutoScaler, now)
+	for _, err := range errs {
+		utilruntime.HandleError(fmt.Errorf("%s: %v", key, err))
+	}
+	if !c.lowCardinalityMetrics {
+		names := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			var value float64
+			if active != nil && rule.Name == active.Name {
+				value = 1
+			}
+			scheduleActive.WithLabelValues(
+				workerPodAutoScaler.Name,
+				workerPodAutoScaler.Namespace,
+				metricQueueName,
+				rule.Name,
+			).Set(value)
+			names = append(names, rule.Name)
+		}
+		c.setSchedules(key, names)
+	}
+
+	condition := metav1.Condition{
+		Type:    v1.ConditionScheduleActive,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoScheduleActive",
+		Message: "The replica bounds of the spec are used",
+	}
+	if active != nil {
+		condition = metav1.Condition{
+			Type:   v1.ConditionScheduleActive,
+			Status: metav1.ConditionTrue,
+			Reason: "ScheduleActive",
+			Message: fmt.Sprintf("Schedule %s is active, min=%d, max=%d",
+				active.Name, minWorkers, maxWorkers),
+		}
+	}
+	workerPodAutoScaler = updateWorkerPodAutoScalerCondition(
+		ctx,
+		c.customclientset,
+		workerPodAutoScaler,
+		condition,
+	)
+	return workerPodAutoScaler, minWorkers, maxWorkers
+}
+
+// getActiveBounds returns the active schedule of the WPA at now and
+// the min and max workers overridden by it
+func getActiveBounds(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	now time.Time) (*v1.ScheduleRule, int32, int32, []error) {
+
+	minWorkers := *workerPodAutoScaler.Spec.MinReplicas
+	maxWorkers := *workerPodAutoScaler.Spec.MaxReplicas
+	active, errs := schedule.Active(workerPodAutoScaler.Spec.Schedules, now)
+	if active != nil {
+		minWorkers, maxWorkers = getScheduledBounds(*active, minWorkers, maxWorkers)
+	}
+	return active, minWorkers, maxWorkers, errs
+}
+
+// getScheduledBounds returns the bounds overridden by the rule,
+// the bounds which the rule does not set are kept
+func getScheduledBounds(
+	rule v1.ScheduleRule, minWorkers int32, maxWorkers int32) (int32, int32) {
+
+	if rule.MinReplicas != nil {
+		minWorkers = *rule.MinReplicas
+	}
+	if rule.MaxReplicas != nil {
+		maxWorkers = *rule.MaxReplicas
+	}
+	return minWorkers, maxWorkers
+}
@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"testing"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestGetScheduledBounds(t *testing.T) {
+	five := int32(5)
+	minWorkers, maxWorkers := getScheduledBounds(
+		v1.ScheduleRule{MinReplicas: &five}, 0, 20)
+	if minWorkers != 5 || maxWorkers != 20 {
+		t.Errorf("expected min=5, max=20, got min=%v, max=%v\n", minWorkers, maxWorkers)
+	}
+
+	minWorkers, maxWorkers = getScheduledBounds(
+		v1.ScheduleRule{MaxReplicas: &five}, 0, 20)
+	if minWorkers != 0 || maxWorkers != 5 {
+		t.Errorf("expected min=0, max=5, got min=%v, max=%v\n", minWorkers, maxWorkers)
+	}
+}
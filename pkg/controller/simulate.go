@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/schedule"
+)
+
+// SimulationSample is the queue at a point of time of a recorded backlog
+type SimulationSample struct {
+	Time                  time.Time
+	QueueMessages         int32
+	MessagesSentPerMinute float64
+	// IdleWorkers is queue.UnsyncedIdleWorkers when it was not recorded
+	IdleWorkers int32
+}
+
+// SimulationOptions are the controller settings used by the simulation
+type SimulationOptions struct {
+	// InitialReplicas is the workers at the first sample,
+	// minReplicas is used when it is negative
+	InitialReplicas       int32
+	DefaultMaxDisruption  string
+	DefaultScaleDownDelay time.Duration
+	// Tolerance is the relative change of the workers which is ignored,
+	// the default of the controller is used when it is not set
+	Tolerance float64
+}
+
+// SimulationStep is the decision of the controller for a sample
+type SimulationStep struct {
+	Time                     time.Time   `json:"time"`
+	QueueMessages            int32       `json:"queueMessages"`
+	MessagesSentPerMinute    float64     `json:"messagesSentPerMinute"`
+	CurrentReplicas          int32       `json:"currentReplicas"`
+	DesiredReplicas          int32       `json:"desiredReplicas"`
+	UnclampedDesiredReplicas int32       `json:"unclampedDesiredReplicas"`
+	Reason                   ScaleReason `json:"reason"`
+	Operation                string      `json:"operation"`
+	// Replicas is the workers after the operation, the workload
+	// is assumed to reach the desired workers before the next sample
+	Replicas int32 `json:"replicas"`
+}
+
+// Simulate replays the samples through the scaling of the WPA without a
+// cluster and returns the decision taken for every sample. The schedules,
+// the target override, the panic mode and the scale delays are evaluated
+// at the time of the samples.
+func Simulate(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	samples []SimulationSample,
+	options SimulationOptions) ([]SimulationStep, error) {
+
+	spec := workerPodAutoScaler.Spec
+	if spec.MinReplicas == nil || spec.MaxReplicas == nil || spec.TargetMessagesPerWorker == nil {
+		return nil, fmt.Errorf("minReplicas, maxReplicas and targetMessagesPerWorker must be set")
+	}
+	if *spec.TargetMessagesPerWorker <= 0 {
+		return nil, fmt.Errorf("targetMessagesPerWorker must be greater than 0")
+	}
+
+	queueName := spec.QueueURI
+	secondsToProcessOneJob := 0.0
+	if spec.SecondsToProcessOneJob != nil {
+		secondsToProcessOneJob = *spec.SecondsToProcessOneJob
+	}
+	scaleDownDelay := workerPodAutoScaler.GetScaleDownDelay(options.DefaultScaleDownDelay)
+
+	currentWorkers := options.InitialReplicas
+	if currentWorkers < 0 {
+		currentWorkers = *spec.MinReplicas
+	}
+	var lastScaleTime *metav1.Time
+	var panicUntil time.Time
+
+	steps := make([]SimulationStep, 0, len(samples))
+	for _, sample := range samples {
+		now := sample.Time
+
+		panicking := false
+		if isAbovePanicThreshold(workerPodAutoScaler, sample.QueueMessages, currentWorkers) {
+			panicUntil = now.Add(workerPodAutoScaler.GetPanicWindow())
+			panicking = true
+		} else if now.Before(panicUntil) {
+			panicking = true
+		}
+
+		_, minWorkers, maxWorkers, errs := getActiveBounds(workerPodAutoScaler, now)
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+		conservativeWindow, errs := schedule.ActiveWindow(spec.ConservativeWindows, now)
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+		targetMessagesPerWorker, _, overridden, err := getTargetOverride(workerPodAutoScaler, now)
+		if err != nil {
+			return nil, err
+		}
+		if !overridden {
+			targetMessagesPerWorker = *spec.TargetMessagesPerWorker
+		}
+
+		result := ComputeDesired(ScalingInput{
+			QueueName:                 queueName,
+			QueueMessages:             sample.QueueMessages,
+			MessagesSentPerMinute:     sample.MessagesSentPerMinute,
+			SecondsToProcessOneJob:    secondsToProcessOneJob,
+			TargetMessagesPerWorker:   targetMessagesPerWorker,
+			CurrentWorkers:            currentWorkers,
+			IdleWorkers:               sample.IdleWorkers,
+			MinWorkers:                minWorkers,
+			MaxWorkers:                maxWorkers,
+			MaxDisruption:             *workerPodAutoScaler.GetMaxDisruption(options.DefaultMaxDisruption),
+			DisableVelocityMinWorkers: workerPodAutoScaler.GetDisableVelocityMinWorkers(),
+			RespectDisruptionOnIdle:   workerPodAutoScaler.GetRespectDisruptionOnIdle(),
+			Panicking:                 panicking,
+			WarmFloor:                 workerPodAutoScaler.GetWarmFloor(),
+			ThroughputMode:            workerPodAutoScaler.GetThroughputMode(),
+			ColdStartReplicas:         workerPodAutoScaler.GetColdStartReplicas(),
+			RoundingStrategy:          workerPodAutoScaler.GetRoundingStrategy(),
+			NoBacklogStrategy:         workerPodAutoScaler.GetNoBacklogStrategy(),
+			Tolerance:                 getConservativeTolerance(conservativeWindow, options.Tolerance),
+		})
+		desiredWorkers, reason := holdConservativeScaleDown(
+			conservativeWindow, result.DesiredWorkers, currentWorkers, result.Reason)
+		result.DesiredWorkers, result.Reason = desiredWorkers, reason
+
+		scaleUpDelay := workerPodAutoScaler.GetScaleUpDelay()
+		if panicking {
+			scaleUpDelay = 0
+		}
+		op := getScaleOperationAt(
+			queueName,
+			result.DesiredWorkers,
+			currentWorkers,
+			lastScaleTime,
+			scaleUpDelay,
+			scaleDownDelay,
+			false,
+			now,
+		)
+
+		step := SimulationStep{
+			Time:                     now,
+			QueueMessages:            sample.QueueMessages,
+			MessagesSentPerMinute:    sample.MessagesSentPerMinute,
+			CurrentReplicas:          currentWorkers,
+			DesiredReplicas:          result.DesiredWorkers,
+			UnclampedDesiredReplicas: result.UnclampedDesiredWorkers,
+			Reason:                   result.Reason,
+			Operation:                scaleOpString(op),
+			Replicas:                 currentWorkers,
+		}
+		if op == ScaleUp || op == ScaleDown {
+			currentWorkers = result.DesiredWorkers
+			scaleTime := metav1.NewTime(now)
+			lastScaleTime = &scaleTime
+			step.Replicas = currentWorkers
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+)
+
+func TestSimulate(t *testing.T) {
+	minReplicas := int32(0)
+	maxReplicas := int32(10)
+	target := int32(10)
+	scaleDownDelay := int32(60)
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			MinReplicas:             &minReplicas,
+			MaxReplicas:             &maxReplicas,
+			TargetMessagesPerWorker: &target,
+			ScaleDownDelaySeconds:   &scaleDownDelay,
+		},
+	}
+
+	start := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	messages := []int32{0, 100, 50, 0}
+	samples := make([]SimulationSample, 0, len(messages))
+	for i, m := range messages {
+		samples = append(samples, SimulationSample{
+			Time:          start.Add(time.Duration(i) * 30 * time.Second),
+			QueueMessages: m,
+			IdleWorkers:   queue.UnsyncedIdleWorkers,
+		})
+	}
+
+	steps, err := Simulate(wpa, samples, SimulationOptions{
+		InitialReplicas:      -1,
+		DefaultMaxDisruption: "100%",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		desired  int32
+		op       ScaleOperation
+		replicas int32
+	}{
+		{0, ScaleNoop, 0},
+		{10, ScaleUp, 10},
+		// the scale down waits for the delay after the scale up
+		{5, ScaleNoop, 10},
+		{0, ScaleDown, 0},
+	}
+	if len(steps) != len(expected) {
+		t.Fatalf("expected %d steps, got=%d", len(expected), len(steps))
+	}
+	for i, e := range expected {
+		step := steps[i]
+		if step.DesiredReplicas != e.desired ||
+			step.Operation != scaleOpString(e.op) ||
+			step.Replicas != e.replicas {
+			t.Errorf("step %d: expected desired=%d op=%s replicas=%d, got=%+v",
+				i, e.desired, scaleOpString(e.op), e.replicas, step)
+		}
+	}
+}
+
+func TestSimulateTolerance(t *testing.T) {
+	minReplicas := int32(0)
+	maxReplicas := int32(20)
+	target := int32(10)
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			MinReplicas:             &minReplicas,
+			MaxReplicas:             &maxReplicas,
+			TargetMessagesPerWorker: &target,
+		},
+	}
+	samples := []SimulationSample{{
+		Time:          time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC),
+		QueueMessages: 120,
+		IdleWorkers:   queue.UnsyncedIdleWorkers,
+	}}
+
+	for _, test := range []struct {
+		tolerance float64
+		expected  int32
+	}{
+		{0, 12},
+		{0.5, 10},
+	} {
+		steps, err := Simulate(wpa, samples, SimulationOptions{
+			InitialReplicas:      10,
+			DefaultMaxDisruption: "100%",
+			Tolerance:            test.tolerance,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if steps[0].Replicas != test.expected {
+			t.Errorf("tolerance %v: expected %d replicas, got=%d",
+				test.tolerance, test.expected, steps[0].Replicas)
+		}
+	}
+}
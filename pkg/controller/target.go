@@ -0,0 +1,462 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/practo/klog/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+// targetResolutionError is returned when the targetRef of the WPA does
+// not resolve to exactly one workload. It is reported in the
+// TargetResolved condition of the WPA.
+type targetResolutionError struct {
+	reason  string
+	message string
+}
+
+func (e *targetResolutionError) Error() string {
+	return e.message
+}
+
+// resolveTarget returns the kind and the name of the workload which is
+// scaled by the WPA. The label selector of the targetRef is resolved
+// using the listers and must match exactly one workload. The kind of the
+// scaleTargetRef is returned as Kind.version.group, see getScaleTargetKind.
+// The first of the weightedTargets is returned for the checks of the
+// workload, all of them are scaled.
+func (c *Controller) resolveTarget(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler) (string, string, error) {
+
+	spec := workerPodAutoScaler.Spec
+	if len(spec.WeightedTargets) > 0 {
+		return spec.WeightedTargets[0].Kind, spec.WeightedTargets[0].Name, nil
+	}
+	if spec.DeploymentName != "" {
+		return v1.TargetKindDeployment, spec.DeploymentName, nil
+	}
+	if spec.ReplicaSetName != "" {
+		return v1.TargetKindReplicaSet, spec.ReplicaSetName, nil
+	}
+	if spec.ScaleTargetRef != nil {
+		return getScaleTargetKind(spec.ScaleTargetRef), spec.ScaleTargetRef.Name, nil
+	}
+	if spec.TargetRef == nil {
+		return "", "", nil
+	}
+	if spec.TargetRef.LabelSelector == nil {
+		return spec.TargetRef.Kind, spec.TargetRef.Name, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(spec.TargetRef.LabelSelector)
+	if err != nil {
+		return "", "", &targetResolutionError{
+			reason:  "InvalidSelector",
+			message: fmt.Sprintf("invalid labelSelector: %v", err),
+		}
+	}
+
+	names, err := c.listTargetNames(
+		workerPodAutoScaler.Namespace, spec.TargetRef.Kind, selector)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch len(names) {
+	case 0:
+		return "", "", &targetResolutionError{
+			reason: "NoMatch",
+			message: fmt.Sprintf("no %s matches the labelSelector %q",
+				spec.TargetRef.Kind, selector.String()),
+		}
+	case 1:
+		return spec.TargetRef.Kind, names[0], nil
+	}
+
+	sort.Strings(names)
+	return "", "", &targetResolutionError{
+		reason: "AmbiguousMatch",
+		message: fmt.Sprintf("%d %ss match the labelSelector %q: %s",
+			len(names), spec.TargetRef.Kind, selector.String(),
+			strings.Join(names, ", ")),
+	}
+}
+
+// listTargetNames lists the names of the workloads of the kind which
+// match the selector. Workloads being deleted are ignored so that the
+// old workload does not make the match ambiguous during a rollout.
+func (c *Controller) listTargetNames(
+	namespace string, kind string, selector labels.Selector) ([]string, error) {
+
+	var objs []metav1.Object
+	switch kind {
+	case v1.TargetKindDeployment:
+		deployments, err := c.deploymentLister.Deployments(namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, deployment := range deployments {
+			objs = append(objs, deployment)
+		}
+	case v1.TargetKindReplicaSet:
+		replicaSets, err := c.replicaSetLister.ReplicaSets(namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, replicaSet := range replicaSets {
+			objs = append(objs, replicaSet)
+		}
+	case v1.TargetKindStatefulSet:
+		statefulSets, err := c.statefulSetLister.StatefulSets(namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, statefulSet := range statefulSets {
+			objs = append(objs, statefulSet)
+		}
+	default:
+		return nil, &targetResolutionError{
+			reason:  "UnsupportedKind",
+			message: fmt.Sprintf("unsupported targetRef kind %q", kind),
+		}
+	}
+
+	names := []string{}
+	for _, obj := range objs {
+		if obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		names = append(names, obj.GetName())
+	}
+	return names, nil
+}
+
+// getTargetReplicas returns the spec replicas and the available replicas
+// of the workload, the available replicas are capped at the spec replicas
+func (c *Controller) getTargetReplicas(
+	namespace string, kind string, name string) (int32, int32, error) {
+
+	replicas, available, err := c.getTargetSpecAndAvailableReplicas(
+		namespace, kind, name)
+	if err != nil {
+		return 0, 0, err
+	}
+	return replicas, capAvailableReplicas(replicas, available), nil
+}
+
+// capAvailableReplicas caps the available replicas at the spec replicas.
+// Right after a scale down the pods being terminated are still available
+// till the workload controller observes the lowered spec replicas, the
+// available replicas would momentarily exceed the replicas.
+func capAvailableReplicas(replicas int32, available int32) int32 {
+	if available > replicas {
+		return replicas
+	}
+	return available
+}
+
+// getTargetSpecAndAvailableReplicas returns the spec replicas and the
+// available replicas of the workload as they are in the workload. The
+// informer cache lags behind the workloads created right before their
+// WPA, the workload missing in the cache is read from the api server
+// and it is not found only if it is missing there as well.
+func (c *Controller) getTargetSpecAndAvailableReplicas(
+	namespace string, kind string, name string) (int32, int32, error) {
+
+	switch kind {
+	case v1.TargetKindDeployment:
+		deployment, err := c.deploymentLister.Deployments(namespace).Get(name)
+		if errors.IsNotFound(err) {
+			deployment, err = c.kubeclientset.AppsV1().Deployments(namespace).Get(
+				context.TODO(), name, metav1.GetOptions{})
+		}
+		if errors.IsNotFound(err) {
+			return 0, 0, fmt.Errorf("deployment %s not found in namespace %s",
+				name, namespace)
+		} else if err != nil {
+			return 0, 0, err
+		}
+		return *deployment.Spec.Replicas, deployment.Status.AvailableReplicas, nil
+	case v1.TargetKindReplicaSet:
+		replicaSet, err := c.replicaSetLister.ReplicaSets(namespace).Get(name)
+		if errors.IsNotFound(err) {
+			replicaSet, err = c.kubeclientset.AppsV1().ReplicaSets(namespace).Get(
+				context.TODO(), name, metav1.GetOptions{})
+		}
+		if errors.IsNotFound(err) {
+			return 0, 0, fmt.Errorf("ReplicaSet %s not found in namespace %s",
+				name, namespace)
+		} else if err != nil {
+			return 0, 0, err
+		}
+		return *replicaSet.Spec.Replicas, replicaSet.Status.AvailableReplicas, nil
+	case v1.TargetKindStatefulSet:
+		statefulSet, err := c.statefulSetLister.StatefulSets(namespace).Get(name)
+		if errors.IsNotFound(err) {
+			statefulSet, err = c.kubeclientset.AppsV1().StatefulSets(namespace).Get(
+				context.TODO(), name, metav1.GetOptions{})
+		}
+		if errors.IsNotFound(err) {
+			return 0, 0, fmt.Errorf("StatefulSet %s not found in namespace %s",
+				name, namespace)
+		} else if err != nil {
+			return 0, 0, err
+		}
+		// StatefulSet status has no available replicas in apps/v1
+		return *statefulSet.Spec.Replicas, statefulSet.Status.ReadyReplicas, nil
+	}
+	if gvk, ok := parseScaleTargetKind(kind); ok {
+		scale, err := c.getScale(context.TODO(), namespace, gvk, name)
+		if err != nil {
+			return 0, 0, err
+		}
+		// the scale subresource has no available replicas
+		return scale.Spec.Replicas, scale.Status.Replicas, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported target kind %q", kind)
+}
+
+// getTargetSelector returns the pod selector of the workload
+func (c *Controller) getTargetSelector(
+	namespace string, kind string, name string) (labels.Selector, error) {
+
+	var selector *metav1.LabelSelector
+	switch kind {
+	case v1.TargetKindDeployment:
+		deployment, err := c.deploymentLister.Deployments(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		selector = deployment.Spec.Selector
+	case v1.TargetKindReplicaSet:
+		replicaSet, err := c.replicaSetLister.ReplicaSets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		selector = replicaSet.Spec.Selector
+	case v1.TargetKindStatefulSet:
+		statefulSet, err := c.statefulSetLister.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		selector = statefulSet.Spec.Selector
+	default:
+		gvk, ok := parseScaleTargetKind(kind)
+		if !ok {
+			return nil, fmt.Errorf("unsupported target kind %q", kind)
+		}
+		scale, err := c.getScale(context.TODO(), namespace, gvk, name)
+		if err != nil {
+			return nil, err
+		}
+		// an empty selector would select all the pods of the namespace
+		if scale.Status.Selector == "" {
+			return nil, fmt.Errorf("%s %s has no selector in its scale subresource",
+				gvk.Kind, name)
+		}
+		return labels.Parse(scale.Status.Selector)
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+// updateTarget updates the workload with the desired number of replicas
+func (c *Controller) updateTarget(
+	ctx context.Context,
+	namespace string,
+	kind string,
+	name string,
+	replicas *int32) error {
+
+	switch kind {
+	case v1.TargetKindDeployment:
+		return c.updateDeployment(ctx, namespace, name, replicas)
+	case v1.TargetKindReplicaSet:
+		return c.updateReplicaSet(ctx, namespace, name, replicas)
+	case v1.TargetKindStatefulSet:
+		return c.updateStatefulSet(ctx, namespace, name, replicas)
+	}
+	if gvk, ok := parseScaleTargetKind(kind); ok {
+		return c.updateScale(ctx, namespace, gvk, name, replicas)
+	}
+	return fmt.Errorf("unsupported target kind %q", kind)
+}
+
+// updateStatefulSet updates the StatefulSet with the desired number of replicas
+func (c *Controller) updateStatefulSet(ctx context.Context, namespace string, statefulSetName string, replicas *int32) error {
+	ctx, span := tracing.Tracer().Start(ctx, "updateStatefulSet")
+	defer span.End()
+
+	attempt := 0
+	retryErr := retry.RetryOnConflict(c.updateRetry, func() error {
+		attempt++
+		// Retrieve the latest version of the StatefulSet before attempting update
+		statefulSet, getErr := c.statefulSetLister.StatefulSets(namespace).Get(statefulSetName)
+		if errors.IsNotFound(getErr) {
+			return fmt.Errorf("StatefulSet %s was not found in namespace %s",
+				statefulSetName, namespace)
+		}
+		if getErr != nil {
+			return fmt.Errorf("failed to get StatefulSet: %v", getErr)
+		}
+
+		statefulSetCopy := statefulSet.DeepCopy()
+		statefulSetCopy.Spec.Replicas = replicas
+		_, updateErr := c.kubeclientset.AppsV1().StatefulSets(namespace).Update(ctx, statefulSetCopy, metav1.UpdateOptions{})
+		if errors.IsConflict(updateErr) {
+			klog.V(2).Infof("%s/%s: conflict updating StatefulSet, attempt: %d, err: %v",
+				namespace, statefulSetName, attempt, updateErr)
+		} else if updateErr != nil {
+			klog.Errorf("Failed to update StatefulSet: %v", updateErr)
+		}
+		return updateErr
+	})
+	if retryErr != nil {
+		return fmt.Errorf("failed to update StatefulSet (retry failed): %v", retryErr)
+	}
+	return nil
+}
+
+// getScaleTargetKind returns the kind of the workload referenced by the
+// scaleTargetRef. The Deployment, ReplicaSet and StatefulSet of apps/v1
+// are read using the listers, the other kinds are returned as
+// Kind.version.group and are scaled using the scale subresource.
+func getScaleTargetKind(scaleTargetRef *v1.ScaleTargetRef) string {
+	gv, err := schema.ParseGroupVersion(scaleTargetRef.APIVersion)
+	if err != nil {
+		return scaleTargetRef.Kind
+	}
+	if gv == appsv1.SchemeGroupVersion {
+		switch scaleTargetRef.Kind {
+		case v1.TargetKindDeployment, v1.TargetKindReplicaSet, v1.TargetKindStatefulSet:
+			return scaleTargetRef.Kind
+		}
+	}
+	return scaleTargetRef.Kind + "." + gv.Version + "." + gv.Group
+}
+
+// parseScaleTargetKind returns the group version kind of the kinds
+// which are scaled using the scale subresource
+func parseScaleTargetKind(kind string) (schema.GroupVersionKind, bool) {
+	gvk, _ := schema.ParseKindArg(kind)
+	if gvk == nil {
+		return schema.GroupVersionKind{}, false
+	}
+	return *gvk, true
+}
+
+// getTargetKindName returns the kind of the workload without
+// the version and the group of the scale targets
+func getTargetKindName(kind string) string {
+	if gvk, ok := parseScaleTargetKind(kind); ok {
+		return gvk.Kind
+	}
+	return kind
+}
+
+// getScaleResource returns the resource of the kind using the
+// rest mapper. The mapper discovers the resources again if the kind is
+// not found, as the CRD may be installed after the resources are cached.
+func (c *Controller) getScaleResource(
+	gvk schema.GroupVersionKind) (schema.GroupResource, error) {
+
+	if c.scaleNamespacer == nil || c.restMapper == nil {
+		return schema.GroupResource{}, fmt.Errorf(
+			"unsupported target kind %q, the scale client is not configured", gvk.Kind)
+	}
+	mapping, err := c.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		if mapper, ok := c.restMapper.(interface{ Reset() }); ok {
+			mapper.Reset()
+			mapping, err = c.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return schema.GroupResource{}, fmt.Errorf(
+			"error mapping %s to a resource: %v", gvk.String(), err)
+	}
+	return mapping.Resource.GroupResource(), nil
+}
+
+// getScale returns the scale subresource of the workload
+func (c *Controller) getScale(
+	ctx context.Context,
+	namespace string,
+	gvk schema.GroupVersionKind,
+	name string) (*autoscalingv1.Scale, error) {
+
+	resource, err := c.getScaleResource(gvk)
+	if err != nil {
+		return nil, err
+	}
+	scale, err := c.scaleNamespacer.Scales(namespace).Get(
+		ctx, resource, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("%s %s not found in namespace %s",
+			gvk.Kind, name, namespace)
+	} else if err != nil {
+		return nil, err
+	}
+	return scale, nil
+}
+
+// updateScale updates the workload with the desired number of
+// replicas using its scale subresource
+func (c *Controller) updateScale(
+	ctx context.Context,
+	namespace string,
+	gvk schema.GroupVersionKind,
+	name string,
+	replicas *int32) error {
+
+	ctx, span := tracing.Tracer().Start(ctx, "updateScale")
+	defer span.End()
+
+	resource, err := c.getScaleResource(gvk)
+	if err != nil {
+		return err
+	}
+
+	attempt := 0
+	retryErr := retry.RetryOnConflict(c.updateRetry, func() error {
+		attempt++
+		// Retrieve the latest version of the scale before attempting update
+		scale, getErr := c.scaleNamespacer.Scales(namespace).Get(
+			ctx, resource, name, metav1.GetOptions{})
+		if errors.IsNotFound(getErr) {
+			return fmt.Errorf("%s %s was not found in namespace %s",
+				gvk.Kind, name, namespace)
+		}
+		if getErr != nil {
+			return fmt.Errorf("failed to get the scale of %s: %v", gvk.Kind, getErr)
+		}
+
+		scale.Spec.Replicas = *replicas
+		_, updateErr := c.scaleNamespacer.Scales(namespace).Update(
+			ctx, resource, scale, metav1.UpdateOptions{})
+		if errors.IsConflict(updateErr) {
+			klog.V(2).Infof("%s/%s: conflict updating the scale of %s, attempt: %d, err: %v",
+				namespace, name, gvk.Kind, attempt, updateErr)
+		} else if updateErr != nil {
+			klog.Errorf("Failed to update the scale of %s: %v", gvk.Kind, updateErr)
+		}
+		return updateErr
+	})
+	if retryErr != nil {
+		return fmt.Errorf("failed to update the scale of %s (retry failed): %v",
+			gvk.Kind, retryErr)
+	}
+	return nil
+}
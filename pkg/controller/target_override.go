@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/practo/klog/v2"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+const (
+	// TargetOverrideAnnotation overrides spec.targetMessagesPerWorker of
+	// the WPA till the time in TargetOverrideExpiresAnnotation, so that
+	// the target can be loosened fast without editing the spec
+	TargetOverrideAnnotation = "workerpodautoscaler.practo.com/target-override"
+
+	// TargetOverrideExpiresAnnotation is the RFC3339 time till which the
+	// target override is used, the override is ignored without it
+	TargetOverrideExpiresAnnotation = "workerpodautoscaler.practo.com/target-override-expires"
+)
+
+// getTargetOverride returns the target override of the WPA and the time
+// it expires at. It returns false if the WPA has no override or if it is
+// expired, an error is returned if the annotations are not valid.
+func getTargetOverride(
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	now time.Time) (int32, time.Time, bool, error) {
+
+	value, ok := workerPodAutoScaler.Annotations[TargetOverrideAnnotation]
+	if !ok || value == "" {
+		return 0, time.Time{}, false, nil
+	}
+
+	target, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || target <= 0 {
+		return 0, time.Time{}, false, fmt.Errorf(
+			"%s=%q is not a positive integer", TargetOverrideAnnotation, value)
+	}
+	expiresValue := workerPodAutoScaler.Annotations[TargetOverrideExpiresAnnotation]
+	if expiresValue == "" {
+		return 0, time.Time{}, false, fmt.Errorf(
+			"%s is set without %s", TargetOverrideAnnotation, TargetOverrideExpiresAnnotation)
+	}
+	expires, err := time.Parse(time.RFC3339, expiresValue)
+	if err != nil {
+		return 0, time.Time{}, false, fmt.Errorf(
+			"%s=%q is not a RFC3339 timestamp", TargetOverrideExpiresAnnotation, expiresValue)
+	}
+	if !now.Before(expires) {
+		return 0, expires, false, nil
+	}
+	return int32(target), expires, true, nil
+}
+
+// getTargetMessagesPerWorker returns the target override of the WPA
+// while it has not expired, else spec.targetMessagesPerWorker. The start
+// and the expiry of every override are logged once.
+func (c *Controller) getTargetMessagesPerWorker(
+	key string,
+	workerPodAutoScaler *v1.WorkerPodAutoScaler,
+	now time.Time) (int32, bool) {
+
+	specTarget := *workerPodAutoScaler.Spec.TargetMessagesPerWorker
+	target, expires, active, err := getTargetOverride(workerPodAutoScaler, now)
+	if err != nil {
+		klog.Warningf("%s: ignoring the target override, %v", key, err)
+		c.targetOverrides.Delete(key)
+		return specTarget, false
+	}
+
+	override := fmt.Sprintf("%d/%s", target, expires.Format(time.RFC3339))
+	handled, ok := c.targetOverrides.Load(key)
+	if !active {
+		if ok {
+			klog.Infof("%s: target override %s expired, targetMessagesPerWorker is back to %d",
+				key, handled.(string), specTarget)
+			c.targetOverrides.Delete(key)
+		}
+		return specTarget, false
+	}
+	if !ok || handled.(string) != override {
+		klog.Infof("%s: targetMessagesPerWorker overridden from %d to %d till %s",
+			key, specTarget, target, expires.Format(time.RFC3339))
+		c.targetOverrides.Store(key, override)
+	}
+	return target, true
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestGetTargetMessagesPerWorker(t *testing.T) {
+	c := &Controller{targetOverrides: new(sync.Map)}
+	now := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	specTarget := int32(100)
+	wpa := func(annotations map[string]string) *v1.WorkerPodAutoScaler {
+		return &v1.WorkerPodAutoScaler{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec: v1.WorkerPodAutoScalerSpec{
+				TargetMessagesPerWorker: &specTarget,
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		expected         int32
+		expectedOverride bool
+	}{
+		{
+			name:        "no override",
+			annotations: nil,
+			expected:    100,
+		},
+		{
+			name: "active override",
+			annotations: map[string]string{
+				TargetOverrideAnnotation:        "500",
+				TargetOverrideExpiresAnnotation: "2021-04-01T11:00:00Z",
+			},
+			expected:         500,
+			expectedOverride: true,
+		},
+		{
+			name: "expired override",
+			annotations: map[string]string{
+				TargetOverrideAnnotation:        "500",
+				TargetOverrideExpiresAnnotation: "2021-04-01T10:00:00Z",
+			},
+			expected: 100,
+		},
+		{
+			name: "override without expiry",
+			annotations: map[string]string{
+				TargetOverrideAnnotation: "500",
+			},
+			expected: 100,
+		},
+		{
+			name: "override not a number",
+			annotations: map[string]string{
+				TargetOverrideAnnotation:        "lots",
+				TargetOverrideExpiresAnnotation: "2021-04-01T11:00:00Z",
+			},
+			expected: 100,
+		},
+		{
+			name: "override not positive",
+			annotations: map[string]string{
+				TargetOverrideAnnotation:        "0",
+				TargetOverrideExpiresAnnotation: "2021-04-01T11:00:00Z",
+			},
+			expected: 100,
+		},
+		{
+			name: "expiry not a timestamp",
+			annotations: map[string]string{
+				TargetOverrideAnnotation:        "500",
+				TargetOverrideExpiresAnnotation: "1h",
+			},
+			expected: 100,
+		},
+	}
+
+	for _, test := range tests {
+		target, overridden := c.getTargetMessagesPerWorker("ns/wpa", wpa(test.annotations), now)
+		if target != test.expected || overridden != test.expectedOverride {
+			t.Errorf("%s: expected=%d, %v, got=%d, %v\n",
+				test.name, test.expected, test.expectedOverride, target, overridden)
+		}
+	}
+}
+
+func TestTargetOverrideReverts(t *testing.T) {
+	c := &Controller{targetOverrides: new(sync.Map)}
+	now := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	specTarget := int32(100)
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			TargetOverrideAnnotation:        "500",
+			TargetOverrideExpiresAnnotation: "2021-04-01T10:30:00Z",
+		}},
+		Spec: v1.WorkerPodAutoScalerSpec{TargetMessagesPerWorker: &specTarget},
+	}
+
+	if target, _ := c.getTargetMessagesPerWorker("ns/wpa", wpa, now); target != 500 {
+		t.Errorf("expected the override, got=%d\n", target)
+	}
+	if _, ok := c.targetOverrides.Load("ns/wpa"); !ok {
+		t.Errorf("expected the active override to be kept\n")
+	}
+
+	target, overridden := c.getTargetMessagesPerWorker("ns/wpa", wpa, now.Add(time.Hour))
+	if target != 100 || overridden {
+		t.Errorf("expected the spec target after the expiry, got=%d, %v\n", target, overridden)
+	}
+	if _, ok := c.targetOverrides.Load("ns/wpa"); ok {
+		t.Errorf("expected the expired override to be dropped\n")
+	}
+}
@@ -0,0 +1,411 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	scalefake "k8s.io/client-go/scale/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func newIndexer() cache.Indexer {
+	return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+}
+
+// fakeAppsClientset serves the workloads read from the api server,
+// the workloads which are not in it are not found
+type fakeAppsClientset struct {
+	kubernetes.Interface
+	deployments  map[string]*appsv1.Deployment
+	replicaSets  map[string]*appsv1.ReplicaSet
+	statefulSets map[string]*appsv1.StatefulSet
+	gets         int
+}
+
+func (f *fakeAppsClientset) AppsV1() typedappsv1.AppsV1Interface {
+	return &fakeAppsV1Client{clientset: f}
+}
+
+type fakeAppsV1Client struct {
+	typedappsv1.AppsV1Interface
+	clientset *fakeAppsClientset
+}
+
+func (f *fakeAppsV1Client) Deployments(namespace string) typedappsv1.DeploymentInterface {
+	return &fakeDeploymentClient{clientset: f.clientset}
+}
+
+func (f *fakeAppsV1Client) ReplicaSets(namespace string) typedappsv1.ReplicaSetInterface {
+	return &fakeReplicaSetClient{clientset: f.clientset}
+}
+
+func (f *fakeAppsV1Client) StatefulSets(namespace string) typedappsv1.StatefulSetInterface {
+	return &fakeStatefulSetClient{clientset: f.clientset}
+}
+
+type fakeDeploymentClient struct {
+	typedappsv1.DeploymentInterface
+	clientset *fakeAppsClientset
+}
+
+func (f *fakeDeploymentClient) Get(
+	ctx context.Context, name string, options metav1.GetOptions) (*appsv1.Deployment, error) {
+
+	f.clientset.gets++
+	deployment, ok := f.clientset.deployments[name]
+	if !ok {
+		return nil, errors.NewNotFound(appsv1.Resource("deployments"), name)
+	}
+	return deployment, nil
+}
+
+type fakeReplicaSetClient struct {
+	typedappsv1.ReplicaSetInterface
+	clientset *fakeAppsClientset
+}
+
+func (f *fakeReplicaSetClient) Get(
+	ctx context.Context, name string, options metav1.GetOptions) (*appsv1.ReplicaSet, error) {
+
+	f.clientset.gets++
+	replicaSet, ok := f.clientset.replicaSets[name]
+	if !ok {
+		return nil, errors.NewNotFound(appsv1.Resource("replicasets"), name)
+	}
+	return replicaSet, nil
+}
+
+type fakeStatefulSetClient struct {
+	typedappsv1.StatefulSetInterface
+	clientset *fakeAppsClientset
+}
+
+func (f *fakeStatefulSetClient) Get(
+	ctx context.Context, name string, options metav1.GetOptions) (*appsv1.StatefulSet, error) {
+
+	f.clientset.gets++
+	statefulSet, ok := f.clientset.statefulSets[name]
+	if !ok {
+		return nil, errors.NewNotFound(appsv1.Resource("statefulsets"), name)
+	}
+	return statefulSet, nil
+}
+
+func newTargetTestController(t *testing.T, objs ...interface{}) *Controller {
+	deployments := newIndexer()
+	replicaSets := newIndexer()
+	statefulSets := newIndexer()
+	for _, obj := range objs {
+		var err error
+		switch obj.(type) {
+		case *appsv1.Deployment:
+			err = deployments.Add(obj)
+		case *appsv1.ReplicaSet:
+			err = replicaSets.Add(obj)
+		case *appsv1.StatefulSet:
+			err = statefulSets.Add(obj)
+		}
+		if err != nil {
+			t.Fatalf("Error adding object to the indexer: %v\n", err)
+		}
+	}
+
+	return &Controller{
+		kubeclientset:     &fakeAppsClientset{},
+		deploymentLister:  appslisters.NewDeploymentLister(deployments),
+		replicaSetLister:  appslisters.NewReplicaSetLister(replicaSets),
+		statefulSetLister: appslisters.NewStatefulSetLister(statefulSets),
+	}
+}
+
+func objectMeta(name string, app string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "testns",
+		Labels:    map[string]string{"app": app},
+	}
+}
+
+func selectorWorkerPodAutoScaler(kind string, app string) *v1.WorkerPodAutoScaler {
+	return &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			TargetRef: &v1.TargetRef{
+				Kind: kind,
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": app},
+				},
+			},
+		},
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	deleting := metav1.Now()
+	oldDeployment := &appsv1.Deployment{ObjectMeta: objectMeta("otpsender-a1", "otpsender")}
+	oldDeployment.DeletionTimestamp = &deleting
+
+	c := newTargetTestController(t,
+		&appsv1.Deployment{ObjectMeta: objectMeta("otpsender-b2", "otpsender")},
+		oldDeployment,
+		&appsv1.Deployment{ObjectMeta: objectMeta("mailer-a1", "mailer")},
+		&appsv1.Deployment{ObjectMeta: objectMeta("mailer-b2", "mailer")},
+		&appsv1.StatefulSet{ObjectMeta: objectMeta("indexer-0", "indexer")},
+	)
+
+	tests := []struct {
+		name   string
+		wpa    *v1.WorkerPodAutoScaler
+		kind   string
+		target string
+		reason string
+	}{
+		{
+			name: "deploymentName",
+			wpa: &v1.WorkerPodAutoScaler{
+				Spec: v1.WorkerPodAutoScalerSpec{DeploymentName: "otpsender"},
+			},
+			kind:   v1.TargetKindDeployment,
+			target: "otpsender",
+		},
+		{
+			name:   "selector ignores the deployment being deleted",
+			wpa:    selectorWorkerPodAutoScaler(v1.TargetKindDeployment, "otpsender"),
+			kind:   v1.TargetKindDeployment,
+			target: "otpsender-b2",
+		},
+		{
+			name:   "selector matching a statefulset",
+			wpa:    selectorWorkerPodAutoScaler(v1.TargetKindStatefulSet, "indexer"),
+			kind:   v1.TargetKindStatefulSet,
+			target: "indexer-0",
+		},
+		{
+			name: "scaleTargetRef of an apps/v1 deployment",
+			wpa: &v1.WorkerPodAutoScaler{
+				Spec: v1.WorkerPodAutoScalerSpec{
+					ScaleTargetRef: &v1.ScaleTargetRef{
+						APIVersion: "apps/v1",
+						Kind:       v1.TargetKindDeployment,
+						Name:       "otpsender",
+					},
+				},
+			},
+			kind:   v1.TargetKindDeployment,
+			target: "otpsender",
+		},
+		{
+			name: "scaleTargetRef of a rollout",
+			wpa: &v1.WorkerPodAutoScaler{
+				Spec: v1.WorkerPodAutoScalerSpec{
+					ScaleTargetRef: &v1.ScaleTargetRef{
+						APIVersion: "argoproj.io/v1alpha1",
+						Kind:       "Rollout",
+						Name:       "otpsender",
+					},
+				},
+			},
+			kind:   "Rollout.v1alpha1.argoproj.io",
+			target: "otpsender",
+		},
+		{
+			name:   "selector matching none",
+			wpa:    selectorWorkerPodAutoScaler(v1.TargetKindReplicaSet, "otpsender"),
+			reason: "NoMatch",
+		},
+		{
+			name:   "selector matching many",
+			wpa:    selectorWorkerPodAutoScaler(v1.TargetKindDeployment, "mailer"),
+			reason: "AmbiguousMatch",
+		},
+	}
+
+	for _, test := range tests {
+		kind, target, err := c.resolveTarget(test.wpa)
+		if test.reason != "" {
+			resolutionErr, ok := err.(*targetResolutionError)
+			if !ok {
+				t.Errorf("%s: expected resolution error, got=%v\n", test.name, err)
+				continue
+			}
+			if resolutionErr.reason != test.reason {
+				t.Errorf("%s: expected reason=%s, got=%s\n",
+					test.name, test.reason, resolutionErr.reason)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got=%v\n", test.name, err)
+			continue
+		}
+		if kind != test.kind || target != test.target {
+			t.Errorf("%s: expected %s/%s, got=%s/%s\n",
+				test.name, test.kind, test.target, kind, target)
+		}
+	}
+}
+
+func TestGetTargetReplicasAfterScaleDown(t *testing.T) {
+	// the deployment was just scaled down from 10 to 4, the pods being
+	// terminated are still available
+	replicas := int32(4)
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender")}
+	deployment.Spec.Replicas = &replicas
+	deployment.Status.AvailableReplicas = 10
+	scalingUp := &appsv1.Deployment{ObjectMeta: objectMeta("mailer", "mailer")}
+	scalingUp.Spec.Replicas = &replicas
+	scalingUp.Status.AvailableReplicas = 2
+	c := newTargetTestController(t, deployment, scalingUp)
+
+	tests := []struct {
+		name              string
+		target            string
+		expectedAvailable int32
+	}{
+		{"available above the replicas", "otpsender", 4},
+		{"available below the replicas", "mailer", 2},
+	}
+	for _, test := range tests {
+		current, available, err := c.getTargetReplicas(
+			"testns", v1.TargetKindDeployment, test.target)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got=%v", test.name, err)
+		}
+		if current != 4 || available != test.expectedAvailable {
+			t.Errorf("%s: expected current=4 available=%d, got current=%d available=%d",
+				test.name, test.expectedAvailable, current, available)
+		}
+	}
+}
+
+func TestScaleTarget(t *testing.T) {
+	rollouts := schema.GroupResource{Group: "argoproj.io", Resource: "rollouts"}
+	rollout := schema.GroupVersionKind{
+		Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(rollout, meta.RESTScopeNamespace)
+
+	scales := &scalefake.FakeScaleClient{}
+	var updated *autoscalingv1.Scale
+	scales.AddReactor("get", "rollouts", func(
+		action k8stesting.Action) (bool, runtime.Object, error) {
+
+		return true, &autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: 4},
+			Status: autoscalingv1.ScaleStatus{
+				Replicas: 3,
+				Selector: "app=otpsender",
+			},
+		}, nil
+	})
+	scales.AddReactor("update", "rollouts", func(
+		action k8stesting.Action) (bool, runtime.Object, error) {
+
+		updated = action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		return true, updated, nil
+	})
+
+	c := newTargetTestController(t)
+	c.scaleNamespacer = scales
+	c.restMapper = restMapper
+	c.updateRetry = retry.DefaultRetry
+	kind := "Rollout.v1alpha1.argoproj.io"
+
+	current, available, err := c.getTargetReplicas("testns", kind, "otpsender")
+	if err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if current != 4 || available != 3 {
+		t.Errorf("expected current=4 available=3, got current=%d available=%d",
+			current, available)
+	}
+
+	selector, err := c.getTargetSelector("testns", kind, "otpsender")
+	if err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if selector.String() != "app=otpsender" {
+		t.Errorf("expected selector=app=otpsender, got=%s", selector.String())
+	}
+
+	replicas := int32(7)
+	err = c.updateTarget(context.Background(), "testns", kind, "otpsender", &replicas)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if updated == nil || updated.Spec.Replicas != 7 {
+		t.Fatalf("expected the scale to be updated to 7 replicas, got=%v", updated)
+	}
+	for _, action := range scales.Actions() {
+		if action.GetResource().GroupResource() != rollouts {
+			t.Errorf("expected the rollouts resource, got=%v", action.GetResource())
+		}
+	}
+
+	_, _, err = c.getTargetReplicas("testns", "Widget.v1.example.com", "otpsender")
+	if err == nil {
+		t.Errorf("expected an error for a kind which is not mapped")
+	}
+}
+
+func TestGetTargetReplicasCacheMiss(t *testing.T) {
+	replicas := int32(4)
+	cached := &appsv1.Deployment{ObjectMeta: objectMeta("mailer", "mailer")}
+	cached.Spec.Replicas = &replicas
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender")}
+	deployment.Spec.Replicas = &replicas
+	deployment.Status.AvailableReplicas = 3
+	c := newTargetTestController(t, cached)
+	clientset := &fakeAppsClientset{
+		deployments: map[string]*appsv1.Deployment{"otpsender": deployment},
+	}
+	c.kubeclientset = clientset
+
+	// the deployment in the cache is not read from the api server
+	if _, _, err := c.getTargetReplicas("testns", v1.TargetKindDeployment, "mailer"); err != nil {
+		t.Fatalf("expected the deployment in the cache, got=%v", err)
+	}
+	if clientset.gets != 0 {
+		t.Errorf("expected no read from the api server, got=%d", clientset.gets)
+	}
+
+	// the deployment missing in the cache is read from the api server
+	current, available, err := c.getTargetReplicas(
+		"testns", v1.TargetKindDeployment, "otpsender")
+	if err != nil {
+		t.Fatalf("expected the deployment from the api server, got=%v", err)
+	}
+	if current != 4 || available != 3 {
+		t.Errorf("expected current=4 available=3, got current=%d available=%d",
+			current, available)
+	}
+	if clientset.gets != 1 {
+		t.Errorf("expected one read from the api server, got=%d", clientset.gets)
+	}
+
+	// the deployment missing in both is not found
+	_, _, err = c.getTargetReplicas("testns", v1.TargetKindDeployment, "smssender")
+	if err == nil {
+		t.Errorf("expected an error for the missing deployment")
+	}
+	if clientset.gets != 2 {
+		t.Errorf("expected the missing deployment to be read once, got=%d reads", clientset.gets)
+	}
+}
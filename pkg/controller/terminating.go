@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"github.com/practo/klog/v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// countTerminatingPods returns the pods which are being deleted
+func countTerminatingPods(pods []*corev1.Pod) int32 {
+	var terminating int32
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			terminating++
+		}
+	}
+	return terminating
+}
+
+// holdTerminatingWorkers keeps the current workers when the desired
+// workers, computed with the terminating pods counted in the current
+// workers, are above the current workers but not above the terminating
+// pods. The terminating pods are going away, such desired workers only
+// tell that the workers are not to be scaled down further while the pods
+// of the earlier scale downs drain in their termination grace period.
+func holdTerminatingWorkers(
+	desiredWorkers int32,
+	currentWorkers int32,
+	terminatingWorkers int32,
+	reason ScaleReason) (int32, ScaleReason) {
+
+	if terminatingWorkers > 0 && desiredWorkers > currentWorkers &&
+		desiredWorkers <= currentWorkers+terminatingWorkers {
+		return currentWorkers, ScaleReasonWorkersTerminating
+	}
+	return desiredWorkers, reason
+}
+
+// getTerminatingWorkers returns the pods of the workload which are
+// terminating, 0 if they are not counted or cannot be listed
+func (c *Controller) getTerminatingWorkers(
+	key string,
+	namespace string,
+	targetKind string,
+	targetName string) int32 {
+
+	if !c.countTerminatingWorkers {
+		return 0
+	}
+	selector, err := c.getTargetSelector(namespace, targetKind, targetName)
+	if err != nil {
+		klog.Warningf("%s: error getting the pods of %s %s, %v",
+			key, targetKind, targetName, err)
+		return 0
+	}
+	pods, err := c.podLister.Pods(namespace).List(selector)
+	if err != nil {
+		klog.Warningf("%s: error listing the pods of %s %s, %v",
+			key, targetKind, targetName, err)
+		return 0
+	}
+	return countTerminatingPods(pods)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCountTerminatingPods(t *testing.T) {
+	now := metav1.Now()
+	pods := []*corev1.Pod{
+		{},
+		{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}},
+		{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}},
+	}
+	if terminating := countTerminatingPods(pods); terminating != 2 {
+		t.Errorf("expected 2 terminating pods, got=%d", terminating)
+	}
+}
+
+func TestHoldTerminatingWorkers(t *testing.T) {
+	tests := []struct {
+		name           string
+		desired        int32
+		current        int32
+		terminating    int32
+		expected       int32
+		expectedReason ScaleReason
+	}{
+		{
+			name:           "no terminating pods",
+			desired:        7,
+			current:        5,
+			terminating:    0,
+			expected:       7,
+			expectedReason: ScaleReasonBacklog,
+		},
+		{
+			name:           "scale down capped above the current workers",
+			desired:        8,
+			current:        5,
+			terminating:    5,
+			expected:       5,
+			expectedReason: ScaleReasonWorkersTerminating,
+		},
+		{
+			name:           "terminating pods within tolerance",
+			desired:        10,
+			current:        5,
+			terminating:    5,
+			expected:       5,
+			expectedReason: ScaleReasonWorkersTerminating,
+		},
+		{
+			name:           "scale down below the current workers",
+			desired:        4,
+			current:        5,
+			terminating:    2,
+			expected:       4,
+			expectedReason: ScaleReasonBacklog,
+		},
+		{
+			name:           "scale up above the terminating pods",
+			desired:        12,
+			current:        5,
+			terminating:    5,
+			expected:       12,
+			expectedReason: ScaleReasonBacklog,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desired, reason := holdTerminatingWorkers(
+				test.desired, test.current, test.terminating, ScaleReasonBacklog)
+			if desired != test.expected || reason != test.expectedReason {
+				t.Errorf("expected desired=%d, reason=%s, got=%d, reason=%s",
+					test.expected, test.expectedReason, desired, reason)
+			}
+		})
+	}
+}
+
+func TestComputeDesiredWithTerminatingWorkers(t *testing.T) {
+	// 8 replicas and 2 pods terminating after the earlier scale down,
+	// the maxDisruption of 50% is of the 10 workers
+	current, terminating := int32(8), int32(2)
+	result := ComputeDesired(ScalingInput{
+		QueueName:               "otpsender",
+		QueueMessages:           2,
+		TargetMessagesPerWorker: 1,
+		CurrentWorkers:          current + terminating,
+		IdleWorkers:             0,
+		MinWorkers:              0,
+		MaxWorkers:              20,
+		MaxDisruption:           "50%",
+	})
+	desired, _ := holdTerminatingWorkers(
+		result.DesiredWorkers, current, terminating, result.Reason)
+	if desired != 5 {
+		t.Errorf("expected desired=5, got=%d", desired)
+	}
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/practo/klog/v2"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// getWeightedTargetReplicas returns the spec replicas and the available
+// replicas of all the weighted targets, they are the workers of the WPA
+func (c *Controller) getWeightedTargetReplicas(
+	namespace string, targets []v1.WeightedTarget) (int32, int32, error) {
+
+	var current, available int32
+	for _, target := range targets {
+		replicas, availableReplicas, err := c.getTargetReplicas(
+			namespace, target.Kind, target.Name)
+		if err != nil {
+			return 0, 0, err
+		}
+		current += replicas
+		available += availableReplicas
+	}
+	return current, available, nil
+}
+
+// updateWeightedTargets splits the desired workers between the weighted
+// targets and updates the targets whose replicas differ from their
+// share. All the targets are tried, the errors are returned together.
+func (c *Controller) updateWeightedTargets(
+	ctx context.Context,
+	namespace string,
+	targets []v1.WeightedTarget,
+	desiredWorkers int32) error {
+
+	shares := splitWeightedReplicas(desiredWorkers, targets)
+	errs := []string{}
+	for i, target := range targets {
+		replicas, _, err := c.getTargetReplicas(namespace, target.Kind, target.Name)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if replicas == shares[i] {
+			continue
+		}
+		klog.V(2).Infof("%s/%s: scaling %s from %d to %d, weight %d",
+			namespace, target.Name, target.Kind, replicas, shares[i], target.Weight)
+		share := shares[i]
+		err = c.updateTarget(ctx, namespace, target.Kind, target.Name, &share)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s %s: %v", target.Kind, target.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error updating the weighted targets: %s",
+			strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// splitWeightedReplicas splits the total replicas between the targets
+// in the ratio of their weights. The share of a target is raised to its
+// minReplicas and capped at its maxReplicas, the rest is split again
+// between the other targets. The shares add up to the total unless the
+// minReplicas of the targets add up to more or their maxReplicas to less.
+func splitWeightedReplicas(total int32, targets []v1.WeightedTarget) []int32 {
+	replicas := make([]int32, len(targets))
+	bounded := make([]bool, len(targets))
+	for {
+		remaining := total
+		for i := range targets {
+			if bounded[i] {
+				remaining -= replicas[i]
+			}
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		shares := splitByWeight(remaining, targets, bounded)
+
+		// the targets below their min are bounded first, raising them
+		// lowers the shares of the rest which may then be within the max
+		changed := false
+		for i, target := range targets {
+			if !bounded[i] && target.MinReplicas != nil &&
+				shares[i] < *target.MinReplicas {
+				replicas[i], bounded[i], changed = *target.MinReplicas, true, true
+			}
+		}
+		if !changed {
+			for i, target := range targets {
+				if !bounded[i] && target.MaxReplicas != nil &&
+					shares[i] > *target.MaxReplicas {
+					replicas[i], bounded[i], changed = *target.MaxReplicas, true, true
+				}
+			}
+		}
+		if !changed {
+			for i := range targets {
+				if !bounded[i] {
+					replicas[i] = shares[i]
+				}
+			}
+			return replicas
+		}
+	}
+}
+
+// splitByWeight splits the total between the targets which are not
+// skipped in the ratio of their weights. The replicas left over by the
+// rounding down go to the targets with the largest remainders, the
+// earlier targets first on a tie, so that the shares add up to the total.
+func splitByWeight(total int32, targets []v1.WeightedTarget, skip []bool) []int32 {
+	shares := make([]int32, len(targets))
+	var weights int64
+	for i, target := range targets {
+		if !skip[i] {
+			weights += int64(target.Weight)
+		}
+	}
+	if weights == 0 {
+		return shares
+	}
+
+	remainders := make([]int64, len(targets))
+	left := total
+	indexes := []int{}
+	for i, target := range targets {
+		if skip[i] {
+			continue
+		}
+		product := int64(total) * int64(target.Weight)
+		shares[i] = int32(product / weights)
+		remainders[i] = product % weights
+		left -= shares[i]
+		indexes = append(indexes, i)
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return remainders[indexes[a]] > remainders[indexes[b]]
+	})
+	for _, i := range indexes {
+		if left == 0 {
+			break
+		}
+		shares[i]++
+		left--
+	}
+	return shares
+}
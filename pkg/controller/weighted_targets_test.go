@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func weightedTarget(name string, weight int32, min *int32, max *int32) v1.WeightedTarget {
+	return v1.WeightedTarget{
+		Kind:        v1.TargetKindDeployment,
+		Name:        name,
+		Weight:      weight,
+		MinReplicas: min,
+		MaxReplicas: max,
+	}
+}
+
+func TestSplitWeightedReplicas(t *testing.T) {
+	one, two, three, ten := int32(1), int32(2), int32(3), int32(10)
+	tests := []struct {
+		name     string
+		total    int32
+		targets  []v1.WeightedTarget
+		expected []int32
+	}{
+		{"by weight", 12, []v1.WeightedTarget{
+			weightedTarget("cpu", 3, nil, nil),
+			weightedTarget("memory", 1, nil, nil)}, []int32{9, 3}},
+		{"rounding adds up to the total", 10, []v1.WeightedTarget{
+			weightedTarget("a", 1, nil, nil),
+			weightedTarget("b", 1, nil, nil),
+			weightedTarget("c", 1, nil, nil)}, []int32{4, 3, 3}},
+		{"largest remainder first", 5, []v1.WeightedTarget{
+			weightedTarget("cpu", 1, nil, nil),
+			weightedTarget("memory", 2, nil, nil)}, []int32{2, 3}},
+		{"zero", 0, []v1.WeightedTarget{
+			weightedTarget("cpu", 3, nil, nil),
+			weightedTarget("memory", 1, nil, nil)}, []int32{0, 0}},
+		{"raised to the min", 4, []v1.WeightedTarget{
+			weightedTarget("cpu", 3, nil, nil),
+			weightedTarget("memory", 1, &two, nil)}, []int32{2, 2}},
+		{"capped at the max", 12, []v1.WeightedTarget{
+			weightedTarget("cpu", 3, nil, &three),
+			weightedTarget("memory", 1, nil, nil)}, []int32{3, 9}},
+		{"min and max", 20, []v1.WeightedTarget{
+			weightedTarget("a", 1, nil, &one),
+			weightedTarget("b", 1, &ten, nil),
+			weightedTarget("c", 2, nil, nil)}, []int32{1, 10, 9}},
+		{"mins above the total", 1, []v1.WeightedTarget{
+			weightedTarget("cpu", 1, &one, nil),
+			weightedTarget("memory", 1, &one, nil)}, []int32{1, 1}},
+		{"maxes below the total", 10, []v1.WeightedTarget{
+			weightedTarget("cpu", 1, nil, &two),
+			weightedTarget("memory", 1, nil, &three)}, []int32{2, 3}},
+	}
+	for _, test := range tests {
+		got := splitWeightedReplicas(test.total, test.targets)
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestGetWeightedTargetReplicas(t *testing.T) {
+	three, five := int32(3), int32(5)
+	c := newTargetTestController(t,
+		&appsv1.Deployment{
+			ObjectMeta: objectMeta("cpu", "workers"),
+			Spec:       appsv1.DeploymentSpec{Replicas: &three},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: objectMeta("memory", "workers"),
+			Spec:       appsv1.DeploymentSpec{Replicas: &five},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: 5},
+		},
+	)
+	targets := []v1.WeightedTarget{
+		weightedTarget("cpu", 1, nil, nil),
+		weightedTarget("memory", 1, nil, nil),
+	}
+	current, available, err := c.getWeightedTargetReplicas("testns", targets)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v", err)
+	}
+	if current != 8 || available != 7 {
+		t.Errorf("expected current=8 available=7, got current=%d available=%d",
+			current, available)
+	}
+
+	targets = append(targets, weightedTarget("missing", 1, nil, nil))
+	if _, _, err := c.getWeightedTargetReplicas("testns", targets); err == nil {
+		t.Errorf("expected an error for a missing target")
+	}
+}
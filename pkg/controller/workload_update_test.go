@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func newWorkloadUpdateTestController(
+	t *testing.T, wpas ...*v1.WorkerPodAutoScaler) *Controller {
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		deploymentNameIndex:  indexByDeploymentName,
+		replicaSetNameIndex:  indexByReplicaSetName,
+		statefulSetNameIndex: indexByStatefulSetName,
+		targetSelectorIndex:  indexByTargetSelector,
+	})
+	for _, wpa := range wpas {
+		if err := indexer.Add(wpa); err != nil {
+			t.Fatalf("Error adding the wpa to the indexer: %v\n", err)
+		}
+	}
+	return &Controller{
+		workerPodAutoScalersIndexer: indexer,
+		workqueue: workqueue.NewRateLimitingQueue(
+			workqueue.DefaultControllerRateLimiter()),
+	}
+}
+
+// queuedKeys returns the keys of the update events in the workqueue
+func queuedKeys(c *Controller) map[string]bool {
+	keys := map[string]bool{}
+	for c.workqueue.Len() > 0 {
+		item, _ := c.workqueue.Get()
+		event := item.(WokerPodAutoScalerEvent)
+		if event.name == WokerPodAutoScalerEventUpdate {
+			keys[event.key] = true
+		}
+		c.workqueue.Done(item)
+	}
+	return keys
+}
+
+func replicasPtr(replicas int32) *int32 {
+	return &replicas
+}
+
+func TestIndexByWorkloadName(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec: v1.WorkerPodAutoScalerSpec{
+			WeightedTargets: []v1.WeightedTarget{
+				{Kind: v1.TargetKindDeployment, Name: "otpsender-a"},
+				{Kind: v1.TargetKindStatefulSet, Name: "otpsender-b"},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		index    cache.IndexFunc
+		expected []string
+	}{
+		{"deployments", indexByDeploymentName, []string{"testns/otpsender-a"}},
+		{"replicasets", indexByReplicaSetName, []string{}},
+		{"statefulsets", indexByStatefulSetName, []string{"testns/otpsender-b"}},
+	}
+	for _, test := range tests {
+		keys, err := test.index(wpa)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got=%v\n", test.name, err)
+		}
+		if len(keys) != len(test.expected) {
+			t.Errorf("%s: expected keys=%v, got=%v\n", test.name, test.expected, keys)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != test.expected[i] {
+				t.Errorf("%s: expected keys=%v, got=%v\n", test.name, test.expected, keys)
+			}
+		}
+	}
+}
+
+func TestHandleDeploymentUpdate(t *testing.T) {
+	byName := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec:       v1.WorkerPodAutoScalerSpec{DeploymentName: "otpsender"},
+	}
+	bySelector := selectorWorkerPodAutoScaler(v1.TargetKindDeployment, "mailer")
+	bySelector.Name = "mailer"
+
+	tests := []struct {
+		name     string
+		old      *appsv1.Deployment
+		new      *appsv1.Deployment
+		expected []string
+	}{
+		{
+			name: "replicas changed outside wpa",
+			old: &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec: appsv1.DeploymentSpec{Replicas: replicasPtr(2)}},
+			new: &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec: appsv1.DeploymentSpec{Replicas: replicasPtr(5)}},
+			expected: []string{"testns/otpsender"},
+		},
+		{
+			name: "replicas of a selected deployment changed",
+			old: &appsv1.Deployment{ObjectMeta: objectMeta("mailer-a1", "mailer"),
+				Spec: appsv1.DeploymentSpec{Replicas: replicasPtr(2)}},
+			new: &appsv1.Deployment{ObjectMeta: objectMeta("mailer-a1", "mailer"),
+				Spec: appsv1.DeploymentSpec{Replicas: replicasPtr(5)}},
+			expected: []string{"testns/mailer"},
+		},
+		{
+			name: "unrelated deployment",
+			old: &appsv1.Deployment{ObjectMeta: objectMeta("smssender", "smssender"),
+				Spec: appsv1.DeploymentSpec{Replicas: replicasPtr(2)}},
+			new: &appsv1.Deployment{ObjectMeta: objectMeta("smssender", "smssender"),
+				Spec: appsv1.DeploymentSpec{Replicas: replicasPtr(5)}},
+		},
+		{
+			name: "status only update",
+			old: &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec:   appsv1.DeploymentSpec{Replicas: replicasPtr(5)},
+				Status: appsv1.DeploymentStatus{AvailableReplicas: 2}},
+			new: &appsv1.Deployment{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec:   appsv1.DeploymentSpec{Replicas: replicasPtr(5)},
+				Status: appsv1.DeploymentStatus{AvailableReplicas: 5}},
+		},
+	}
+
+	for _, test := range tests {
+		c := newWorkloadUpdateTestController(t, byName, bySelector)
+		c.handleDeploymentUpdate(test.old, test.new)
+		keys := queuedKeys(c)
+		c.workqueue.ShutDown()
+		if len(keys) != len(test.expected) {
+			t.Errorf("%s: expected the wpas %v to be queued, got=%v\n",
+				test.name, test.expected, keys)
+		}
+		for _, key := range test.expected {
+			if !keys[key] {
+				t.Errorf("%s: expected %s to be queued, got=%v\n", test.name, key, keys)
+			}
+		}
+	}
+}
+
+func TestHandleReplicaSetUpdate(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "otpsender", Namespace: "testns"},
+		Spec:       v1.WorkerPodAutoScalerSpec{ReplicaSetName: "otpsender"},
+	}
+
+	tests := []struct {
+		name     string
+		old      *appsv1.ReplicaSet
+		new      *appsv1.ReplicaSet
+		expected []string
+	}{
+		{
+			name: "replicas changed outside wpa",
+			old: &appsv1.ReplicaSet{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec: appsv1.ReplicaSetSpec{Replicas: replicasPtr(2)}},
+			new: &appsv1.ReplicaSet{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec: appsv1.ReplicaSetSpec{Replicas: replicasPtr(0)}},
+			expected: []string{"testns/otpsender"},
+		},
+		{
+			name: "unrelated replicaset",
+			old: &appsv1.ReplicaSet{ObjectMeta: objectMeta("smssender", "smssender"),
+				Spec: appsv1.ReplicaSetSpec{Replicas: replicasPtr(2)}},
+			new: &appsv1.ReplicaSet{ObjectMeta: objectMeta("smssender", "smssender"),
+				Spec: appsv1.ReplicaSetSpec{Replicas: replicasPtr(0)}},
+		},
+		{
+			name: "status only update",
+			old: &appsv1.ReplicaSet{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec:   appsv1.ReplicaSetSpec{Replicas: replicasPtr(2)},
+				Status: appsv1.ReplicaSetStatus{AvailableReplicas: 1}},
+			new: &appsv1.ReplicaSet{ObjectMeta: objectMeta("otpsender", "otpsender"),
+				Spec:   appsv1.ReplicaSetSpec{Replicas: replicasPtr(2)},
+				Status: appsv1.ReplicaSetStatus{AvailableReplicas: 2}},
+		},
+	}
+
+	for _, test := range tests {
+		c := newWorkloadUpdateTestController(t, wpa)
+		c.handleReplicaSetUpdate(test.old, test.new)
+		keys := queuedKeys(c)
+		c.workqueue.ShutDown()
+		if len(keys) != len(test.expected) {
+			t.Errorf("%s: expected the wpas %v to be queued, got=%v\n",
+				test.name, test.expected, keys)
+		}
+		for _, key := range test.expected {
+			if !keys[key] {
+				t.Errorf("%s: expected %s to be queued, got=%v\n", test.name, key, keys)
+			}
+		}
+	}
+}
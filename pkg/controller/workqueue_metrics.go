@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// The metrics of the client-go workqueues, labelled by the name of
+// the queue. They tell if the controller is falling behind and the
+// wpa-threads need to be increased.
+var (
+	workqueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "depth",
+			Help:      "Current depth of the workqueue",
+		},
+		[]string{"name"},
+	)
+
+	workqueueAdds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "adds_total",
+			Help:      "Total number of adds handled by the workqueue",
+		},
+		[]string{"name"},
+	)
+
+	workqueueLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "queue_duration_seconds",
+			Help:      "How long in seconds an item stays in the workqueue before being processed",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name"},
+	)
+
+	workqueueWorkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "work_duration_seconds",
+			Help:      "How long in seconds processing an item from the workqueue takes",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name"},
+	)
+
+	workqueueUnfinishedWork = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "unfinished_work_seconds",
+			Help:      "Seconds of work in progress which has not been observed by work_duration, large values indicate stuck threads",
+		},
+		[]string{"name"},
+	)
+
+	workqueueLongestRunningProcessor = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "longest_running_processor_seconds",
+			Help:      "Seconds the longest running processor of the workqueue has been running",
+		},
+		[]string{"name"},
+	)
+
+	workqueueRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Subsystem: "workqueue",
+			Name:      "retries_total",
+			Help:      "Total number of retries handled by the workqueue",
+		},
+		[]string{"name"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(workqueueDepth)
+	prometheus.MustRegister(workqueueAdds)
+	prometheus.MustRegister(workqueueLatency)
+	prometheus.MustRegister(workqueueWorkDuration)
+	prometheus.MustRegister(workqueueUnfinishedWork)
+	prometheus.MustRegister(workqueueLongestRunningProcessor)
+	prometheus.MustRegister(workqueueRetries)
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// workqueueMetricsProvider provides the prometheus metrics
+// to the named client-go workqueues
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
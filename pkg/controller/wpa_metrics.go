@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsCardinalityHigh exports the metrics of the WPAs partitioned
+	// by the queue, and by the reason, algorithm, direction and schedule
+	MetricsCardinalityHigh = "high"
+	// MetricsCardinalityLow exports the metrics of the WPAs with an empty
+	// queueName and drops the metrics partitioned further than the WPA
+	MetricsCardinalityLow = "low"
+)
+
+// metricSeries is the label values of the series exported for a WPA,
+// they are kept to delete the series when the WPA or its queue is removed
+type metricSeries struct {
+	name      string
+	namespace string
+	queueName string
+	// schedules are the names of the schedules in wpa_schedule_active
+	schedules map[string]bool
+}
+
+// queueMetrics are the metrics of the WPAs labelled by
+// workerpodautoscaler, namespace and queueName
+func queueMetrics() []*prometheus.GaugeVec {
+	return []*prometheus.GaugeVec{
+		qMsgs,
+		qMsgsSPM,
+		qMsgsSPMAvg,
+		oldestMessageAgeSeconds,
+		backlogAgeSLOViolated,
+		queueUnsynced,
+		backlogBalance,
+		queuePollInterval,
+		queueAPICallsPerMinute,
+		secondsToProcessOneJobEstimate,
+		workersIdle,
+		workersCurrent,
+		workersDesired,
+		workersAvailable,
+		workersMin,
+		workersMinComputed,
+		workersMaxDisruptionPods,
+		panicMode,
+		scalingBreakerState,
+		targetOverrideActive,
+		atMaxReplicas,
+	}
+}
+
+// deleteQueueSeries deletes the series of the WPA labelled with its queue
+func deleteQueueSeries(series *metricSeries) {
+	labels := []string{series.name, series.namespace, series.queueName}
+	for _, metric := range queueMetrics() {
+		metric.DeleteLabelValues(labels...)
+	}
+	for _, reason := range scaleReasons {
+		scaleReasonGauge.DeleteLabelValues(append(labels, string(reason))...)
+	}
+	for _, algorithm := range scalingAlgorithms {
+		scalerAlgorithmInfo.DeleteLabelValues(append(labels, string(algorithm))...)
+	}
+	for _, direction := range []string{"up", "down"} {
+		scaleCooldownRemaining.DeleteLabelValues(append(labels, direction)...)
+	}
+	for schedule := range series.schedules {
+		scheduleActive.DeleteLabelValues(append(labels, schedule)...)
+	}
+}
+
+// getMetricQueueName returns the queueName label of the metrics of the
+// WPA, it is empty in the low cardinality. The series of the previous
+// queue of the WPA are deleted when its queue changes.
+func (c *Controller) getMetricQueueName(
+	key string, name string, namespace string, queueName string) string {
+
+	if c.lowCardinalityMetrics {
+		queueName = ""
+	}
+	if obj, ok := c.metricSeries.Load(key); ok {
+		series := obj.(*metricSeries)
+		if series.queueName == queueName {
+			return queueName
+		}
+		deleteQueueSeries(series)
+	}
+	c.metricSeries.Store(key, &metricSeries{
+		name:      name,
+		namespace: namespace,
+		queueName: queueName,
+		schedules: make(map[string]bool),
+	})
+	return queueName
+}
+
+// setSchedules keeps the schedules of the WPA exported in
+// wpa_schedule_active and deletes the series of the removed schedules
+func (c *Controller) setSchedules(key string, schedules []string) {
+	obj, ok := c.metricSeries.Load(key)
+	if !ok {
+		return
+	}
+	series := obj.(*metricSeries)
+	current := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		current[schedule] = true
+	}
+	for schedule := range series.schedules {
+		if !current[schedule] {
+			scheduleActive.DeleteLabelValues(series.name, series.namespace,
+				series.queueName, schedule)
+		}
+	}
+	series.schedules = current
+}
+
+// deleteQueueMetrics deletes the series of the WPA labelled with its
+// queue, e.g. when the queue of the WPA is not polled anymore
+func (c *Controller) deleteQueueMetrics(key string) {
+	if obj, ok := c.metricSeries.Load(key); ok {
+		deleteQueueSeries(obj.(*metricSeries))
+		c.metricSeries.Delete(key)
+	}
+}
+
+// deleteWorkerPodAutoScalerMetrics deletes all the series of the WPA
+// so that the removed WPAs do not leave stale series behind
+func (c *Controller) deleteWorkerPodAutoScalerMetrics(
+	key string, name string, namespace string) {
+
+	c.deleteQueueMetrics(key)
+	loopDurationSeconds.DeleteLabelValues(name, namespace)
+	loopCountSuccess.DeleteLabelValues(name, namespace)
+	lastReconcileTimestamp.DeleteLabelValues(name, namespace)
+	scaleUpsDeferred.DeleteLabelValues(name, namespace)
+	scaleSuppressedByTolerance.DeleteLabelValues(name, namespace)
+}
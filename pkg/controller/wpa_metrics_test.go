@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGetMetricQueueName(t *testing.T) {
+	c := &Controller{metricSeries: new(sync.Map)}
+	key := "testns/otpsender"
+
+	queueName := c.getMetricQueueName(key, "otpsender", "testns", "otpsender")
+	if queueName != "otpsender" {
+		t.Errorf("expected queueName=otpsender, got=%q", queueName)
+	}
+	qMsgs.WithLabelValues("otpsender", "testns", "otpsender").Set(10)
+	scaleReasonGauge.WithLabelValues("otpsender", "testns", "otpsender",
+		string(ScaleReasonBacklog)).Set(1)
+
+	// the series of the old queue are deleted when the queue changes
+	c.getMetricQueueName(key, "otpsender", "testns", "otpsender-v2")
+	if qMsgs.DeleteLabelValues("otpsender", "testns", "otpsender") {
+		t.Errorf("expected the series of the old queue to be deleted")
+	}
+	if scaleReasonGauge.DeleteLabelValues("otpsender", "testns", "otpsender",
+		string(ScaleReasonBacklog)) {
+		t.Errorf("expected the scale reason series of the old queue to be deleted")
+	}
+
+	c.lowCardinalityMetrics = true
+	if queueName := c.getMetricQueueName(key, "otpsender", "testns", "otpsender-v2"); queueName != "" {
+		t.Errorf("expected an empty queueName in the low cardinality, got=%q", queueName)
+	}
+}
+
+func TestDeleteWorkerPodAutoScalerMetrics(t *testing.T) {
+	c := &Controller{metricSeries: new(sync.Map)}
+	key := "testns/otpsender"
+
+	queueName := c.getMetricQueueName(key, "otpsender", "testns", "otpsender")
+	workersDesired.WithLabelValues("otpsender", "testns", queueName).Set(5)
+	scheduleActive.WithLabelValues("otpsender", "testns", queueName, "weekdays").Set(1)
+	scheduleActive.WithLabelValues("otpsender", "testns", queueName, "weekends").Set(0)
+	c.setSchedules(key, []string{"weekdays", "weekends"})
+	loopCountSuccess.WithLabelValues("otpsender", "testns").Inc()
+
+	// the series of the removed schedule are deleted
+	c.setSchedules(key, []string{"weekdays"})
+	if scheduleActive.DeleteLabelValues("otpsender", "testns", queueName, "weekends") {
+		t.Errorf("expected the series of the removed schedule to be deleted")
+	}
+
+	c.deleteWorkerPodAutoScalerMetrics(key, "otpsender", "testns")
+	if workersDesired.DeleteLabelValues("otpsender", "testns", queueName) {
+		t.Errorf("expected the desired workers series to be deleted")
+	}
+	if scheduleActive.DeleteLabelValues("otpsender", "testns", queueName, "weekdays") {
+		t.Errorf("expected the schedule series to be deleted")
+	}
+	if loopCountSuccess.DeleteLabelValues("otpsender", "testns") {
+		t.Errorf("expected the loop count series to be deleted")
+	}
+	if _, ok := c.metricSeries.Load(key); ok {
+		t.Errorf("expected the series of the wpa to be forgotten")
+	}
+}
+
+func TestIsSuppressedByTolerance(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   ScalingResult
+		expected bool
+	}{
+		{"scale within tolerance", ScalingResult{
+			DesiredWorkers: 20, UnclampedDesiredWorkers: 21,
+			Reason: ScaleReasonWithinTolerance}, true},
+		{"no change", ScalingResult{
+			DesiredWorkers: 20, UnclampedDesiredWorkers: 20,
+			Reason: ScaleReasonWithinTolerance}, false},
+		{"scaled", ScalingResult{
+			DesiredWorkers: 30, UnclampedDesiredWorkers: 30,
+			Reason: ScaleReasonBacklog}, false},
+	}
+	for _, test := range tests {
+		if got := isSuppressedByTolerance(test.result, 20); got != test.expected {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+		}
+	}
+}
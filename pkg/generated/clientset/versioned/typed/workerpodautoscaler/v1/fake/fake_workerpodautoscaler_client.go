@@ -32,6 +32,10 @@ func (c *FakeK8sV1) WorkerPodAutoScalers(namespace string) v1.WorkerPodAutoScale
 	return &FakeWorkerPodAutoScalers{c, namespace}
 }
 
+func (c *FakeK8sV1) WorkerPodAutoScalerDefaults() v1.WorkerPodAutoScalerDefaultInterface {
+	return &FakeWorkerPodAutoScalerDefaults{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeK8sV1) RESTClient() rest.Interface {
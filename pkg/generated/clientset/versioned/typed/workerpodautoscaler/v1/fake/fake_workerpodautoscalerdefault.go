@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Practo Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	workerpodautoscalerv1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeWorkerPodAutoScalerDefaults implements WorkerPodAutoScalerDefaultInterface
+type FakeWorkerPodAutoScalerDefaults struct {
+	Fake *FakeK8sV1
+}
+
+var workerpodautoscalerdefaultsResource = schema.GroupVersionResource{Group: "k8s.practo.dev", Version: "v1", Resource: "workerpodautoscalerdefaults"}
+
+var workerpodautoscalerdefaultsKind = schema.GroupVersionKind{Group: "k8s.practo.dev", Version: "v1", Kind: "WorkerPodAutoScalerDefault"}
+
+// Get takes name of the workerPodAutoScalerDefault, and returns the corresponding workerPodAutoScalerDefault object, and an error if there is any.
+func (c *FakeWorkerPodAutoScalerDefaults) Get(ctx context.Context, name string, options v1.GetOptions) (result *workerpodautoscalerv1.WorkerPodAutoScalerDefault, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(workerpodautoscalerdefaultsResource, name), &workerpodautoscalerv1.WorkerPodAutoScalerDefault{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*workerpodautoscalerv1.WorkerPodAutoScalerDefault), err
+}
+
+// List takes label and field selectors, and returns the list of WorkerPodAutoScalerDefaults that match those selectors.
+func (c *FakeWorkerPodAutoScalerDefaults) List(ctx context.Context, opts v1.ListOptions) (result *workerpodautoscalerv1.WorkerPodAutoScalerDefaultList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(workerpodautoscalerdefaultsResource, workerpodautoscalerdefaultsKind, opts), &workerpodautoscalerv1.WorkerPodAutoScalerDefaultList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &workerpodautoscalerv1.WorkerPodAutoScalerDefaultList{ListMeta: obj.(*workerpodautoscalerv1.WorkerPodAutoScalerDefaultList).ListMeta}
+	for _, item := range obj.(*workerpodautoscalerv1.WorkerPodAutoScalerDefaultList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested workerPodAutoScalerDefaults.
+func (c *FakeWorkerPodAutoScalerDefaults) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(workerpodautoscalerdefaultsResource, opts))
+}
+
+// Create takes the representation of a workerPodAutoScalerDefault and creates it.  Returns the server's representation of the workerPodAutoScalerDefault, and an error, if there is any.
+func (c *FakeWorkerPodAutoScalerDefaults) Create(ctx context.Context, workerPodAutoScalerDefault *workerpodautoscalerv1.WorkerPodAutoScalerDefault, opts v1.CreateOptions) (result *workerpodautoscalerv1.WorkerPodAutoScalerDefault, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(workerpodautoscalerdefaultsResource, workerPodAutoScalerDefault), &workerpodautoscalerv1.WorkerPodAutoScalerDefault{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*workerpodautoscalerv1.WorkerPodAutoScalerDefault), err
+}
+
+// Update takes the representation of a workerPodAutoScalerDefault and updates it. Returns the server's representation of the workerPodAutoScalerDefault, and an error, if there is any.
+func (c *FakeWorkerPodAutoScalerDefaults) Update(ctx context.Context, workerPodAutoScalerDefault *workerpodautoscalerv1.WorkerPodAutoScalerDefault, opts v1.UpdateOptions) (result *workerpodautoscalerv1.WorkerPodAutoScalerDefault, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(workerpodautoscalerdefaultsResource, workerPodAutoScalerDefault), &workerpodautoscalerv1.WorkerPodAutoScalerDefault{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*workerpodautoscalerv1.WorkerPodAutoScalerDefault), err
+}
+
+// Delete takes name of the workerPodAutoScalerDefault and deletes it. Returns an error if one occurs.
+func (c *FakeWorkerPodAutoScalerDefaults) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(workerpodautoscalerdefaultsResource, name), &workerpodautoscalerv1.WorkerPodAutoScalerDefault{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeWorkerPodAutoScalerDefaults) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(workerpodautoscalerdefaultsResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &workerpodautoscalerv1.WorkerPodAutoScalerDefaultList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched workerPodAutoScalerDefault.
+func (c *FakeWorkerPodAutoScalerDefaults) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *workerpodautoscalerv1.WorkerPodAutoScalerDefault, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(workerpodautoscalerdefaultsResource, name, pt, data, subresources...), &workerpodautoscalerv1.WorkerPodAutoScalerDefault{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*workerpodautoscalerv1.WorkerPodAutoScalerDefault), err
+}
@@ -27,6 +27,7 @@ import (
 type K8sV1Interface interface {
 	RESTClient() rest.Interface
 	WorkerPodAutoScalersGetter
+	WorkerPodAutoScalerDefaultsGetter
 }
 
 // K8sV1Client is used to interact with features provided by the k8s.practo.dev group.
@@ -38,6 +39,10 @@ func (c *K8sV1Client) WorkerPodAutoScalers(namespace string) WorkerPodAutoScaler
 	return newWorkerPodAutoScalers(c, namespace)
 }
 
+func (c *K8sV1Client) WorkerPodAutoScalerDefaults() WorkerPodAutoScalerDefaultInterface {
+	return newWorkerPodAutoScalerDefaults(c)
+}
+
 // NewForConfig creates a new K8sV1Client for the given config.
 func NewForConfig(c *rest.Config) (*K8sV1Client, error) {
 	config := *c
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 Practo Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	scheme "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// WorkerPodAutoScalerDefaultsGetter has a method to return a WorkerPodAutoScalerDefaultInterface.
+// A group's client should implement this interface.
+type WorkerPodAutoScalerDefaultsGetter interface {
+	WorkerPodAutoScalerDefaults() WorkerPodAutoScalerDefaultInterface
+}
+
+// WorkerPodAutoScalerDefaultInterface has methods to work with WorkerPodAutoScalerDefault resources.
+type WorkerPodAutoScalerDefaultInterface interface {
+	Create(ctx context.Context, workerPodAutoScalerDefault *v1.WorkerPodAutoScalerDefault, opts metav1.CreateOptions) (*v1.WorkerPodAutoScalerDefault, error)
+	Update(ctx context.Context, workerPodAutoScalerDefault *v1.WorkerPodAutoScalerDefault, opts metav1.UpdateOptions) (*v1.WorkerPodAutoScalerDefault, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.WorkerPodAutoScalerDefault, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.WorkerPodAutoScalerDefaultList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.WorkerPodAutoScalerDefault, err error)
+	WorkerPodAutoScalerDefaultExpansion
+}
+
+// workerPodAutoScalerDefaults implements WorkerPodAutoScalerDefaultInterface
+type workerPodAutoScalerDefaults struct {
+	client rest.Interface
+}
+
+// newWorkerPodAutoScalerDefaults returns a WorkerPodAutoScalerDefaults
+func newWorkerPodAutoScalerDefaults(c *K8sV1Client) *workerPodAutoScalerDefaults {
+	return &workerPodAutoScalerDefaults{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the workerPodAutoScalerDefault, and returns the corresponding workerPodAutoScalerDefault object, and an error if there is any.
+func (c *workerPodAutoScalerDefaults) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.WorkerPodAutoScalerDefault, err error) {
+	result = &v1.WorkerPodAutoScalerDefault{}
+	err = c.client.Get().
+		Resource("workerpodautoscalerdefaults").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of WorkerPodAutoScalerDefaults that match those selectors.
+func (c *workerPodAutoScalerDefaults) List(ctx context.Context, opts metav1.ListOptions) (result *v1.WorkerPodAutoScalerDefaultList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.WorkerPodAutoScalerDefaultList{}
+	err = c.client.Get().
+		Resource("workerpodautoscalerdefaults").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested workerPodAutoScalerDefaults.
+func (c *workerPodAutoScalerDefaults) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("workerpodautoscalerdefaults").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a workerPodAutoScalerDefault and creates it.  Returns the server's representation of the workerPodAutoScalerDefault, and an error, if there is any.
+func (c *workerPodAutoScalerDefaults) Create(ctx context.Context, workerPodAutoScalerDefault *v1.WorkerPodAutoScalerDefault, opts metav1.CreateOptions) (result *v1.WorkerPodAutoScalerDefault, err error) {
+	result = &v1.WorkerPodAutoScalerDefault{}
+	err = c.client.Post().
+		Resource("workerpodautoscalerdefaults").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(workerPodAutoScalerDefault).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a workerPodAutoScalerDefault and updates it. Returns the server's representation of the workerPodAutoScalerDefault, and an error, if there is any.
+func (c *workerPodAutoScalerDefaults) Update(ctx context.Context, workerPodAutoScalerDefault *v1.WorkerPodAutoScalerDefault, opts metav1.UpdateOptions) (result *v1.WorkerPodAutoScalerDefault, err error) {
+	result = &v1.WorkerPodAutoScalerDefault{}
+	err = c.client.Put().
+		Resource("workerpodautoscalerdefaults").
+		Name(workerPodAutoScalerDefault.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(workerPodAutoScalerDefault).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the workerPodAutoScalerDefault and deletes it. Returns an error if one occurs.
+func (c *workerPodAutoScalerDefaults) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("workerpodautoscalerdefaults").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *workerPodAutoScalerDefaults) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("workerpodautoscalerdefaults").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched workerPodAutoScalerDefault.
+func (c *workerPodAutoScalerDefaults) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.WorkerPodAutoScalerDefault, err error) {
+	result = &v1.WorkerPodAutoScalerDefault{}
+	err = c.client.Patch(pt).
+		Resource("workerpodautoscalerdefaults").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -55,6 +55,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=k8s.practo.dev, Version=v1
 	case v1.SchemeGroupVersion.WithResource("workerpodautoscalers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.K8s().V1().WorkerPodAutoScalers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("workerpodautoscalerdefaults"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.K8s().V1().WorkerPodAutoScalerDefaults().Informer()}, nil
 
 	}
 
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// WorkerPodAutoScalers returns a WorkerPodAutoScalerInformer.
 	WorkerPodAutoScalers() WorkerPodAutoScalerInformer
+	// WorkerPodAutoScalerDefaults returns a WorkerPodAutoScalerDefaultInformer.
+	WorkerPodAutoScalerDefaults() WorkerPodAutoScalerDefaultInformer
 }
 
 type version struct {
@@ -43,3 +45,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) WorkerPodAutoScalers() WorkerPodAutoScalerInformer {
 	return &workerPodAutoScalerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// WorkerPodAutoScalerDefaults returns a WorkerPodAutoScalerDefaultInformer.
+func (v *version) WorkerPodAutoScalerDefaults() WorkerPodAutoScalerDefaultInformer {
+	return &workerPodAutoScalerDefaultInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Practo Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	workerpodautoscalerv1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	versioned "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/generated/listers/workerpodautoscaler/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// WorkerPodAutoScalerDefaultInformer provides access to a shared informer and lister for
+// WorkerPodAutoScalerDefaults.
+type WorkerPodAutoScalerDefaultInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.WorkerPodAutoScalerDefaultLister
+}
+
+type workerPodAutoScalerDefaultInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewWorkerPodAutoScalerDefaultInformer constructs a new informer for WorkerPodAutoScalerDefault type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewWorkerPodAutoScalerDefaultInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredWorkerPodAutoScalerDefaultInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredWorkerPodAutoScalerDefaultInformer constructs a new informer for WorkerPodAutoScalerDefault type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredWorkerPodAutoScalerDefaultInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.K8sV1().WorkerPodAutoScalerDefaults().List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.K8sV1().WorkerPodAutoScalerDefaults().Watch(context.TODO(), options)
+			},
+		},
+		&workerpodautoscalerv1.WorkerPodAutoScalerDefault{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *workerPodAutoScalerDefaultInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredWorkerPodAutoScalerDefaultInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *workerPodAutoScalerDefaultInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&workerpodautoscalerv1.WorkerPodAutoScalerDefault{}, f.defaultInformer)
+}
+
+func (f *workerPodAutoScalerDefaultInformer) Lister() v1.WorkerPodAutoScalerDefaultLister {
+	return v1.NewWorkerPodAutoScalerDefaultLister(f.Informer().GetIndexer())
+}
@@ -25,3 +25,7 @@ type WorkerPodAutoScalerListerExpansion interface{}
 // WorkerPodAutoScalerNamespaceListerExpansion allows custom methods to be added to
 // WorkerPodAutoScalerNamespaceLister.
 type WorkerPodAutoScalerNamespaceListerExpansion interface{}
+
+// WorkerPodAutoScalerDefaultListerExpansion allows custom methods to be added to
+// WorkerPodAutoScalerDefaultLister.
+type WorkerPodAutoScalerDefaultListerExpansion interface{}
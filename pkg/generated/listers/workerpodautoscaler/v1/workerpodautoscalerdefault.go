@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Practo Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WorkerPodAutoScalerDefaultLister helps list WorkerPodAutoScalerDefaults.
+// All objects returned here must be treated as read-only.
+type WorkerPodAutoScalerDefaultLister interface {
+	// List lists all WorkerPodAutoScalerDefaults in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.WorkerPodAutoScalerDefault, err error)
+	// Get retrieves the WorkerPodAutoScalerDefault from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.WorkerPodAutoScalerDefault, error)
+	WorkerPodAutoScalerDefaultListerExpansion
+}
+
+// workerPodAutoScalerDefaultLister implements the WorkerPodAutoScalerDefaultLister interface.
+type workerPodAutoScalerDefaultLister struct {
+	indexer cache.Indexer
+}
+
+// NewWorkerPodAutoScalerDefaultLister returns a new WorkerPodAutoScalerDefaultLister.
+func NewWorkerPodAutoScalerDefaultLister(indexer cache.Indexer) WorkerPodAutoScalerDefaultLister {
+	return &workerPodAutoScalerDefaultLister{indexer: indexer}
+}
+
+// List lists all WorkerPodAutoScalerDefaults in the indexer.
+func (s *workerPodAutoScalerDefaultLister) List(selector labels.Selector) (ret []*v1.WorkerPodAutoScalerDefault, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.WorkerPodAutoScalerDefault))
+	})
+	return ret, err
+}
+
+// Get retrieves the WorkerPodAutoScalerDefault from the index for a given name.
+func (s *workerPodAutoScalerDefaultLister) Get(name string) (*v1.WorkerPodAutoScalerDefault, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("workerpodautoscalerdefault"), name)
+	}
+	return obj.(*v1.WorkerPodAutoScalerDefault), nil
+}
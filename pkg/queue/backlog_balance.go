@@ -0,0 +1,46 @@
+package queue
+
+import (
+	"math"
+	"time"
+)
+
+// backlogBalanceWindow is the window of the rolling backlog balance,
+// older samples decay exponentially
+const backlogBalanceWindow = 5 * time.Minute
+
+// estimateBacklogBalance updates the rolling balance of the queue in
+// messages per minute, the inflow minus the outflow, with the messages
+// processed between two polls:
+//
+//	processed = messagesSent - (newMessages - oldMessages)
+//	sample = (messagesSent - processed) / elapsed
+//
+// A positive balance means the backlog grows as the workers do not keep
+// up with the messages sent, a negative balance means it drains. The
+// processed messages are never negative, the messages which are deleted
+// from the queue without being processed are not outflow.
+// It returns the previous balance if no sample can be taken.
+func estimateBacklogBalance(
+	previous float64,
+	known bool,
+	oldMessages int32,
+	newMessages int32,
+	messagesSentPerMinute float64,
+	elapsed time.Duration) (float64, bool) {
+
+	if oldMessages < 0 || newMessages < 0 ||
+		messagesSentPerMinute < 0 || elapsed <= 0 {
+		return previous, known
+	}
+
+	sent := messagesSentPerMinute * elapsed.Minutes()
+	processed := math.Max(sent-float64(newMessages-oldMessages), 0)
+	sample := (sent - processed) / elapsed.Minutes()
+	if !known {
+		return sample, true
+	}
+
+	alpha := 1 - math.Exp(-elapsed.Seconds()/backlogBalanceWindow.Seconds())
+	return previous + alpha*(sample-previous), true
+}
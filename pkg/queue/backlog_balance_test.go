@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEstimateBacklogBalance(t *testing.T) {
+	tests := []struct {
+		name                  string
+		previous              float64
+		known                 bool
+		oldMessages           int32
+		newMessages           int32
+		messagesSentPerMinute float64
+		elapsed               time.Duration
+		expected              float64
+		expectedKnown         bool
+	}{
+		{
+			// 120 sent - 60 processed, the backlog grows by 60 a minute
+			name:                  "growing backlog",
+			oldMessages:           100,
+			newMessages:           160,
+			messagesSentPerMinute: 120,
+			elapsed:               time.Minute,
+			expected:              60,
+			expectedKnown:         true,
+		},
+		{
+			// 60 sent - 120 processed
+			name:                  "draining backlog",
+			oldMessages:           100,
+			newMessages:           40,
+			messagesSentPerMinute: 60,
+			elapsed:               time.Minute,
+			expected:              -60,
+			expectedKnown:         true,
+		},
+		{
+			// the messages grew by more than sent, nothing was processed
+			name:                  "no outflow",
+			oldMessages:           100,
+			newMessages:           300,
+			messagesSentPerMinute: 60,
+			elapsed:               time.Minute,
+			expected:              60,
+			expectedKnown:         true,
+		},
+		{
+			// the sample of -60 is weighted by 1-e^(-1/5)
+			name:                  "rolling balance",
+			previous:              60,
+			known:                 true,
+			oldMessages:           100,
+			newMessages:           40,
+			messagesSentPerMinute: 60,
+			elapsed:               time.Minute,
+			expected:              60 + (1-math.Exp(-0.2))*-120,
+			expectedKnown:         true,
+		},
+		{
+			name:                  "messages sent not known",
+			previous:              60,
+			known:                 true,
+			oldMessages:           100,
+			newMessages:           40,
+			messagesSentPerMinute: UnsyncedMessagesSentPerMinute,
+			elapsed:               time.Minute,
+			expected:              60,
+			expectedKnown:         true,
+		},
+		{
+			name:                  "first poll",
+			oldMessages:           UnsyncedQueueMessageCount,
+			newMessages:           40,
+			messagesSentPerMinute: 60,
+			elapsed:               time.Minute,
+			expected:              0,
+			expectedKnown:         false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			balance, known := estimateBacklogBalance(
+				test.previous,
+				test.known,
+				test.oldMessages,
+				test.newMessages,
+				test.messagesSentPerMinute,
+				test.elapsed,
+			)
+			if known != test.expectedKnown || math.Abs(balance-test.expected) > 1e-9 {
+				t.Errorf("expected balance=%v, known=%v, got balance=%v, known=%v",
+					test.expected, test.expectedKnown, balance, known)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"github.com/practo/klog/v2"
+)
+
+// getBacklogStalenessGuardPolls returns the number of polls in a row
+// which must read zero messages before a drop to zero is used
+func getBacklogStalenessGuardPolls(spec QueueSpec) int32 {
+	if spec.sqsOptions == nil {
+		return 0
+	}
+	return spec.sqsOptions.BacklogStalenessGuardPolls
+}
+
+// guardStaleZeroBacklog returns the messages of the queue to use for the
+// messages read by the poll. The approximate number of messages of SQS is
+// eventually consistent and can briefly read zero after a burst, a drop
+// from a backlog to zero is used only once it is read by the guard polls
+// in a row, till then the backlog of the earlier polls is kept.
+func guardStaleZeroBacklog(key string, spec *QueueSpec, messages int32) int32 {
+	guardPolls := getBacklogStalenessGuardPolls(*spec)
+	if messages != 0 || guardPolls <= 1 || spec.messages <= 0 {
+		spec.zeroBacklogPolls = 0
+		return messages
+	}
+
+	spec.zeroBacklogPolls++
+	if spec.zeroBacklogPolls >= guardPolls {
+		spec.zeroBacklogPolls = 0
+		return 0
+	}
+	klog.V(2).Infof("%s: read zero messages %d/%d times, keeping the backlog %d",
+		key, spec.zeroBacklogPolls, guardPolls, spec.messages)
+	return spec.messages
+}
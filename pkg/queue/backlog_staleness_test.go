@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestGuardStaleZeroBacklog(t *testing.T) {
+	queues := NewQueues()
+	stop := make(chan struct{})
+	defer close(stop)
+	go queues.Sync(stop)
+
+	queues.Add("testns", "otpsender",
+		"https://sqs.ap-south-1.amazonaws.com/123456789012/otpsender",
+		1, 0.0, false, nil, nil, "", &SQSOptions{BacklogStalenessGuardPolls: 3})
+	key := getKey("testns", "otpsender")
+
+	polls := []struct {
+		read     int32
+		messages int32
+	}{
+		{100, 100},
+		// a transient zero keeps the backlog
+		{0, 100},
+		{80, 80},
+		// the zero is used when it is read by 3 polls in a row
+		{0, 80},
+		{0, 80},
+		{0, 0},
+		{0, 0},
+		{10, 10},
+	}
+	for i, poll := range polls {
+		queues.updateMessage(key, poll.read)
+		_, messages, _, _ := queues.GetQueueInfo("testns", "otpsender")
+		if messages != poll.messages {
+			t.Errorf("poll %d: read %d, expected %d messages, got=%d",
+				i, poll.read, poll.messages, messages)
+		}
+	}
+}
+
+func TestGuardStaleZeroBacklogDisabled(t *testing.T) {
+	spec := QueueSpec{messages: 100}
+	if messages := guardStaleZeroBacklog("testns/otpsender", &spec, 0); messages != 0 {
+		t.Errorf("expected the zero backlog without the guard, got=%d", messages)
+	}
+
+	// the first poll of the queue is not guarded
+	spec = QueueSpec{
+		messages:   UnsyncedQueueMessageCount,
+		sqsOptions: &SQSOptions{BacklogStalenessGuardPolls: 3},
+	}
+	if messages := guardStaleZeroBacklog("testns/otpsender", &spec, 0); messages != 0 {
+		t.Errorf("expected the zero backlog of the first poll, got=%d", messages)
+	}
+}
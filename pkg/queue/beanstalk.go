@@ -318,8 +318,8 @@ func (b *Beanstalk) longPollReceiveMessage(
 	return messages, idleWorkers, err
 }
 
-func (b *Beanstalk) waitForShortPollInterval() {
-	time.Sleep(b.shortPollInterval)
+func (b *Beanstalk) waitForShortPollInterval(queueSpec QueueSpec) {
+	waitForPollInterval(b.shortPollInterval, queueSpec.pollNowCh)
 }
 
 func (b *Beanstalk) reestablishConn(queueURI string) {
@@ -337,7 +337,11 @@ func (b *Beanstalk) GetName() string {
 	return b.name
 }
 
-func (b *Beanstalk) poll(key string, queueSpec QueueSpec) {
+// reset has nothing to drop, the idle workers are read from the tube
+// stats on every poll and the connection is re-established by the poll
+func (b *Beanstalk) reset(key string, queueSpec QueueSpec) {}
+
+func (b *Beanstalk) poll(key string, queueSpec QueueSpec) error {
 	if queueSpec.workers == 0 && queueSpec.messages == 0 {
 		// If there are no workers running we do a long poll to find a job(s)
 		// in the queue. On finding job(s) we increment the queue message
@@ -345,18 +349,18 @@ func (b *Beanstalk) poll(key string, queueSpec QueueSpec) {
 		messagesReceived, idleWorkers, err := b.longPollReceiveMessage(queueSpec.uri)
 		e, ok := err.(beanstalk.ConnError)
 		if ok && e.Err == beanstalk.ErrNotFound {
-			return
+			return nil
 		}
 		if err != nil {
 			klog.Errorf("Unable to perform request long polling %q, %v.",
 				queueSpec.name, err)
 			b.reestablishConn(queueSpec.uri)
-			return
+			return newPollError(ErrQueueTransient, err)
 		}
 
 		b.queues.updateMessage(key, messagesReceived)
 		b.queues.updateIdleWorkers(key, idleWorkers)
-		return
+		return nil
 	}
 
 	// TODO: beanstalk does not support secondsToProcessOneJob at present
@@ -377,15 +381,15 @@ func (b *Beanstalk) poll(key string, queueSpec QueueSpec) {
 		klog.Errorf("Unable to get approximate messages in queue %q, %v.",
 			queueSpec.name, err)
 		b.reestablishConn(queueSpec.uri)
-		return
+		return newPollError(ErrQueueTransient, err)
 	}
 	klog.V(3).Infof("%s: approxMessages=%d", queueSpec.name, approxMessages)
 	b.queues.updateMessage(key, approxMessages+approxMessagesNotVisible)
 
 	if approxMessages != 0 {
 		b.queues.updateIdleWorkers(key, -1)
-		b.waitForShortPollInterval()
-		return
+		b.waitForShortPollInterval(queueSpec)
+		return nil
 	}
 
 	// approxMessagesNotVisible is queried to prevent scaling down when their are
@@ -396,8 +400,8 @@ func (b *Beanstalk) poll(key string, queueSpec QueueSpec) {
 
 	if approxMessagesNotVisible > 0 {
 		klog.V(3).Infof("%s: approxMessagesNotVisible > 0, not scaling down", queueSpec.name)
-		b.waitForShortPollInterval()
-		return
+		b.waitForShortPollInterval(queueSpec)
+		return nil
 	}
 
 	idleWorkers, err := b.getIdleWorkers(queueSpec.uri)
@@ -405,8 +409,7 @@ func (b *Beanstalk) poll(key string, queueSpec QueueSpec) {
 		klog.Errorf("Unable to fetch idle workers %q, %v.",
 			queueSpec.name, err)
 		b.reestablishConn(queueSpec.uri)
-		time.Sleep(100 * time.Millisecond)
-		return
+		return newPollError(ErrQueueTransient, err)
 	}
 
 	klog.V(3).Infof("%s: workers=%d, idleWorkers=%d",
@@ -415,6 +418,6 @@ func (b *Beanstalk) poll(key string, queueSpec QueueSpec) {
 		idleWorkers,
 	)
 	b.queues.updateIdleWorkers(key, idleWorkers)
-	b.waitForShortPollInterval()
-	return
+	b.waitForShortPollInterval(queueSpec)
+	return nil
 }
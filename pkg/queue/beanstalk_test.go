@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"errors"
 	"github.com/practo/klog/v2"
 	"os/exec"
 	"testing"
@@ -46,6 +47,11 @@ func buildQueues(
 			getQueueURI(spec.namespace, spec.name),
 			spec.workers,
 			spec.secondsToProcessOneJob,
+			false,
+			nil,
+			nil,
+			"",
+			nil,
 		)
 		<-doneChan
 	}
@@ -338,6 +344,86 @@ func TestPollSyncWhenNoMessagesInQueueAndNoMessagesAreInFlight(t *testing.T) {
 	}
 }
 
+func TestPollSyncWhenQueueUnreachable(t *testing.T) {
+	doneChan := make(chan struct{}, 1)
+	doneQueueSync = func() {
+		doneChan <- struct{}{}
+	}
+	defer func() {
+		doneQueueSync = func() {}
+	}()
+
+	queueSpecs := []QueueSpec{
+		QueueSpec{
+			name:                   "otpsender",
+			namespace:              "testns",
+			workers:                10,
+			secondsToProcessOneJob: 0.0,
+		},
+	}
+	messages := int32(25)
+	name := queueSpecs[0].name
+	namespace := queueSpecs[0].namespace
+	queueURI := getQueueURI(namespace, name)
+	queues, poller, err := buildQueues(doneChan, queueSpecs)
+
+	if err != nil {
+		klog.Fatalf("error setting up beanstalk test: %v\n", err)
+	}
+	key := getKey(namespace, name)
+	queues.updateMessage(key, messages)
+	<-doneChan
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockBeanstalkClient := NewMockBeanstalkClientInterface(mockCtrl)
+	gomock.InOrder(
+		mockBeanstalkClient.
+			EXPECT().
+			getStats().
+			Return(int32(0), int32(0), int32(0), errors.New("dial-error: connection refused")).
+			Times(1),
+		mockBeanstalkClient.
+			EXPECT().
+			reestablishConn().
+			Return(nil).
+			Times(1),
+		mockBeanstalkClient.
+			EXPECT().
+			getStats().
+			Return(messages, int32(0), int32(0), nil).
+			Times(1),
+	)
+
+	// TODO: due to this call not able to use poller as an interface
+	poller.clientPool.Store(queueURI, mockBeanstalkClient)
+
+	klog.Info("Running poll and sync with the queue unreachable.")
+	err = poller.poll(key, queues.item[key])
+	if !errors.Is(err, ErrQueueTransient) {
+		t.Errorf("expected a transient poll error, got=%v\n", err)
+	}
+	if err == nil || err.Error() != "dial-error: connection refused" {
+		t.Errorf("expected the dial error, got=%v\n", err)
+	}
+
+	klog.Info("Running poll and sync with the queue reachable.")
+	err = poller.poll(key, queues.item[key])
+	if err != nil {
+		t.Errorf("expected no poll error, got=%v\n", err)
+	}
+	<-doneChan
+	<-doneChan
+
+	healthy, lastPollError := queues.GetQueueHealth(namespace, name)
+	if !healthy {
+		t.Errorf("expected queue healthy\n")
+	}
+	if lastPollError != "" {
+		t.Errorf("expected no lastPollError, got=%q\n", lastPollError)
+	}
+}
+
 func runBeanstalkdProcess(
 	startCh chan bool, killCh chan bool, doneCh chan bool) {
 
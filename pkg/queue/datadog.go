@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practo/klog/v2"
+)
+
+const (
+	// datadogQueryTimeout is the timeout of one query to datadog
+	datadogQueryTimeout = 10 * time.Second
+	// datadogQueryWindow is the time range queried, the last point of
+	// the series in the range is the backlog
+	datadogQueryWindow = 5 * time.Minute
+
+	datadogAPIKey = "DD_API_KEY"
+	datadogAppKey = "DD_APP_KEY"
+)
+
+// Datadog is used by the Poller to get the backlog from the result of
+// a Datadog metric query, it implements the QueuingService interface.
+// The queueURI is the api url of the Datadog site, the query is in the
+// WPA spec and the api and application keys are read from the
+// credentials. The query should return a single series.
+type Datadog struct {
+	name   string
+	queues *Queues
+	client *http.Client
+
+	shortPollInterval time.Duration
+}
+
+func NewDatadog(
+	name string,
+	queues *Queues,
+	shortPollInterval int) (QueuingService, error) {
+
+	return &Datadog{
+		name:   name,
+		queues: queues,
+		client: &http.Client{Timeout: datadogQueryTimeout},
+
+		shortPollInterval: time.Second * time.Duration(shortPollInterval),
+	}, nil
+}
+
+// datadogResponse is the response of the /api/v1/query api
+type datadogResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Series []struct {
+		// Pointlist is the [<unix time in ms>, <value>] pairs,
+		// the value is null when there is no data at the time
+		Pointlist [][2]*float64 `json:"pointlist"`
+	} `json:"series"`
+}
+
+// errNoDatadogResult is returned when the query has no result,
+// the backlog is then unknown
+var errNoDatadogResult = fmt.Errorf("query returned no result")
+
+// query runs the query over the query window and returns
+// the last value of its series
+func (d *Datadog) query(
+	baseURI string, query string, credentials *Credentials) (float64, error) {
+
+	if credentials == nil {
+		return 0, newPollError(ErrQueueAuth, fmt.Errorf(
+			"%s and %s must be set in the credentials", datadogAPIKey, datadogAppKey))
+	}
+	apiKey := string(credentials.Data[datadogAPIKey])
+	appKey := string(credentials.Data[datadogAppKey])
+	if apiKey == "" || appKey == "" {
+		return 0, newPollError(ErrQueueAuth, fmt.Errorf(
+			"%s and %s must be set in the credentials", datadogAPIKey, datadogAppKey))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), datadogQueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	queryURL := strings.TrimSuffix(baseURI, "/") + "/api/v1/query?" +
+		url.Values{
+			"query": []string{query},
+			"from":  []string{strconv.FormatInt(now.Add(-datadogQueryWindow).Unix(), 10)},
+			"to":    []string{strconv.FormatInt(now.Unix(), 10)},
+		}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("DD-API-KEY", apiKey)
+	req.Header.Set("DD-APPLICATION-KEY", appKey)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	value, err := parseDatadogResponse(resp.StatusCode, body)
+	if err != nil && resp.StatusCode != http.StatusOK {
+		return 0, classifyHTTPError(resp.StatusCode, err)
+	}
+	return value, err
+}
+
+// parseDatadogResponse returns the last value of the single series
+// in the response
+func parseDatadogResponse(statusCode int, body []byte) (float64, error) {
+	var response datadogResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("unable to parse the response, status: %d, err: %v",
+			statusCode, err)
+	}
+	if statusCode != http.StatusOK || response.Status == "error" {
+		return 0, fmt.Errorf("query failed, status: %d, err: %s",
+			statusCode, response.Error)
+	}
+
+	if len(response.Series) == 0 {
+		return 0, errNoDatadogResult
+	}
+	if len(response.Series) > 1 {
+		return 0, fmt.Errorf(
+			"query returned %d series, expected a single series",
+			len(response.Series))
+	}
+
+	points := response.Series[0].Pointlist
+	for i := len(points) - 1; i >= 0; i-- {
+		value := points[i][1]
+		if value == nil || math.IsNaN(*value) || math.IsInf(*value, 0) {
+			continue
+		}
+		return *value, nil
+	}
+	return 0, errNoDatadogResult
+}
+
+func (d *Datadog) waitForShortPollInterval(queueSpec QueueSpec) {
+	waitForPollInterval(d.shortPollInterval, queueSpec.pollNowCh)
+}
+
+func (d *Datadog) GetName() string {
+	return d.name
+}
+
+// reset has nothing to drop, the backlog is the result of the query
+func (d *Datadog) reset(key string, queueSpec QueueSpec) {}
+
+func (d *Datadog) poll(key string, queueSpec QueueSpec) error {
+	// the idle workers are not known from a metric
+	d.queues.updateIdleWorkers(key, -1)
+
+	value, err := d.query(queueSpec.uri, queueSpec.query, queueSpec.credentials)
+	if err != nil {
+		klog.Errorf("Unable to query datadog for queue %q, %v.",
+			queueSpec.name, err)
+		// the backlog is unknown, the wpa is not scaled till
+		// the query returns a result
+		d.queues.updateMessage(key, UnsyncedQueueMessageCount)
+		return newPollError(ErrQueueTransient, err)
+	}
+
+	messages := int32(math.Min(math.Ceil(math.Max(value, 0)), math.MaxInt32))
+	klog.V(3).Infof("%s: datadog value=%v, messages=%d",
+		queueSpec.name, value, messages)
+	d.queues.updateMessage(key, messages)
+	d.waitForShortPollInterval(queueSpec)
+	return nil
+}
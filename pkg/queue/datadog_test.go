@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseDatadogResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected float64
+		err      bool
+	}{
+		{
+			name:     "single series",
+			status:   http.StatusOK,
+			body:     `{"status":"ok","series":[{"pointlist":[[1617000000000,40],[1617000060000,42.5]]}]}`,
+			expected: 42.5,
+		},
+		{
+			name:     "last point without data",
+			status:   http.StatusOK,
+			body:     `{"status":"ok","series":[{"pointlist":[[1617000000000,40],[1617000060000,null]]}]}`,
+			expected: 40,
+		},
+		{
+			name:   "no series",
+			status: http.StatusOK,
+			body:   `{"status":"ok","series":[]}`,
+			err:    true,
+		},
+		{
+			name:   "no points",
+			status: http.StatusOK,
+			body:   `{"status":"ok","series":[{"pointlist":[[1617000000000,null]]}]}`,
+			err:    true,
+		},
+		{
+			name:   "many series",
+			status: http.StatusOK,
+			body:   `{"status":"ok","series":[{"pointlist":[[1,1]]},{"pointlist":[[1,2]]}]}`,
+			err:    true,
+		},
+		{
+			name:   "query error",
+			status: http.StatusOK,
+			body:   `{"status":"error","error":"Error parsing query"}`,
+			err:    true,
+		},
+		{
+			name:   "forbidden",
+			status: http.StatusForbidden,
+			body:   `{"errors":["Forbidden"]}`,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		value, err := parseDatadogResponse(test.status, []byte(test.body))
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got value=%v\n", test.name, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got=%v\n", test.name, err)
+			continue
+		}
+		if value != test.expected {
+			t.Errorf("%s: expected value=%v, got=%v\n",
+				test.name, test.expected, value)
+		}
+	}
+}
+
+func TestDatadogQueueService(t *testing.T) {
+	tests := map[string]string{
+		"https://api.datadoghq.com":      DatadogQueueService,
+		"https://api.us5.datadoghq.com/": DatadogQueueService,
+		"https://api.datadoghq.eu":       DatadogQueueService,
+		"https://api.ddog-gov.com":       DatadogQueueService,
+		"https://app.datadoghq.com":      PrometheusQueueService,
+		"http://prometheus:9090":         PrometheusQueueService,
+	}
+	for uri, expected := range tests {
+		if got := GetQueueServiceName(uri); got != expected {
+			t.Errorf("%s: expected %q, got %q\n", uri, expected, got)
+		}
+	}
+}
+
+func TestDatadogPoll(t *testing.T) {
+	// a failed poll makes three updates before returning
+	doneChan := make(chan struct{}, 3)
+	doneQueueSync = func() {
+		doneChan <- struct{}{}
+	}
+	defer func() {
+		doneQueueSync = func() {}
+	}()
+
+	series := `[{"pointlist":[[1617000000000,24.2]]}]`
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("DD-API-KEY") != "api" ||
+				r.Header.Get("DD-APPLICATION-KEY") != "app" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"errors":["Forbidden"]}`)
+				return
+			}
+			if r.URL.Path != "/api/v1/query" ||
+				r.URL.Query().Get("query") != "sum:jobs.pending{*}" ||
+				r.URL.Query().Get("from") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"status":"error","error":"unexpected query"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"status":"ok","series":%s}`, series)
+		}))
+	defer server.Close()
+
+	credentials := &Credentials{
+		Data: map[string][]byte{
+			datadogAPIKey: []byte("api"),
+			datadogAppKey: []byte("app"),
+		},
+		Version: "1",
+	}
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	queues.Add("testns", "otpsender", server.URL, 1, 0.0, false, credentials, nil,
+		"sum:jobs.pending{*}", nil)
+	<-doneChan
+
+	poller, _ := NewDatadog(DatadogQueueService, queues, 0)
+	key := getKey("testns", "otpsender")
+	poller.poll(key, queues.ListQueue(key))
+	<-doneChan
+	<-doneChan
+
+	_, messages, _, idle := queues.GetQueueInfo("testns", "otpsender")
+	if messages != 25 {
+		t.Errorf("expected 25 messages, got=%v\n", messages)
+	}
+	if idle != -1 {
+		t.Errorf("expected -1 idle, got=%v\n", idle)
+	}
+
+	// the backlog is unknown when the query has no result
+	series = `[]`
+	err := poller.poll(key, queues.ListQueue(key))
+	<-doneChan
+	<-doneChan
+
+	_, messages, _, _ = queues.GetQueueInfo("testns", "otpsender")
+	if messages != UnsyncedQueueMessageCount {
+		t.Errorf("expected unsynced messages, got=%v\n", messages)
+	}
+	if !errors.Is(err, errNoDatadogResult) || !errors.Is(err, ErrQueueTransient) {
+		t.Errorf("expected a transient poll error with no result, got=%v\n", err)
+	}
+}
@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// The kinds of the failed polls, the error returned by the poll of a
+// queue service wraps one of them so that the poller and the controller
+// can react to the failure
+var (
+	// ErrQueueNotFound means the queue does not exist, the backlog is
+	// not known till the queue is created
+	ErrQueueNotFound = errors.New("queue not found")
+	// ErrQueueAuth means the credentials are missing or were rejected,
+	// retrying fast does not help
+	ErrQueueAuth = errors.New("queue authentication failed")
+	// ErrQueueThrottled means the requests to the queue service are
+	// throttled, the poll is backed off
+	ErrQueueThrottled = errors.New("queue requests throttled")
+	// ErrQueueTransient means the poll failed for a reason which is
+	// expected to go away, the poll is retried soon
+	ErrQueueTransient = errors.New("queue temporarily unavailable")
+)
+
+// The reasons of the QueueAvailable condition for the kinds of the poll errors
+const (
+	PollErrorReasonQueueNotFound    = "QueueNotFound"
+	PollErrorReasonQueueAuthFailed  = "QueueAuthFailed"
+	PollErrorReasonQueueThrottled   = "QueueThrottled"
+	PollErrorReasonQueueUnavailable = "QueueUnavailable"
+)
+
+const (
+	// pollBackoffQueueNotFound is the wait before the missing queue is polled again
+	pollBackoffQueueNotFound = 20 * time.Second
+	// pollBackoffQueueAuth is the wait before the queue is polled
+	// again with the rejected credentials
+	pollBackoffQueueAuth = time.Minute
+	// the waits after the throttled and the transient polls double
+	// with every consecutive failure up to their max
+	pollBackoffQueueThrottled    = 5 * time.Second
+	pollBackoffQueueThrottledMax = 5 * time.Minute
+	pollBackoffQueueTransient    = time.Second
+	pollBackoffQueueTransientMax = 30 * time.Second
+)
+
+// PollError is the error of a failed poll, Kind is one of the
+// ErrQueue errors and Err is the error of the queue service
+type PollError struct {
+	Kind error
+	Err  error
+}
+
+func (e *PollError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PollError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is match the kind of the poll error
+func (e *PollError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// newPollError wraps the error of the queue service in a poll error of
+// the kind, the error is returned as is if it is already classified
+func newPollError(kind error, err error) error {
+	var pollErr *PollError
+	if errors.As(err, &pollErr) {
+		return err
+	}
+	return &PollError{Kind: kind, Err: err}
+}
+
+// pollErrorKind returns the kind of the poll error,
+// the errors which are not classified are transient
+func pollErrorKind(err error) error {
+	for _, kind := range []error{ErrQueueNotFound, ErrQueueAuth, ErrQueueThrottled} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return ErrQueueTransient
+}
+
+// GetPollErrorReason returns the reason of the QueueAvailable
+// condition for the kind of the poll error
+func GetPollErrorReason(err error) string {
+	switch pollErrorKind(err) {
+	case ErrQueueNotFound:
+		return PollErrorReasonQueueNotFound
+	case ErrQueueAuth:
+		return PollErrorReasonQueueAuthFailed
+	case ErrQueueThrottled:
+		return PollErrorReasonQueueThrottled
+	}
+	return PollErrorReasonQueueUnavailable
+}
+
+// IsTerminalPollErrorReason tells if the poll keeps failing till the
+// queue or the credentials are fixed, the backlog is not known till then
+func IsTerminalPollErrorReason(reason string) bool {
+	return reason == PollErrorReasonQueueNotFound ||
+		reason == PollErrorReasonQueueAuthFailed
+}
+
+// getPollBackoff returns the wait before the queue is polled again
+// after the consecutive failures of its poll
+func getPollBackoff(err error, failures int) time.Duration {
+	switch pollErrorKind(err) {
+	case ErrQueueNotFound:
+		return pollBackoffQueueNotFound
+	case ErrQueueAuth:
+		return pollBackoffQueueAuth
+	case ErrQueueThrottled:
+		return exponentialBackoff(
+			pollBackoffQueueThrottled, pollBackoffQueueThrottledMax, failures)
+	}
+	return exponentialBackoff(
+		pollBackoffQueueTransient, pollBackoffQueueTransientMax, failures)
+}
+
+// exponentialBackoff doubles the base wait for every failure after the first
+func exponentialBackoff(base time.Duration, max time.Duration, failures int) time.Duration {
+	backoff := base
+	for i := 1; i < failures && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// sqsAuthErrorCodes are the aws error codes of the rejected credentials
+var sqsAuthErrorCodes = map[string]bool{
+	"AccessDenied":                true,
+	"AccessDeniedException":       true,
+	"InvalidAccessKeyId":          true,
+	"InvalidClientTokenId":        true,
+	"MissingAuthenticationToken":  true,
+	"SignatureDoesNotMatch":       true,
+	"UnrecognizedClientException": true,
+}
+
+// classifySQSError returns the poll error of the failed aws request
+func classifySQSError(err error) error {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return newPollError(ErrQueueTransient, err)
+	}
+	switch {
+	case aerr.Code() == sqs.ErrCodeQueueDoesNotExist:
+		return newPollError(ErrQueueNotFound, err)
+	case sqsAuthErrorCodes[aerr.Code()] || request.IsErrorExpiredCreds(err):
+		return newPollError(ErrQueueAuth, err)
+	case request.IsErrorThrottle(err):
+		return newPollError(ErrQueueThrottled, err)
+	}
+	return newPollError(ErrQueueTransient, err)
+}
+
+// classifyHTTPError returns the poll error of the failed
+// http request to the metrics backend
+func classifyHTTPError(statusCode int, err error) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return newPollError(ErrQueueAuth, err)
+	case http.StatusNotFound:
+		return newPollError(ErrQueueNotFound, err)
+	case http.StatusTooManyRequests:
+		return newPollError(ErrQueueThrottled, err)
+	}
+	return newPollError(ErrQueueTransient, err)
+}
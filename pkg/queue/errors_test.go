@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func TestClassifySQSError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "queue does not exist",
+			err:      awserr.New(sqs.ErrCodeQueueDoesNotExist, "no queue", nil),
+			expected: ErrQueueNotFound,
+		},
+		{
+			name:     "access denied",
+			err:      awserr.New("AccessDenied", "denied", nil),
+			expected: ErrQueueAuth,
+		},
+		{
+			name:     "expired token",
+			err:      awserr.New("ExpiredToken", "expired", nil),
+			expected: ErrQueueAuth,
+		},
+		{
+			name:     "throttled",
+			err:      awserr.New("ThrottlingException", "slow down", nil),
+			expected: ErrQueueThrottled,
+		},
+		{
+			name:     "request error",
+			err:      awserr.New("RequestError", "send request failed", nil),
+			expected: ErrQueueTransient,
+		},
+		{
+			name:     "not an aws error",
+			err:      errors.New("boom"),
+			expected: ErrQueueTransient,
+		},
+	}
+
+	for _, test := range tests {
+		err := classifySQSError(test.err)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, pollErrorKind(err))
+		}
+		if err.Error() != test.err.Error() {
+			t.Errorf("%s: expected the message %q, got=%q", test.name, test.err.Error(), err.Error())
+		}
+	}
+}
+
+func TestClassifyHTTPError(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		expected   string
+	}{
+		{http.StatusUnauthorized, PollErrorReasonQueueAuthFailed},
+		{http.StatusForbidden, PollErrorReasonQueueAuthFailed},
+		{http.StatusNotFound, PollErrorReasonQueueNotFound},
+		{http.StatusTooManyRequests, PollErrorReasonQueueThrottled},
+		{http.StatusBadGateway, PollErrorReasonQueueUnavailable},
+	}
+
+	for _, test := range tests {
+		reason := GetPollErrorReason(classifyHTTPError(test.statusCode, errors.New("failed")))
+		if reason != test.expected {
+			t.Errorf("%d: expected %s, got=%s", test.statusCode, test.expected, reason)
+		}
+	}
+}
+
+func TestNewPollErrorKeepsTheKind(t *testing.T) {
+	err := newPollError(ErrQueueTransient, newPollError(ErrQueueAuth, errors.New("denied")))
+	if !errors.Is(err, ErrQueueAuth) || errors.Is(err, ErrQueueTransient) {
+		t.Errorf("expected the auth kind to be kept, got=%v", pollErrorKind(err))
+	}
+}
+
+func TestGetPollBackoff(t *testing.T) {
+	throttled := newPollError(ErrQueueThrottled, errors.New("slow down"))
+	transient := newPollError(ErrQueueTransient, errors.New("boom"))
+	tests := []struct {
+		name     string
+		err      error
+		failures int
+		expected time.Duration
+	}{
+		{"not found", newPollError(ErrQueueNotFound, errors.New("gone")), 3, pollBackoffQueueNotFound},
+		{"auth", newPollError(ErrQueueAuth, errors.New("denied")), 3, pollBackoffQueueAuth},
+		{"first throttle", throttled, 1, 5 * time.Second},
+		{"third throttle", throttled, 3, 20 * time.Second},
+		{"throttle capped", throttled, 20, pollBackoffQueueThrottledMax},
+		{"first transient", transient, 1, time.Second},
+		{"transient capped", transient, 10, pollBackoffQueueTransientMax},
+		{"unclassified is transient", errors.New("boom"), 2, 2 * time.Second},
+	}
+
+	for _, test := range tests {
+		backoff := getPollBackoff(test.err, test.failures)
+		if backoff != test.expected {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, backoff)
+		}
+	}
+}
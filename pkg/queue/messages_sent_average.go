@@ -0,0 +1,34 @@
+package queue
+
+import (
+	"math"
+	"time"
+)
+
+// messagesSentAverageWindow is the window of the moving average of the
+// messages sent per minute, older samples decay exponentially
+const messagesSentAverageWindow = 5 * time.Minute
+
+// averageMessagesSentPerMinute updates the moving average of the messages
+// sent per minute with the sample polled at now. The unsynced samples are
+// skipped and the first sample starts the average.
+func averageMessagesSentPerMinute(
+	previous float64,
+	previousTime time.Time,
+	sample float64,
+	now time.Time) float64 {
+
+	if sample < 0 {
+		return previous
+	}
+	if previous < 0 || previousTime.IsZero() {
+		return sample
+	}
+
+	elapsed := now.Sub(previousTime)
+	if elapsed <= 0 {
+		return previous
+	}
+	alpha := 1 - math.Exp(-elapsed.Seconds()/messagesSentAverageWindow.Seconds())
+	return previous + alpha*(sample-previous)
+}
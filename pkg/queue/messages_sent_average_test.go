@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAverageMessagesSentPerMinute(t *testing.T) {
+	now := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		previous     float64
+		previousTime time.Time
+		sample       float64
+		expected     float64
+	}{
+		{
+			name:     "first sample",
+			previous: UnsyncedMessagesSentPerMinute,
+			sample:   120,
+			expected: 120,
+		},
+		{
+			// the sample a minute later is weighted by 1-e^(-1/5)
+			name:         "moving average",
+			previous:     100,
+			previousTime: now.Add(-time.Minute),
+			sample:       200,
+			expected:     100 + (1-math.Exp(-0.2))*100,
+		},
+		{
+			name:         "unsynced sample is skipped",
+			previous:     100,
+			previousTime: now.Add(-time.Minute),
+			sample:       UnsyncedMessagesSentPerMinute,
+			expected:     100,
+		},
+		{
+			name:         "sample at the same time",
+			previous:     100,
+			previousTime: now,
+			sample:       200,
+			expected:     100,
+		},
+	}
+
+	for _, test := range tests {
+		average := averageMessagesSentPerMinute(
+			test.previous, test.previousTime, test.sample, now)
+		if math.Abs(average-test.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, average)
+		}
+	}
+}
+
+func TestGetMessagesSentPerMinuteAverage(t *testing.T) {
+	queues := NewQueues()
+	stop := make(chan struct{})
+	defer close(stop)
+	go queues.Sync(stop)
+
+	queues.Add("testns", "otpsender", getQueueURI("testns", "otpsender"),
+		1, 0.0, false, nil, nil, "", nil)
+	if average := queues.GetMessagesSentPerMinuteAverage("testns", "otpsender"); average != UnsyncedMessagesSentPerMinute {
+		t.Errorf("expected the average to be unsynced, got=%v", average)
+	}
+
+	key := getKey("testns", "otpsender")
+	queues.updateMessageSent(key, 60)
+	if average := queues.GetMessagesSentPerMinuteAverage("testns", "otpsender"); average != 60 {
+		t.Errorf("expected the first sample to start the average, got=%v", average)
+	}
+
+	queues.resetPollState(key)
+	if average := queues.GetMessagesSentPerMinuteAverage("testns", "otpsender"); average != UnsyncedMessagesSentPerMinute {
+		t.Errorf("expected the average to be reset, got=%v", average)
+	}
+}
@@ -1,11 +1,56 @@
 package queue
 
 import (
+	"context"
+	"sort"
 	"time"
 
 	"github.com/practo/klog/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/tracing"
+)
+
+var (
+	activeQueuePolls = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "active_queue_polls",
+			Help:      "Number of queues being actively polled by the queue service",
+		},
+		[]string{"queueService"},
+	)
+
+	waitingQueuePolls = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "waiting_queue_polls",
+			Help:      "Number of queues waiting to be polled as the max queues per backend is reached",
+		},
+		[]string{"queueService"},
+	)
+
+	queuePollErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "queue_poll_errors_total",
+			Help:      "Number of failed queue polls by the reason of the failure",
+		},
+		[]string{"queueService", "reason"},
+	)
 )
 
+func init() {
+	prometheus.MustRegister(activeQueuePolls)
+	prometheus.MustRegister(waitingQueuePolls)
+	prometheus.MustRegister(queuePollErrors)
+}
+
 // Poller is the generic poller which manages polling of queues from
 // the configured message queuing service provider
 type Poller struct {
@@ -14,15 +59,25 @@ type Poller struct {
 	threads        map[string]bool
 	listThreadCh   chan chan map[string]bool
 	updateThreadCh chan map[string]bool
+
+	// maxThreads is the max number of queues polled at once,
+	// 0 means there is no limit
+	maxThreads int
+	// waiting are the queues waiting for a thread, oldest first
+	waiting []string
+	// threadURIs is the uri polled by the thread of the queue
+	threadURIs map[string]string
 }
 
-func NewPoller(queues *Queues, queueService QueuingService) *Poller {
+func NewPoller(queues *Queues, queueService QueuingService, maxThreads int) *Poller {
 	return &Poller{
 		queues:         queues,
 		queueService:   queueService,
 		threads:        make(map[string]bool),
 		listThreadCh:   make(chan chan map[string]bool),
 		updateThreadCh: make(chan map[string]bool),
+		maxThreads:     maxThreads,
+		threadURIs:     make(map[string]string),
 	}
 }
 
@@ -34,7 +89,10 @@ func (p *Poller) isThreadRequired(key string) bool {
 	return threads[key]
 }
 
-func (p *Poller) runPollThread(key string) {
+func (p *Poller) runPollThread(key string, uri string) {
+	// failures are the consecutive failed polls of the queue,
+	// the poll is backed off longer with every failure
+	failures := 0
 	for {
 		if !p.isThreadRequired(key) {
 			return
@@ -43,7 +101,44 @@ func (p *Poller) runPollThread(key string) {
 		if queueSpec.name == "" {
 			return
 		}
-		p.queueService.poll(key, queueSpec)
+		// the queue of the WPA was changed, the thread
+		// for the new queue is started by syncThreads
+		if queueSpec.uri != uri ||
+			queueSpec.queueServiceName != p.queueService.GetName() {
+			return
+		}
+		_, span := tracing.Tracer().Start(context.Background(), "poll",
+			trace.WithAttributes(
+				attribute.String("queue", queueSpec.name),
+				attribute.String("namespace", queueSpec.namespace),
+				attribute.String("queueService", queueSpec.queueServiceName),
+			),
+		)
+		err := p.queueService.poll(key, queueSpec)
+		if err == nil {
+			failures = 0
+			span.End()
+			continue
+		}
+		failures++
+		if failures == 1 {
+			// the state derived before the failure is stale
+			// by the time the queue can be polled again
+			klog.V(2).Infof("%s: poll failed, resetting the poll state", key)
+			p.queues.resetPollState(key)
+			p.queueService.reset(key, queueSpec)
+		}
+		reason := GetPollErrorReason(err)
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("reason", reason))
+		span.End()
+		p.queues.updatePollError(key, err)
+		queuePollErrors.WithLabelValues(queueSpec.queueServiceName, reason).Inc()
+
+		backoff := getPollBackoff(err, failures)
+		klog.V(2).Infof("%s: poll failed with %s, polling again in %v",
+			key, reason, backoff)
+		waitForPollInterval(backoff, queueSpec.pollNowCh)
 	}
 }
 
@@ -79,28 +174,75 @@ func (p *Poller) Sync(stopCh <-chan struct{}) {
 	}
 }
 
+// syncThreads starts a thread for the new queues and shuts down the
+// threads of the deleted queues. The thread of a queue whose uri was
+// changed is replaced. When the max threads are running the new queues
+// wait for a thread in the order they were added.
+func (p *Poller) syncThreads() {
+	queueServiceName := p.queueService.GetName()
+	queues := p.queues.List(queueServiceName)
+	threads := p.listThreads()
+
+	// Trigger graceful shutdown of not required threads
+	for key, _ := range threads {
+		if _, ok := queues[key]; !ok {
+			p.updateThreads(key, false)
+			delete(threads, key)
+			delete(p.threadURIs, key)
+			continue
+		}
+		if queues[key].uri != p.threadURIs[key] {
+			// the old thread stops on seeing the new uri,
+			// the new thread takes over its place
+			klog.V(2).Infof("%s: queue of %s changed from %s to %s, replacing its thread",
+				queueServiceName, key, p.threadURIs[key], queues[key].uri)
+			p.threadURIs[key] = queues[key].uri
+			go p.runPollThread(key, queues[key].uri)
+		}
+	}
+
+	waiting := []string{}
+	isWaiting := make(map[string]bool)
+	for _, key := range p.waiting {
+		if _, ok := queues[key]; ok {
+			waiting = append(waiting, key)
+			isWaiting[key] = true
+		}
+	}
+	newKeys := []string{}
+	for key, _ := range queues {
+		if _, ok := threads[key]; !ok && !isWaiting[key] {
+			newKeys = append(newKeys, key)
+		}
+	}
+	sort.Strings(newKeys)
+	waiting = append(waiting, newKeys...)
+
+	// Create a new thread
+	for len(waiting) > 0 && (p.maxThreads == 0 || len(threads) < p.maxThreads) {
+		key := waiting[0]
+		waiting = waiting[1:]
+		p.updateThreads(key, true)
+		threads[key] = true
+		p.threadURIs[key] = queues[key].uri
+		go p.runPollThread(key, queues[key].uri)
+	}
+	if len(waiting) > 0 && len(waiting) != len(p.waiting) {
+		klog.V(2).Infof("%s: %d queues waiting to be polled, max queues per backend: %d",
+			queueServiceName, len(waiting), p.maxThreads)
+	}
+	p.waiting = waiting
+
+	activeQueuePolls.WithLabelValues(queueServiceName).Set(float64(len(threads)))
+	waitingQueuePolls.WithLabelValues(queueServiceName).Set(float64(len(waiting)))
+}
+
 func (p *Poller) Run(stopCh <-chan struct{}) {
 	ticker := time.NewTicker(time.Second * 1)
-	queueServiceName := p.queueService.GetName()
 	for {
 		select {
 		case <-ticker.C:
-			queues := p.queues.List(queueServiceName)
-			// Create a new thread
-			for key, _ := range queues {
-				threads := p.listThreads()
-				if _, ok := threads[key]; !ok {
-					p.updateThreads(key, true)
-					go p.runPollThread(key)
-				}
-			}
-
-			// Trigger graceful shutdown of not required threads
-			for key, _ := range p.listThreads() {
-				if _, ok := queues[key]; !ok {
-					p.updateThreads(key, false)
-				}
-			}
+			p.syncThreads()
 		case <-stopCh:
 			klog.V(1).Info("Stopping poller(s) and thread manager gracefully.")
 			return
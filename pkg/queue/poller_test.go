@@ -0,0 +1,218 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeQueueService struct {
+	mu sync.Mutex
+	// polledURIs are the uris polled since the last reset
+	polledURIs map[string]bool
+}
+
+func (f *fakeQueueService) GetName() string {
+	return BeanstalkQueueService
+}
+
+func (f *fakeQueueService) poll(key string, queueSpec QueueSpec) error {
+	f.mu.Lock()
+	if f.polledURIs == nil {
+		f.polledURIs = make(map[string]bool)
+	}
+	f.polledURIs[queueSpec.uri] = true
+	f.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func (f *fakeQueueService) reset(key string, queueSpec QueueSpec) {}
+
+func (f *fakeQueueService) listPolledURIs() map[string]bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	polledURIs := f.polledURIs
+	f.polledURIs = make(map[string]bool)
+	return polledURIs
+}
+
+func TestPollerMaxThreads(t *testing.T) {
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	for _, name := range []string{"otpsender", "mailer", "indexer"} {
+		queues.Add("testns", name, getQueueURI("testns", name), 1, 0.0, false, nil, nil, "", nil)
+	}
+
+	poller := NewPoller(queues, &fakeQueueService{}, 2)
+	go poller.Sync(stopCh)
+
+	poller.syncThreads()
+	threads := poller.listThreads()
+	if len(threads) != 2 {
+		t.Errorf("expected 2 threads, got=%d\n", len(threads))
+	}
+	if len(poller.waiting) != 1 || poller.waiting[0] != "testns/otpsender" {
+		t.Errorf("expected otpsender to wait, waiting=%v\n", poller.waiting)
+	}
+
+	// the waiting queue gets the thread of the deleted queue
+	queues.Delete("testns", "indexer")
+	poller.syncThreads()
+	threads = poller.listThreads()
+	if len(threads) != 2 || !threads["testns/otpsender"] {
+		t.Errorf("expected otpsender to be polled, threads=%v\n", threads)
+	}
+	if len(poller.waiting) != 0 {
+		t.Errorf("expected no queue to wait, waiting=%v\n", poller.waiting)
+	}
+
+	// no limit
+	queues.Add("testns", "indexer", getQueueURI("testns", "indexer"), 1, 0.0, false, nil, nil, "", nil)
+	poller.maxThreads = 0
+	poller.syncThreads()
+	threads = poller.listThreads()
+	if len(threads) != 3 {
+		t.Errorf("expected 3 threads, got=%d\n", len(threads))
+	}
+
+	for key := range threads {
+		poller.updateThreads(key, false)
+	}
+}
+
+func TestPollerQueueURIChange(t *testing.T) {
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	oldURI := getQueueURI("testns", "otpsender")
+	newURI := getQueueURI("testns", "otpsender-v2")
+	queues.Add("testns", "otpsender", oldURI, 1, 0.0, false, nil, nil, "", nil)
+
+	queueService := &fakeQueueService{}
+	poller := NewPoller(queues, queueService, 0)
+	go poller.Sync(stopCh)
+	poller.syncThreads()
+	time.Sleep(30 * time.Millisecond)
+
+	queues.Add("testns", "otpsender", newURI, 1, 0.0, false, nil, nil, "", nil)
+	_, messages, _, _ := queues.GetQueueInfo("testns", "otpsender")
+	if messages != UnsyncedQueueMessageCount {
+		t.Errorf("expected the messages of the old queue to be reset, got=%v\n", messages)
+	}
+
+	poller.syncThreads()
+	// the old thread stops after its poll in progress
+	time.Sleep(30 * time.Millisecond)
+	queueService.listPolledURIs()
+	time.Sleep(50 * time.Millisecond)
+
+	polledURIs := queueService.listPolledURIs()
+	if len(polledURIs) != 1 || !polledURIs[newURI] {
+		t.Errorf("expected only %s to be polled, polled=%v\n", newURI, polledURIs)
+	}
+	threads := poller.listThreads()
+	if len(threads) != 1 || poller.threadURIs["testns/otpsender"] != newURI {
+		t.Errorf("expected one thread polling %s, threads=%v, uris=%v\n",
+			newURI, threads, poller.threadURIs)
+	}
+
+	poller.updateThreads("testns/otpsender", false)
+}
+
+// flappingQueueService fails the polls in failPolls, the other
+// polls find no messages and update the idle workers to idleWorkers
+type flappingQueueService struct {
+	queues      *Queues
+	idleWorkers int32
+	failPolls   map[int]bool
+
+	mu     sync.Mutex
+	polls  int
+	resets int
+}
+
+func (f *flappingQueueService) GetName() string {
+	return BeanstalkQueueService
+}
+
+func (f *flappingQueueService) poll(key string, queueSpec QueueSpec) error {
+	f.mu.Lock()
+	f.polls++
+	fail := f.failPolls[f.polls]
+	idleWorkers := f.idleWorkers
+	f.mu.Unlock()
+	if fail {
+		return newPollError(ErrQueueTransient, errors.New("connection reset"))
+	}
+	f.queues.updateMessage(key, 0)
+	f.queues.updateIdleWorkers(key, idleWorkers)
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func (f *flappingQueueService) reset(key string, queueSpec QueueSpec) {
+	f.mu.Lock()
+	f.resets++
+	f.mu.Unlock()
+}
+
+func (f *flappingQueueService) getResets() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.resets
+}
+
+func TestPollerResetsThePollStateOnReconnect(t *testing.T) {
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	uri := getQueueURI("testns", "otpsender")
+	key := getKey("testns", "otpsender")
+	queues.Add("testns", "otpsender", uri, 10, 0.0, false, nil, nil, "", nil)
+	queues.updateMessage(key, 0)
+	queues.updateMessageSent(key, 30)
+
+	queueService := &flappingQueueService{
+		queues:      queues,
+		idleWorkers: 10,
+		failPolls:   map[int]bool{2: true},
+	}
+	poller := NewPoller(queues, queueService, 0)
+	go poller.Sync(stopCh)
+	poller.updateThreads(key, true)
+	go poller.runPollThread(key, uri)
+
+	// the second poll fails and the thread backs off
+	time.Sleep(100 * time.Millisecond)
+	_, messages, messagesSent, idle := queues.GetQueueInfo("testns", "otpsender")
+	if idle != UnsyncedIdleWorkers {
+		t.Errorf("expected the idle workers to be reset, got=%v\n", idle)
+	}
+	if messagesSent != UnsyncedMessagesSentPerMinute {
+		t.Errorf("expected the messages sent to be reset, got=%v\n", messagesSent)
+	}
+	if messages != 0 {
+		t.Errorf("expected the messages to be kept, got=%v\n", messages)
+	}
+	if resets := queueService.getResets(); resets != 1 {
+		t.Errorf("expected the queue service to be reset once, got=%d\n", resets)
+	}
+
+	// the poll after the reconnect derives the idle workers again
+	queueService.mu.Lock()
+	queueService.idleWorkers = 3
+	queueService.mu.Unlock()
+	if !queues.PollNow("testns", "otpsender", time.Second) {
+		t.Errorf("expected the queue to be polled after the reconnect\n")
+	}
+	time.Sleep(50 * time.Millisecond)
+	_, _, _, idle = queues.GetQueueInfo("testns", "otpsender")
+	if idle != 3 {
+		t.Errorf("expected 3 idle workers after the reconnect, got=%v\n", idle)
+	}
+	if resets := queueService.getResets(); resets != 1 {
+		t.Errorf("expected no reset after the reconnect, got=%d\n", resets)
+	}
+
+	poller.updateThreads(key, false)
+}
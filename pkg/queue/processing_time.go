@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"math"
+	"time"
+)
+
+// processingTimeEstimateWindow is the window of the rolling estimate of
+// the seconds to process one job, older samples decay exponentially
+const processingTimeEstimateWindow = 10 * time.Minute
+
+// estimateSecondsToProcessOneJob updates the rolling estimate of the
+// seconds taken by one worker to process one job with the messages
+// processed between two polls:
+//
+//	processed = messagesSent - (newMessages - oldMessages)
+//	sample = workers * elapsed / processed
+//
+// Samples are only taken when the workers had a backlog during the
+// whole time, idle workers would make the jobs look slower than they are.
+// It returns the previous estimate if no sample can be taken.
+func estimateSecondsToProcessOneJob(
+	previous float64,
+	oldMessages int32,
+	newMessages int32,
+	messagesSentPerMinute float64,
+	workers int32,
+	elapsed time.Duration) float64 {
+
+	if oldMessages <= 0 || newMessages <= 0 || workers <= 0 ||
+		messagesSentPerMinute < 0 || elapsed <= 0 {
+		return previous
+	}
+
+	processed := messagesSentPerMinute*elapsed.Minutes() -
+		float64(newMessages-oldMessages)
+	if processed <= 0 {
+		return previous
+	}
+
+	sample := float64(workers) * elapsed.Seconds() / processed
+	if previous == 0 {
+		return sample
+	}
+
+	alpha := 1 - math.Exp(-elapsed.Seconds()/processingTimeEstimateWindow.Seconds())
+	return previous + alpha*(sample-previous)
+}
+
+// isProcessingTimeMismatch tells if the configured seconds to process
+// one job and the estimate differ by more than the factor either way.
+// Both need to be known, the estimate is 0 till a sample is taken.
+func isProcessingTimeMismatch(configured, estimate, factor float64) bool {
+	if factor <= 1 || configured <= 0 || estimate <= 0 {
+		return false
+	}
+	return configured > estimate*factor || estimate > configured*factor
+}
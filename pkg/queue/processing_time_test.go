@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEstimateSecondsToProcessOneJob(t *testing.T) {
+	tests := []struct {
+		name                  string
+		previous              float64
+		oldMessages           int32
+		newMessages           int32
+		messagesSentPerMinute float64
+		workers               int32
+		elapsed               time.Duration
+		expected              float64
+	}{
+		{
+			// 60 sent + 60 drained = 120 processed by 10 workers in 60s
+			name:                  "first sample",
+			oldMessages:           100,
+			newMessages:           40,
+			messagesSentPerMinute: 60,
+			workers:               10,
+			elapsed:               time.Minute,
+			expected:              5,
+		},
+		{
+			// 120 sent - 60 grown = 60 processed by 10 workers in 60s,
+			// the sample of 10s is weighted by 1-e^(-1/10)
+			name:                  "rolling estimate",
+			previous:              5,
+			oldMessages:           100,
+			newMessages:           160,
+			messagesSentPerMinute: 120,
+			workers:               10,
+			elapsed:               time.Minute,
+			expected:              5 + (1-math.Exp(-0.1))*5,
+		},
+		{
+			name:                  "workers without backlog",
+			previous:              5,
+			oldMessages:           0,
+			newMessages:           10,
+			messagesSentPerMinute: 60,
+			workers:               10,
+			elapsed:               time.Minute,
+			expected:              5,
+		},
+		{
+			name:                  "messages sent not synced",
+			previous:              5,
+			oldMessages:           100,
+			newMessages:           40,
+			messagesSentPerMinute: UnsyncedMessagesSentPerMinute,
+			workers:               10,
+			elapsed:               time.Minute,
+			expected:              5,
+		},
+		{
+			name:                  "nothing processed",
+			oldMessages:           100,
+			newMessages:           200,
+			messagesSentPerMinute: 60,
+			workers:               10,
+			elapsed:               time.Minute,
+			expected:              0,
+		},
+	}
+
+	for _, test := range tests {
+		estimate := estimateSecondsToProcessOneJob(
+			test.previous,
+			test.oldMessages,
+			test.newMessages,
+			test.messagesSentPerMinute,
+			test.workers,
+			test.elapsed,
+		)
+		if math.Abs(estimate-test.expected) > 1e-9 {
+			t.Errorf("%s: expected estimate=%v, got=%v\n",
+				test.name, test.expected, estimate)
+		}
+	}
+}
+
+func TestIsProcessingTimeMismatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured float64
+		estimate   float64
+		factor     float64
+		expected   bool
+	}{
+		{"close", 5, 7, 10, false},
+		{"configured too high", 100, 5, 10, true},
+		{"configured too low", 0.5, 6, 10, true},
+		{"at the factor", 50, 5, 10, false},
+		{"not estimated", 5, 0, 10, false},
+		{"not configured", 0, 5, 10, false},
+		{"disabled", 100, 5, 0, false},
+	}
+	for _, test := range tests {
+		got := isProcessingTimeMismatch(test.configured, test.estimate, test.factor)
+		if got != test.expected {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+		}
+	}
+}
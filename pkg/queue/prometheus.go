@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practo/klog/v2"
+)
+
+// prometheusQueryTimeout is the timeout of one query to prometheus
+const prometheusQueryTimeout = 10 * time.Second
+
+// Prometheus is used by the Poller to get the backlog from the result of
+// a PromQL query, it implements the QueuingService interface.
+// The queueURI is the base url of prometheus and the query is in the
+// WPA spec. The query should return a scalar or a single series.
+type Prometheus struct {
+	name   string
+	queues *Queues
+	client *http.Client
+
+	shortPollInterval time.Duration
+}
+
+func NewPrometheus(
+	name string,
+	queues *Queues,
+	shortPollInterval int) (QueuingService, error) {
+
+	return &Prometheus{
+		name:   name,
+		queues: queues,
+		client: &http.Client{Timeout: prometheusQueryTimeout},
+
+		shortPollInterval: time.Second * time.Duration(shortPollInterval),
+	}, nil
+}
+
+// prometheusResponse is the response of the /api/v1/query api
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// prometheusSample is the [<unix time>, "<value>"] pair of the result
+type prometheusSample [2]interface{}
+
+func (s prometheusSample) value() (float64, error) {
+	value, ok := s[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value: %v", s[1])
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// errNoPrometheusResult is returned when the query has no result,
+// the backlog is then unknown
+var errNoPrometheusResult = fmt.Errorf("query returned no result")
+
+// query runs the instant query and returns its scalar result
+func (p *Prometheus) query(baseURI string, query string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), prometheusQueryTimeout)
+	defer cancel()
+
+	queryURL := strings.TrimSuffix(baseURI, "/") + "/api/v1/query?" +
+		url.Values{"query": []string{query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	value, err := parsePrometheusResponse(resp.StatusCode, body)
+	if err != nil && resp.StatusCode != http.StatusOK {
+		return 0, classifyHTTPError(resp.StatusCode, err)
+	}
+	return value, err
+}
+
+// parsePrometheusResponse returns the value of the scalar or of the
+// single series in the response
+func parsePrometheusResponse(statusCode int, body []byte) (float64, error) {
+	var response prometheusResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("unable to parse the response, status: %d, err: %v",
+			statusCode, err)
+	}
+	if response.Status != "success" {
+		return 0, fmt.Errorf("query failed, status: %d, err: %s",
+			statusCode, response.Error)
+	}
+
+	var sample prometheusSample
+	switch response.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(response.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+	case "vector":
+		var vector []struct {
+			Value prometheusSample `json:"value"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &vector); err != nil {
+			return 0, err
+		}
+		if len(vector) == 0 {
+			return 0, errNoPrometheusResult
+		}
+		if len(vector) > 1 {
+			return 0, fmt.Errorf(
+				"query returned %d series, expected a single series", len(vector))
+		}
+		sample = vector[0].Value
+	default:
+		return 0, fmt.Errorf("unsupported result type %q, expected scalar or vector",
+			response.Data.ResultType)
+	}
+
+	value, err := sample.value()
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errNoPrometheusResult
+	}
+	return value, nil
+}
+
+func (p *Prometheus) waitForShortPollInterval(queueSpec QueueSpec) {
+	waitForPollInterval(p.shortPollInterval, queueSpec.pollNowCh)
+}
+
+func (p *Prometheus) GetName() string {
+	return p.name
+}
+
+// reset has nothing to drop, the backlog is the result of the query
+func (p *Prometheus) reset(key string, queueSpec QueueSpec) {}
+
+func (p *Prometheus) poll(key string, queueSpec QueueSpec) error {
+	// the idle workers are not known from a metric
+	p.queues.updateIdleWorkers(key, -1)
+
+	value, err := p.query(queueSpec.uri, queueSpec.query)
+	if err != nil {
+		klog.Errorf("Unable to query prometheus for queue %q, %v.",
+			queueSpec.name, err)
+		if err == errNoPrometheusResult {
+			// the backlog is unknown, the wpa is not scaled till
+			// the query returns a result
+			p.queues.updateMessage(key, UnsyncedQueueMessageCount)
+		}
+		return newPollError(ErrQueueTransient, err)
+	}
+
+	messages := int32(math.Min(math.Ceil(math.Max(value, 0)), math.MaxInt32))
+	klog.V(3).Infof("%s: prometheus value=%v, messages=%d",
+		queueSpec.name, value, messages)
+	p.queues.updateMessage(key, messages)
+	p.waitForShortPollInterval(queueSpec)
+	return nil
+}
@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePrometheusResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected float64
+		err      bool
+	}{
+		{
+			name:     "scalar",
+			body:     `{"status":"success","data":{"resultType":"scalar","result":[1617000000.1,"42"]}}`,
+			expected: 42,
+		},
+		{
+			name:     "single series",
+			body:     `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1617000000.1,"7.5"]}]}}`,
+			expected: 7.5,
+		},
+		{
+			name: "no series",
+			body: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			err:  true,
+		},
+		{
+			name: "many series",
+			body: `{"status":"success","data":{"resultType":"vector","result":[{"value":[1,"1"]},{"value":[1,"2"]}]}}`,
+			err:  true,
+		},
+		{
+			name: "NaN",
+			body: `{"status":"success","data":{"resultType":"scalar","result":[1617000000.1,"NaN"]}}`,
+			err:  true,
+		},
+		{
+			name: "query error",
+			body: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		value, err := parsePrometheusResponse(http.StatusOK, []byte(test.body))
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got value=%v\n", test.name, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got=%v\n", test.name, err)
+			continue
+		}
+		if value != test.expected {
+			t.Errorf("%s: expected value=%v, got=%v\n",
+				test.name, test.expected, value)
+		}
+	}
+}
+
+func TestPrometheusPoll(t *testing.T) {
+	// a failed poll makes three updates before returning
+	doneChan := make(chan struct{}, 3)
+	doneQueueSync = func() {
+		doneChan <- struct{}{}
+	}
+	defer func() {
+		doneQueueSync = func() {}
+	}()
+
+	result := `{"resultType":"vector","result":[{"metric":{},"value":[1617000000.1,"24.2"]}]}`
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/query" ||
+				r.URL.Query().Get("query") != "sum(pending_jobs)" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"status":"error","error":"unexpected query"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"status":"success","data":%s}`, result)
+		}))
+	defer server.Close()
+
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	queues.Add("testns", "otpsender", server.URL, 1, 0.0, false, nil, nil,
+		"sum(pending_jobs)", nil)
+	<-doneChan
+
+	poller, _ := NewPrometheus(PrometheusQueueService, queues, 0)
+	key := getKey("testns", "otpsender")
+	poller.poll(key, queues.ListQueue(key))
+	<-doneChan
+	<-doneChan
+
+	name, messages, _, idle := queues.GetQueueInfo("testns", "otpsender")
+	if name != "otpsender" {
+		t.Errorf("expected otpsender qName, got=%v\n", name)
+	}
+	if messages != 25 {
+		t.Errorf("expected 25 messages, got=%v\n", messages)
+	}
+	if idle != -1 {
+		t.Errorf("expected -1 idle, got=%v\n", idle)
+	}
+
+	// the backlog is unknown when the query has no result
+	result = `{"resultType":"vector","result":[]}`
+	err := poller.poll(key, queues.ListQueue(key))
+	<-doneChan
+	<-doneChan
+
+	_, messages, _, _ = queues.GetQueueInfo("testns", "otpsender")
+	if messages != UnsyncedQueueMessageCount {
+		t.Errorf("expected unsynced messages, got=%v\n", messages)
+	}
+	if !errors.Is(err, errNoPrometheusResult) || !errors.Is(err, ErrQueueTransient) {
+		t.Errorf("expected a transient poll error with no result, got=%v\n", err)
+	}
+}
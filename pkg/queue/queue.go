@@ -3,21 +3,38 @@ package queue
 import (
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/practo/klog/v2"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
 	// doneQueueSync is a noop function to make synchronization
 	// work in unit tests
 	doneQueueSync = func() {}
+
+	polledQueues = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "wpa",
+			Subsystem: "controller",
+			Name:      "polled_queues",
+			Help:      "Number of queues being polled, including the safety queues",
+		},
+	)
 )
 
+func init() {
+	prometheus.MustRegister(polledQueues)
+}
+
 const (
 	BenanstalkProtocol            = "beanstalk"
 	UnsyncedQueueMessageCount     = -1
 	UnsyncedMessagesSentPerMinute = -1
 	UnsyncedIdleWorkers           = -1
+	UnsyncedMessageGroups         = -1
+	UnsyncedOldestMessageAge      = -1
 )
 
 // Queues maintains a list of all queues as specified in WPAs in memory
@@ -29,6 +46,11 @@ type Queues struct {
 	updateMessageCh     chan map[string]int32
 	idleWorkerCh        chan map[string]int32
 	updateMessageSentCh chan map[string]float64
+	pollErrorCh         chan map[string]error
+	resetCh             chan string
+	messageGroupsCh     chan map[string]int32
+	oldestMessageAgeCh  chan map[string]float64
+	sqsPollScheduleCh   chan map[string]sqsPollSchedule
 	item                map[string]QueueSpec
 }
 
@@ -50,6 +72,12 @@ type QueueSpec struct {
 	// It is most useful for workers which process very fast and
 	// always has a messages = 0  in the queue
 	messagesSentPerMinute float64
+	// messagesSentPerMinuteAverage is the moving average of
+	// messagesSentPerMinute, see averageMessagesSentPerMinute
+	messagesSentPerMinuteAverage float64
+	// messagesSentPerMinuteAverageTime is the time of the
+	// last sample in messagesSentPerMinuteAverage
+	messagesSentPerMinuteAverageTime time.Time
 	// idleWorkers tells the number of workers which are idle
 	// and not doing any processing.
 	idleWorkers int32
@@ -58,6 +86,138 @@ type QueueSpec struct {
 	// secondsToProcessOneJob tells the time to process
 	// one job by one worker process
 	secondsToProcessOneJob float64
+
+	// autoEstimateProcessingTime tells if the seconds to process one job
+	// should be estimated from the throughput of the workers
+	autoEstimateProcessingTime bool
+	// secondsToProcessOneJobEstimate is the rolling estimate of the
+	// seconds to process one job, 0 if not estimated yet. It is also
+	// taken without autoEstimateProcessingTime to check the
+	// secondsToProcessOneJob.
+	secondsToProcessOneJobEstimate float64
+
+	// backlogBalance is the rolling inflow minus outflow of the queue in
+	// messages per minute, see estimateBacklogBalance. It is only
+	// known if backlogBalanceKnown, it can be negative.
+	backlogBalance      float64
+	backlogBalanceKnown bool
+
+	// credentials are used by the queue service to connect to the queue
+	// nil means the default credentials of the queue service are used
+	credentials *Credentials
+
+	// messageWeights weighs the messages by their type,
+	// nil means the messages are counted
+	messageWeights *MessageWeights
+
+	// query is the PromQL or Datadog query whose result is the backlog,
+	// only used by the prometheus and datadog queue services
+	query string
+
+	// sqsOptions override the defaults of the SQS poll,
+	// nil means the defaults are used
+	sqsOptions *SQSOptions
+
+	// lastPollTime is the last time the messages were updated by the poller
+	lastPollTime time.Time
+
+	// lastPollError is the error of the last failed poll, it is
+	// cleared when the messages are updated by the poller
+	lastPollError string
+	// lastPollErrorReason is the reason of the kind of lastPollError,
+	// see GetPollErrorReason
+	lastPollErrorReason string
+
+	// messageGroups is the number of message groups which can be
+	// processed at once, only known for the FIFO queues.
+	// UnsyncedMessageGroups means the workers are not bounded by it.
+	messageGroups int32
+
+	// oldestMessageAgeSeconds is the age of the oldest message in the
+	// queue, only known for the SQS queues which request it using
+	// SQSOptions.OldestMessageAge. UnsyncedOldestMessageAge if not known.
+	oldestMessageAgeSeconds float64
+
+	// zeroBacklogPolls is the number of polls in a row which read zero
+	// messages while the backlog was kept, see guardStaleZeroBacklog
+	zeroBacklogPolls int32
+
+	// sqsPollSchedule is the adaptive poll interval and the
+	// GetQueueAttributes calls of the SQS queue
+	sqsPollSchedule sqsPollSchedule
+
+	// pollNowCh wakes up the poll of the queue waiting for
+	// the poll interval, see PollNow
+	pollNowCh chan struct{}
+}
+
+// QueueStatus is the in-memory state of a queue, used for debugging
+type QueueStatus struct {
+	Name                  string    `json:"name"`
+	Namespace             string    `json:"namespace"`
+	QueueServiceName      string    `json:"queueServiceName"`
+	Messages              int32     `json:"messages"`
+	MessagesSentPerMinute float64   `json:"messagesSentPerMinute"`
+	IdleWorkers           int32     `json:"idleWorkers"`
+	Workers               int32     `json:"workers"`
+	LastPollTime          time.Time `json:"lastPollTime,omitempty"`
+	Synced                bool      `json:"synced"`
+	LastPollError         string    `json:"lastPollError,omitempty"`
+	LastPollErrorReason   string    `json:"lastPollErrorReason,omitempty"`
+
+	// MessagesSentPerMinuteAverage is the moving average of
+	// MessagesSentPerMinute
+	MessagesSentPerMinuteAverage float64 `json:"messagesSentPerMinuteAverage"`
+}
+
+// Credentials are read from the secret referenced in the WPA spec.
+// SQS uses the keys AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN(optional). Beanstalk does not support authentication
+// so the credentials are not used by it.
+type Credentials struct {
+	Data map[string][]byte
+	// Version changes whenever the secret is rotated,
+	// it is the resourceVersion of the secret
+	Version string
+}
+
+// MessageWeights is the weight of every message type in the queue,
+// the type of the message is read from the message attribute.
+// Only SQS supports it.
+type MessageWeights struct {
+	AttributeName string
+	Weights       map[string]float64
+	DefaultWeight float64
+}
+
+// getWeight returns the weight of the message type
+func (m *MessageWeights) getWeight(messageType string) float64 {
+	if weight, ok := m.Weights[messageType]; ok {
+		return weight
+	}
+	return m.DefaultWeight
+}
+
+// SQSOptions override the defaults of the SQS poll of the queue,
+// the zero values mean the defaults of the queue service are used
+type SQSOptions struct {
+	// WaitTimeSeconds is the long poll wait time, nil means the default
+	WaitTimeSeconds *int64
+	// QueueAttributes are requested by every poll, empty means the default
+	QueueAttributes []string
+	// QueuePrefix polls all the queues whose name starts with it in the
+	// account and region of the queue uri, empty means the queue uri is polled
+	QueuePrefix string
+	// MaxDiscoveredQueues caps the queues polled for the QueuePrefix,
+	// nil means DefaultSQSMaxDiscoveredQueues
+	MaxDiscoveredQueues *int32
+	// OldestMessageAge fetches the age of the oldest message in the
+	// queue, it is not fetched for the QueuePrefix
+	OldestMessageAge bool
+	// BacklogStalenessGuardPolls is the number of polls in a row which
+	// must read zero messages before a drop to zero is used,
+	// 0 or 1 means the zero backlog is used at once
+	BacklogStalenessGuardPolls int32
 }
 
 func NewQueues() *Queues {
@@ -68,6 +228,11 @@ func NewQueues() *Queues {
 		updateMessageCh:     make(chan map[string]int32),
 		updateMessageSentCh: make(chan map[string]float64),
 		idleWorkerCh:        make(chan map[string]int32),
+		pollErrorCh:         make(chan map[string]error),
+		resetCh:             make(chan string),
+		messageGroupsCh:     make(chan map[string]int32),
+		oldestMessageAgeCh:  make(chan map[string]float64),
+		sqsPollScheduleCh:   make(chan map[string]sqsPollSchedule),
 		item:                make(map[string]QueueSpec),
 	}
 }
@@ -90,13 +255,52 @@ func (q *Queues) updateIdleWorkers(key string, idleWorkers int32) {
 	}
 }
 
+// updateMessageGroups records the message groups of the
+// FIFO queue which can be processed at once
+func (q *Queues) updateMessageGroups(key string, messageGroups int32) {
+	q.messageGroupsCh <- map[string]int32{
+		key: messageGroups,
+	}
+}
+
+// updateOldestMessageAge records the age of the
+// oldest message in the queue in seconds
+func (q *Queues) updateOldestMessageAge(key string, seconds float64) {
+	q.oldestMessageAgeCh <- map[string]float64{
+		key: seconds,
+	}
+}
+
+// updateSQSPollSchedule records the poll interval and
+// the GetQueueAttributes calls of the SQS queue
+func (q *Queues) updateSQSPollSchedule(key string, schedule sqsPollSchedule) {
+	q.sqsPollScheduleCh <- map[string]sqsPollSchedule{
+		key: schedule,
+	}
+}
+
+// updatePollError records the error of the failed poll of the queue
+func (q *Queues) updatePollError(key string, err error) {
+	q.pollErrorCh <- map[string]error{
+		key: err,
+	}
+}
+
+// resetPollState forgets the idle workers, the messages sent per minute
+// and the message groups of the queue, they are unsynced till the queue
+// is polled again. The backlog is kept.
+func (q *Queues) resetPollState(key string) {
+	q.resetCh <- key
+}
+
 func (q *Queues) Sync(stopCh <-chan struct{}) {
 	for {
 		select {
 		case queueSpecMap := <-q.addCh:
 			for key, value := range queueSpecMap {
-				q.item[key] = value
+				q.item[key] = keepPolledState(key, q.item[key], value)
 			}
+			polledQueues.Set(float64(len(q.item)))
 			doneQueueSync()
 		case message := <-q.updateMessageCh:
 			for key, value := range message {
@@ -104,7 +308,34 @@ func (q *Queues) Sync(stopCh <-chan struct{}) {
 					continue
 				}
 				var spec = q.item[key]
+				now := time.Now()
+				value = guardStaleZeroBacklog(key, &spec, value)
+				if !spec.lastPollTime.IsZero() {
+					spec.backlogBalance, spec.backlogBalanceKnown = estimateBacklogBalance(
+						spec.backlogBalance,
+						spec.backlogBalanceKnown,
+						spec.messages,
+						value,
+						spec.messagesSentPerMinute,
+						now.Sub(spec.lastPollTime),
+					)
+				}
+				// the estimate is taken even when it is not used in
+				// the scaling, to check the secondsToProcessOneJob
+				if !spec.lastPollTime.IsZero() {
+					spec.secondsToProcessOneJobEstimate = estimateSecondsToProcessOneJob(
+						spec.secondsToProcessOneJobEstimate,
+						spec.messages,
+						value,
+						spec.messagesSentPerMinute,
+						spec.workers,
+						now.Sub(spec.lastPollTime),
+					)
+				}
 				spec.messages = value
+				spec.lastPollTime = now
+				spec.lastPollError = ""
+				spec.lastPollErrorReason = ""
 				q.item[key] = spec
 			}
 			doneQueueSync()
@@ -114,7 +345,17 @@ func (q *Queues) Sync(stopCh <-chan struct{}) {
 					continue
 				}
 				var spec = q.item[key]
+				now := time.Now()
 				spec.messagesSentPerMinute = value
+				spec.messagesSentPerMinuteAverage = averageMessagesSentPerMinute(
+					spec.messagesSentPerMinuteAverage,
+					spec.messagesSentPerMinuteAverageTime,
+					value,
+					now,
+				)
+				if value >= 0 {
+					spec.messagesSentPerMinuteAverageTime = now
+				}
 				q.item[key] = spec
 			}
 			doneQueueSync()
@@ -128,11 +369,70 @@ func (q *Queues) Sync(stopCh <-chan struct{}) {
 				q.item[key] = spec
 			}
 			doneQueueSync()
+		case messageGroups := <-q.messageGroupsCh:
+			for key, value := range messageGroups {
+				if _, ok := q.item[key]; !ok {
+					continue
+				}
+				var spec = q.item[key]
+				spec.messageGroups = value
+				q.item[key] = spec
+			}
+			doneQueueSync()
+		case oldestMessageAge := <-q.oldestMessageAgeCh:
+			for key, value := range oldestMessageAge {
+				if _, ok := q.item[key]; !ok {
+					continue
+				}
+				var spec = q.item[key]
+				spec.oldestMessageAgeSeconds = value
+				q.item[key] = spec
+			}
+			doneQueueSync()
+		case sqsPollSchedule := <-q.sqsPollScheduleCh:
+			for key, value := range sqsPollSchedule {
+				if _, ok := q.item[key]; !ok {
+					continue
+				}
+				var spec = q.item[key]
+				spec.sqsPollSchedule = value
+				q.item[key] = spec
+			}
+			doneQueueSync()
+		case pollError := <-q.pollErrorCh:
+			for key, value := range pollError {
+				if _, ok := q.item[key]; !ok {
+					continue
+				}
+				var spec = q.item[key]
+				spec.lastPollError = value.Error()
+				spec.lastPollErrorReason = GetPollErrorReason(value)
+				q.item[key] = spec
+			}
+			doneQueueSync()
+		case key := <-q.resetCh:
+			if _, ok := q.item[key]; ok {
+				var spec = q.item[key]
+				spec.idleWorkers = UnsyncedIdleWorkers
+				spec.messagesSentPerMinute = UnsyncedMessagesSentPerMinute
+				spec.messagesSentPerMinuteAverage = UnsyncedMessagesSentPerMinute
+				spec.messagesSentPerMinuteAverageTime = time.Time{}
+				spec.messageGroups = UnsyncedMessageGroups
+				spec.oldestMessageAgeSeconds = UnsyncedOldestMessageAge
+				spec.backlogBalance = 0
+				spec.backlogBalanceKnown = false
+				// the queue is polled at the min interval after the
+				// failure, the calls are still counted in the budget
+				spec.sqsPollSchedule.interval = 0
+				q.item[key] = spec
+			}
+			doneQueueSync()
 		case key := <-q.deleteCh:
 			_, ok := q.item[key]
 			if ok {
 				delete(q.item, key)
 			}
+			polledQueues.Set(float64(len(q.item)))
 			doneQueueSync()
 		case listResultCh := <-q.listCh:
 			listResultCh <- DeepCopyItem(q.item)
@@ -144,7 +444,31 @@ func (q *Queues) Sync(stopCh <-chan struct{}) {
 }
 
 func (q *Queues) Add(namespace string, name string, uri string,
-	workers int32, secondsToProcessOneJob float64) error {
+	workers int32, secondsToProcessOneJob float64,
+	autoEstimateProcessingTime bool,
+	credentials *Credentials, messageWeights *MessageWeights,
+	query string, sqsOptions *SQSOptions) error {
+
+	return q.add(getKey(namespace, name), namespace, name, uri,
+		workers, secondsToProcessOneJob, autoEstimateProcessingTime,
+		credentials, messageWeights, query, sqsOptions)
+}
+
+// AddSafetyQueue adds the safety queue of the WPA. The safety queue is
+// polled like any other queue but does not drive the desired workers,
+// it is only used to block the scale down when it has a backlog.
+func (q *Queues) AddSafetyQueue(namespace string, name string, uri string,
+	credentials *Credentials) error {
+
+	return q.add(getSafetyQueueKey(namespace, name), namespace, name, uri,
+		0, 0.0, false, credentials, nil, "", nil)
+}
+
+func (q *Queues) add(key string, namespace string, name string, uri string,
+	workers int32, secondsToProcessOneJob float64,
+	autoEstimateProcessingTime bool,
+	credentials *Credentials, messageWeights *MessageWeights,
+	query string, sqsOptions *SQSOptions) error {
 
 	if uri == "" {
 		klog.Warningf(
@@ -152,7 +476,6 @@ func (q *Queues) Add(namespace string, name string, uri string,
 		return nil
 	}
 
-	key := getKey(namespace, name)
 	queueName := getQueueName(uri)
 	protocol, host, err := parseQueueURI(uri)
 	if err != nil {
@@ -165,17 +488,12 @@ func (q *Queues) Add(namespace string, name string, uri string,
 			"Unsupported: %s, skipping wpa: %s", queueServiceName, name)
 		return nil
 	}
-
-	messages := int32(UnsyncedQueueMessageCount)
-	idleWorkers := int32(UnsyncedIdleWorkers)
-	messagesSent := float64(UnsyncedMessagesSentPerMinute)
-	spec := q.listQueueByNamespace(namespace, name)
-	if spec.name != "" {
-		messages = spec.messages
-		messagesSent = spec.messagesSentPerMinute
-		idleWorkers = spec.idleWorkers
+	if IsMetricQueueService(queueServiceName) {
+		// the uri is the base url of the metrics backend
+		queueName = name
 	}
 
+	// the polled state of the queue is kept by Sync if it already exists
 	queueSpec := QueueSpec{
 		name:                   queueName,
 		namespace:              namespace,
@@ -183,22 +501,72 @@ func (q *Queues) Add(namespace string, name string, uri string,
 		protocol:               protocol,
 		host:                   host,
 		queueServiceName:       queueServiceName,
-		messages:               messages,
-		messagesSentPerMinute:  messagesSent,
+		messages:               UnsyncedQueueMessageCount,
+		messagesSentPerMinute:  UnsyncedMessagesSentPerMinute,
 		workers:                workers,
-		idleWorkers:            idleWorkers,
+		idleWorkers:            UnsyncedIdleWorkers,
 		secondsToProcessOneJob: secondsToProcessOneJob,
+		credentials:            credentials,
+		messageWeights:         messageWeights,
+		query:                  query,
+		sqsOptions:             sqsOptions,
+		pollNowCh:              make(chan struct{}, 1),
+		messageGroups:          UnsyncedMessageGroups,
+
+		messagesSentPerMinuteAverage: UnsyncedMessagesSentPerMinute,
+		oldestMessageAgeSeconds:      UnsyncedOldestMessageAge,
+		autoEstimateProcessingTime:   autoEstimateProcessingTime,
 	}
 
 	q.addCh <- map[string]QueueSpec{key: queueSpec}
 	return nil
 }
 
+// keepPolledState returns the spec being added with the polled state of
+// the existing spec of the queue. It runs in Sync, reading the existing
+// spec before sending the new one would drop the polls recorded in between.
+func keepPolledState(key string, existing QueueSpec, spec QueueSpec) QueueSpec {
+	if existing.name == "" {
+		return spec
+	}
+
+	// the poll of the queue keeps waiting on the same channel
+	spec.pollNowCh = existing.pollNowCh
+	if existing.uri != spec.uri || existing.queueServiceName != spec.queueServiceName {
+		// the state of the previous queue does not apply to the new one
+		klog.V(2).Infof("%s: queue changed from %s to %s", key, existing.uri, spec.uri)
+		return spec
+	}
+
+	spec.messages = existing.messages
+	spec.messagesSentPerMinute = existing.messagesSentPerMinute
+	spec.messagesSentPerMinuteAverage = existing.messagesSentPerMinuteAverage
+	spec.messagesSentPerMinuteAverageTime = existing.messagesSentPerMinuteAverageTime
+	spec.idleWorkers = existing.idleWorkers
+	spec.messageGroups = existing.messageGroups
+	spec.oldestMessageAgeSeconds = existing.oldestMessageAgeSeconds
+	spec.zeroBacklogPolls = existing.zeroBacklogPolls
+	spec.backlogBalance = existing.backlogBalance
+	spec.backlogBalanceKnown = existing.backlogBalanceKnown
+	spec.sqsPollSchedule = existing.sqsPollSchedule
+	spec.lastPollTime = existing.lastPollTime
+	spec.lastPollError = existing.lastPollError
+	spec.lastPollErrorReason = existing.lastPollErrorReason
+	spec.secondsToProcessOneJobEstimate = existing.secondsToProcessOneJobEstimate
+	return spec
+}
+
 func (q *Queues) Delete(namespace string, name string) error {
 	q.deleteCh <- getKey(namespace, name)
 	return nil
 }
 
+// DeleteSafetyQueue deletes the safety queue of the WPA
+func (q *Queues) DeleteSafetyQueue(namespace string, name string) error {
+	q.deleteCh <- getSafetyQueueKey(namespace, name)
+	return nil
+}
+
 func (q *Queues) ListAll() map[string]QueueSpec {
 	listResultCh := make(chan map[string]QueueSpec)
 	q.listCh <- listResultCh
@@ -240,6 +608,203 @@ func (q *Queues) GetQueueInfo(
 		spec.messagesSentPerMinute, spec.idleWorkers
 }
 
+// GetMessagesSentPerMinuteAverage returns the moving average of the
+// messages sent per minute to the queue, UnsyncedMessagesSentPerMinute
+// if it is not known yet
+func (q *Queues) GetMessagesSentPerMinuteAverage(
+	namespace string, name string) float64 {
+
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.name == "" {
+		return UnsyncedMessagesSentPerMinute
+	}
+	return spec.messagesSentPerMinuteAverage
+}
+
+// GetOldestMessageAge returns the age of the oldest message in the queue
+// in seconds, UnsyncedOldestMessageAge if it is not known
+func (q *Queues) GetOldestMessageAge(namespace string, name string) float64 {
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.name == "" {
+		return UnsyncedOldestMessageAge
+	}
+	return spec.oldestMessageAgeSeconds
+}
+
+// GetSecondsToProcessOneJobEstimate returns the rolling estimate of the
+// seconds to process one job, 0 if it is not estimated yet
+func (q *Queues) GetSecondsToProcessOneJobEstimate(
+	namespace string, name string) float64 {
+
+	spec := q.listQueueByNamespace(namespace, name)
+	return spec.secondsToProcessOneJobEstimate
+}
+
+// GetProcessingTimeMismatch compares the secondsToProcessOneJob of the
+// queue with the estimate of the seconds to process one job observed
+// from the backlog. It returns both and true if one is more than the
+// factor times the other. A factor not greater than 1 disables it.
+func (q *Queues) GetProcessingTimeMismatch(
+	namespace string, name string, factor float64) (float64, float64, bool) {
+
+	spec := q.listQueueByNamespace(namespace, name)
+	mismatch := isProcessingTimeMismatch(
+		spec.secondsToProcessOneJob, spec.secondsToProcessOneJobEstimate, factor)
+	return spec.secondsToProcessOneJob, spec.secondsToProcessOneJobEstimate, mismatch
+}
+
+// GetBacklogBalance returns the rolling inflow minus outflow of the
+// queue in messages per minute, positive when the backlog grows. It
+// returns false if it is not known yet.
+func (q *Queues) GetBacklogBalance(namespace string, name string) (float64, bool) {
+	spec := q.listQueueByNamespace(namespace, name)
+	return spec.backlogBalance, spec.backlogBalanceKnown
+}
+
+// GetPollSchedule returns the wait between the polls of the queue and
+// the rate of its GetQueueAttributes calls per minute, it returns false
+// if the queue is not polled on an adaptive schedule, only SQS is
+func (q *Queues) GetPollSchedule(
+	namespace string, name string) (time.Duration, float64, bool) {
+
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.sqsPollSchedule.interval == 0 {
+		return 0, 0, false
+	}
+	return spec.sqsPollSchedule.interval,
+		spec.sqsPollSchedule.getCallsPerMinute(), true
+}
+
+// GetMessageGroups returns the number of message groups of the FIFO
+// queue which can be processed at once. It returns UnsyncedMessageGroups
+// for the standard queues or if it is not known.
+func (q *Queues) GetMessageGroups(namespace string, name string) int32 {
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.name == "" {
+		return UnsyncedMessageGroups
+	}
+	return spec.messageGroups
+}
+
+// GetQueueHealth tells if the last poll of the queue succeeded and
+// returns the error of the last failed poll. The queue is not healthy
+// till it is polled successfully.
+func (q *Queues) GetQueueHealth(
+	namespace string, name string) (bool, string) {
+
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.name == "" {
+		return false, ""
+	}
+
+	healthy := spec.lastPollError == "" && !spec.lastPollTime.IsZero()
+	return healthy, spec.lastPollError
+}
+
+// GetPollErrorReason returns the reason of the last failed poll of the
+// queue, it is empty if the last poll succeeded. The reasons are the
+// PollErrorReason constants.
+func (q *Queues) GetPollErrorReason(namespace string, name string) string {
+	spec := q.listQueueByNamespace(namespace, name)
+	return spec.lastPollErrorReason
+}
+
+// PollNow wakes up the poll of the queue if it is waiting for the poll
+// interval and waits till the queue is polled or the timeout expires.
+// It returns false if the queue was not polled within the timeout.
+func (q *Queues) PollNow(
+	namespace string, name string, timeout time.Duration) bool {
+
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.name == "" {
+		return false
+	}
+
+	requested := time.Now()
+	requestPoll(spec)
+
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			spec = q.listQueueByNamespace(namespace, name)
+			if spec.lastPollTime.After(requested) {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// RequestPoll wakes up the poll of the queue if it is waiting for the
+// poll interval, without waiting for the poll. It returns false if the
+// queue is not known.
+func (q *Queues) RequestPoll(namespace string, name string) bool {
+	spec := q.listQueueByNamespace(namespace, name)
+	if spec.name == "" {
+		return false
+	}
+	requestPoll(spec)
+	return true
+}
+
+func requestPoll(spec QueueSpec) {
+	select {
+	case spec.pollNowCh <- struct{}{}:
+	default:
+		// a poll is already requested
+	}
+}
+
+// waitForPollInterval waits for the poll interval, the wait
+// ends early when the poll of the queue is requested by PollNow
+func waitForPollInterval(interval time.Duration, pollNowCh <-chan struct{}) {
+	select {
+	case <-time.After(interval):
+	case <-pollNowCh:
+	}
+}
+
+// ListStatus returns the in-memory state of all the queues
+// keyed by namespace/name
+func (q *Queues) ListStatus() map[string]QueueStatus {
+	status := make(map[string]QueueStatus)
+	for key, spec := range q.ListAll() {
+		status[key] = QueueStatus{
+			Name:                  spec.name,
+			Namespace:             spec.namespace,
+			QueueServiceName:      spec.queueServiceName,
+			Messages:              spec.messages,
+			MessagesSentPerMinute: spec.messagesSentPerMinute,
+			IdleWorkers:           spec.idleWorkers,
+			Workers:               spec.workers,
+			LastPollTime:          spec.lastPollTime,
+			Synced:                spec.messages != UnsyncedQueueMessageCount,
+			LastPollError:         spec.lastPollError,
+			LastPollErrorReason:   spec.lastPollErrorReason,
+
+			MessagesSentPerMinuteAverage: spec.messagesSentPerMinuteAverage,
+		}
+	}
+	return status
+}
+
+// GetSafetyQueueInfo returns the name and the messages in
+// the safety queue of the WPA
+func (q *Queues) GetSafetyQueueInfo(
+	namespace string, name string) (string, int32) {
+
+	spec := q.ListQueue(getSafetyQueueKey(namespace, name))
+	if spec.name == "" {
+		return "", UnsyncedQueueMessageCount
+	}
+
+	return spec.name, spec.messages
+}
+
 func parseQueueURI(uri string) (string, string, error) {
 	parsedURI, err := url.Parse(uri)
 	if err != nil {
@@ -258,6 +823,10 @@ func getKey(namespace string, name string) string {
 	return namespace + "/" + name
 }
 
+func getSafetyQueueKey(namespace string, name string) string {
+	return getKey(namespace, name) + "/safety"
+}
+
 func DeepCopyItem(original map[string]QueueSpec) map[string]QueueSpec {
 	copy := make(map[string]QueueSpec)
 	for key, value := range original {
@@ -1,17 +1,25 @@
 package queue
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 )
 
 // QueuingService is the interface for the message queueing service
 // For example: SQS and Beanstalk implements QueuingService interface
 
 const (
-	SqsQueueService       = "sqs"
-	BeanstalkQueueService = "beanstalkd"
+	SqsQueueService        = "sqs"
+	BeanstalkQueueService  = "beanstalkd"
+	PrometheusQueueService = "prometheus"
+	DatadogQueueService    = "datadog"
 )
 
+// datadogHostPattern matches the api hosts of the Datadog sites
+var datadogHostPattern = regexp.MustCompile(
+	`^api\.([a-z0-9]+\.)?(datadoghq\.(com|eu)|ddog-gov\.com)$`)
+
 type QueuingService interface {
 	// GetName returns the name of the queing service
 	GetName() string
@@ -21,12 +29,26 @@ type QueuingService interface {
 	//1. updateMessageSent(key, messagesSentPerMinute) i.e messagesSentPerMinute
 	//2. updateIdleWorkers(key, -1) i.e tells how many workers are idle
 	//3. updateMessage(key, approxMessagesVisible) i.e queuedMessages
-	poll(key string, queueSpec QueueSpec)
+	// it returns a PollError when the poll fails, the poller records it
+	// and backs off the next poll by its kind
+	poll(key string, queueSpec QueueSpec) error
+	// reset drops the state the queue service derived from the polls of
+	// the queue, e.g. the cached metrics. It is called when the poll of
+	// the queue starts failing so that the state is derived again after
+	// the reconnect and is not carried over the flap. It can be called
+	// any number of times.
+	reset(key string, queueSpec QueueSpec)
 }
 
 // getQueueService returns the provider name
 // TODO: add validation for the queue service in the wpa custom resource
 func getQueueServiceName(host, protocol string) (bool, string, error) {
+	// the registered queue services are matched before the built in
+	// ones, the prometheus url matches any other http url
+	if name := matchRegisteredQueueService(protocol, host); name != "" {
+		return true, name, nil
+	}
+
 	matched, err := regexp.MatchString(
 		"^sqs.[a-z][a-z]-[a-z]*-[0-9]{1}.amazonaws.com", host)
 	if err != nil {
@@ -41,5 +63,82 @@ func getQueueServiceName(host, protocol string) (bool, string, error) {
 		return true, BeanstalkQueueService, nil
 	}
 
+	if protocol == "https" && datadogHostPattern.MatchString(host) {
+		return true, DatadogQueueService, nil
+	}
+
+	// any other http url is the base url of prometheus
+	if protocol == "http" || protocol == "https" {
+		return true, PrometheusQueueService, nil
+	}
+
 	return false, "", nil
 }
+
+// ValidateQueueURI checks that the uri can be parsed and that it belongs
+// to one of the supported queue services
+func ValidateQueueURI(uri string) error {
+	if uri == "" {
+		return fmt.Errorf("queueURI must be specified")
+	}
+
+	protocol, host, err := parseQueueURI(uri)
+	if err != nil {
+		return fmt.Errorf("unable to parse queueURI: %v", err)
+	}
+
+	supported, queueServiceName, _ := getQueueServiceName(host, protocol)
+	if !supported {
+		return fmt.Errorf(
+			"unsupported queue service for %q, expected an sqs url, a prometheus url, a datadog api url or %s://",
+			uri, BenanstalkProtocol)
+	}
+
+	if !IsMetricQueueService(queueServiceName) && getQueueName(uri) == "" {
+		return fmt.Errorf("queue name is missing in %q", uri)
+	}
+
+	// every queue service checks the form of its own queueURIs
+	if validateURI := getQueueURIValidator(queueServiceName); validateURI != nil {
+		return validateURI(uri)
+	}
+	return nil
+}
+
+// validateSQSQueueURI checks that the uri is the url of an sqs queue
+func validateSQSQueueURI(uri string) error {
+	if len(strings.Split(uri, "/")) < 5 {
+		return fmt.Errorf(
+			"sqs queueURI %q must be of the form https://<host>/<account>/<queue>",
+			uri)
+	}
+	return nil
+}
+
+// validateBeanstalkQueueURI checks that the uri has the host and the
+// tube of the beanstalk queue
+func validateBeanstalkQueueURI(uri string) error {
+	if _, _, err := parseBeanstalkQueueURI(uri); err != nil {
+		return fmt.Errorf("unable to parse beanstalk queueURI: %v", err)
+	}
+	return nil
+}
+
+// IsMetricQueueService tells if the backlog of the queue service is the
+// result of the query in the WPA spec, the queueURI is then the url of
+// the metrics backend and not of a queue
+func IsMetricQueueService(queueServiceName string) bool {
+	return queueServiceName == PrometheusQueueService ||
+		queueServiceName == DatadogQueueService
+}
+
+// GetQueueServiceName returns the name of the queue service of the uri,
+// it is empty if the queue service is not supported
+func GetQueueServiceName(uri string) string {
+	protocol, host, err := parseQueueURI(uri)
+	if err != nil {
+		return ""
+	}
+	_, queueServiceName, _ := getQueueServiceName(host, protocol)
+	return queueServiceName
+}
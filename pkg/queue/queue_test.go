@@ -0,0 +1,133 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPollNow(t *testing.T) {
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	queues.Add("testns", "otpsender", getQueueURI("testns", "otpsender"),
+		1, 0.0, false, nil, nil, "", nil)
+
+	if queues.PollNow("testns", "mailer", time.Second) {
+		t.Errorf("expected no poll of a queue which does not exist\n")
+	}
+
+	key := getKey("testns", "otpsender")
+	queueSpec := queues.ListQueue(key)
+	go func() {
+		waitForPollInterval(time.Hour, queueSpec.pollNowCh)
+		queues.updateMessage(key, 5)
+	}()
+
+	if !queues.PollNow("testns", "otpsender", 5*time.Second) {
+		t.Fatalf("expected the queue to be polled\n")
+	}
+	_, messages, _, _ := queues.GetQueueInfo("testns", "otpsender")
+	if messages != 5 {
+		t.Errorf("expected 5 messages, got=%v\n", messages)
+	}
+}
+
+func TestQueuesConcurrentAccess(t *testing.T) {
+	queues := NewQueues()
+	stop := make(chan struct{})
+	defer close(stop)
+	go queues.Sync(stop)
+
+	names := []string{"otpsender", "mailer", "notifier"}
+	var wg sync.WaitGroup
+	// the poller records the polls while the controller adds,
+	// deletes and reads the queues
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			name := names[i%len(names)]
+			key := getKey("testns", name)
+			queues.updateMessage(key, int32(i))
+			queues.updateMessageSent(key, float64(i))
+			queues.updateIdleWorkers(key, int32(i%3))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			name := names[i%len(names)]
+			if i%5 == 4 {
+				queues.Delete("testns", name)
+				continue
+			}
+			queues.Add("testns", name, getQueueURI("testns", name),
+				int32(i), 0.0, true, nil, nil, "", nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			name := names[i%len(names)]
+			queues.GetQueueInfo("testns", name)
+			queues.GetQueueHealth("testns", name)
+			queues.ListStatus()
+		}
+	}()
+	wg.Wait()
+}
+
+func TestAddKeepsThePolledState(t *testing.T) {
+	queues := NewQueues()
+	stop := make(chan struct{})
+	defer close(stop)
+	go queues.Sync(stop)
+
+	uri := getQueueURI("testns", "otpsender")
+	queues.Add("testns", "otpsender", uri, 1, 0.0, false, nil, nil, "", nil)
+	key := getKey("testns", "otpsender")
+	pollNowCh := queues.ListQueue(key).pollNowCh
+	queues.updateMessage(key, 7)
+	queues.updateIdleWorkers(key, 2)
+
+	queues.Add("testns", "otpsender", uri, 3, 0.0, false, nil, nil, "", nil)
+	spec := queues.ListQueue(key)
+	if spec.messages != 7 || spec.idleWorkers != 2 || spec.workers != 3 {
+		t.Errorf("expected messages=7 idle=2 workers=3, got messages=%d idle=%d workers=%d",
+			spec.messages, spec.idleWorkers, spec.workers)
+	}
+	if spec.pollNowCh != pollNowCh {
+		t.Errorf("expected the poll now channel to be kept")
+	}
+
+	queues.Add("testns", "otpsender", uri+"-v2", 3, 0.0, false, nil, nil, "", nil)
+	spec = queues.ListQueue(key)
+	if spec.messages != UnsyncedQueueMessageCount || spec.pollNowCh != pollNowCh {
+		t.Errorf("expected the state of the changed queue to be unsynced, got messages=%d",
+			spec.messages)
+	}
+}
+
+func TestGetOldestMessageAge(t *testing.T) {
+	queues := NewQueues()
+	stop := make(chan struct{})
+	defer close(stop)
+	go queues.Sync(stop)
+
+	queues.Add("testns", "otpsender", getQueueURI("testns", "otpsender"),
+		1, 0.0, false, nil, nil, "", &SQSOptions{OldestMessageAge: true})
+	if age := queues.GetOldestMessageAge("testns", "otpsender"); age != UnsyncedOldestMessageAge {
+		t.Errorf("expected the age to be unsynced, got=%v", age)
+	}
+
+	key := getKey("testns", "otpsender")
+	queues.updateOldestMessageAge(key, 420)
+	if age := queues.GetOldestMessageAge("testns", "otpsender"); age != 420 {
+		t.Errorf("expected the age to be 420, got=%v", age)
+	}
+
+	queues.resetPollState(key)
+	if age := queues.GetOldestMessageAge("testns", "otpsender"); age != UnsyncedOldestMessageAge {
+		t.Errorf("expected the age to be reset, got=%v", age)
+	}
+}
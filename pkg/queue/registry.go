@@ -0,0 +1,248 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/practo/klog/v2"
+)
+
+// QueueServiceConfig is the configuration of the queue services from
+// the flags of the controller, every queue service reads its own
+type QueueServiceConfig struct {
+	AWSRegions                     []string
+	AWSEndpoint                    string
+	SQSShortPollInterval           int
+	SQSLongPollInterval            int
+	SQSQueueAttributes             []string
+	SQSQueueDiscoveryInterval      int
+	SQSMaxPollInterval             int
+	SQSMaxCallsPerMinute           int
+	SQSMessageWeightSampleInterval int
+	BeanstalkShortPollInterval     int
+	BeanstalkLongPollInterval      int
+	PrometheusPollInterval         int
+	DatadogPollInterval            int
+}
+
+// QueueServiceConstructor makes the queue service of the name which
+// updates the polled information in the queues
+type QueueServiceConstructor func(
+	name string,
+	queues *Queues,
+	config QueueServiceConfig) (QueuingService, error)
+
+// QueueURIMatcher tells if the queueURI of the protocol and the host
+// belongs to the queue service
+type QueueURIMatcher func(protocol, host string) bool
+
+// QueueURIValidator checks that a queueURI matched by the queue service
+// is of the form the queue service can poll
+type QueueURIValidator func(uri string) error
+
+type queueServiceRegistration struct {
+	constructor QueueServiceConstructor
+	// matchURI is nil for the built in queue services,
+	// they are matched by getQueueServiceName
+	matchURI QueueURIMatcher
+	// validateURI is nil if the queue service has no checks of its own
+	validateURI QueueURIValidator
+	order       int
+}
+
+var (
+	queueServicesMu sync.RWMutex
+	queueServices   = make(map[string]queueServiceRegistration)
+)
+
+func init() {
+	registerQueueService(SqsQueueService, newSQSFromConfig, nil,
+		validateSQSQueueURI)
+	registerQueueService(BeanstalkQueueService, newBeanstalkFromConfig, nil,
+		validateBeanstalkQueueURI)
+	registerQueueService(PrometheusQueueService, newPrometheusFromConfig, nil, nil)
+	registerQueueService(DatadogQueueService, newDatadogFromConfig, nil, nil)
+}
+
+// RegisterQueueService registers a queue service so that it can be
+// started with --queue-services. The queueURIs matched by matchURI are
+// polled by it, they are matched before the ones of the built in queue
+// services and in the order the queue services are registered. The
+// queueURIs matched are checked by validateURI when the WPA is validated,
+// it can be nil. It is meant to be called from main or from the init of
+// a package imported by main, before the queue services are started. It
+// panics if the name is already registered.
+func RegisterQueueService(
+	name string,
+	constructor QueueServiceConstructor,
+	matchURI QueueURIMatcher,
+	validateURI QueueURIValidator) {
+
+	if constructor == nil || matchURI == nil {
+		panic(fmt.Sprintf("queue service %q needs a constructor and a uri matcher", name))
+	}
+	registerQueueService(name, constructor, matchURI, validateURI)
+}
+
+func registerQueueService(
+	name string,
+	constructor QueueServiceConstructor,
+	matchURI QueueURIMatcher,
+	validateURI QueueURIValidator) {
+
+	queueServicesMu.Lock()
+	defer queueServicesMu.Unlock()
+	if _, ok := queueServices[name]; ok {
+		panic(fmt.Sprintf("queue service %q is already registered", name))
+	}
+	queueServices[name] = queueServiceRegistration{
+		constructor: constructor,
+		matchURI:    matchURI,
+		validateURI: validateURI,
+		order:       len(queueServices),
+	}
+}
+
+// NewQueueService makes the registered queue service of the name
+func NewQueueService(
+	name string,
+	queues *Queues,
+	config QueueServiceConfig) (QueuingService, error) {
+
+	queueServicesMu.RLock()
+	registration, ok := queueServices[name]
+	queueServicesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported queue service %q, registered: %v",
+			name, RegisteredQueueServices())
+	}
+	return registration.constructor(name, queues, config)
+}
+
+// RegisteredQueueServices returns the names of the registered queue
+// services in the order they were registered
+func RegisteredQueueServices() []string {
+	queueServicesMu.RLock()
+	defer queueServicesMu.RUnlock()
+	names := make([]string, 0, len(queueServices))
+	for name := range queueServices {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return queueServices[names[i]].order < queueServices[names[j]].order
+	})
+	return names
+}
+
+// getQueueURIValidator returns the uri validator of the queue service,
+// it is nil if the queue service has none or is not registered
+func getQueueURIValidator(name string) QueueURIValidator {
+	queueServicesMu.RLock()
+	defer queueServicesMu.RUnlock()
+	return queueServices[name].validateURI
+}
+
+// matchRegisteredQueueService returns the name of the registered queue
+// service which matches the queueURI, it is empty if none matches
+func matchRegisteredQueueService(protocol, host string) string {
+	for _, name := range RegisteredQueueServices() {
+		queueServicesMu.RLock()
+		matchURI := queueServices[name].matchURI
+		queueServicesMu.RUnlock()
+		if matchURI != nil && matchURI(protocol, host) {
+			return name
+		}
+	}
+	return ""
+}
+
+func newSQSFromConfig(
+	name string, queues *Queues, config QueueServiceConfig) (QueuingService, error) {
+	return NewSQS(name, config.AWSRegions, config.AWSEndpoint, queues,
+		config.SQSShortPollInterval, config.SQSLongPollInterval,
+		config.SQSQueueAttributes, config.SQSQueueDiscoveryInterval,
+		config.SQSMaxPollInterval, config.SQSMaxCallsPerMinute,
+		config.SQSMessageWeightSampleInterval)
+}
+
+func newBeanstalkFromConfig(
+	name string, queues *Queues, config QueueServiceConfig) (QueuingService, error) {
+	return NewBeanstalk(name, queues,
+		config.BeanstalkShortPollInterval, config.BeanstalkLongPollInterval)
+}
+
+func newPrometheusFromConfig(
+	name string, queues *Queues, config QueueServiceConfig) (QueuingService, error) {
+	return NewPrometheus(name, queues, config.PrometheusPollInterval)
+}
+
+func newDatadogFromConfig(
+	name string, queues *Queues, config QueueServiceConfig) (QueuingService, error) {
+	return NewDatadog(name, queues, config.DatadogPollInterval)
+}
+
+// QueueBackend is the backlog of a queue service which is not built in,
+// it is polled by the queue service made by NewBackendQueueService
+type QueueBackend interface {
+	// Poll returns the messages in the queue of the queueURI and the
+	// messages sent to it per minute, UnsyncedMessagesSentPerMinute if
+	// they are not known. The error is returned as a PollError of kind
+	// ErrQueueTransient unless it is a PollError already.
+	Poll(queueName string, queueURI string) (int32, float64, error)
+}
+
+// backendQueueService polls a QueueBackend, it implements the
+// QueuingService interface
+type backendQueueService struct {
+	name    string
+	queues  *Queues
+	backend QueueBackend
+
+	pollInterval time.Duration
+}
+
+// NewBackendQueueService makes the queue service of the name which
+// polls the backend every pollInterval, it is meant to be returned by
+// the QueueServiceConstructor of the queue services which are not built in
+func NewBackendQueueService(
+	name string,
+	queues *Queues,
+	backend QueueBackend,
+	pollInterval time.Duration) QueuingService {
+
+	return &backendQueueService{
+		name:         name,
+		queues:       queues,
+		backend:      backend,
+		pollInterval: pollInterval,
+	}
+}
+
+func (b *backendQueueService) GetName() string {
+	return b.name
+}
+
+// reset has nothing to drop, the backend keeps its own state
+func (b *backendQueueService) reset(key string, queueSpec QueueSpec) {}
+
+func (b *backendQueueService) poll(key string, queueSpec QueueSpec) error {
+	// the idle workers are not known from the backend
+	b.queues.updateIdleWorkers(key, -1)
+
+	messages, messagesSentPerMinute, err := b.backend.Poll(
+		queueSpec.name, queueSpec.uri)
+	if err != nil {
+		klog.Errorf("Unable to poll %s for queue %q, %v.",
+			b.name, queueSpec.name, err)
+		return newPollError(ErrQueueTransient, err)
+	}
+
+	klog.V(3).Infof("%s: %s messages=%d, messagesSentPerMinute=%v",
+		queueSpec.name, b.name, messages, messagesSentPerMinute)
+	b.queues.updateMessage(key, messages)
+	b.queues.updateMessageSent(key, messagesSentPerMinute)
+	waitForPollInterval(b.pollInterval, queueSpec.pollNowCh)
+	return nil
+}
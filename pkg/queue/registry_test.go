@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeQueueBackend struct {
+	messages              int32
+	messagesSentPerMinute float64
+	err                   error
+}
+
+func (f *fakeQueueBackend) Poll(queueName string, queueURI string) (int32, float64, error) {
+	return f.messages, f.messagesSentPerMinute, f.err
+}
+
+func TestRegisterQueueService(t *testing.T) {
+	backend := &fakeQueueBackend{messages: 12, messagesSentPerMinute: 3}
+	RegisterQueueService("inhouse",
+		func(name string, queues *Queues, config QueueServiceConfig) (QueuingService, error) {
+			return NewBackendQueueService(name, queues, backend, time.Millisecond), nil
+		},
+		func(protocol, host string) bool { return protocol == "inhouse" },
+		func(uri string) error {
+			if strings.Count(uri, "/") != 3 {
+				return errors.New("inhouse queueURI must be inhouse://<host>/<queue>")
+			}
+			return nil
+		})
+	defer func() {
+		queueServicesMu.Lock()
+		delete(queueServices, "inhouse")
+		queueServicesMu.Unlock()
+	}()
+
+	uri := "inhouse://broker.example.com/otpsender"
+	if name := GetQueueServiceName(uri); name != "inhouse" {
+		t.Fatalf("expected the uri to be matched by inhouse, got=%q", name)
+	}
+	if err := ValidateQueueURI(uri); err != nil {
+		t.Errorf("expected the uri to be valid, got=%v", err)
+	}
+	// the queueURIs of the queue service are checked by its validator
+	if err := ValidateQueueURI("inhouse://broker.example.com/otpsender/extra"); err == nil {
+		t.Errorf("expected the uri to be rejected by the inhouse validator")
+	}
+	// the built in queue services are still matched
+	if name := GetQueueServiceName("http://prometheus.monitoring:9090"); name != PrometheusQueueService {
+		t.Errorf("expected prometheus, got=%q", name)
+	}
+
+	if _, err := NewQueueService("unknown", NewQueues(), QueueServiceConfig{}); err == nil {
+		t.Errorf("expected an error for an unregistered queue service")
+	}
+	// a poll makes three updates before returning
+	doneChan := make(chan struct{}, 3)
+	doneQueueSync = func() {
+		doneChan <- struct{}{}
+	}
+	defer func() {
+		doneQueueSync = func() {}
+	}()
+
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+	queues.Add("testns", "otpsender", uri, 1, 0.0, false, nil, nil, "", nil)
+	<-doneChan
+
+	queueService, err := NewQueueService("inhouse", queues, QueueServiceConfig{})
+	if err != nil {
+		t.Fatalf("expected the queue service to be made, got=%v", err)
+	}
+	if queueService.GetName() != "inhouse" {
+		t.Errorf("expected inhouse, got=%q", queueService.GetName())
+	}
+
+	key := getKey("testns", "otpsender")
+	if err := queueService.poll(key, queues.ListQueue(key)); err != nil {
+		t.Errorf("expected the poll to succeed, got=%v", err)
+	}
+	<-doneChan
+	<-doneChan
+	<-doneChan
+
+	name, messages, sentPerMinute, idle := queues.GetQueueInfo("testns", "otpsender")
+	if name != "otpsender" || messages != 12 || sentPerMinute != 3 || idle != -1 {
+		t.Errorf("expected otpsender with 12 messages, 3 sent per minute and -1 idle, got=%v %v %v %v",
+			name, messages, sentPerMinute, idle)
+	}
+
+	backend.err = errors.New("broker down")
+	err = queueService.poll(key, queues.ListQueue(key))
+	<-doneChan
+	if !errors.Is(err, ErrQueueTransient) {
+		t.Errorf("expected a transient poll error, got=%v", err)
+	}
+
+	if _, err := NewQueueService("unknown", queues, QueueServiceConfig{}); err == nil {
+		t.Errorf("expected an error for an unregistered queue service")
+	}
+}
+
+func TestRegisterQueueServiceTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a built in queue service again to panic")
+		}
+	}()
+	RegisterQueueService(SqsQueueService,
+		func(name string, queues *Queues, config QueueServiceConfig) (QueuingService, error) {
+			return nil, nil
+		},
+		func(protocol, host string) bool { return false }, nil)
+}
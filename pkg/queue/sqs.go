@@ -2,6 +2,7 @@ package queue
 
 import (
 	"fmt"
+	"math"
 	"path"
 	"strconv"
 	"strings"
@@ -11,12 +12,46 @@ import (
 	"github.com/practo/klog/v2"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/sqs"
 )
 
+const (
+	// SQSMaxWaitTimeSeconds is the longest long poll allowed by SQS
+	SQSMaxWaitTimeSeconds = 20
+
+	sqsApproximateNumberOfMessages           = "ApproximateNumberOfMessages"
+	sqsApproximateNumberOfMessagesNotVisible = "ApproximateNumberOfMessagesNotVisible"
+	sqsApproximateNumberOfMessagesDelayed    = "ApproximateNumberOfMessagesDelayed"
+)
+
+// SupportedSQSQueueAttributes are the queue attributes
+// which can be requested by the poll of the SQS queues
+var SupportedSQSQueueAttributes = []string{
+	sqsApproximateNumberOfMessages,
+	sqsApproximateNumberOfMessagesNotVisible,
+	sqsApproximateNumberOfMessagesDelayed,
+}
+
+// DefaultSQSQueueAttributes are requested by the poll when
+// no queue attributes are configured
+var DefaultSQSQueueAttributes = []string{
+	sqsApproximateNumberOfMessages,
+	sqsApproximateNumberOfMessagesNotVisible,
+}
+
+// IsSupportedSQSQueueAttribute tells if the poll can request the attribute
+func IsSupportedSQSQueueAttribute(name string) bool {
+	for _, supported := range SupportedSQSQueueAttributes {
+		if name == supported {
+			return true
+		}
+	}
+	return false
+}
+
 // SQS is used to by the Poller to get the queue
 // information from AWS SQS, it implements the QueuingService interface
 type SQS struct {
@@ -25,9 +60,33 @@ type SQS struct {
 	sqsClientPool map[string]*sqs.SQS
 	cwClientPool  map[string]*cloudwatch.CloudWatch
 
+	// endpoint overrides the aws api endpoint, used for testing
+	endpoint string
+
+	// credentialedClientPool keeps the clients of the queues which
+	// specify their own credentials, keyed by the key of the wpa. The
+	// wpas polling the same queue do not share their credentials.
+	credentialedClientPool *sync.Map
+
+	// roleCredentials shares the credentials of the roles
+	// assumed by the queues
+	roleCredentials *roleCredentialsCache
+
 	shortPollInterval time.Duration
 	longPollInterval  int64
 
+	// maxPollInterval is the longest wait between the polls of a queue
+	// whose backlog is stable, the shortPollInterval means the queues
+	// are always polled at the shortPollInterval
+	maxPollInterval time.Duration
+	// maxCallsPerMinute caps the GetQueueAttributes calls of a queue
+	// in a minute, 0 means there is no cap
+	maxCallsPerMinute int32
+
+	// queueAttributes are requested by the poll of the
+	// queues which do not specify their own
+	queueAttributes []string
+
 	// cache the numberOfSentMessages as it is refreshed
 	// in aws every 1minute - prevent un-necessary api calls
 	cacheSentMessages              *sync.Map
@@ -39,22 +98,70 @@ type SQS struct {
 	cacheReceiveMessages              *sync.Map
 	cacheReceiveMessagesValidity      time.Duration
 	cacheReceiveMessageslastTimestamp *sync.Map
+
+	// cache the numberOfGroupsWithInflightMessages of the FIFO queues
+	// as it is refreshed in aws every 1minute - prevent un-necessary api calls
+	cacheInflightGroups              *sync.Map
+	cacheInflightGroupsValidity      time.Duration
+	cacheInflightGroupslastTimestamp *sync.Map
+
+	// cache the approximateAgeOfOldestMessage as it is refreshed
+	// in aws every 1minute - prevent un-necessary api calls
+	cacheOldestMessageAge              *sync.Map
+	cacheOldestMessageAgeValidity      time.Duration
+	cacheOldestMessageAgelastTimestamp *sync.Map
+
+	// discoveredQueues keeps the queues found by the prefix of the
+	// queues, keyed by the WPA, they are listed again after the
+	// queueDiscoveryInterval
+	discoveredQueues       *sync.Map
+	queueDiscoveryInterval time.Duration
+
+	// cacheMessageWeights keeps the average weight of the sampled
+	// messages of the queues, keyed by the WPA, they are sampled again
+	// after the messageWeightSampleInterval
+	cacheMessageWeights         *sync.Map
+	messageWeightSampleInterval time.Duration
 }
 
 func NewSQS(
 	name string,
 	awsRegions []string,
+	endpoint string,
 	queues *Queues,
 	shortPollInterval int,
-	longPollInterval int) (QueuingService, error) {
+	longPollInterval int,
+	queueAttributes []string,
+	queueDiscoveryInterval int,
+	maxPollInterval int,
+	maxCallsPerMinute int,
+	messageWeightSampleInterval int) (QueuingService, error) {
+
+	for _, name := range queueAttributes {
+		if !IsSupportedSQSQueueAttribute(name) {
+			return nil, fmt.Errorf("unsupported sqs queue attribute %q, supported: %s",
+				name, strings.Join(SupportedSQSQueueAttributes, ","))
+		}
+	}
+	if len(queueAttributes) == 0 {
+		queueAttributes = DefaultSQSQueueAttributes
+	}
+	if longPollInterval > shortPollInterval {
+		klog.Warningf("sqs long poll interval %ds is longer than the short poll interval %ds, using %ds",
+			longPollInterval, shortPollInterval, shortPollInterval)
+	}
+	if maxPollInterval < shortPollInterval {
+		maxPollInterval = shortPollInterval
+	}
+	if messageWeightSampleInterval < shortPollInterval {
+		messageWeightSampleInterval = shortPollInterval
+	}
 
 	sqsClientPool := make(map[string]*sqs.SQS)
 	cwClientPool := make(map[string]*cloudwatch.CloudWatch)
 
 	for _, region := range awsRegions {
-		sess, err := session.NewSession(&aws.Config{
-			Region: aws.String(region)},
-		)
+		sess, err := session.NewSession(getAWSConfig(region, endpoint))
 
 		if err != nil {
 			return nil, err
@@ -69,9 +176,16 @@ func NewSQS(
 		queues:        queues,
 		sqsClientPool: sqsClientPool,
 		cwClientPool:  cwClientPool,
+		endpoint:      endpoint,
+
+		credentialedClientPool: new(sync.Map),
+		roleCredentials:        newRoleCredentialsCache(),
 
 		shortPollInterval: time.Second * time.Duration(shortPollInterval),
 		longPollInterval:  int64(longPollInterval),
+		maxPollInterval:   time.Second * time.Duration(maxPollInterval),
+		maxCallsPerMinute: int32(maxCallsPerMinute),
+		queueAttributes:   queueAttributes,
 
 		cacheSentMessages:              new(sync.Map),
 		cacheSentMessagesValidity:      time.Second * time.Duration(60),
@@ -80,14 +194,112 @@ func NewSQS(
 		cacheReceiveMessages:              new(sync.Map),
 		cacheReceiveMessagesValidity:      time.Second * time.Duration(60),
 		cacheReceiveMessageslastTimestamp: new(sync.Map),
+
+		cacheInflightGroups:              new(sync.Map),
+		cacheInflightGroupsValidity:      time.Second * time.Duration(60),
+		cacheInflightGroupslastTimestamp: new(sync.Map),
+
+		cacheOldestMessageAge:              new(sync.Map),
+		cacheOldestMessageAgeValidity:      time.Second * time.Duration(60),
+		cacheOldestMessageAgelastTimestamp: new(sync.Map),
+
+		discoveredQueues:       new(sync.Map),
+		queueDiscoveryInterval: time.Second * time.Duration(queueDiscoveryInterval),
+
+		cacheMessageWeights:         new(sync.Map),
+		messageWeightSampleInterval: time.Second * time.Duration(messageWeightSampleInterval),
 	}, nil
 }
 
-func (s *SQS) getSQSClient(queueURI string) *sqs.SQS {
+// getAWSConfig returns the aws config for the region,
+// the endpoint is overridden if specified
+func getAWSConfig(region string, endpoint string) *aws.Config {
+	config := &aws.Config{
+		Region: aws.String(region),
+	}
+	if endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+	}
+	return config
+}
+
+// sqsCredentialedClients are the clients made using the
+// credentials specified for the queue
+type sqsCredentialedClients struct {
+	version   string
+	sqsClient *sqs.SQS
+	cwClient  *cloudwatch.CloudWatch
+}
+
+// syncCredentials makes the clients for the queue when the queue specifies
+// credentials, the clients are made again when the credentials are rotated
+func (s *SQS) syncCredentials(key string, queueSpec QueueSpec) error {
+	if queueSpec.credentials == nil {
+		s.credentialedClientPool.Delete(key)
+		return nil
+	}
+
+	clients, ok := s.credentialedClientPool.Load(key)
+	if ok && clients.(*sqsCredentialedClients).version == queueSpec.credentials.Version {
+		return nil
+	}
+
+	accessKeyID := string(queueSpec.credentials.Data["AWS_ACCESS_KEY_ID"])
+	secretAccessKey := string(queueSpec.credentials.Data["AWS_SECRET_ACCESS_KEY"])
+	sessionToken := string(queueSpec.credentials.Data["AWS_SESSION_TOKEN"])
+	roleARN := string(queueSpec.credentials.Data["AWS_ROLE_ARN"])
+
+	config := getAWSConfig(getRegion(queueSpec.uri), s.endpoint)
+	switch {
+	case roleARN != "" && accessKeyID != "":
+		return fmt.Errorf(
+			"AWS_ROLE_ARN can not be set with AWS_ACCESS_KEY_ID in the credentials")
+	case roleARN != "":
+		// the role is assumed with the credentials of the controller
+		creds, err := s.roleCredentials.get(roleARN,
+			getAWSConfig(getRegion(queueSpec.uri), s.endpoint))
+		if err != nil {
+			return err
+		}
+		config.Credentials = creds
+	case accessKeyID == "" || secretAccessKey == "":
+		return fmt.Errorf(
+			"AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set in the credentials")
+	default:
+		config.Credentials = credentials.NewStaticCredentials(
+			accessKeyID, secretAccessKey, sessionToken)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("%s: using credentials version %s",
+		queueSpec.name, queueSpec.credentials.Version)
+	s.credentialedClientPool.Store(key, &sqsCredentialedClients{
+		version:   queueSpec.credentials.Version,
+		sqsClient: sqs.New(sess),
+		cwClient:  cloudwatch.New(sess),
+	})
+	return nil
+}
+
+// getSQSClient returns the client made using the credentials of the wpa,
+// the client of the region of the queue is used when it has none
+func (s *SQS) getSQSClient(key string, queueURI string) *sqs.SQS {
+	clients, ok := s.credentialedClientPool.Load(key)
+	if ok {
+		return clients.(*sqsCredentialedClients).sqsClient
+	}
 	return s.sqsClientPool[getRegion(queueURI)]
 }
 
-func (s *SQS) getCWClient(queueURI string) (*cloudwatch.CloudWatch, error) {
+func (s *SQS) getCWClient(key string, queueURI string) (*cloudwatch.CloudWatch, error) {
+	clients, found := s.credentialedClientPool.Load(key)
+	if found {
+		return clients.(*sqsCredentialedClients).cwClient, nil
+	}
+
 	client, ok := s.cwClientPool[getRegion(queueURI)]
 	if !ok {
 		return nil, fmt.Errorf("Client not found for queue: %s\n", queueURI)
@@ -96,8 +308,10 @@ func (s *SQS) getCWClient(queueURI string) (*cloudwatch.CloudWatch, error) {
 	return client, nil
 }
 
-func (s *SQS) longPollReceiveMessage(queueURI string) (int32, error) {
-	result, err := s.getSQSClient(queueURI).ReceiveMessage(&sqs.ReceiveMessageInput{
+func (s *SQS) longPollReceiveMessage(
+	key string, queueURI string, waitTimeSeconds int64) (int32, error) {
+
+	result, err := s.getSQSClient(key, queueURI).ReceiveMessage(&sqs.ReceiveMessageInput{
 		QueueUrl: aws.String(queueURI),
 		AttributeNames: aws.StringSlice([]string{
 			"SentTimestamp",
@@ -107,7 +321,7 @@ func (s *SQS) longPollReceiveMessage(queueURI string) (int32, error) {
 		MessageAttributeNames: aws.StringSlice([]string{
 			"All",
 		}),
-		WaitTimeSeconds: aws.Int64(s.longPollInterval),
+		WaitTimeSeconds: aws.Int64(waitTimeSeconds),
 	})
 
 	if err != nil {
@@ -117,55 +331,148 @@ func (s *SQS) longPollReceiveMessage(queueURI string) (int32, error) {
 	return int32(len(result.Messages)), nil
 }
 
-func (s *SQS) getApproxMessages(queueURI string) (int32, error) {
-	result, err := s.getSQSClient(queueURI).GetQueueAttributes(&sqs.GetQueueAttributesInput{
-		QueueUrl:       &queueURI,
-		AttributeNames: []*string{aws.String("ApproximateNumberOfMessages")},
-	})
+// sampleMessageWeight receives upto 10 visible messages without hiding
+// them and returns their average weight. It costs one ReceiveMessage
+// call and increments the ApproximateReceiveCount of the sampled messages,
+// use cachedMessageWeight to sample at the sample interval.
+func (s *SQS) sampleMessageWeight(
+	key string, queueURI string, messageWeights *MessageWeights) (float64, error) {
 
+	result, err := s.getSQSClient(key, queueURI).ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURI),
+		VisibilityTimeout:   aws.Int64(0),
+		MaxNumberOfMessages: aws.Int64(10),
+		MessageAttributeNames: aws.StringSlice([]string{
+			messageWeights.AttributeName,
+		}),
+		WaitTimeSeconds: aws.Int64(0),
+	})
 	if err != nil {
-		return 0, err
+		return 0.0, err
 	}
 
-	messages, ok := result.Attributes["ApproximateNumberOfMessages"]
-	if !ok {
-		return 0, fmt.Errorf("ApproximateNumberOfMessages not found: %+v",
-			result.Attributes)
-	}
+	return averageMessageWeight(result.Messages, messageWeights), nil
+}
 
-	i64, err := strconv.ParseInt(*messages, 10, 32)
-	if err != nil {
-		return 0, err
+// averageMessageWeight returns the average weight of the messages,
+// it is 1 when there are no messages so that the count is used as is
+func averageMessageWeight(
+	messages []*sqs.Message, messageWeights *MessageWeights) float64 {
+
+	if len(messages) == 0 {
+		return 1.0
 	}
 
-	return int32(i64), nil
+	var sum float64
+	for _, message := range messages {
+		var messageType string
+		attribute, ok := message.MessageAttributes[messageWeights.AttributeName]
+		if ok && attribute.StringValue != nil {
+			messageType = *attribute.StringValue
+		}
+		sum += messageWeights.getWeight(messageType)
+	}
+	return sum / float64(len(messages))
 }
 
-func (s *SQS) getApproxMessagesNotVisible(queueURI string) (int32, error) {
-	result, err := s.getSQSClient(queueURI).GetQueueAttributes(&sqs.GetQueueAttributesInput{
+// getQueueAttributes returns the approximate message counts of the
+// queue using one GetQueueAttributes call
+func (s *SQS) getQueueAttributes(
+	key string, queueURI string, attributeNames []string) (sqsQueueAttributes, error) {
+
+	result, err := s.getSQSClient(key, queueURI).GetQueueAttributes(&sqs.GetQueueAttributesInput{
 		QueueUrl:       &queueURI,
-		AttributeNames: []*string{aws.String("ApproximateNumberOfMessagesNotVisible")},
+		AttributeNames: aws.StringSlice(attributeNames),
 	})
-
 	if err != nil {
-		return 0, err
+		return sqsQueueAttributes{}, err
 	}
 
-	messages, ok := result.Attributes["ApproximateNumberOfMessagesNotVisible"]
-	if !ok {
-		return 0, fmt.Errorf("ApproximateNumberOfMessages not found: %+v",
-			result.Attributes)
+	return parseQueueAttributes(result.Attributes, attributeNames)
+}
+
+// sqsQueueAttributes are the approximate message counts of the queue,
+// the counts whose attribute was not requested are 0
+type sqsQueueAttributes struct {
+	messages           int32
+	messagesNotVisible int32
+	messagesDelayed    int32
+}
+
+// parseQueueAttributes parses the requested attributes of the queue,
+// it is an error if any of them is missing
+func parseQueueAttributes(
+	attributes map[string]*string, attributeNames []string) (sqsQueueAttributes, error) {
+
+	var parsed sqsQueueAttributes
+	for _, name := range attributeNames {
+		value, ok := attributes[name]
+		if !ok || value == nil {
+			return parsed, fmt.Errorf("%s not found: %+v", name, attributes)
+		}
+
+		i64, err := strconv.ParseInt(*value, 10, 32)
+		if err != nil {
+			return parsed, err
+		}
+
+		switch name {
+		case sqsApproximateNumberOfMessages:
+			parsed.messages = int32(i64)
+		case sqsApproximateNumberOfMessagesNotVisible:
+			parsed.messagesNotVisible = int32(i64)
+		case sqsApproximateNumberOfMessagesDelayed:
+			parsed.messagesDelayed = int32(i64)
+		}
 	}
+	return parsed, nil
+}
 
-	i64, err := strconv.ParseInt(*messages, 10, 32)
-	if err != nil {
-		return 0, err
+// getQueueAttributeNames returns the attributes requested by the poll of
+// the queue. ApproximateNumberOfMessages is always requested as the
+// poll can not work without it.
+func (s *SQS) getQueueAttributeNames(queueSpec QueueSpec) []string {
+	attributeNames := s.queueAttributes
+	if queueSpec.sqsOptions != nil && len(queueSpec.sqsOptions.QueueAttributes) > 0 {
+		attributeNames = queueSpec.sqsOptions.QueueAttributes
+	}
+
+	names := []string{sqsApproximateNumberOfMessages}
+	for _, name := range attributeNames {
+		if name != sqsApproximateNumberOfMessages {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// getWaitTimeSeconds returns the long poll wait time of the queue
+func (s *SQS) getWaitTimeSeconds(queueSpec QueueSpec) int64 {
+	waitTimeSeconds := s.longPollInterval
+	if queueSpec.sqsOptions != nil && queueSpec.sqsOptions.WaitTimeSeconds != nil {
+		waitTimeSeconds = *queueSpec.sqsOptions.WaitTimeSeconds
 	}
+	return clampWaitTimeSeconds(waitTimeSeconds, s.shortPollInterval)
+}
 
-	return int32(i64), nil
+// clampWaitTimeSeconds keeps the long poll wait time between 0 and the
+// maximum allowed by SQS. It is also kept less than the poll interval, in
+// whole seconds, so that a long poll does not keep the queue unpolled for
+// as long as it. The wait time is 0 for the intervals below 2 seconds.
+func clampWaitTimeSeconds(waitTimeSeconds int64, pollInterval time.Duration) int64 {
+	if max := int64(pollInterval/time.Second) - 1; waitTimeSeconds > max {
+		waitTimeSeconds = max
+	}
+	if waitTimeSeconds > SQSMaxWaitTimeSeconds {
+		waitTimeSeconds = SQSMaxWaitTimeSeconds
+	}
+	if waitTimeSeconds < 0 {
+		waitTimeSeconds = 0
+	}
+	return waitTimeSeconds
 }
 
-func (s *SQS) getNumberOfMessagesReceived(queueURI string) (float64, error) {
+func (s *SQS) getNumberOfMessagesReceived(key string, queueURI string) (float64, error) {
 	period := int64(60)
 	duration, err := time.ParseDuration("-10m")
 	if err != nil {
@@ -192,7 +499,7 @@ func (s *SQS) getNumberOfMessagesReceived(queueURI string) (float64, error) {
 		},
 	}
 
-	cwClient, err := s.getCWClient(queueURI)
+	cwClient, err := s.getCWClient(key, queueURI)
 	if err != nil {
 		return 0.0, err
 	}
@@ -224,8 +531,15 @@ func (s *SQS) getNumberOfMessagesReceived(queueURI string) (float64, error) {
 	return 0.0, nil
 }
 
-func (s *SQS) getSentMessageCache(queueURI string) (float64, bool) {
-	cache, _ := s.cacheSentMessages.Load(queueURI)
+// getMetricsCacheKey returns the key of the cached cloudwatch metrics of
+// the queue polled by the wpa. The metrics are cached per wpa as they
+// are fetched using the credentials of the wpa.
+func getMetricsCacheKey(key string, queueURI string) string {
+	return key + "|" + queueURI
+}
+
+func (s *SQS) getSentMessageCache(cacheKey string) (float64, bool) {
+	cache, _ := s.cacheSentMessages.Load(cacheKey)
 	if cache != nil {
 		return cache.(float64), true
 	}
@@ -233,12 +547,13 @@ func (s *SQS) getSentMessageCache(queueURI string) (float64, bool) {
 	return 0.0, false
 }
 
-func (s *SQS) updateSentMessageCache(key string, cache float64) {
-	s.cacheSentMessages.Store(key, cache)
+func (s *SQS) updateSentMessageCache(cacheKey string, cache float64) {
+	s.cacheSentMessages.Store(cacheKey, cache)
 }
 
-func (s *SQS) cachedNumberOfSentMessages(queueURI string) (float64, error) {
-	lastTimeStamp, _ := s.cacheSentMessageslastTimestamp.Load(queueURI)
+func (s *SQS) cachedNumberOfSentMessages(key string, queueURI string) (float64, error) {
+	cacheKey := getMetricsCacheKey(key, queueURI)
+	lastTimeStamp, _ := s.cacheSentMessageslastTimestamp.Load(cacheKey)
 	if lastTimeStamp == nil {
 		lastTimeStamp = time.Now().UnixNano() -
 			(time.Second * time.Duration(70)).Nanoseconds()
@@ -246,23 +561,23 @@ func (s *SQS) cachedNumberOfSentMessages(queueURI string) (float64, error) {
 
 	now := time.Now().UnixNano()
 	if (lastTimeStamp.(int64) + s.cacheSentMessagesValidity.Nanoseconds()) > now {
-		cache, cacheHit := s.getSentMessageCache(queueURI)
+		cache, cacheHit := s.getSentMessageCache(cacheKey)
 		if cacheHit {
 			return cache, nil
 		}
 	}
 
-	messagesSent, err := s.getAverageNumberOfMessagesSent(queueURI)
+	messagesSent, err := s.getAverageNumberOfMessagesSent(key, queueURI)
 	if err != nil {
 		return messagesSent, err
 	}
-	s.updateSentMessageCache(queueURI, messagesSent)
-	s.cacheSentMessageslastTimestamp.Store(queueURI, now)
+	s.updateSentMessageCache(cacheKey, messagesSent)
+	s.cacheSentMessageslastTimestamp.Store(cacheKey, now)
 	return messagesSent, nil
 }
 
-func (s *SQS) getReceiveMessageCache(queueURI string) (float64, bool) {
-	cache, _ := s.cacheReceiveMessages.Load(queueURI)
+func (s *SQS) getReceiveMessageCache(cacheKey string) (float64, bool) {
+	cache, _ := s.cacheReceiveMessages.Load(cacheKey)
 	if cache != nil {
 		return cache.(float64), true
 	}
@@ -270,12 +585,13 @@ func (s *SQS) getReceiveMessageCache(queueURI string) (float64, bool) {
 	return 0.0, false
 }
 
-func (s *SQS) updateReceiveMessageCache(key string, cache float64) {
-	s.cacheReceiveMessages.Store(key, cache)
+func (s *SQS) updateReceiveMessageCache(cacheKey string, cache float64) {
+	s.cacheReceiveMessages.Store(cacheKey, cache)
 }
 
-func (s *SQS) cachedNumberOfReceiveMessages(queueURI string) (float64, error) {
-	lastTimeStamp, _ := s.cacheReceiveMessageslastTimestamp.Load(queueURI)
+func (s *SQS) cachedNumberOfReceiveMessages(key string, queueURI string) (float64, error) {
+	cacheKey := getMetricsCacheKey(key, queueURI)
+	lastTimeStamp, _ := s.cacheReceiveMessageslastTimestamp.Load(cacheKey)
 	if lastTimeStamp == nil {
 		lastTimeStamp = time.Now().UnixNano() -
 			(time.Second * time.Duration(70)).Nanoseconds()
@@ -283,22 +599,22 @@ func (s *SQS) cachedNumberOfReceiveMessages(queueURI string) (float64, error) {
 
 	now := time.Now().UnixNano()
 	if (lastTimeStamp.(int64) + s.cacheReceiveMessagesValidity.Nanoseconds()) > now {
-		cache, cacheHit := s.getReceiveMessageCache(queueURI)
+		cache, cacheHit := s.getReceiveMessageCache(cacheKey)
 		if cacheHit {
 			return cache, nil
 		}
 	}
 
-	messagesReceived, err := s.getNumberOfMessagesReceived(queueURI)
+	messagesReceived, err := s.getNumberOfMessagesReceived(key, queueURI)
 	if err != nil {
 		return messagesReceived, err
 	}
-	s.updateReceiveMessageCache(queueURI, messagesReceived)
-	s.cacheReceiveMessageslastTimestamp.Store(queueURI, now)
+	s.updateReceiveMessageCache(cacheKey, messagesReceived)
+	s.cacheReceiveMessageslastTimestamp.Store(cacheKey, now)
 	return messagesReceived, nil
 }
 
-func (s *SQS) getAverageNumberOfMessagesSent(queueURI string) (float64, error) {
+func (s *SQS) getAverageNumberOfMessagesSent(key string, queueURI string) (float64, error) {
 	period := int64(60)
 	duration, err := time.ParseDuration("-5m")
 	if err != nil {
@@ -325,7 +641,7 @@ func (s *SQS) getAverageNumberOfMessagesSent(queueURI string) (float64, error) {
 		},
 	}
 
-	cwClient, err := s.getCWClient(queueURI)
+	cwClient, err := s.getCWClient(key, queueURI)
 	if err != nil {
 		return 0.0, err
 	}
@@ -357,8 +673,185 @@ func (s *SQS) getAverageNumberOfMessagesSent(queueURI string) (float64, error) {
 	return 0.0, nil
 }
 
-func (s *SQS) waitForShortPollInterval() {
-	time.Sleep(s.shortPollInterval)
+// isFIFOQueue tells if the queue is a FIFO queue, the name of
+// the FIFO queues ends with .fifo
+func isFIFOQueue(queueURI string) bool {
+	return strings.HasSuffix(queueURI, ".fifo")
+}
+
+// getNumberOfGroupsWithInflightMessages returns the latest number of
+// message groups of the FIFO queue which have messages in flight
+func (s *SQS) getNumberOfGroupsWithInflightMessages(key string, queueURI string) (int32, error) {
+	period := int64(60)
+	endTime := time.Now()
+	startTime := endTime.Add(-10 * time.Minute)
+
+	query := &cloudwatch.MetricDataQuery{
+		Id: aws.String("id1"),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String("AWS/SQS"),
+				MetricName: aws.String("ApproximateNumberOfGroupsWithInflightMessages"),
+				Dimensions: []*cloudwatch.Dimension{
+					&cloudwatch.Dimension{
+						Name:  aws.String("QueueName"),
+						Value: aws.String(path.Base(queueURI)),
+					},
+				},
+			},
+			Period: &period,
+			Stat:   aws.String("Maximum"),
+		},
+	}
+
+	cwClient, err := s.getCWClient(key, queueURI)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := cwClient.GetMetricData(&cloudwatch.GetMetricDataInput{
+		EndTime:           &endTime,
+		StartTime:         &startTime,
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{query},
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(result.MetricDataResults) != 1 {
+		return 0, fmt.Errorf("Expecting cloudwatch metric to return single data point")
+	}
+
+	values := result.MetricDataResults[0].Values
+	if len(values) == 0 {
+		return 0, fmt.Errorf("ApproximateNumberOfGroupsWithInflightMessages Cloudwatch API returned empty result")
+	}
+	return int32(*values[0]), nil
+}
+
+func (s *SQS) cachedNumberOfGroupsWithInflightMessages(key string, queueURI string) (int32, error) {
+	cacheKey := getMetricsCacheKey(key, queueURI)
+	lastTimeStamp, _ := s.cacheInflightGroupslastTimestamp.Load(cacheKey)
+	now := time.Now().UnixNano()
+	if lastTimeStamp != nil &&
+		(lastTimeStamp.(int64)+s.cacheInflightGroupsValidity.Nanoseconds()) > now {
+		if cache, ok := s.cacheInflightGroups.Load(cacheKey); ok {
+			return cache.(int32), nil
+		}
+	}
+
+	groups, err := s.getNumberOfGroupsWithInflightMessages(key, queueURI)
+	if err != nil {
+		return groups, err
+	}
+	s.cacheInflightGroups.Store(cacheKey, groups)
+	s.cacheInflightGroupslastTimestamp.Store(cacheKey, now)
+	return groups, nil
+}
+
+// getApproximateAgeOfOldestMessage returns the latest age of the
+// oldest message in the queue in seconds
+func (s *SQS) getApproximateAgeOfOldestMessage(key string, queueURI string) (float64, error) {
+	period := int64(60)
+	endTime := time.Now()
+	startTime := endTime.Add(-10 * time.Minute)
+
+	query := &cloudwatch.MetricDataQuery{
+		Id: aws.String("id1"),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String("AWS/SQS"),
+				MetricName: aws.String("ApproximateAgeOfOldestMessage"),
+				Dimensions: []*cloudwatch.Dimension{
+					&cloudwatch.Dimension{
+						Name:  aws.String("QueueName"),
+						Value: aws.String(path.Base(queueURI)),
+					},
+				},
+			},
+			Period: &period,
+			Stat:   aws.String("Maximum"),
+		},
+	}
+
+	cwClient, err := s.getCWClient(key, queueURI)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := cwClient.GetMetricData(&cloudwatch.GetMetricDataInput{
+		EndTime:           &endTime,
+		StartTime:         &startTime,
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{query},
+		ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(result.MetricDataResults) != 1 {
+		return 0, fmt.Errorf("Expecting cloudwatch metric to return single data point")
+	}
+
+	values := result.MetricDataResults[0].Values
+	if len(values) == 0 {
+		return 0, fmt.Errorf("ApproximateAgeOfOldestMessage Cloudwatch API returned empty result")
+	}
+	return *values[0], nil
+}
+
+func (s *SQS) cachedApproximateAgeOfOldestMessage(key string, queueURI string) (float64, error) {
+	cacheKey := getMetricsCacheKey(key, queueURI)
+	lastTimeStamp, _ := s.cacheOldestMessageAgelastTimestamp.Load(cacheKey)
+	now := time.Now().UnixNano()
+	if lastTimeStamp != nil &&
+		(lastTimeStamp.(int64)+s.cacheOldestMessageAgeValidity.Nanoseconds()) > now {
+		if cache, ok := s.cacheOldestMessageAge.Load(cacheKey); ok {
+			return cache.(float64), nil
+		}
+	}
+
+	age, err := s.getApproximateAgeOfOldestMessage(key, queueURI)
+	if err != nil {
+		return age, err
+	}
+	s.cacheOldestMessageAge.Store(cacheKey, age)
+	s.cacheOldestMessageAgelastTimestamp.Store(cacheKey, now)
+	return age, nil
+}
+
+// getMessageGroups returns the number of message groups of the FIFO
+// queue which can be processed at once. The messages of a group are
+// processed one at a time, so the groups in flight and the groups of the
+// visible messages bound the useful workers. The groups of the visible
+// messages are not known, every visible message is taken as a group.
+func getMessageGroups(inflightGroups int32, approxMessages int32) int32 {
+	return inflightGroups + approxMessages
+}
+
+func (s *SQS) waitForShortPollInterval(queueSpec QueueSpec) {
+	waitForPollInterval(s.shortPollInterval, queueSpec.pollNowCh)
+}
+
+// waitForPollSchedule waits for the adaptive poll interval of the queue,
+// the interval is longer when the backlog did not change since the
+// last poll, see nextSQSPollInterval
+func (s *SQS) waitForPollSchedule(
+	key string, queueSpec QueueSpec, schedule sqsPollSchedule, messages int32) {
+
+	schedule.interval = nextSQSPollInterval(
+		schedule.interval,
+		s.shortPollInterval,
+		s.maxPollInterval,
+		s.maxCallsPerMinute,
+		queueSpec.messages,
+		messages,
+	)
+	klog.V(3).Infof("%s: pollInterval=%v, callsPerMinute=%v",
+		queueSpec.name, schedule.interval, schedule.getCallsPerMinute())
+	s.queues.updateSQSPollSchedule(key, schedule)
+	waitForPollInterval(schedule.interval, queueSpec.pollNowCh)
 }
 
 // TODO: get rid of string parsing
@@ -371,7 +864,41 @@ func (s *SQS) GetName() string {
 	return s.name
 }
 
-func (s *SQS) poll(key string, queueSpec QueueSpec) {
+// reset drops the clients of the credentials of the wpa, the queues
+// discovered for it and the cached metrics of all of them. They are
+// made and fetched again by the next poll.
+func (s *SQS) reset(key string, queueSpec QueueSpec) {
+	s.credentialedClientPool.Delete(key)
+	s.cacheMessageWeights.Delete(key)
+	uris := []string{queueSpec.uri}
+	if cached, ok := s.discoveredQueues.Load(key); ok {
+		uris = append(uris, cached.(*sqsDiscoveredQueues).uris...)
+		s.discoveredQueues.Delete(key)
+	}
+	for _, uri := range uris {
+		cacheKey := getMetricsCacheKey(key, uri)
+		s.cacheSentMessages.Delete(cacheKey)
+		s.cacheSentMessageslastTimestamp.Delete(cacheKey)
+		s.cacheReceiveMessages.Delete(cacheKey)
+		s.cacheReceiveMessageslastTimestamp.Delete(cacheKey)
+		s.cacheInflightGroups.Delete(cacheKey)
+		s.cacheInflightGroupslastTimestamp.Delete(cacheKey)
+		s.cacheOldestMessageAge.Delete(cacheKey)
+		s.cacheOldestMessageAgelastTimestamp.Delete(cacheKey)
+	}
+}
+
+func (s *SQS) poll(key string, queueSpec QueueSpec) error {
+	if err := s.syncCredentials(key, queueSpec); err != nil {
+		klog.Errorf("Unable to use the credentials for queue %q, %v.",
+			queueSpec.name, err)
+		return newPollError(ErrQueueAuth, err)
+	}
+
+	if queueSpec.sqsOptions != nil && queueSpec.sqsOptions.QueuePrefix != "" {
+		return s.pollDiscoveredQueues(key, queueSpec)
+	}
+
 	if queueSpec.workers == 0 && queueSpec.messages == 0 && queueSpec.messagesSentPerMinute == 0 {
 		s.queues.updateIdleWorkers(key, -1)
 
@@ -379,98 +906,121 @@ func (s *SQS) poll(key string, queueSpec QueueSpec) {
 		// in the queue. On finding job(s) we increment the queue message
 		// by no of messages received to trigger scale up.
 		// Long polling is done to keep SQS api calls to minimum.
-		messagesReceived, err := s.longPollReceiveMessage(queueSpec.uri)
+		messagesReceived, err := s.longPollReceiveMessage(
+			key, queueSpec.uri, s.getWaitTimeSeconds(queueSpec))
 		if err != nil {
-			aerr, ok := err.(awserr.Error)
-			if ok && aerr.Code() == sqs.ErrCodeQueueDoesNotExist {
-				klog.Errorf("Unable to find queue %q, %v.", queueSpec.name, err)
-				return
-			} else if ok && aerr.Code() == "RequestError" {
-				klog.Errorf("Unable to perform request long polling %q, %v.",
-					queueSpec.name, err)
-				return
-			} else {
-				klog.Errorf("Unable to receive message from queue %q, %v.",
-					queueSpec.name, err)
-				return
-			}
+			klog.Errorf("Unable to receive message from queue %q, %v.",
+				queueSpec.name, err)
+			return classifySQSError(err)
 		}
 
+		if messagesReceived == 0 && queueSpec.sqsOptions != nil &&
+			queueSpec.sqsOptions.OldestMessageAge {
+			// nothing was received in the long poll, the queue is empty
+			s.queues.updateOldestMessageAge(key, 0)
+		}
 		s.queues.updateMessage(key, messagesReceived)
-		return
+		return nil
 	}
 
-	if queueSpec.secondsToProcessOneJob != 0.0 {
-		messagesSentPerMinute, err := s.cachedNumberOfSentMessages(queueSpec.uri)
+	if queueSpec.secondsToProcessOneJob != 0.0 || queueSpec.autoEstimateProcessingTime {
+		messagesSentPerMinute, err := s.cachedNumberOfSentMessages(key, queueSpec.uri)
 		if err != nil {
 			klog.Errorf("Unable to fetch no of messages to the queue %q, %v.",
 				queueSpec.name, err)
-			return
+			return classifySQSError(err)
 		}
 		s.queues.updateMessageSent(key, messagesSentPerMinute)
 		klog.V(3).Infof("%s: messagesSentPerMinute=%v", queueSpec.name, messagesSentPerMinute)
 	}
 
-	approxMessages, err := s.getApproxMessages(queueSpec.uri)
+	// the calls over the budget of the queue wait for the next minute
+	schedule := queueSpec.sqsPollSchedule
+	if wait := schedule.budgetWait(time.Now(), s.maxCallsPerMinute); wait > 0 {
+		klog.V(3).Infof("%s: %d calls made in the minute, waiting %v",
+			queueSpec.name, schedule.windowCalls, wait)
+		time.Sleep(wait)
+	}
+	attributes, err := s.getQueueAttributes(
+		key, queueSpec.uri, s.getQueueAttributeNames(queueSpec))
+	schedule = schedule.recordCall(time.Now())
 	if err != nil {
-		aerr, ok := err.(awserr.Error)
-		if ok && aerr.Code() == sqs.ErrCodeQueueDoesNotExist {
-			klog.Errorf("Unable to find queue %q, %v. (checking after 20s)", queueSpec.name, err)
-			time.Sleep(20 * time.Second)
-			return
-		} else if ok && aerr.Code() == "RequestError" {
-			klog.Errorf("Unable to perform request get approximate messages %q, %v.",
-				queueSpec.name, err)
-			return
-		} else {
-			klog.Errorf("Unable to get approximate messages in queue %q, %v.",
-				queueSpec.name, err)
-			return
-		}
+		klog.Errorf("Unable to get queue attributes of queue %q, %v.",
+			queueSpec.name, err)
+		s.queues.updateSQSPollSchedule(key, schedule)
+		return classifySQSError(err)
 	}
-	klog.V(3).Infof("%s: approxMessages=%d", queueSpec.name, approxMessages)
 
+	approxMessages := attributes.messages
 	// approxMessagesNotVisible is queried to prevent scaling down when their are
 	// workers which are doing the processing, so if approxMessagesNotVisible > 0 we
 	// do not scale down as those messages are still being processed (and we dont know which worker)
-	approxMessagesNotVisible, err := s.getApproxMessagesNotVisible(queueSpec.uri)
-	if err != nil {
-		aerr, ok := err.(awserr.Error)
-		if ok && aerr.Code() == sqs.ErrCodeQueueDoesNotExist {
-			klog.Errorf("Unable to find queue %q, %v.", queueSpec.name, err)
-			return
-		} else if ok && aerr.Code() == "RequestError" {
-			klog.Errorf("Unable to perform request get approximate messages not visible %q, %v.",
+	approxMessagesNotVisible := attributes.messagesNotVisible
+	klog.V(3).Infof("%s: approxMessages=%d, approxMessagesNotVisible=%d, approxMessagesDelayed=%d",
+		queueSpec.name, approxMessages, approxMessagesNotVisible, attributes.messagesDelayed)
+
+	messages := approxMessages + approxMessagesNotVisible + attributes.messagesDelayed
+	if queueSpec.messageWeights != nil && approxMessages > 0 {
+		// the messages not visible can not be sampled, they are
+		// assumed to have the same mix of types as the visible ones
+		weight, err := s.cachedMessageWeight(key, queueSpec)
+		if err != nil {
+			klog.Errorf("Unable to sample messages in queue %q, using the message count, %v.",
+				queueSpec.name, err)
+		} else {
+			messages = int32(math.Ceil(float64(messages) * weight))
+			klog.V(3).Infof("%s: averageWeight=%v, weightedMessages=%d",
+				queueSpec.name, weight, messages)
+		}
+	}
+
+	if isFIFOQueue(queueSpec.uri) {
+		messageGroups := int32(UnsyncedMessageGroups)
+		inflightGroups, err := s.cachedNumberOfGroupsWithInflightMessages(key, queueSpec.uri)
+		if err != nil {
+			klog.Errorf("Unable to fetch the message groups in flight of queue %q, not bounding the workers, %v.",
 				queueSpec.name, err)
-			return
 		} else {
-			klog.Errorf("Unable to get approximate messages not visible in queue %q, %v.",
+			messageGroups = getMessageGroups(inflightGroups, approxMessages)
+			klog.V(3).Infof("%s: inflightGroups=%d, messageGroups=%d",
+				queueSpec.name, inflightGroups, messageGroups)
+		}
+		s.queues.updateMessageGroups(key, messageGroups)
+	}
+
+	if queueSpec.sqsOptions != nil && queueSpec.sqsOptions.OldestMessageAge {
+		oldestMessageAge := float64(UnsyncedOldestMessageAge)
+		age, err := s.cachedApproximateAgeOfOldestMessage(key, queueSpec.uri)
+		if err != nil {
+			klog.Errorf("Unable to fetch the age of the oldest message of queue %q, %v.",
 				queueSpec.name, err)
-			return
+		} else {
+			oldestMessageAge = age
+			klog.V(3).Infof("%s: oldestMessageAge=%v", queueSpec.name, oldestMessageAge)
 		}
+		s.queues.updateOldestMessageAge(key, oldestMessageAge)
 	}
-	klog.V(3).Infof("approxMessagesNotVisible=%d", approxMessagesNotVisible)
 
-	s.queues.updateMessage(key, approxMessages+approxMessagesNotVisible)
+	s.queues.updateMessage(key, messages)
 
 	if approxMessages != 0 {
 		s.queues.updateIdleWorkers(key, -1)
-		s.waitForShortPollInterval()
-		return
+		s.waitForPollSchedule(key, queueSpec, schedule, messages)
+		return nil
 	}
 
 	if approxMessagesNotVisible > 0 {
 		klog.V(3).Infof("%s: approxMessagesNotVisible > 0, not scaling down", queueSpec.name)
-		s.waitForShortPollInterval()
-		return
+		s.waitForPollSchedule(key, queueSpec, schedule, messages)
+		return nil
 	}
 
-	numberOfMessagesReceived, err := s.cachedNumberOfReceiveMessages(queueSpec.uri)
+	numberOfMessagesReceived, err := s.cachedNumberOfReceiveMessages(key, queueSpec.uri)
 	if err != nil {
 		klog.Errorf("Unable to fetch no of received messages for queue %q, %v.",
 			queueSpec.name, err)
-		time.Sleep(100 * time.Millisecond)
-		return
+		s.queues.updateSQSPollSchedule(key, schedule)
+		return classifySQSError(err)
 	}
 
 	var idleWorkers int32
@@ -488,6 +1038,6 @@ func (s *SQS) poll(key string, queueSpec QueueSpec) {
 		idleWorkers,
 	)
 	s.queues.updateIdleWorkers(key, idleWorkers)
-	s.waitForShortPollInterval()
-	return
+	s.waitForPollSchedule(key, queueSpec, schedule, messages)
+	return nil
 }
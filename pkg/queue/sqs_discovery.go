@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/practo/klog/v2"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const (
+	// DefaultSQSMaxDiscoveredQueues is the number of queues polled
+	// when the queues are discovered by a prefix and no cap is set
+	DefaultSQSMaxDiscoveredQueues = 100
+
+	// SQSMaxDiscoveredQueues is the most queues returned by ListQueues
+	SQSMaxDiscoveredQueues = 1000
+)
+
+// sqsDiscoveredQueues are the queues found by the prefix of a queue
+type sqsDiscoveredQueues struct {
+	prefix       string
+	maxQueues    int
+	uris         []string
+	discoveredAt time.Time
+}
+
+// getMaxDiscoveredQueues returns the number of queues polled for the prefix
+func getMaxDiscoveredQueues(options *SQSOptions) int {
+	if options == nil || options.MaxDiscoveredQueues == nil {
+		return DefaultSQSMaxDiscoveredQueues
+	}
+	return int(*options.MaxDiscoveredQueues)
+}
+
+// getQueueURIWithName returns the uri of the queue with the name in the
+// same account and region as the queueURI, the queues listed by the
+// prefix are polled using the endpoint and the credentials of the queueURI
+func getQueueURIWithName(queueURI string, name string) string {
+	return queueURI[:strings.LastIndex(queueURI, "/")+1] + name
+}
+
+// capDiscoveredQueues sorts the uris so that the same queues are polled
+// on every discovery and keeps the first maxQueues of them
+func capDiscoveredQueues(uris []string, maxQueues int) ([]string, bool) {
+	sort.Strings(uris)
+	if len(uris) > maxQueues {
+		return uris[:maxQueues], true
+	}
+	return uris, false
+}
+
+// listQueuesByPrefix returns the uris of the queues whose name starts
+// with the prefix, in the account and region of the queueURI
+func (s *SQS) listQueuesByPrefix(
+	key string, queueURI string, prefix string) ([]string, error) {
+
+	var uris []string
+	err := s.getSQSClient(key, queueURI).ListQueuesPages(&sqs.ListQueuesInput{
+		QueueNamePrefix: aws.String(prefix),
+		MaxResults:      aws.Int64(SQSMaxDiscoveredQueues),
+	}, func(page *sqs.ListQueuesOutput, lastPage bool) bool {
+		for _, url := range page.QueueUrls {
+			if url == nil {
+				continue
+			}
+			uris = append(uris, getQueueURIWithName(
+				queueURI, (*url)[strings.LastIndex(*url, "/")+1:]))
+		}
+		return len(uris) < SQSMaxDiscoveredQueues
+	})
+	return uris, err
+}
+
+// getDiscoveredQueues returns the queues matching the prefix of the queue,
+// the queues are listed again once the discovery interval has passed
+// so that the queues created or deleted since then are picked up
+func (s *SQS) getDiscoveredQueues(key string, queueSpec QueueSpec) ([]string, error) {
+	prefix := queueSpec.sqsOptions.QueuePrefix
+	maxQueues := getMaxDiscoveredQueues(queueSpec.sqsOptions)
+	if cached, ok := s.discoveredQueues.Load(key); ok {
+		discovered := cached.(*sqsDiscoveredQueues)
+		if discovered.prefix == prefix && discovered.maxQueues == maxQueues &&
+			time.Since(discovered.discoveredAt) < s.queueDiscoveryInterval {
+			return discovered.uris, nil
+		}
+	}
+
+	uris, err := s.listQueuesByPrefix(key, queueSpec.uri, prefix)
+	if err != nil {
+		return nil, err
+	}
+	uris, truncated := capDiscoveredQueues(uris, maxQueues)
+	if truncated {
+		klog.Warningf("%s: more than %d queues match the prefix %q, polling the first %d",
+			queueSpec.name, maxQueues, prefix, maxQueues)
+	}
+	klog.V(2).Infof("%s: discovered %d queues with the prefix %q",
+		queueSpec.name, len(uris), prefix)
+
+	s.discoveredQueues.Store(key, &sqsDiscoveredQueues{
+		prefix:       prefix,
+		maxQueues:    maxQueues,
+		uris:         uris,
+		discoveredAt: time.Now(),
+	})
+	return uris, nil
+}
+
+// forgetDiscoveredQueue drops the queue deleted since the last discovery
+func (s *SQS) forgetDiscoveredQueue(key string, queueURI string) {
+	cached, ok := s.discoveredQueues.Load(key)
+	if !ok {
+		return
+	}
+	discovered := cached.(*sqsDiscoveredQueues)
+	uris := make([]string, 0, len(discovered.uris))
+	for _, uri := range discovered.uris {
+		if uri != queueURI {
+			uris = append(uris, uri)
+		}
+	}
+	s.discoveredQueues.Store(key, &sqsDiscoveredQueues{
+		prefix:       discovered.prefix,
+		maxQueues:    discovered.maxQueues,
+		uris:         uris,
+		discoveredAt: discovered.discoveredAt,
+	})
+}
+
+// addQueueAttributes sums the message counts of the queues
+func addQueueAttributes(sum sqsQueueAttributes, attributes sqsQueueAttributes) sqsQueueAttributes {
+	return sqsQueueAttributes{
+		messages:           sum.messages + attributes.messages,
+		messagesNotVisible: sum.messagesNotVisible + attributes.messagesNotVisible,
+		messagesDelayed:    sum.messagesDelayed + attributes.messagesDelayed,
+	}
+}
+
+// pollDiscoveredQueues polls all the queues matching the prefix of the
+// queue and updates their total backlog. The queues are not long polled
+// when there are no workers, every queue costs one GetQueueAttributes
+// call per poll and the CloudWatch calls when the queue is empty.
+func (s *SQS) pollDiscoveredQueues(key string, queueSpec QueueSpec) error {
+	uris, err := s.getDiscoveredQueues(key, queueSpec)
+	if err != nil {
+		klog.Errorf("Unable to list the queues with the prefix %q for %q, %v.",
+			queueSpec.sqsOptions.QueuePrefix, queueSpec.name, err)
+		return classifySQSError(err)
+	}
+	if len(uris) == 0 {
+		err := fmt.Errorf("no queues found with the prefix %q",
+			queueSpec.sqsOptions.QueuePrefix)
+		klog.Errorf("Unable to poll %q, %v.", queueSpec.name, err)
+		return newPollError(ErrQueueNotFound, err)
+	}
+
+	if queueSpec.secondsToProcessOneJob != 0.0 || queueSpec.autoEstimateProcessingTime {
+		var messagesSentPerMinute float64
+		for _, uri := range uris {
+			sent, err := s.cachedNumberOfSentMessages(key, uri)
+			if err != nil {
+				klog.Errorf("Unable to fetch no of messages to the queue %q, %v.",
+					uri, err)
+				return classifySQSError(err)
+			}
+			messagesSentPerMinute += sent
+		}
+		s.queues.updateMessageSent(key, messagesSentPerMinute)
+		klog.V(3).Infof("%s: messagesSentPerMinute=%v", queueSpec.name, messagesSentPerMinute)
+	}
+
+	var total sqsQueueAttributes
+	attributeNames := s.getQueueAttributeNames(queueSpec)
+	for _, uri := range uris {
+		attributes, err := s.getQueueAttributes(key, uri, attributeNames)
+		if err != nil {
+			pollErr := classifySQSError(err)
+			if errors.Is(pollErr, ErrQueueNotFound) {
+				// deleted since the discovery, it has no backlog
+				klog.Warningf("%s: queue %q was deleted, skipping it", queueSpec.name, uri)
+				s.forgetDiscoveredQueue(key, uri)
+				continue
+			}
+			klog.Errorf("Unable to get queue attributes of queue %q, %v.", uri, err)
+			return pollErr
+		}
+		total = addQueueAttributes(total, attributes)
+	}
+	klog.V(3).Infof("%s: queues=%d, approxMessages=%d, approxMessagesNotVisible=%d, approxMessagesDelayed=%d",
+		queueSpec.name, len(uris), total.messages, total.messagesNotVisible, total.messagesDelayed)
+
+	s.queues.updateMessage(key,
+		total.messages+total.messagesNotVisible+total.messagesDelayed)
+
+	if total.messages != 0 {
+		s.queues.updateIdleWorkers(key, -1)
+		s.waitForShortPollInterval(queueSpec)
+		return nil
+	}
+
+	if total.messagesNotVisible > 0 {
+		klog.V(3).Infof("%s: approxMessagesNotVisible > 0, not scaling down", queueSpec.name)
+		s.waitForShortPollInterval(queueSpec)
+		return nil
+	}
+
+	var numberOfMessagesReceived float64
+	for _, uri := range uris {
+		received, err := s.cachedNumberOfReceiveMessages(key, uri)
+		if err != nil {
+			klog.Errorf("Unable to fetch no of received messages for queue %q, %v.",
+				uri, err)
+			return classifySQSError(err)
+		}
+		numberOfMessagesReceived += received
+	}
+
+	var idleWorkers int32
+	if numberOfMessagesReceived == 0.0 {
+		idleWorkers = queueSpec.workers
+	}
+	klog.V(3).Infof("%s: msgsReceived=%f, workers=%d, idleWorkers=%d",
+		queueSpec.name, numberOfMessagesReceived, queueSpec.workers, idleWorkers)
+	s.queues.updateIdleWorkers(key, idleWorkers)
+	s.waitForShortPollInterval(queueSpec)
+	return nil
+}
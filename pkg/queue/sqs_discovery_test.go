@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetQueueURIWithName(t *testing.T) {
+	uri := getQueueURIWithName(
+		"https://sqs.ap-south-1.amazonaws.com/123456789/otpsender", "otpsender-acme")
+	expected := "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender-acme"
+	if uri != expected {
+		t.Errorf("expected %q, got %q", expected, uri)
+	}
+}
+
+func TestCapDiscoveredQueues(t *testing.T) {
+	uris, truncated := capDiscoveredQueues([]string{"c", "a", "b"}, 2)
+	if !truncated || !reflect.DeepEqual(uris, []string{"a", "b"}) {
+		t.Errorf("expected [a b] truncated, got %v truncated=%v", uris, truncated)
+	}
+
+	uris, truncated = capDiscoveredQueues([]string{"b", "a"}, 2)
+	if truncated || !reflect.DeepEqual(uris, []string{"a", "b"}) {
+		t.Errorf("expected [a b] not truncated, got %v truncated=%v", uris, truncated)
+	}
+}
+
+func TestGetMaxDiscoveredQueues(t *testing.T) {
+	if max := getMaxDiscoveredQueues(nil); max != DefaultSQSMaxDiscoveredQueues {
+		t.Errorf("expected the default %d, got %d", DefaultSQSMaxDiscoveredQueues, max)
+	}
+	maxQueues := int32(5)
+	if max := getMaxDiscoveredQueues(&SQSOptions{MaxDiscoveredQueues: &maxQueues}); max != 5 {
+		t.Errorf("expected 5, got %d", max)
+	}
+}
+
+func TestAddQueueAttributes(t *testing.T) {
+	sum := addQueueAttributes(
+		sqsQueueAttributes{messages: 1, messagesNotVisible: 2, messagesDelayed: 3},
+		sqsQueueAttributes{messages: 10, messagesNotVisible: 20},
+	)
+	expected := sqsQueueAttributes{messages: 11, messagesNotVisible: 22, messagesDelayed: 3}
+	if sum != expected {
+		t.Errorf("expected %+v, got %+v", expected, sum)
+	}
+}
+
+func TestGetDiscoveredQueuesFromCache(t *testing.T) {
+	s := &SQS{
+		discoveredQueues:       new(sync.Map),
+		queueDiscoveryInterval: time.Minute,
+	}
+	s.discoveredQueues.Store("default/otpsender", &sqsDiscoveredQueues{
+		prefix:       "otpsender-",
+		maxQueues:    DefaultSQSMaxDiscoveredQueues,
+		uris:         []string{"a", "b", "c"},
+		discoveredAt: time.Now(),
+	})
+
+	s.forgetDiscoveredQueue("default/otpsender", "b")
+	uris, err := s.getDiscoveredQueues("default/otpsender", QueueSpec{
+		sqsOptions: &SQSOptions{QueuePrefix: "otpsender-"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(uris, []string{"a", "c"}) {
+		t.Errorf("expected the deleted queue to be dropped, got %v", uris)
+	}
+}
@@ -0,0 +1,189 @@
+//go:build integration
+// +build integration
+
+package queue
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/practo/klog/v2"
+)
+
+// The integration tests run against LocalStack. If LOCALSTACK_ENDPOINT
+// is not set, LocalStack is started using docker.
+// Run them using: go test -tags integration ./pkg/queue/...
+
+const (
+	localStackEndpoint  = "http://localhost:4566"
+	localStackContainer = "wpa-localstack"
+	localStackRegion    = "us-east-1"
+	localStackAccountID = "000000000000"
+)
+
+func startLocalStack(t *testing.T) (string, func()) {
+	if endpoint := os.Getenv("LOCALSTACK_ENDPOINT"); endpoint != "" {
+		return endpoint, func() {}
+	}
+
+	cmd := exec.Command("docker", "run", "-d", "--rm",
+		"--name", localStackContainer,
+		"-p", "4566:4566",
+		"-e", "SERVICES=sqs,cloudwatch",
+		"localstack/localstack")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Error starting localstack: %v, %s\n", err, out)
+	}
+	klog.Info("Started localstack container.")
+
+	stop := func() {
+		klog.Info("Stopping localstack container.")
+		if err := exec.Command("docker", "stop", localStackContainer).Run(); err != nil {
+			klog.Errorf("Error stopping localstack: %v\n", err)
+		}
+	}
+
+	for i := 0; i < 60; i++ {
+		resp, err := http.Get(localStackEndpoint + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return localStackEndpoint, stop
+			}
+		}
+		time.Sleep(time.Second)
+	}
+
+	stop()
+	t.Fatalf("Timed out waiting for localstack to be ready")
+	return "", nil
+}
+
+func setupLocalStackSQS(
+	t *testing.T, endpoint string, queueName string) (*Queues, *SQS, string) {
+
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		os.Setenv("AWS_ACCESS_KEY_ID", "test")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	}
+
+	queues := NewQueues()
+	go queues.Sync(stopCh)
+
+	service, err := NewSQS(SqsQueueService,
+		[]string{localStackRegion}, endpoint, queues, 1, 1, nil, 300, 1, 0, 1)
+	if err != nil {
+		t.Fatalf("Error creating sqs: %v\n", err)
+	}
+	s := service.(*SQS)
+
+	_, err = s.sqsClientPool[localStackRegion].CreateQueue(&sqs.CreateQueueInput{
+		QueueName: aws.String(queueName),
+	})
+	if err != nil {
+		t.Fatalf("Error creating queue: %v\n", err)
+	}
+
+	// the queue uri is in the aws format so that the region can be
+	// found from it, the requests are sent to the overridden endpoint
+	queueURI := "https://sqs." + localStackRegion + ".amazonaws.com/" +
+		localStackAccountID + "/" + queueName
+
+	return queues, s, queueURI
+}
+
+func sendMessages(t *testing.T, s *SQS, queueURI string, n int) {
+	for i := 0; i < n; i++ {
+		_, err := s.getSQSClient("", queueURI).SendMessage(&sqs.SendMessageInput{
+			QueueUrl:    aws.String(queueURI),
+			MessageBody: aws.String("51620"),
+		})
+		if err != nil {
+			t.Fatalf("Error sending message: %v\n", err)
+		}
+	}
+}
+
+func TestSQSPollWithLocalStack(t *testing.T) {
+	endpoint, stop := startLocalStack(t)
+	defer stop()
+
+	name := "otpsender"
+	namespace := "testns"
+	messages := 5
+	queues, s, queueURI := setupLocalStackSQS(t, endpoint, name)
+	key := getKey(namespace, name)
+
+	// test1: messages in the queue with workers running
+	sendMessages(t, s, queueURI, messages)
+	if err := queues.Add(namespace, name, queueURI, 2, 0.0, false, nil, nil, "", nil); err != nil {
+		t.Fatalf("Error adding queue: %v\n", err)
+	}
+	s.poll(key, queues.ListQueue(key))
+
+	_, messagesGot, messagesPerMinGot, idleGot := queues.GetQueueInfo(
+		namespace, name)
+	if messagesGot != int32(messages) {
+		t.Errorf("expected %v messages, got=%v\n", messages, messagesGot)
+	}
+	if messagesPerMinGot != UnsyncedMessagesSentPerMinute {
+		t.Errorf("expected %v messagesSentPerMinute, got=%v\n",
+			UnsyncedMessagesSentPerMinute, messagesPerMinGot)
+	}
+	if idleGot != -1 {
+		t.Errorf("expected -1 idle, got=%v\n", idleGot)
+	}
+
+	// test2: secondsToProcessOneJob fetches the messages sent per minute
+	if err := queues.Add(namespace, name, queueURI, 2, 1.0, false, nil, nil, "", nil); err != nil {
+		t.Fatalf("Error adding queue: %v\n", err)
+	}
+	s.poll(key, queues.ListQueue(key))
+
+	_, messagesGot, messagesPerMinGot, _ = queues.GetQueueInfo(
+		namespace, name)
+	if messagesGot != int32(messages) {
+		t.Errorf("expected %v messages, got=%v\n", messages, messagesGot)
+	}
+	if messagesPerMinGot < 0 {
+		t.Errorf("expected messagesSentPerMinute to be synced, got=%v\n",
+			messagesPerMinGot)
+	}
+
+	// test3: empty queue with workers running, all the workers are idle
+	_, err := s.getSQSClient("", queueURI).PurgeQueue(&sqs.PurgeQueueInput{
+		QueueUrl: aws.String(queueURI),
+	})
+	if err != nil {
+		t.Fatalf("Error purging queue: %v\n", err)
+	}
+	if err := queues.Add(namespace, name, queueURI, 2, 0.0, false, nil, nil, "", nil); err != nil {
+		t.Fatalf("Error adding queue: %v\n", err)
+	}
+	s.poll(key, queues.ListQueue(key))
+
+	_, messagesGot, _, idleGot = queues.GetQueueInfo(namespace, name)
+	if messagesGot != 0 {
+		t.Errorf("expected 0 messages, got=%v\n", messagesGot)
+	}
+	if idleGot != 2 {
+		t.Errorf("expected 2 idle, got=%v\n", idleGot)
+	}
+
+	// test4: no workers, the long poll finds the message
+	sendMessages(t, s, queueURI, 1)
+	if err := queues.Add(namespace, name, queueURI, 0, 0.0, false, nil, nil, "", nil); err != nil {
+		t.Fatalf("Error adding queue: %v\n", err)
+	}
+	s.poll(key, queues.ListQueue(key))
+
+	_, messagesGot, _, _ = queues.GetQueueInfo(namespace, name)
+	if messagesGot != 1 {
+		t.Errorf("expected 1 message, got=%v\n", messagesGot)
+	}
+}
@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practo/klog/v2"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// sqsMessageWeight is the average weight of the last sample of the
+// messages of a queue, it is sampled again after the sample interval
+type sqsMessageWeight struct {
+	weight    float64
+	sampledAt time.Time
+	// redriveChecked tells if the redrive policy of the
+	// queue was checked against the sample interval
+	redriveChecked bool
+}
+
+// cachedMessageWeight returns the average weight of the messages of the
+// queue. The messages are sampled at most once in the sample interval as
+// every sample increments the ApproximateReceiveCount of the messages.
+func (s *SQS) cachedMessageWeight(key string, queueSpec QueueSpec) (float64, error) {
+	var cached sqsMessageWeight
+	if value, ok := s.cacheMessageWeights.Load(key); ok {
+		cached = value.(sqsMessageWeight)
+		if time.Since(cached.sampledAt) < s.messageWeightSampleInterval {
+			return cached.weight, nil
+		}
+	}
+
+	if !cached.redriveChecked {
+		if err := s.checkRedrivePolicy(key, queueSpec); err != nil {
+			klog.Errorf("Unable to check the redrive policy of queue %q, %v.",
+				queueSpec.name, err)
+		} else {
+			cached.redriveChecked = true
+		}
+	}
+
+	weight, err := s.sampleMessageWeight(key, queueSpec.uri, queueSpec.messageWeights)
+	if err != nil {
+		s.cacheMessageWeights.Store(key, cached)
+		return 0.0, err
+	}
+	s.cacheMessageWeights.Store(key, sqsMessageWeight{
+		weight:         weight,
+		sampledAt:      time.Now(),
+		redriveChecked: cached.redriveChecked,
+	})
+	return weight, nil
+}
+
+// checkRedrivePolicy warns when the samples of the messages alone can
+// move a message to the dead letter queue, i.e. a message waiting in the
+// queue for the retention period can be sampled maxReceiveCount times
+func (s *SQS) checkRedrivePolicy(key string, queueSpec QueueSpec) error {
+	result, err := s.getSQSClient(key, queueSpec.uri).GetQueueAttributes(
+		&sqs.GetQueueAttributesInput{
+			QueueUrl: aws.String(queueSpec.uri),
+			AttributeNames: aws.StringSlice([]string{
+				sqs.QueueAttributeNameRedrivePolicy,
+				sqs.QueueAttributeNameMessageRetentionPeriod,
+			}),
+		})
+	if err != nil {
+		return err
+	}
+
+	redrivePolicy, ok := result.Attributes[sqs.QueueAttributeNameRedrivePolicy]
+	if !ok || redrivePolicy == nil {
+		return nil
+	}
+	maxReceiveCount, err := parseMaxReceiveCount(*redrivePolicy)
+	if err != nil {
+		return err
+	}
+	retention, ok := result.Attributes[sqs.QueueAttributeNameMessageRetentionPeriod]
+	if !ok || retention == nil {
+		return fmt.Errorf("%s not found: %+v",
+			sqs.QueueAttributeNameMessageRetentionPeriod, result.Attributes)
+	}
+	retentionSeconds, err := strconv.ParseInt(*retention, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	samples := maxMessageSamples(
+		time.Duration(retentionSeconds)*time.Second, s.messageWeightSampleInterval)
+	if samples >= maxReceiveCount {
+		klog.Warningf("%s: a message can be sampled upto %d times for the message weights "+
+			"in its retention period, which reaches the maxReceiveCount %d of the redrive policy, "+
+			"raise --sqs-message-weight-sample-interval to not move the waiting messages to the dead letter queue",
+			queueSpec.name, samples, maxReceiveCount)
+	}
+	return nil
+}
+
+// maxMessageSamples returns the most times a message waiting in the
+// queue for the retention period is sampled at the sample interval
+func maxMessageSamples(retention time.Duration, sampleInterval time.Duration) int64 {
+	if sampleInterval <= 0 {
+		return 0
+	}
+	return int64(retention / sampleInterval)
+}
+
+// parseMaxReceiveCount returns the maxReceiveCount of the redrive
+// policy of the queue, SQS returns it as a number or as a string
+func parseMaxReceiveCount(redrivePolicy string) (int64, error) {
+	var policy struct {
+		MaxReceiveCount json.RawMessage `json:"maxReceiveCount"`
+	}
+	if err := json.Unmarshal([]byte(redrivePolicy), &policy); err != nil {
+		return 0, err
+	}
+	if len(policy.MaxReceiveCount) == 0 {
+		return 0, fmt.Errorf("maxReceiveCount not found in redrive policy: %s", redrivePolicy)
+	}
+	return strconv.ParseInt(strings.Trim(string(policy.MaxReceiveCount), `"`), 10, 64)
+}
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMaxReceiveCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		redrivePolicy string
+		expected      int64
+		expectErr     bool
+	}{
+		{
+			name:          "number",
+			redrivePolicy: `{"deadLetterTargetArn":"arn:aws:sqs:ap-south-1:123456789:otpsender-dlq","maxReceiveCount":5}`,
+			expected:      5,
+		},
+		{
+			name:          "string",
+			redrivePolicy: `{"deadLetterTargetArn":"arn:aws:sqs:ap-south-1:123456789:otpsender-dlq","maxReceiveCount":"3"}`,
+			expected:      3,
+		},
+		{
+			name:          "missing",
+			redrivePolicy: `{"deadLetterTargetArn":"arn:aws:sqs:ap-south-1:123456789:otpsender-dlq"}`,
+			expectErr:     true,
+		},
+		{
+			name:          "invalid",
+			redrivePolicy: `maxReceiveCount=5`,
+			expectErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := parseMaxReceiveCount(test.redrivePolicy)
+		if test.expectErr != (err != nil) {
+			t.Errorf("%s: expectErr=%v, got=%v\n", test.name, test.expectErr, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("%s: expected=%d, got=%d\n", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestMaxMessageSamples(t *testing.T) {
+	if got := maxMessageSamples(4*24*time.Hour, 300*time.Second); got != 1152 {
+		t.Errorf("expected 1152 samples in 4 days, got=%d\n", got)
+	}
+	if got := maxMessageSamples(time.Minute, 300*time.Second); got != 0 {
+		t.Errorf("expected 0 samples in a minute, got=%d\n", got)
+	}
+	if got := maxMessageSamples(time.Minute, 0); got != 0 {
+		t.Errorf("expected 0 samples without an interval, got=%d\n", got)
+	}
+}
+
+func TestCachedMessageWeight(t *testing.T) {
+	service, err := NewSQS(SqsQueueService, []string{"ap-south-1"}, "",
+		NewQueues(), 20, 20, nil, 300, 20, 0, 5)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	s := service.(*SQS)
+	if s.messageWeightSampleInterval != 20*time.Second {
+		t.Errorf("expected the sample interval to be at least the poll interval, got=%v\n",
+			s.messageWeightSampleInterval)
+	}
+
+	// the weight sampled within the interval is used without a call
+	key := "testns/otpsender"
+	queueSpec := QueueSpec{
+		name: "otpsender",
+		uri:  "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender",
+	}
+	s.cacheMessageWeights.Store(key, sqsMessageWeight{
+		weight:         2.5,
+		sampledAt:      time.Now(),
+		redriveChecked: true,
+	})
+	weight, err := s.cachedMessageWeight(key, queueSpec)
+	if err != nil || weight != 2.5 {
+		t.Errorf("expected the cached weight 2.5, got=%v, err=%v\n", weight, err)
+	}
+
+	s.reset(key, queueSpec)
+	if _, ok := s.cacheMessageWeights.Load(key); ok {
+		t.Errorf("expected the cached weight to be dropped\n")
+	}
+}
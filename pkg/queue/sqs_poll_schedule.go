@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"time"
+)
+
+// sqsPollSchedule is the adaptive poll interval of the SQS queue and
+// the GetQueueAttributes calls it made in the current window
+type sqsPollSchedule struct {
+	// interval is the wait after the last poll, 0 before the first poll
+	interval time.Duration
+
+	// windowStart is the start of the minute in which
+	// the windowCalls were made
+	windowStart time.Time
+	windowCalls int32
+	// callsPerMinute is the rate of the calls of the last window
+	callsPerMinute float64
+}
+
+// recordCall counts the GetQueueAttributes call made at now, a new
+// window is started a minute after the start of the previous one
+func (p sqsPollSchedule) recordCall(now time.Time) sqsPollSchedule {
+	if p.windowStart.IsZero() {
+		p.windowStart = now
+	}
+	if elapsed := now.Sub(p.windowStart); elapsed >= time.Minute {
+		p.callsPerMinute = float64(p.windowCalls) / elapsed.Minutes()
+		p.windowStart = now
+		p.windowCalls = 0
+	}
+	p.windowCalls++
+	return p
+}
+
+// getCallsPerMinute returns the rate of the calls of the last window,
+// the current window is used till one window is complete
+func (p sqsPollSchedule) getCallsPerMinute() float64 {
+	if p.callsPerMinute == 0 {
+		return float64(p.windowCalls)
+	}
+	return p.callsPerMinute
+}
+
+// budgetWait returns how long the next call waits so that the queue
+// makes at most maxCallsPerMinute calls in a window, 0 means no cap
+func (p sqsPollSchedule) budgetWait(now time.Time, maxCallsPerMinute int32) time.Duration {
+	if maxCallsPerMinute <= 0 || p.windowStart.IsZero() ||
+		p.windowCalls < maxCallsPerMinute {
+		return 0
+	}
+	wait := p.windowStart.Add(time.Minute).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// nextSQSPollInterval returns the wait before the next poll of the
+// queue. A queue whose backlog changed since the last poll is polled
+// at the minInterval, the interval of a queue with a stable or empty
+// backlog is doubled with every poll up to the maxInterval. The wait
+// is never shorter than a minute divided by the maxCallsPerMinute.
+func nextSQSPollInterval(
+	previous time.Duration,
+	minInterval time.Duration,
+	maxInterval time.Duration,
+	maxCallsPerMinute int32,
+	oldMessages int32,
+	newMessages int32) time.Duration {
+
+	interval := minInterval
+	if previous > 0 && oldMessages != UnsyncedQueueMessageCount &&
+		oldMessages == newMessages {
+		interval = previous * 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	if interval < minInterval {
+		interval = minInterval
+	}
+	if maxCallsPerMinute > 0 {
+		budgetInterval := time.Minute / time.Duration(maxCallsPerMinute)
+		if interval < budgetInterval {
+			interval = budgetInterval
+		}
+	}
+	return interval
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextSQSPollInterval(t *testing.T) {
+	min := 20 * time.Second
+	max := 160 * time.Second
+	tests := []struct {
+		name              string
+		previous          time.Duration
+		maxInterval       time.Duration
+		maxCallsPerMinute int32
+		oldMessages       int32
+		newMessages       int32
+		expected          time.Duration
+	}{
+		{"first poll", 0, max, 0, UnsyncedQueueMessageCount, 0, min},
+		{"unsynced backlog", 40 * time.Second, max, 0, UnsyncedQueueMessageCount, 0, min},
+		{"empty queue backs off", min, max, 0, 0, 0, 40 * time.Second},
+		{"stable backlog backs off", 40 * time.Second, max, 0, 12, 12, 80 * time.Second},
+		{"capped at the max", 120 * time.Second, max, 0, 0, 0, max},
+		{"busy queue polls at the min", max, max, 0, 12, 30, min},
+		{"adaptive disabled", min, min, 0, 0, 0, min},
+		{"budget floors the interval", min, max, 2, 12, 30, 30 * time.Second},
+		{"budget under the interval", 40 * time.Second, max, 10, 0, 0, 80 * time.Second},
+	}
+	for _, test := range tests {
+		got := nextSQSPollInterval(test.previous, min, test.maxInterval,
+			test.maxCallsPerMinute, test.oldMessages, test.newMessages)
+		if got != test.expected {
+			t.Errorf("%s: expected %v, got=%v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestSQSPollScheduleBudget(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	var schedule sqsPollSchedule
+	if wait := schedule.budgetWait(now, 2); wait != 0 {
+		t.Errorf("expected no wait before the first call, got=%v", wait)
+	}
+
+	schedule = schedule.recordCall(now)
+	schedule = schedule.recordCall(now.Add(10 * time.Second))
+	if got := schedule.getCallsPerMinute(); got != 2 {
+		t.Errorf("expected 2 calls per minute in the first window, got=%v", got)
+	}
+	if wait := schedule.budgetWait(now.Add(20*time.Second), 2); wait != 40*time.Second {
+		t.Errorf("expected to wait for the next minute, got=%v", wait)
+	}
+	if wait := schedule.budgetWait(now.Add(20*time.Second), 0); wait != 0 {
+		t.Errorf("expected no wait without a budget, got=%v", wait)
+	}
+
+	// the next window starts with the call after a minute, the rate is
+	// of the calls of the previous window
+	schedule = schedule.recordCall(now.Add(2 * time.Minute))
+	if got := schedule.getCallsPerMinute(); got != 1 {
+		t.Errorf("expected 1 call per minute over two minutes, got=%v", got)
+	}
+	if wait := schedule.budgetWait(now.Add(2*time.Minute), 2); wait != 0 {
+		t.Errorf("expected no wait in the new window, got=%v", wait)
+	}
+}
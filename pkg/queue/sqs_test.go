@@ -0,0 +1,332 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func typedMessage(messageType string) *sqs.Message {
+	return &sqs.Message{
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			"type": &sqs.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(messageType),
+			},
+		},
+	}
+}
+
+func TestAverageMessageWeight(t *testing.T) {
+	messageWeights := &MessageWeights{
+		AttributeName: "type",
+		Weights: map[string]float64{
+			"video": 10.0,
+			"email": 0.5,
+		},
+		DefaultWeight: 1.0,
+	}
+
+	tests := []struct {
+		name     string
+		messages []*sqs.Message
+		expected float64
+	}{
+		{
+			name:     "no messages",
+			messages: []*sqs.Message{},
+			expected: 1.0,
+		},
+		{
+			name: "weighted types",
+			messages: []*sqs.Message{
+				typedMessage("video"),
+				typedMessage("email"),
+				typedMessage("email"),
+				typedMessage("email"),
+			},
+			expected: 2.875,
+		},
+		{
+			name: "unknown type and missing attribute use default weight",
+			messages: []*sqs.Message{
+				typedMessage("sms"),
+				&sqs.Message{},
+			},
+			expected: 1.0,
+		},
+	}
+
+	for _, test := range tests {
+		got := averageMessageWeight(test.messages, messageWeights)
+		if got != test.expected {
+			t.Errorf("%s: expected=%v, got=%v\n", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestMessageGroups(t *testing.T) {
+	tests := []struct {
+		name           string
+		uri            string
+		inflightGroups int32
+		approxMessages int32
+		fifo           bool
+		expected       int32
+	}{
+		{"standard", "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender", 0, 0, false, 0},
+		{"fifo in flight", "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender.fifo", 3, 0, true, 3},
+		{"fifo visible", "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender.fifo", 3, 4, true, 7},
+	}
+
+	for _, test := range tests {
+		if fifo := isFIFOQueue(test.uri); fifo != test.fifo {
+			t.Errorf("%s: expected fifo=%v, got=%v\n", test.name, test.fifo, fifo)
+		}
+		if !test.fifo {
+			continue
+		}
+		groups := getMessageGroups(test.inflightGroups, test.approxMessages)
+		if groups != test.expected {
+			t.Errorf("%s: expected groups=%v, got=%v\n", test.name, test.expected, groups)
+		}
+	}
+}
+
+func TestWaitTimeSeconds(t *testing.T) {
+	s := &SQS{
+		shortPollInterval: 10 * time.Second,
+		longPollInterval:  5,
+	}
+	tests := []struct {
+		name       string
+		sqsOptions *SQSOptions
+		expected   int64
+	}{
+		{"default", nil, 5},
+		{"override", &SQSOptions{WaitTimeSeconds: aws.Int64(2)}, 2},
+		{"equal to the poll interval", &SQSOptions{WaitTimeSeconds: aws.Int64(10)}, 9},
+		{"above the poll interval", &SQSOptions{WaitTimeSeconds: aws.Int64(15)}, 9},
+		{"negative", &SQSOptions{WaitTimeSeconds: aws.Int64(-1)}, 0},
+	}
+
+	for _, test := range tests {
+		waitTime := s.getWaitTimeSeconds(QueueSpec{sqsOptions: test.sqsOptions})
+		if waitTime != test.expected {
+			t.Errorf("%s: expected waitTimeSeconds=%v, got=%v\n",
+				test.name, test.expected, waitTime)
+		}
+	}
+
+	if waitTime := clampWaitTimeSeconds(30, time.Minute); waitTime != SQSMaxWaitTimeSeconds {
+		t.Errorf("expected the sqs maximum, got=%v\n", waitTime)
+	}
+	if waitTime := clampWaitTimeSeconds(5, 1500*time.Millisecond); waitTime != 0 {
+		t.Errorf("expected no wait for a sub 2s poll interval, got=%v\n", waitTime)
+	}
+	if waitTime := clampWaitTimeSeconds(5, 2500*time.Millisecond); waitTime != 1 {
+		t.Errorf("expected 1s wait for a 2.5s poll interval, got=%v\n", waitTime)
+	}
+}
+
+func TestParseQueueAttributes(t *testing.T) {
+	s := &SQS{queueAttributes: DefaultSQSQueueAttributes}
+	names := s.getQueueAttributeNames(QueueSpec{sqsOptions: &SQSOptions{
+		QueueAttributes: []string{sqsApproximateNumberOfMessagesDelayed},
+	}})
+	if len(names) != 2 || names[0] != sqsApproximateNumberOfMessages {
+		t.Fatalf("expected ApproximateNumberOfMessages to be requested, got=%v\n", names)
+	}
+
+	attributes, err := parseQueueAttributes(map[string]*string{
+		sqsApproximateNumberOfMessages:        aws.String("4"),
+		sqsApproximateNumberOfMessagesDelayed: aws.String("2"),
+	}, names)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	expected := sqsQueueAttributes{messages: 4, messagesDelayed: 2}
+	if attributes != expected {
+		t.Errorf("expected attributes=%+v, got=%+v\n", expected, attributes)
+	}
+
+	_, err = parseQueueAttributes(map[string]*string{
+		sqsApproximateNumberOfMessages: aws.String("4"),
+	}, DefaultSQSQueueAttributes)
+	if err == nil {
+		t.Errorf("expected error when a requested attribute is missing\n")
+	}
+}
+
+func TestResetDropsTheCachedMetrics(t *testing.T) {
+	service, err := NewSQS(SqsQueueService, []string{"ap-south-1"}, "",
+		NewQueues(), 20, 20, nil, 300, 20, 0, 300)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	s := service.(*SQS)
+	uri := "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender"
+	discoveredURI := "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender-eu"
+	s.discoveredQueues.Store("testns/otpsender", &sqsDiscoveredQueues{
+		prefix: "otpsender",
+		uris:   []string{discoveredURI},
+	})
+	for _, u := range []string{uri, discoveredURI} {
+		cacheKey := getMetricsCacheKey("testns/otpsender", u)
+		s.updateSentMessageCache(cacheKey, 30.0)
+		s.updateReceiveMessageCache(cacheKey, 12.0)
+		s.cacheInflightGroups.Store(cacheKey, int32(2))
+	}
+	// another wpa polling the same queue with its own credentials
+	otherCacheKey := getMetricsCacheKey("otherns/otpsender", uri)
+	s.updateSentMessageCache(otherCacheKey, 40.0)
+
+	// reset can be called any number of times
+	for i := 0; i < 2; i++ {
+		s.reset("testns/otpsender", QueueSpec{uri: uri})
+	}
+
+	for _, u := range []string{uri, discoveredURI} {
+		cacheKey := getMetricsCacheKey("testns/otpsender", u)
+		if _, ok := s.getSentMessageCache(cacheKey); ok {
+			t.Errorf("%s: expected the sent messages to be dropped\n", u)
+		}
+		if _, ok := s.getReceiveMessageCache(cacheKey); ok {
+			t.Errorf("%s: expected the received messages to be dropped\n", u)
+		}
+		if _, ok := s.cacheInflightGroups.Load(cacheKey); ok {
+			t.Errorf("%s: expected the message groups to be dropped\n", u)
+		}
+	}
+	if sent, ok := s.getSentMessageCache(otherCacheKey); !ok || sent != 40.0 {
+		t.Errorf("expected the sent messages of the other wpa to be kept, got=%v\n", sent)
+	}
+	if _, ok := s.discoveredQueues.Load("testns/otpsender"); ok {
+		t.Errorf("expected the discovered queues to be dropped\n")
+	}
+}
+
+func staticCredentials(accessKeyID string, version string) *Credentials {
+	return &Credentials{
+		Data: map[string][]byte{
+			"AWS_ACCESS_KEY_ID":     []byte(accessKeyID),
+			"AWS_SECRET_ACCESS_KEY": []byte("secret"),
+		},
+		Version: version,
+	}
+}
+
+func TestSyncCredentials(t *testing.T) {
+	service, err := NewSQS(SqsQueueService, []string{"ap-south-1"}, "",
+		NewQueues(), 20, 20, nil, 300, 20, 0, 300)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	s := service.(*SQS)
+	uri := "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender"
+
+	tests := []struct {
+		name        string
+		credentials *Credentials
+		expectErr   bool
+	}{
+		{
+			name:        "no credentials",
+			credentials: nil,
+		},
+		{
+			name: "role and access keys",
+			credentials: &Credentials{
+				Data: map[string][]byte{
+					"AWS_ROLE_ARN":          []byte("arn:aws:iam::123456789:role/otpsender"),
+					"AWS_ACCESS_KEY_ID":     []byte("AKIAOTPSENDER"),
+					"AWS_SECRET_ACCESS_KEY": []byte("secret"),
+				},
+				Version: "1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "missing secret access key",
+			credentials: &Credentials{
+				Data:    map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("AKIAOTPSENDER")},
+				Version: "1",
+			},
+			expectErr: true,
+		},
+		{
+			name:        "access keys",
+			credentials: staticCredentials("AKIAOTPSENDER", "1"),
+		},
+	}
+
+	for _, test := range tests {
+		key := "testns/" + test.name
+		err := s.syncCredentials(key, QueueSpec{uri: uri, credentials: test.credentials})
+		if test.expectErr != (err != nil) {
+			t.Errorf("%s: expectErr=%v, got=%v\n", test.name, test.expectErr, err)
+		}
+		_, ok := s.credentialedClientPool.Load(key)
+		if expected := test.credentials != nil && !test.expectErr; ok != expected {
+			t.Errorf("%s: expected credentialed clients=%v, got=%v\n",
+				test.name, expected, ok)
+		}
+		if !ok && s.getSQSClient(key, uri) != s.sqsClientPool["ap-south-1"] {
+			t.Errorf("%s: expected the client of the region\n", test.name)
+		}
+	}
+}
+
+func TestSyncCredentialsIsPerWPA(t *testing.T) {
+	service, err := NewSQS(SqsQueueService, []string{"ap-south-1"}, "",
+		NewQueues(), 20, 20, nil, 300, 20, 0, 300)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	s := service.(*SQS)
+	uri := "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender"
+
+	// two wpas polling the same queue with their own credentials
+	if err := s.syncCredentials("team1/otpsender",
+		QueueSpec{uri: uri, credentials: staticCredentials("AKIATEAM1", "1")}); err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	if err := s.syncCredentials("team2/otpsender",
+		QueueSpec{uri: uri, credentials: staticCredentials("AKIATEAM2", "1")}); err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	team1 := s.getSQSClient("team1/otpsender", uri)
+	if team1 == s.getSQSClient("team2/otpsender", uri) {
+		t.Errorf("expected the wpas to not share the clients of the queue\n")
+	}
+
+	// the same version keeps the clients
+	if err := s.syncCredentials("team1/otpsender",
+		QueueSpec{uri: uri, credentials: staticCredentials("AKIATEAM1", "1")}); err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	if s.getSQSClient("team1/otpsender", uri) != team1 {
+		t.Errorf("expected the clients to be kept for the same version\n")
+	}
+
+	// the rotated secret makes the clients again
+	if err := s.syncCredentials("team1/otpsender",
+		QueueSpec{uri: uri, credentials: staticCredentials("AKIATEAM1NEW", "2")}); err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	if s.getSQSClient("team1/otpsender", uri) == team1 {
+		t.Errorf("expected the clients to be made again on rotation\n")
+	}
+
+	// deleting one wpa keeps the clients of the other
+	team2 := s.getSQSClient("team2/otpsender", uri)
+	s.reset("team1/otpsender", QueueSpec{uri: uri})
+	if _, ok := s.credentialedClientPool.Load("team1/otpsender"); ok {
+		t.Errorf("expected the clients of the deleted wpa to be dropped\n")
+	}
+	if s.getSQSClient("team2/otpsender", uri) != team2 {
+		t.Errorf("expected the clients of the other wpa to be kept\n")
+	}
+}
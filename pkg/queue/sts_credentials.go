@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	stsAssumeRoles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Name:      "sts_assume_role_total",
+			Help:      "Number of sts assume role calls made for the roles of the queues, partitioned by the result",
+		},
+		[]string{"result"},
+	)
+
+	stsCredentialCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "wpa",
+			Name:      "sts_credential_cache_hits_total",
+			Help:      "Number of times a queue used the cached credentials of a role instead of assuming the role again",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(stsAssumeRoles)
+	prometheus.MustRegister(stsCredentialCacheHits)
+}
+
+// roleCredentialsExpiryWindow is the time before the credentials of a
+// role expire when they are refreshed, so that the polls never wait for
+// the assume role with expired credentials
+const roleCredentialsExpiryWindow = 2 * time.Minute
+
+// countingProvider counts the assume role calls of the provider
+type countingProvider struct {
+	*stscreds.AssumeRoleProvider
+}
+
+func (p *countingProvider) Retrieve() (credentials.Value, error) {
+	return p.RetrieveWithContext(aws.BackgroundContext())
+}
+
+func (p *countingProvider) RetrieveWithContext(
+	ctx credentials.Context) (credentials.Value, error) {
+
+	value, err := p.AssumeRoleProvider.RetrieveWithContext(ctx)
+	if err != nil {
+		stsAssumeRoles.WithLabelValues("error").Inc()
+		return value, err
+	}
+	stsAssumeRoles.WithLabelValues("success").Inc()
+	return value, nil
+}
+
+// roleCredentialsCache shares the credentials of a role across all the
+// queues assuming it. The credentials refresh in a single flight, the
+// concurrent polls of the queues of a role make one assume role call.
+type roleCredentialsCache struct {
+	// credentials are keyed by the role arn
+	credentials *sync.Map
+
+	newCredentials func(roleARN string, config *aws.Config) (*credentials.Credentials, error)
+}
+
+func newRoleCredentialsCache() *roleCredentialsCache {
+	return &roleCredentialsCache{
+		credentials:    new(sync.Map),
+		newCredentials: newRoleCredentials,
+	}
+}
+
+// newRoleCredentials returns the credentials of the role assumed with
+// the credentials of the controller
+func newRoleCredentials(
+	roleARN string, config *aws.Config) (*credentials.Credentials, error) {
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return newRoleCredentialsWithClient(sts.New(sess), roleARN), nil
+}
+
+// newRoleCredentialsWithClient returns the credentials of the role
+// assumed using the sts client, they are refreshed in the expiry window
+func newRoleCredentialsWithClient(
+	client stscreds.AssumeRoler, roleARN string) *credentials.Credentials {
+
+	return credentials.NewCredentials(&countingProvider{
+		AssumeRoleProvider: &stscreds.AssumeRoleProvider{
+			Client:          client,
+			RoleARN:         roleARN,
+			RoleSessionName: "workerpodautoscaler",
+			Duration:        stscreds.DefaultDuration,
+			ExpiryWindow:    roleCredentialsExpiryWindow,
+		},
+	})
+}
+
+// get returns the cached credentials of the role, the credentials
+// are made using the config the first time the role is used
+func (c *roleCredentialsCache) get(
+	roleARN string, config *aws.Config) (*credentials.Credentials, error) {
+
+	if creds, ok := c.credentials.Load(roleARN); ok {
+		stsCredentialCacheHits.Inc()
+		return creds.(*credentials.Credentials), nil
+	}
+	creds, err := c.newCredentials(roleARN, config)
+	if err != nil {
+		return nil, err
+	}
+	actual, loaded := c.credentials.LoadOrStore(roleARN, creds)
+	if loaded {
+		stsCredentialCacheHits.Inc()
+	}
+	return actual.(*credentials.Credentials), nil
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+type fakeAssumeRoler struct {
+	calls      int32
+	expiration time.Time
+}
+
+func (f *fakeAssumeRoler) AssumeRole(
+	input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+
+	atomic.AddInt32(&f.calls, 1)
+	// slow enough for the concurrent pollers to wait on the same call
+	time.Sleep(10 * time.Millisecond)
+	return &sts.AssumeRoleOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("id"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(f.expiration),
+		},
+	}, nil
+}
+
+func TestRoleCredentialsCache(t *testing.T) {
+	roleARN := "arn:aws:iam::123456789012:role/otpsender"
+	client := &fakeAssumeRoler{expiration: time.Now().Add(time.Hour)}
+	cache := newRoleCredentialsCache()
+	made := 0
+	cache.newCredentials = func(
+		roleARN string, config *aws.Config) (*credentials.Credentials, error) {
+
+		made++
+		return newRoleCredentialsWithClient(client, roleARN), nil
+	}
+
+	first, err := cache.get(roleARN, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := cache.get(roleARN, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != second || made != 1 {
+		t.Errorf("expected the queues of the role to share its credentials, made=%d", made)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := first.Get(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("expected 1 assume role for the concurrent pollers, got=%d", calls)
+	}
+}
+
+func TestRoleCredentialsRefreshBeforeExpiry(t *testing.T) {
+	// the credentials expire within the expiry window
+	client := &fakeAssumeRoler{
+		expiration: time.Now().Add(roleCredentialsExpiryWindow / 2)}
+	creds := newRoleCredentialsWithClient(client, "arn:aws:iam::123456789012:role/otpsender")
+
+	for i := 0; i < 2; i++ {
+		if _, err := creds.Get(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+		t.Errorf("expected the credentials to be refreshed before they expire, got=%d assume roles", calls)
+	}
+}
@@ -0,0 +1,104 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+	// the time zones are embedded as the image may not have them
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// Parse parses the cron expression of the rule in its time zone.
+// Only the standard 5 field expressions and the descriptors like @daily
+// are supported, @every has no fixed start and is rejected.
+func Parse(rule v1.ScheduleRule) (*cron.SpecSchedule, error) {
+	return parse(rule.Schedule, rule.TimeZone)
+}
+
+// ParseWindow parses the cron expression of the conservative
+// window in its time zone, like Parse
+func ParseWindow(window v1.ConservativeWindow) (*cron.SpecSchedule, error) {
+	return parse(window.Schedule, window.TimeZone)
+}
+
+func parse(expression string, timeZone string) (*cron.SpecSchedule, error) {
+	location := time.UTC
+	if timeZone != "" {
+		var err error
+		location, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeZone %q: %v", timeZone, err)
+		}
+	}
+
+	parsed, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", expression, err)
+	}
+	schedule, ok := parsed.(*cron.SpecSchedule)
+	if !ok {
+		return nil, fmt.Errorf("unsupported schedule %q, it has no fixed start",
+			expression)
+	}
+	schedule.Location = location
+	return schedule, nil
+}
+
+// IsActive tells if now is within the duration of the
+// last start of the schedule
+func IsActive(rule v1.ScheduleRule, now time.Time) (bool, error) {
+	schedule, err := Parse(rule)
+	if err != nil {
+		return false, err
+	}
+	return isActive(schedule, rule.DurationSeconds, now), nil
+}
+
+func isActive(schedule *cron.SpecSchedule, durationSeconds int32, now time.Time) bool {
+	// the first start after the window opening at now-duration
+	// is a start whose window contains now, if it is not after now
+	duration := time.Duration(durationSeconds) * time.Second
+	start := schedule.Next(now.Add(-duration))
+	return !start.IsZero() && !start.After(now)
+}
+
+// Active returns the first rule active at now, nil if none is active.
+// The rules which can not be parsed are returned in the errors
+// and are never active.
+func Active(rules []v1.ScheduleRule, now time.Time) (*v1.ScheduleRule, []error) {
+	var errs []error
+	for i := range rules {
+		active, err := IsActive(rules[i], now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %v", rules[i].Name, err))
+			continue
+		}
+		if active {
+			return &rules[i], errs
+		}
+	}
+	return nil, errs
+}
+
+// ActiveWindow returns the first conservative window active at now, nil
+// if none is active. The windows which can not be parsed are returned
+// in the errors and are never active.
+func ActiveWindow(
+	windows []v1.ConservativeWindow, now time.Time) (*v1.ConservativeWindow, []error) {
+
+	var errs []error
+	for i := range windows {
+		schedule, err := ParseWindow(windows[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("conservative window %s: %v", windows[i].Name, err))
+			continue
+		}
+		if isActive(schedule, windows[i].DurationSeconds, now) {
+			return &windows[i], errs
+		}
+	}
+	return nil, errs
+}
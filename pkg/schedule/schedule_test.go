@@ -0,0 +1,85 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/schedule"
+)
+
+func TestIsActive(t *testing.T) {
+	// weekdays 08:00 to 10:00 in Kolkata, i.e. 02:30 to 04:30 UTC
+	morning := v1.ScheduleRule{
+		Name:            "morning",
+		Schedule:        "0 8 * * 1-5",
+		TimeZone:        "Asia/Kolkata",
+		DurationSeconds: 7200,
+	}
+	tests := []struct {
+		name     string
+		now      string
+		expected bool
+	}{
+		{"before the start", "2021-06-07T02:29:59Z", false},
+		{"at the start", "2021-06-07T02:30:00Z", true},
+		{"within the window", "2021-06-07T04:00:00Z", true},
+		{"at the end", "2021-06-07T04:30:00Z", false},
+		{"on a sunday", "2021-06-06T03:00:00Z", false},
+	}
+
+	for _, test := range tests {
+		now, _ := time.Parse(time.RFC3339, test.now)
+		active, err := schedule.IsActive(morning, now)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got=%v\n", test.name, err)
+		}
+		if active != test.expected {
+			t.Errorf("%s: expected active=%v, got=%v\n", test.name, test.expected, active)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	rules := []v1.ScheduleRule{
+		{Name: "invalid", Schedule: "@every 1h", DurationSeconds: 3600},
+		{Name: "night", Schedule: "0 22 * * *", DurationSeconds: 3600},
+		{Name: "daily", Schedule: "@daily", DurationSeconds: 86400},
+	}
+	now, _ := time.Parse(time.RFC3339, "2021-06-07T22:30:00Z")
+
+	active, errs := schedule.Active(rules, now)
+	if active == nil || active.Name != "night" {
+		t.Errorf("expected the night schedule to be active, got=%v\n", active)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the @every schedule to be rejected, got=%v\n", errs)
+	}
+
+	_, err := schedule.Parse(v1.ScheduleRule{
+		Schedule: "0 8 * * *", TimeZone: "Mars/Olympus"})
+	if err == nil {
+		t.Errorf("expected error for an unknown time zone\n")
+	}
+}
+
+func TestActiveWindow(t *testing.T) {
+	windows := []v1.ConservativeWindow{
+		{Name: "invalid", Schedule: "0 2 * * *", TimeZone: "Mars/Olympus", DurationSeconds: 3600},
+		{Name: "maintenance", Schedule: "0 2 * * *", DurationSeconds: 3600},
+	}
+
+	now, _ := time.Parse(time.RFC3339, "2021-06-07T02:30:00Z")
+	active, errs := schedule.ActiveWindow(windows, now)
+	if active == nil || active.Name != "maintenance" {
+		t.Errorf("expected the maintenance window to be active, got=%v\n", active)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the invalid time zone to be rejected, got=%v\n", errs)
+	}
+
+	now, _ = time.Parse(time.RFC3339, "2021-06-07T03:00:00Z")
+	if active, _ := schedule.ActiveWindow(windows, now); active != nil {
+		t.Errorf("expected no window to be active after it ends, got=%v\n", active)
+	}
+}
@@ -0,0 +1,152 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/practo/klog/v2"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	kafkaSinkName = "kafka"
+	// kafkaBufferSize is the number of events buffered while the
+	// previous events are written, the events above it are dropped
+	kafkaBufferSize = 1000
+	// kafkaWriteTimeout is the timeout of the write of one event
+	kafkaWriteTimeout = 10 * time.Second
+)
+
+// KafkaConfig configures the kafka sink
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	// SASLMechanism is one of plain, scram-sha-256 or scram-sha-512,
+	// SASL is not used if it is empty
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+	TLS           bool
+}
+
+// Kafka publishes the scale events as JSON messages to a kafka topic,
+// the messages are keyed by the WPA namespace/name so the events of a
+// WPA are kept in order. It implements the Sink interface.
+type Kafka struct {
+	writer *kafka.Writer
+	events chan ScaleEvent
+	wg     sync.WaitGroup
+}
+
+func NewKafka(config KafkaConfig) (Sink, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka brokers must be specified")
+	}
+	if config.Topic == "" {
+		return nil, fmt.Errorf("kafka topic must be specified")
+	}
+
+	transport := &kafka.Transport{}
+	if config.TLS {
+		transport.TLS = &tls.Config{}
+	}
+	mechanism, err := getSASLMechanism(
+		config.SASLMechanism, config.SASLUsername, config.SASLPassword)
+	if err != nil {
+		return nil, err
+	}
+	transport.SASL = mechanism
+
+	k := &Kafka{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			WriteTimeout: kafkaWriteTimeout,
+			Transport:    transport,
+		},
+		events: make(chan ScaleEvent, kafkaBufferSize),
+	}
+	k.wg.Add(1)
+	go k.run()
+	return k, nil
+}
+
+// getSASLMechanism returns the SASL mechanism of the name,
+// it is nil if the name is empty
+func getSASLMechanism(
+	name string, username string, password string) (sasl.Mechanism, error) {
+
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	}
+	return nil, fmt.Errorf(
+		"unsupported kafka sasl mechanism %q, use plain, scram-sha-256 or scram-sha-512",
+		name)
+}
+
+// Publish queues the event to be written to the topic,
+// the event is dropped if the buffer is full
+func (k *Kafka) Publish(event ScaleEvent) {
+	select {
+	case k.events <- event:
+	default:
+		klog.Errorf("%s/%s: dropped the scale event, the kafka sink buffer is full",
+			event.Namespace, event.Name)
+		publishFailures.WithLabelValues(kafkaSinkName).Inc()
+	}
+}
+
+// run writes the queued events to the topic till the sink is closed
+func (k *Kafka) run() {
+	defer k.wg.Done()
+	for event := range k.events {
+		message, err := newKafkaMessage(event)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(
+				context.Background(), kafkaWriteTimeout)
+			err = k.writer.WriteMessages(ctx, message)
+			cancel()
+		}
+		if err != nil {
+			klog.Errorf("%s/%s: unable to publish the scale event to kafka, err: %v",
+				event.Namespace, event.Name, err)
+			publishFailures.WithLabelValues(kafkaSinkName).Inc()
+		}
+	}
+}
+
+// newKafkaMessage returns the message of the event
+func newKafkaMessage(event ScaleEvent) (kafka.Message, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafka.Message{
+		Key:   []byte(event.Namespace + "/" + event.Name),
+		Value: value,
+		Time:  event.Time,
+	}, nil
+}
+
+// Close writes the queued events and closes the writer
+func (k *Kafka) Close() error {
+	close(k.events)
+	k.wg.Wait()
+	return k.writer.Close()
+}
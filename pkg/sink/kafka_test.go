@@ -0,0 +1,98 @@
+package sink
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewKafkaMessage(t *testing.T) {
+	event := ScaleEvent{
+		Time:            time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		Name:            "otpsender",
+		Namespace:       "default",
+		QueueName:       "otpsender",
+		TargetKind:      "Deployment",
+		TargetName:      "otpsender",
+		Operation:       "scale-up",
+		Reason:          "Backlog",
+		QueueMessages:   100,
+		CurrentReplicas: 2,
+		DesiredReplicas: 10,
+	}
+
+	message, err := newKafkaMessage(event)
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	if string(message.Key) != "default/otpsender" {
+		t.Errorf("expected key=default/otpsender, got=%s\n", message.Key)
+	}
+	expected := `{"time":"2021-06-01T10:00:00Z","name":"otpsender","namespace":"default",` +
+		`"queueName":"otpsender","targetKind":"Deployment","targetName":"otpsender",` +
+		`"operation":"scale-up","reason":"Backlog","queueMessages":100,` +
+		`"currentReplicas":2,"desiredReplicas":10}`
+	if string(message.Value) != expected {
+		t.Errorf("expected value=%s, got=%s\n", expected, message.Value)
+	}
+
+	var decoded ScaleEvent
+	if err := json.Unmarshal(message.Value, &decoded); err != nil || decoded != event {
+		t.Errorf("expected decoded=%+v, got=%+v, err=%v\n", event, decoded, err)
+	}
+}
+
+func TestGetSASLMechanism(t *testing.T) {
+	tests := []struct {
+		name      string
+		mechanism string
+		expected  string
+		err       bool
+	}{
+		{name: "no sasl", mechanism: "", expected: ""},
+		{name: "plain", mechanism: "plain", expected: "PLAIN"},
+		{name: "scram sha 256", mechanism: "SCRAM-SHA-256", expected: "SCRAM-SHA-256"},
+		{name: "scram sha 512", mechanism: "scram-sha-512", expected: "SCRAM-SHA-512"},
+		{name: "unsupported", mechanism: "gssapi", err: true},
+	}
+
+	for _, test := range tests {
+		mechanism, err := getSASLMechanism(test.mechanism, "wpa", "secret")
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error=%v, got=%v\n", test.name, test.err, err)
+			continue
+		}
+		got := ""
+		if mechanism != nil {
+			got = mechanism.Name()
+		}
+		if got != test.expected {
+			t.Errorf("%s: expected mechanism=%q, got=%q\n",
+				test.name, test.expected, got)
+		}
+	}
+}
+
+func TestKafkaPublishDoesNotBlock(t *testing.T) {
+	s, err := NewKafka(KafkaConfig{
+		Brokers: []string{"127.0.0.1:1"},
+		Topic:   "wpa-scale-events",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got=%v\n", err)
+	}
+	k := s.(*Kafka)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < kafkaBufferSize+10; i++ {
+			k.Publish(ScaleEvent{Name: "otpsender", Namespace: "default"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected publish to not block on an unreachable broker\n")
+	}
+}
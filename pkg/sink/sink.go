@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	publishFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wpa_scale_event_publish_failures_total",
+			Help: "Number of scale events which could not be published to the sink",
+		},
+		[]string{"sink"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(publishFailures)
+}
+
+// ScaleEvent is the record of a scale operation of a WPA
+// published to the sinks
+type ScaleEvent struct {
+	Time            time.Time `json:"time"`
+	Name            string    `json:"name"`
+	Namespace       string    `json:"namespace"`
+	QueueName       string    `json:"queueName"`
+	TargetKind      string    `json:"targetKind"`
+	TargetName      string    `json:"targetName"`
+	Operation       string    `json:"operation"`
+	Reason          string    `json:"reason"`
+	QueueMessages   int32     `json:"queueMessages"`
+	CurrentReplicas int32     `json:"currentReplicas"`
+	DesiredReplicas int32     `json:"desiredReplicas"`
+	// Error is set when the update of the workload failed
+	Error string `json:"error,omitempty"`
+}
+
+// Sink publishes the scale events of the WPAs for the downstream
+// consumers. Publish must not block the scaling, the events which
+// can not be published are logged and counted in the
+// wpa_scale_event_publish_failures_total metric.
+type Sink interface {
+	Publish(event ScaleEvent)
+	Close() error
+}
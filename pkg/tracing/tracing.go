@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "github.com/practo/k8s-worker-pod-autoscaler"
+	serviceName = "workerpodautoscaler"
+)
+
+// Init sets up the global tracer provider to export the spans to the
+// OTLP endpoint over http. Tracing is a no-op if the endpoint is empty.
+// The endpoint can be host:port or a url, http:// urls are
+// exported without TLS.
+// It returns the function to flush and stop the exporter.
+func Init(
+	ctx context.Context, endpoint string) (func(context.Context) error, error) {
+
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{}
+	parsedURL, err := url.Parse(endpoint)
+	if err == nil && parsedURL.Host != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(parsedURL.Host))
+		if parsedURL.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if parsedURL.Path != "" && parsedURL.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(parsedURL.Path))
+		}
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// TraceID returns the trace id of the span in the context, it is false
+// if there is no span or the span is not sampled, e.g. tracing is disabled
+func TraceID(ctx context.Context) (string, bool) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() || !spanContext.IsSampled() {
+		return "", false
+	}
+	return spanContext.TraceID().String(), true
+}
+
+// Tracer returns the tracer used by WPA, it is a no-op tracer until
+// Init is called with an endpoint
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
@@ -0,0 +1,601 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	queue "github.com/practo/k8s-worker-pod-autoscaler/pkg/queue"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/schedule"
+)
+
+// ValidateWorkerPodAutoScaler validates the WorkerPodAutoScaler spec.
+// It does not need a cluster and is shared by the offline validate
+// command and any admission webhook.
+func ValidateWorkerPodAutoScaler(wpa *v1.WorkerPodAutoScaler) field.ErrorList {
+	fldPath := field.NewPath("spec")
+	allErrs := ValidateWorkerPodAutoScalerSpec(&wpa.Spec, fldPath)
+
+	// the secret of another namespace would let the WPA use the
+	// credentials of a namespace its owner has no access to
+	secretRef := wpa.Spec.CredentialsSecretRef
+	if secretRef != nil && secretRef.Namespace != "" && secretRef.Namespace != wpa.Namespace {
+		allErrs = append(allErrs, field.Forbidden(
+			fldPath.Child("credentialsSecretRef", "namespace"),
+			"must be the namespace of the WPA"))
+	}
+	return allErrs
+}
+
+// ValidateWorkerPodAutoScalerSpec validates the spec fields
+func ValidateWorkerPodAutoScalerSpec(
+	spec *v1.WorkerPodAutoScalerSpec, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+
+	targets := 0
+	for _, set := range []bool{
+		spec.DeploymentName != "",
+		spec.ReplicaSetName != "",
+		spec.TargetRef != nil,
+		spec.ScaleTargetRef != nil,
+		len(spec.WeightedTargets) > 0,
+	} {
+		if set {
+			targets++
+		}
+	}
+	if targets == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("deploymentName"),
+			"one of deploymentName, replicaSetName, targetRef, scaleTargetRef or weightedTargets must be specified"))
+	}
+	if targets > 1 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("replicaSetName"),
+			"only one of deploymentName, replicaSetName, targetRef, scaleTargetRef or weightedTargets may be specified"))
+	}
+	if spec.TargetRef != nil {
+		allErrs = append(allErrs, validateTargetRef(
+			spec.TargetRef, fldPath.Child("targetRef"))...)
+	}
+	if spec.ScaleTargetRef != nil {
+		allErrs = append(allErrs, validateScaleTargetRef(
+			spec.ScaleTargetRef, fldPath.Child("scaleTargetRef"))...)
+	}
+	if len(spec.WeightedTargets) > 0 {
+		allErrs = append(allErrs, validateWeightedTargets(
+			spec.WeightedTargets, fldPath.Child("weightedTargets"))...)
+	}
+
+	if spec.MinReplicas == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("minReplicas"), ""))
+	} else if *spec.MinReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minReplicas"),
+			*spec.MinReplicas, "must be greater than or equal to 0"))
+	}
+
+	if spec.MaxReplicas == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("maxReplicas"), ""))
+	} else if *spec.MaxReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicas"),
+			*spec.MaxReplicas, "must be greater than or equal to 0"))
+	}
+
+	if spec.MinReplicas != nil && spec.MaxReplicas != nil &&
+		*spec.MinReplicas > *spec.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minReplicas"),
+			*spec.MinReplicas, "must be less than or equal to maxReplicas"))
+	}
+
+	if spec.TargetMessagesPerWorker == nil {
+		allErrs = append(allErrs, field.Required(
+			fldPath.Child("targetMessagesPerWorker"), ""))
+	} else if *spec.TargetMessagesPerWorker <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("targetMessagesPerWorker"),
+			*spec.TargetMessagesPerWorker, "must be greater than 0"))
+	}
+
+	if spec.MaxDisruption != nil {
+		allErrs = append(allErrs, validateMaxDisruption(
+			*spec.MaxDisruption, fldPath.Child("maxDisruption"))...)
+	}
+
+	if spec.SecondsToProcessOneJob != nil && *spec.SecondsToProcessOneJob < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("secondsToProcessOneJob"),
+			*spec.SecondsToProcessOneJob, "must be greater than or equal to 0"))
+	}
+
+	if spec.ThroughputMode != nil && *spec.ThroughputMode &&
+		(spec.SecondsToProcessOneJob == nil || *spec.SecondsToProcessOneJob <= 0) &&
+		(spec.AutoEstimateProcessingTime == nil || !*spec.AutoEstimateProcessingTime) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("throughputMode"),
+			*spec.ThroughputMode,
+			"requires secondsToProcessOneJob greater than 0 or autoEstimateProcessingTime"))
+	}
+
+	if spec.CredentialsSecretRef != nil && spec.CredentialsSecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(
+			fldPath.Child("credentialsSecretRef", "name"), ""))
+	}
+
+	queueServiceName := queue.GetQueueServiceName(spec.QueueURI)
+	if err := queue.ValidateQueueURI(spec.QueueURI); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("queueURI"),
+			spec.QueueURI, err.Error()))
+	} else if queue.IsMetricQueueService(queueServiceName) {
+		if spec.Query == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("query"),
+				"must be specified when the queueURI is a prometheus or datadog url"))
+		}
+		if queueServiceName == queue.DatadogQueueService && spec.CredentialsSecretRef == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("credentialsSecretRef"),
+				"must be specified when the queueURI is a datadog url, the secret should have the DD_API_KEY and DD_APP_KEY"))
+		}
+	} else if spec.Query != "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("query"),
+			spec.Query, "only supported when the queueURI is a prometheus or datadog url"))
+	}
+
+	if spec.SafetyQueue != nil {
+		safetyQueuePath := fldPath.Child("safetyQueue")
+		if err := queue.ValidateQueueURI(spec.SafetyQueue.QueueURI); err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				safetyQueuePath.Child("queueURI"),
+				spec.SafetyQueue.QueueURI, err.Error()))
+		}
+		if spec.SafetyQueue.Threshold != nil && *spec.SafetyQueue.Threshold < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				safetyQueuePath.Child("threshold"),
+				*spec.SafetyQueue.Threshold, "must be greater than or equal to 0"))
+		}
+	}
+
+	if spec.PanicThreshold != nil && *spec.PanicThreshold <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("panicThreshold"),
+			*spec.PanicThreshold, "must be greater than 0"))
+	}
+	if spec.FastScaleUpThreshold != nil && *spec.FastScaleUpThreshold <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("fastScaleUpThreshold"),
+			*spec.FastScaleUpThreshold, "must be greater than 0"))
+	}
+	if spec.PanicWindowSeconds != nil && *spec.PanicWindowSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("panicWindowSeconds"),
+			*spec.PanicWindowSeconds, "must be greater than or equal to 0"))
+	}
+	if spec.ScaleDownDelaySeconds != nil && *spec.ScaleDownDelaySeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleDownDelaySeconds"),
+			*spec.ScaleDownDelaySeconds, "must be greater than or equal to 0"))
+	}
+
+	if spec.ScaleUpDelaySeconds != nil && *spec.ScaleUpDelaySeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleUpDelaySeconds"),
+			*spec.ScaleUpDelaySeconds, "must be greater than or equal to 0"))
+	}
+
+	if spec.WarmFloor != nil && *spec.WarmFloor < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("warmFloor"),
+			*spec.WarmFloor, "must be greater than or equal to 0"))
+	}
+
+	if spec.ColdStartReplicas != nil && *spec.ColdStartReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("coldStartReplicas"),
+			*spec.ColdStartReplicas, "must be greater than or equal to 0"))
+	}
+
+	if spec.InitialStabilizationSeconds != nil && *spec.InitialStabilizationSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("initialStabilizationSeconds"),
+			*spec.InitialStabilizationSeconds, "must be greater than or equal to 0"))
+	}
+
+	if spec.ScalingGroup != nil {
+		scalingGroupPath := fldPath.Child("scalingGroup")
+		if spec.ScalingGroup.Name == "" {
+			allErrs = append(allErrs, field.Required(
+				scalingGroupPath.Child("name"), ""))
+		}
+		if spec.ScalingGroup.MaxReplicas < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				scalingGroupPath.Child("maxReplicas"),
+				spec.ScalingGroup.MaxReplicas, "must be greater than or equal to 0"))
+		}
+	}
+
+	if spec.IdleSinceAnnotation != "" {
+		idleSincePath := fldPath.Child("idleSinceAnnotation")
+		for _, msg := range validation.IsQualifiedName(spec.IdleSinceAnnotation) {
+			allErrs = append(allErrs, field.Invalid(idleSincePath,
+				spec.IdleSinceAnnotation, msg))
+		}
+		if spec.PreferIdlePodsOnScaleDown == nil || !*spec.PreferIdlePodsOnScaleDown {
+			allErrs = append(allErrs, field.Invalid(idleSincePath,
+				spec.IdleSinceAnnotation, "requires preferIdlePodsOnScaleDown"))
+		}
+	}
+
+	if spec.IdleSource != nil {
+		allErrs = append(allErrs, validateIdleSource(
+			spec.IdleSource, fldPath.Child("idleSource"))...)
+	}
+
+	if spec.MessageWeights != nil {
+		allErrs = append(allErrs, validateMessageWeights(
+			spec.MessageWeights, fldPath.Child("messageWeights"))...)
+	}
+
+	if spec.BacklogAgeSLOSeconds != nil {
+		sloPath := fldPath.Child("backlogAgeSLOSeconds")
+		if *spec.BacklogAgeSLOSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(sloPath,
+				*spec.BacklogAgeSLOSeconds, "must be greater than 0"))
+		}
+		if queueServiceName != queue.SqsQueueService {
+			allErrs = append(allErrs, field.Invalid(sloPath,
+				*spec.BacklogAgeSLOSeconds, "only supported when the queueURI is a sqs url"))
+		}
+		if spec.SQS != nil && spec.SQS.QueuePrefix != "" {
+			allErrs = append(allErrs, field.Forbidden(sloPath,
+				"not supported with sqs.queuePrefix"))
+		}
+	}
+
+	switch spec.RoundingStrategy {
+	case "", v1.RoundingStrategyCeil, v1.RoundingStrategyRound, v1.RoundingStrategyFloor:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("roundingStrategy"),
+			spec.RoundingStrategy, []string{
+				v1.RoundingStrategyCeil,
+				v1.RoundingStrategyRound,
+				v1.RoundingStrategyFloor,
+			}))
+	}
+
+	switch spec.NoBacklogStrategy {
+	case "", v1.NoBacklogStrategyScaleToMin, v1.NoBacklogStrategyHoldCurrent:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("noBacklogStrategy"),
+			spec.NoBacklogStrategy, []string{
+				v1.NoBacklogStrategyScaleToMin,
+				v1.NoBacklogStrategyHoldCurrent,
+			}))
+	}
+
+	if spec.SQS != nil {
+		allErrs = append(allErrs, validateSQSOptions(
+			spec.SQS, fldPath.Child("sqs"))...)
+		if spec.SQS.QueuePrefix != "" && queueServiceName != queue.SqsQueueService {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sqs", "queuePrefix"),
+				spec.SQS.QueuePrefix, "only supported when the queueURI is a sqs url"))
+		}
+		if spec.SQS.QueuePrefix != "" && spec.MessageWeights != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("messageWeights"),
+				"not supported with sqs.queuePrefix"))
+		}
+	}
+
+	allErrs = append(allErrs, validateSchedules(
+		spec.Schedules, fldPath.Child("schedules"))...)
+	allErrs = append(allErrs, validateConservativeWindows(
+		spec.ConservativeWindows, fldPath.Child("conservativeWindows"))...)
+
+	return allErrs
+}
+
+// ValidateWorkerPodAutoScalerDefault validates the spec of the cluster
+// default of the WPAs, the controller ignores a default which is not valid
+func ValidateWorkerPodAutoScalerDefault(
+	wpaDefault *v1.WorkerPodAutoScalerDefault) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	fldPath := field.NewPath("spec")
+	spec := wpaDefault.Spec
+	if spec.MaxDisruption != nil {
+		allErrs = append(allErrs, validateMaxDisruption(
+			*spec.MaxDisruption, fldPath.Child("maxDisruption"))...)
+	}
+	for name, delay := range map[string]*int32{
+		"scaleDownDelaySeconds": spec.ScaleDownDelaySeconds,
+		"scaleUpDelaySeconds":   spec.ScaleUpDelaySeconds,
+	} {
+		if delay != nil && *delay < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(name),
+				*delay, "must be greater than or equal to 0"))
+		}
+	}
+	if spec.Tolerance != nil && *spec.Tolerance < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("tolerance"),
+			*spec.Tolerance, "must be greater than or equal to 0"))
+	}
+	if spec.SQS != nil {
+		sqsPath := fldPath.Child("sqs")
+		allErrs = append(allErrs, validateSQSOptions(spec.SQS, sqsPath)...)
+		if spec.SQS.QueuePrefix != "" {
+			allErrs = append(allErrs, field.Forbidden(sqsPath.Child("queuePrefix"),
+				"not supported in the default"))
+		}
+	}
+	return allErrs
+}
+
+// validateSQSOptions checks the wait time is allowed by SQS, the
+// queue attributes are supported by the poll, the cap of the
+// discovered queues is allowed by ListQueues and the guard polls
+// of the zero backlog are positive
+func validateSQSOptions(
+	options *v1.SQSOptions, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	if options.WaitTimeSeconds != nil && (*options.WaitTimeSeconds < 0 ||
+		*options.WaitTimeSeconds > queue.SQSMaxWaitTimeSeconds) {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("waitTimeSeconds"), *options.WaitTimeSeconds,
+			fmt.Sprintf("must be between 0 and %d", queue.SQSMaxWaitTimeSeconds)))
+	}
+	for i, name := range options.QueueAttributes {
+		if !queue.IsSupportedSQSQueueAttribute(name) {
+			allErrs = append(allErrs, field.NotSupported(
+				fldPath.Child("queueAttributes").Index(i),
+				name, queue.SupportedSQSQueueAttributes))
+		}
+	}
+	if options.MaxDiscoveredQueues != nil && (*options.MaxDiscoveredQueues < 1 ||
+		*options.MaxDiscoveredQueues > queue.SQSMaxDiscoveredQueues) {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("maxDiscoveredQueues"), *options.MaxDiscoveredQueues,
+			fmt.Sprintf("must be between 1 and %d", queue.SQSMaxDiscoveredQueues)))
+	}
+	if options.BacklogStalenessGuardPolls != nil && *options.BacklogStalenessGuardPolls < 1 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("backlogStalenessGuardPolls"), *options.BacklogStalenessGuardPolls,
+			"must be greater than 0"))
+	}
+	return allErrs
+}
+
+// validateSchedules checks the names of the rules are unique, the
+// cron expressions parse in their time zone and the bounds are valid
+func validateSchedules(
+	rules []v1.ScheduleRule, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	names := make(map[string]bool)
+	for i, rule := range rules {
+		rulePath := fldPath.Index(i)
+		if rule.Name == "" {
+			allErrs = append(allErrs, field.Required(rulePath.Child("name"), ""))
+		} else if names[rule.Name] {
+			allErrs = append(allErrs, field.Duplicate(rulePath.Child("name"), rule.Name))
+		}
+		names[rule.Name] = true
+
+		if _, err := schedule.Parse(rule); err != nil {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("schedule"),
+				rule.Schedule, err.Error()))
+		}
+		if rule.DurationSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("durationSeconds"),
+				rule.DurationSeconds, "must be greater than 0"))
+		}
+		if rule.MinReplicas != nil && *rule.MinReplicas < 0 {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("minReplicas"),
+				*rule.MinReplicas, "must be greater than or equal to 0"))
+		}
+		if rule.MaxReplicas != nil && *rule.MaxReplicas < 0 {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("maxReplicas"),
+				*rule.MaxReplicas, "must be greater than or equal to 0"))
+		}
+		if rule.MinReplicas != nil && rule.MaxReplicas != nil &&
+			*rule.MinReplicas > *rule.MaxReplicas {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("minReplicas"),
+				*rule.MinReplicas, "must be less than or equal to maxReplicas"))
+		}
+	}
+	return allErrs
+}
+
+// validateConservativeWindows checks the names of the windows are
+// unique, the cron expressions parse in their time zone and the
+// tolerance is not negative
+func validateConservativeWindows(
+	windows []v1.ConservativeWindow, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	names := make(map[string]bool)
+	for i, window := range windows {
+		windowPath := fldPath.Index(i)
+		if window.Name == "" {
+			allErrs = append(allErrs, field.Required(windowPath.Child("name"), ""))
+		} else if names[window.Name] {
+			allErrs = append(allErrs, field.Duplicate(windowPath.Child("name"), window.Name))
+		}
+		names[window.Name] = true
+
+		if _, err := schedule.ParseWindow(window); err != nil {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("schedule"),
+				window.Schedule, err.Error()))
+		}
+		if window.DurationSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("durationSeconds"),
+				window.DurationSeconds, "must be greater than 0"))
+		}
+		if window.Tolerance != nil && *window.Tolerance < 0 {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("tolerance"),
+				*window.Tolerance, "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
+
+// validateMessageWeights checks the attribute name is set and
+// the weights are not negative
+func validateMessageWeights(
+	messageWeights *v1.MessageWeights, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	if messageWeights.MessageAttributeName == "" {
+		allErrs = append(allErrs, field.Required(
+			fldPath.Child("messageAttributeName"), ""))
+	}
+	for messageType, weight := range messageWeights.Weights {
+		if weight < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				fldPath.Child("weights").Key(messageType),
+				weight, "must be greater than or equal to 0"))
+		}
+	}
+	if messageWeights.DefaultWeight != nil && *messageWeights.DefaultWeight < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("defaultWeight"),
+			*messageWeights.DefaultWeight, "must be greater than or equal to 0"))
+	}
+	return allErrs
+}
+
+// validateTargetRef checks the kind is supported and exactly one of
+// name or labelSelector is set
+func validateTargetRef(
+	targetRef *v1.TargetRef, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	switch targetRef.Kind {
+	case v1.TargetKindDeployment, v1.TargetKindReplicaSet, v1.TargetKindStatefulSet:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("kind"),
+			targetRef.Kind, []string{
+				v1.TargetKindDeployment,
+				v1.TargetKindReplicaSet,
+				v1.TargetKindStatefulSet,
+			}))
+	}
+
+	if targetRef.Name == "" && targetRef.LabelSelector == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"),
+			"one of name or labelSelector must be specified"))
+	}
+	if targetRef.Name != "" && targetRef.LabelSelector != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("labelSelector"),
+			"only one of name or labelSelector may be specified"))
+	}
+	if targetRef.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(targetRef.LabelSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				fldPath.Child("labelSelector"), targetRef.LabelSelector, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateWeightedTargets checks the kind, the name and the weight of
+// every target, its bounds and that no workload is listed twice
+func validateWeightedTargets(
+	targets []v1.WeightedTarget, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	seen := make(map[string]bool)
+	for i, target := range targets {
+		idxPath := fldPath.Index(i)
+		switch target.Kind {
+		case v1.TargetKindDeployment, v1.TargetKindReplicaSet, v1.TargetKindStatefulSet:
+		default:
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("kind"),
+				target.Kind, []string{
+					v1.TargetKindDeployment,
+					v1.TargetKindReplicaSet,
+					v1.TargetKindStatefulSet,
+				}))
+		}
+		if target.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if seen[target.Kind+"/"+target.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), target.Name))
+		}
+		seen[target.Kind+"/"+target.Name] = true
+		if target.Weight < 1 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("weight"),
+				target.Weight, "must be greater than 0"))
+		}
+		if target.MinReplicas != nil && *target.MinReplicas < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("minReplicas"),
+				*target.MinReplicas, "must be greater than or equal to 0"))
+		}
+		if target.MinReplicas != nil && target.MaxReplicas != nil &&
+			*target.MaxReplicas < *target.MinReplicas {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("maxReplicas"),
+				*target.MaxReplicas, "must be greater than or equal to minReplicas"))
+		}
+	}
+	return allErrs
+}
+
+// validateIdleSource checks the port, path and concurrency of the
+// endpoint of the idle workers
+func validateIdleSource(
+	idleSource *v1.IdleSource, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsValidPortNum(int(idleSource.Port)) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"),
+			idleSource.Port, msg))
+	}
+	if idleSource.Path != "" && !strings.HasPrefix(idleSource.Path, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("path"),
+			idleSource.Path, "must start with /"))
+	}
+	if idleSource.Concurrency != nil && *idleSource.Concurrency < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("concurrency"),
+			*idleSource.Concurrency, "must be greater than 0"))
+	}
+	return allErrs
+}
+
+// validateScaleTargetRef checks the apiVersion, kind and name are set
+func validateScaleTargetRef(
+	scaleTargetRef *v1.ScaleTargetRef, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	if scaleTargetRef.APIVersion == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("apiVersion"), ""))
+	} else if _, err := schema.ParseGroupVersion(scaleTargetRef.APIVersion); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("apiVersion"),
+			scaleTargetRef.APIVersion, err.Error()))
+	}
+	if scaleTargetRef.Kind == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("kind"), ""))
+	}
+	if scaleTargetRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	}
+	return allErrs
+}
+
+// validateMaxDisruption checks maxDisruption is a non negative integer
+// or a percentage
+func validateMaxDisruption(
+	maxDisruption string, fldPath *field.Path) field.ErrorList {
+
+	allErrs := field.ErrorList{}
+	maxDisruptionIntOrStr := intstr.Parse(maxDisruption)
+	value, err := intstr.GetValueFromIntOrPercent(
+		&maxDisruptionIntOrStr, 100, true,
+	)
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath, maxDisruption,
+			"must be an integer or a percentage, e.g. 2 or 10%"))
+	}
+	if value < 0 {
+		return append(allErrs, field.Invalid(fldPath, maxDisruption,
+			"must be greater than or equal to 0"))
+	}
+	// an integer is the number of workers, so only a percentage is bounded
+	if maxDisruptionIntOrStr.Type == intstr.String && value > 100 {
+		return append(allErrs, field.Invalid(fldPath, maxDisruption,
+			"must be less than or equal to 100%"))
+	}
+	return allErrs
+}
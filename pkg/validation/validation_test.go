@@ -0,0 +1,631 @@
+package validation_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/validation"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func validWorkerPodAutoScaler() *v1.WorkerPodAutoScaler {
+	return &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			MinReplicas:             int32Ptr(0),
+			MaxReplicas:             int32Ptr(10),
+			MaxDisruption:           stringPtr("10%"),
+			QueueURI:                "https://sqs.ap-south-1.amazonaws.com/123456789/otpsender",
+			DeploymentName:          "otpsender",
+			TargetMessagesPerWorker: int32Ptr(2),
+		},
+	}
+}
+
+func TestValidateWorkerPodAutoScaler(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(wpa *v1.WorkerPodAutoScaler)
+		errors int
+	}{
+		{
+			name:   "valid sqs",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {},
+			errors: 0,
+		},
+		{
+			name: "valid beanstalk",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "beanstalk://beanstalkd:11300/otpsender"
+			},
+			errors: 0,
+		},
+		{
+			name: "no target",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+			},
+			errors: 1,
+		},
+		{
+			name: "both targets",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.ReplicaSetName = "otpsender"
+			},
+			errors: 1,
+		},
+		{
+			name: "valid targetRef with labelSelector",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+				wpa.Spec.TargetRef = &v1.TargetRef{
+					Kind: v1.TargetKindStatefulSet,
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "otpsender"},
+					},
+				}
+			},
+			errors: 0,
+		},
+		{
+			name: "targetRef with deploymentName",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.TargetRef = &v1.TargetRef{
+					Kind: v1.TargetKindDeployment,
+					Name: "otpsender",
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "targetRef with name and labelSelector",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+				wpa.Spec.TargetRef = &v1.TargetRef{
+					Kind: v1.TargetKindDeployment,
+					Name: "otpsender",
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "otpsender"},
+					},
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "targetRef with unsupported kind",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+				wpa.Spec.TargetRef = &v1.TargetRef{
+					Kind: "DaemonSet",
+					Name: "otpsender",
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "valid scaleTargetRef",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+				wpa.Spec.ScaleTargetRef = &v1.ScaleTargetRef{
+					APIVersion: "argoproj.io/v1alpha1",
+					Kind:       "Rollout",
+					Name:       "otpsender",
+				}
+			},
+			errors: 0,
+		},
+		{
+			name: "scaleTargetRef with deploymentName and no kind",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.ScaleTargetRef = &v1.ScaleTargetRef{
+					APIVersion: "argoproj.io/v1alpha1",
+					Name:       "otpsender",
+				}
+			},
+			errors: 2,
+		},
+		{
+			name: "negative message weight",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MessageWeights = &v1.MessageWeights{
+					MessageAttributeName: "type",
+					Weights: map[string]float64{
+						"video": 10.0,
+						"email": -1.0,
+					},
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "scalingGroup without name",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.ScalingGroup = &v1.ScalingGroup{MaxReplicas: 20}
+			},
+			errors: 1,
+		},
+		{
+			name: "negative scaleDownDelaySeconds",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.ScaleDownDelaySeconds = int32Ptr(-1)
+			},
+			errors: 1,
+		},
+		{
+			name: "valid schedule",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.Schedules = []v1.ScheduleRule{{
+					Name:            "morning",
+					Schedule:        "0 8 * * 1-5",
+					TimeZone:        "Asia/Kolkata",
+					DurationSeconds: 7200,
+					MinReplicas:     int32Ptr(5),
+				}}
+			},
+			errors: 0,
+		},
+		{
+			name: "invalid schedules",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.Schedules = []v1.ScheduleRule{
+					{Name: "morning", Schedule: "0 25 * * *", DurationSeconds: 60},
+					{Name: "morning", Schedule: "0 8 * * *"},
+				}
+			},
+			errors: 3,
+		},
+		{
+			name: "valid conservative window",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				tolerance := 0.5
+				wpa.Spec.ConservativeWindows = []v1.ConservativeWindow{{
+					Name:            "maintenance",
+					Schedule:        "0 2 * * *",
+					DurationSeconds: 3600,
+					Tolerance:       &tolerance,
+				}}
+			},
+			errors: 0,
+		},
+		{
+			name: "invalid conservative windows",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				tolerance := -0.1
+				wpa.Spec.ConservativeWindows = []v1.ConservativeWindow{
+					{Name: "maintenance", Schedule: "@every 1h", DurationSeconds: 60},
+					{Name: "maintenance", Schedule: "0 2 * * *", Tolerance: &tolerance},
+				}
+			},
+			errors: 4,
+		},
+		{
+			name: "negative scaleUpDelaySeconds",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.ScaleUpDelaySeconds = int32Ptr(-1)
+			},
+			errors: 1,
+		},
+		{
+			name: "negative warmFloor",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.WarmFloor = int32Ptr(-1)
+			},
+			errors: 1,
+		},
+		{
+			name: "throughputMode without secondsToProcessOneJob",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				throughputMode := true
+				wpa.Spec.ThroughputMode = &throughputMode
+			},
+			errors: 1,
+		},
+		{
+			name: "throughputMode with secondsToProcessOneJob",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				throughputMode := true
+				secondsToProcessOneJob := 0.5
+				wpa.Spec.ThroughputMode = &throughputMode
+				wpa.Spec.SecondsToProcessOneJob = &secondsToProcessOneJob
+			},
+			errors: 0,
+		},
+		{
+			name: "sqs wait time above the sqs maximum",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.SQS = &v1.SQSOptions{WaitTimeSeconds: int32Ptr(21)}
+			},
+			errors: 1,
+		},
+		{
+			name: "unsupported sqs queue attribute",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.SQS = &v1.SQSOptions{
+					WaitTimeSeconds: int32Ptr(10),
+					QueueAttributes: []string{
+						"ApproximateNumberOfMessagesDelayed",
+						"VisibilityTimeout",
+					},
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "sqs queue prefix",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.SQS = &v1.SQSOptions{
+					QueuePrefix:         "otpsender-",
+					MaxDiscoveredQueues: int32Ptr(20),
+				}
+			},
+			errors: 0,
+		},
+		{
+			name: "sqs max discovered queues above the ListQueues maximum",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.SQS = &v1.SQSOptions{
+					QueuePrefix:         "otpsender-",
+					MaxDiscoveredQueues: int32Ptr(1001),
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "sqs backlog staleness guard",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.SQS = &v1.SQSOptions{BacklogStalenessGuardPolls: int32Ptr(3)}
+			},
+			errors: 0,
+		},
+		{
+			name: "sqs backlog staleness guard of zero polls",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.SQS = &v1.SQSOptions{BacklogStalenessGuardPolls: int32Ptr(0)}
+			},
+			errors: 1,
+		},
+		{
+			name: "sqs queue prefix with a beanstalk queue",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "beanstalk://beanstalkd:11300/otpsender"
+				wpa.Spec.SQS = &v1.SQSOptions{QueuePrefix: "otpsender-"}
+			},
+			errors: 1,
+		},
+		{
+			name: "min greater than max",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MinReplicas = int32Ptr(11)
+			},
+			errors: 1,
+		},
+		{
+			name: "invalid maxDisruption",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MaxDisruption = stringPtr("ten%")
+			},
+			errors: 1,
+		},
+		{
+			name: "maxDisruption above 100%",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MaxDisruption = stringPtr("150%")
+			},
+			errors: 1,
+		},
+		{
+			name: "maxDisruption in workers above 100",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MaxDisruption = stringPtr("150")
+			},
+			errors: 0,
+		},
+		{
+			name: "negative maxDisruption",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MaxDisruption = stringPtr("-2")
+			},
+			errors: 1,
+		},
+		{
+			name: "valid prometheus",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "http://prometheus.monitoring:9090"
+				wpa.Spec.Query = "sum(pending_jobs{app=\"otpsender\"})"
+			},
+			errors: 0,
+		},
+		{
+			name: "prometheus without query",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "http://prometheus.monitoring:9090"
+			},
+			errors: 1,
+		},
+		{
+			name: "valid datadog",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "https://api.datadoghq.com"
+				wpa.Spec.Query = "sum:jobs.pending{app:otpsender}"
+				wpa.Spec.CredentialsSecretRef = &v1.SecretReference{Name: "datadog-keys"}
+			},
+			errors: 0,
+		},
+		{
+			name: "credentials in the namespace of the wpa",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Namespace = "payments"
+				wpa.Spec.CredentialsSecretRef = &v1.SecretReference{
+					Namespace: "payments",
+					Name:      "sqs-keys",
+				}
+			},
+			errors: 0,
+		},
+		{
+			name: "credentials in another namespace",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Namespace = "payments"
+				wpa.Spec.CredentialsSecretRef = &v1.SecretReference{
+					Namespace: "kube-system",
+					Name:      "sqs-keys",
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "datadog without credentials",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "https://api.datadoghq.com"
+				wpa.Spec.Query = "sum:jobs.pending{app:otpsender}"
+			},
+			errors: 1,
+		},
+		{
+			name: "idleSinceAnnotation with preferIdlePodsOnScaleDown",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				preferIdlePods := true
+				wpa.Spec.PreferIdlePodsOnScaleDown = &preferIdlePods
+				wpa.Spec.IdleSinceAnnotation = "example.com/idle-since"
+			},
+			errors: 0,
+		},
+		{
+			name: "idleSinceAnnotation without preferIdlePodsOnScaleDown",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.IdleSinceAnnotation = "example.com/idle-since"
+			},
+			errors: 1,
+		},
+		{
+			name: "invalid idleSinceAnnotation",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				preferIdlePods := true
+				wpa.Spec.PreferIdlePodsOnScaleDown = &preferIdlePods
+				wpa.Spec.IdleSinceAnnotation = "idle since"
+			},
+			errors: 1,
+		},
+		{
+			name: "valid idleSource",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.IdleSource = &v1.IdleSource{
+					Port:        9090,
+					Path:        "/idle",
+					Concurrency: int32Ptr(4),
+				}
+			},
+			errors: 0,
+		},
+		{
+			name: "idleSource with no port and zero concurrency",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.IdleSource = &v1.IdleSource{
+					Path:        "idle",
+					Concurrency: int32Ptr(0),
+				}
+			},
+			errors: 3,
+		},
+		{
+			name: "negative coldStartReplicas",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.ColdStartReplicas = int32Ptr(-1)
+			},
+			errors: 1,
+		},
+		{
+			name: "valid weightedTargets",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+				wpa.Spec.WeightedTargets = []v1.WeightedTarget{
+					{Kind: v1.TargetKindDeployment, Name: "cpu", Weight: 3},
+					{Kind: v1.TargetKindDeployment, Name: "memory", Weight: 1,
+						MinReplicas: int32Ptr(1), MaxReplicas: int32Ptr(5)},
+				}
+			},
+			errors: 0,
+		},
+		{
+			name: "invalid weightedTargets",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.DeploymentName = ""
+				wpa.Spec.WeightedTargets = []v1.WeightedTarget{
+					{Kind: "Rollout", Name: "cpu", Weight: 0},
+					{Kind: v1.TargetKindDeployment, Name: "memory", Weight: 1,
+						MinReplicas: int32Ptr(5), MaxReplicas: int32Ptr(1)},
+					{Kind: v1.TargetKindDeployment, Name: "memory", Weight: 1},
+				}
+			},
+			errors: 4,
+		},
+		{
+			name: "weightedTargets with deploymentName",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.WeightedTargets = []v1.WeightedTarget{
+					{Kind: v1.TargetKindDeployment, Name: "cpu", Weight: 1},
+				}
+			},
+			errors: 1,
+		},
+		{
+			name: "zero fastScaleUpThreshold",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				threshold := 0.0
+				wpa.Spec.FastScaleUpThreshold = &threshold
+			},
+			errors: 1,
+		},
+		{
+			name: "negative initialStabilizationSeconds",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.InitialStabilizationSeconds = int32Ptr(-1)
+			},
+			errors: 1,
+		},
+		{
+			name: "valid backlogAgeSLOSeconds",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.BacklogAgeSLOSeconds = int32Ptr(300)
+			},
+			errors: 0,
+		},
+		{
+			name: "zero backlogAgeSLOSeconds",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.BacklogAgeSLOSeconds = int32Ptr(0)
+			},
+			errors: 1,
+		},
+		{
+			name: "backlogAgeSLOSeconds with beanstalk",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "beanstalk://beanstalkd:11300/otpsender"
+				wpa.Spec.BacklogAgeSLOSeconds = int32Ptr(300)
+			},
+			errors: 1,
+		},
+		{
+			name: "floor roundingStrategy",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.RoundingStrategy = v1.RoundingStrategyFloor
+			},
+			errors: 0,
+		},
+		{
+			name: "unsupported roundingStrategy",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.RoundingStrategy = "Truncate"
+			},
+			errors: 1,
+		},
+		{
+			name: "holdCurrent noBacklogStrategy",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.NoBacklogStrategy = v1.NoBacklogStrategyHoldCurrent
+			},
+			errors: 0,
+		},
+		{
+			name: "unsupported noBacklogStrategy",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.NoBacklogStrategy = "ScaleToZero"
+			},
+			errors: 1,
+		},
+		{
+			name: "query with sqs",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.Query = "sum(pending_jobs)"
+			},
+			errors: 1,
+		},
+		{
+			name: "unsupported queue service",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.QueueURI = "amqp://rabbitmq:5672/otpsender"
+			},
+			errors: 1,
+		},
+		{
+			name: "missing required fields",
+			mutate: func(wpa *v1.WorkerPodAutoScaler) {
+				wpa.Spec.MinReplicas = nil
+				wpa.Spec.TargetMessagesPerWorker = nil
+				wpa.Spec.QueueURI = ""
+			},
+			errors: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		wpa := validWorkerPodAutoScaler()
+		tc.mutate(wpa)
+		errs := validation.ValidateWorkerPodAutoScaler(wpa)
+		if len(errs) != tc.errors {
+			t.Errorf("%s: expected %d errors, got=%v", tc.name, tc.errors, errs)
+		}
+	}
+}
+
+func TestValidateWorkerPodAutoScalerDefault(t *testing.T) {
+	tolerance := -0.1
+	waitTime := int32(30)
+	tests := []struct {
+		name   string
+		spec   v1.WorkerPodAutoScalerDefaultSpec
+		errors int
+	}{
+		{
+			name: "valid",
+			spec: v1.WorkerPodAutoScalerDefaultSpec{
+				MaxDisruption:         stringPtr("10%"),
+				ScaleDownDelaySeconds: int32Ptr(300),
+			},
+			errors: 0,
+		},
+		{
+			name: "invalid maxDisruption",
+			spec: v1.WorkerPodAutoScalerDefaultSpec{
+				MaxDisruption: stringPtr("ten"),
+			},
+			errors: 1,
+		},
+		{
+			name: "negative delay and tolerance",
+			spec: v1.WorkerPodAutoScalerDefaultSpec{
+				ScaleUpDelaySeconds: int32Ptr(-1),
+				Tolerance:           &tolerance,
+			},
+			errors: 2,
+		},
+		{
+			name: "sqs wait time and queue prefix",
+			spec: v1.WorkerPodAutoScalerDefaultSpec{
+				SQS: &v1.SQSOptions{
+					WaitTimeSeconds: &waitTime,
+					QueuePrefix:     "otp",
+				},
+			},
+			errors: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := validation.ValidateWorkerPodAutoScalerDefault(
+				&v1.WorkerPodAutoScalerDefault{Spec: test.spec})
+			if len(errs) != test.errors {
+				t.Errorf("expected %d errors, got=%v", test.errors, errs)
+			}
+		})
+	}
+}
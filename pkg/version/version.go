@@ -4,6 +4,14 @@ package version
 // real value during build.
 var Version = "UNKNOWN"
 
+// GitCommit is the commit the binary is built from, substituted
+// with a real value during build like the Version.
+var GitCommit = "UNKNOWN"
+
 func GetVersion() string {
 	return Version
 }
+
+func GetGitCommit() string {
+	return GitCommit
+}
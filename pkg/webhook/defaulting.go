@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"time"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// SetDefaults sets the optional fields of the WPA spec which are not set
+// to the values used by the controller. The controller falls back to the
+// same values for the WPAs created before the webhook was installed.
+// maxDisruption and scaleDownDelaySeconds are not set, they are resolved
+// by the controller from the WorkerPodAutoScalerDefault and its flags,
+// and the default would not apply to the WPAs they are stamped on.
+func SetDefaults(wpa *v1.WorkerPodAutoScaler) {
+	spec := &wpa.Spec
+
+	if spec.SecondsToProcessOneJob == nil {
+		spec.SecondsToProcessOneJob = float64Ptr(0.0)
+	}
+	if spec.DisableVelocityMinWorkers == nil {
+		spec.DisableVelocityMinWorkers = boolPtr(wpa.GetDisableVelocityMinWorkers())
+	}
+	if spec.AutoEstimateProcessingTime == nil {
+		spec.AutoEstimateProcessingTime = boolPtr(wpa.GetAutoEstimateProcessingTime())
+	}
+	if spec.PreferIdlePodsOnScaleDown == nil {
+		spec.PreferIdlePodsOnScaleDown = boolPtr(wpa.GetPreferIdlePodsOnScaleDown())
+	}
+	if spec.PanicWindowSeconds == nil {
+		spec.PanicWindowSeconds = int32Ptr(int32(wpa.GetPanicWindow() / time.Second))
+	}
+	if spec.SafetyQueue != nil && spec.SafetyQueue.Threshold == nil {
+		spec.SafetyQueue.Threshold = int32Ptr(spec.SafetyQueue.GetThreshold())
+	}
+	if spec.MessageWeights != nil && spec.MessageWeights.DefaultWeight == nil {
+		spec.MessageWeights.DefaultWeight = float64Ptr(
+			spec.MessageWeights.GetDefaultWeight())
+	}
+}
+
+// patchOperation is a JSON patch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// createPatch returns the JSON patch which adds the fields
+// set by SetDefaults on the original WPA
+func createPatch(
+	original *v1.WorkerPodAutoScaler,
+	defaulted *v1.WorkerPodAutoScaler) []patchOperation {
+
+	patch := []patchOperation{}
+	add := func(path string, originalNil bool, value interface{}) {
+		if originalNil {
+			patch = append(patch, patchOperation{
+				Op:    "add",
+				Path:  path,
+				Value: value,
+			})
+		}
+	}
+
+	o := &original.Spec
+	d := &defaulted.Spec
+	add("/spec/secondsToProcessOneJob",
+		o.SecondsToProcessOneJob == nil, d.SecondsToProcessOneJob)
+	add("/spec/disableVelocityMinWorkers",
+		o.DisableVelocityMinWorkers == nil, d.DisableVelocityMinWorkers)
+	add("/spec/autoEstimateProcessingTime",
+		o.AutoEstimateProcessingTime == nil, d.AutoEstimateProcessingTime)
+	add("/spec/preferIdlePodsOnScaleDown",
+		o.PreferIdlePodsOnScaleDown == nil, d.PreferIdlePodsOnScaleDown)
+	add("/spec/panicWindowSeconds",
+		o.PanicWindowSeconds == nil, d.PanicWindowSeconds)
+	if o.SafetyQueue != nil {
+		add("/spec/safetyQueue/threshold",
+			o.SafetyQueue.Threshold == nil, d.SafetyQueue.Threshold)
+	}
+	if o.MessageWeights != nil {
+		add("/spec/messageWeights/defaultWeight",
+			o.MessageWeights.DefaultWeight == nil, d.MessageWeights.DefaultWeight)
+	}
+	return patch
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
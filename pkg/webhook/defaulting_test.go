@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestSetDefaults(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			PanicWindowSeconds: int32Ptr(30),
+			SafetyQueue:        &v1.SafetyQueue{QueueURI: "beanstalk://beanstalkd:11300/dlq"},
+		},
+	}
+	SetDefaults(wpa)
+
+	spec := wpa.Spec
+	// the fields which the cluster default can set are left to the controller
+	if spec.MaxDisruption != nil || spec.ScaleDownDelaySeconds != nil {
+		t.Errorf("expected maxDisruption and scaleDownDelaySeconds not to be set, got=%v, %v\n",
+			spec.MaxDisruption, spec.ScaleDownDelaySeconds)
+	}
+	if *spec.PanicWindowSeconds != 30 {
+		t.Errorf("expected panicWindowSeconds to be kept at 30, got=%v\n", *spec.PanicWindowSeconds)
+	}
+	if *spec.DisableVelocityMinWorkers || *spec.AutoEstimateProcessingTime ||
+		*spec.PreferIdlePodsOnScaleDown {
+		t.Errorf("expected the booleans to default to false\n")
+	}
+	if *spec.SafetyQueue.Threshold != 0 {
+		t.Errorf("expected safetyQueue.threshold=0, got=%v\n", *spec.SafetyQueue.Threshold)
+	}
+	if spec.MessageWeights != nil {
+		t.Errorf("expected messageWeights not to be set\n")
+	}
+}
+
+func TestMutateHandler(t *testing.T) {
+	wpa := &v1.WorkerPodAutoScaler{
+		Spec: v1.WorkerPodAutoScalerSpec{
+			MaxDisruption:             stringPtr("10%"),
+			SecondsToProcessOneJob:    float64Ptr(0.5),
+			DisableVelocityMinWorkers: boolPtr(true),
+			PanicWindowSeconds:        int32Ptr(60),
+		},
+	}
+	raw, _ := json.Marshal(wpa)
+	review := admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:    types.UID("1"),
+			Object: runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	recorder := httptest.NewRecorder()
+	NewMutateHandler().ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got=%v\n", recorder.Code)
+	}
+
+	response := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unable to decode the response: %v\n", err)
+	}
+	if !response.Response.Allowed || response.Response.UID != "1" {
+		t.Errorf("expected the request 1 to be allowed, got=%+v\n", response.Response)
+	}
+
+	var patch []patchOperation
+	if err := json.Unmarshal(response.Response.Patch, &patch); err != nil {
+		t.Fatalf("unable to decode the patch: %v\n", err)
+	}
+	paths := map[string]bool{}
+	for _, operation := range patch {
+		paths[operation.Path] = true
+	}
+	expected := []string{
+		"/spec/autoEstimateProcessingTime",
+		"/spec/preferIdlePodsOnScaleDown",
+	}
+	if len(paths) != len(expected) {
+		t.Errorf("expected the patch to add %v, got=%v\n", expected, paths)
+	}
+	for _, path := range expected {
+		if !paths[path] {
+			t.Errorf("expected the patch to add %s, got=%v\n", path, paths)
+		}
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/practo/klog/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+// NewMutateHandler returns the handler of the mutating admission webhook
+// which stamps the defaults on the WPAs when they are created or updated
+func NewMutateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		review := admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		review.Response = mutate(review.Request)
+		review.Response.UID = review.Request.UID
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			klog.Errorf("Error writing admission review response: %v", err)
+		}
+	}
+}
+
+// mutate returns the response with the patch which stamps the defaults
+func mutate(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+
+	wpa := &v1.WorkerPodAutoScaler{}
+	if err := json.Unmarshal(request.Object.Raw, wpa); err != nil {
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("unable to decode the wpa: %v", err),
+			},
+		}
+	}
+
+	defaulted := wpa.DeepCopy()
+	SetDefaults(defaulted)
+	patch := createPatch(wpa, defaulted)
+	if len(patch) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("unable to create the patch: %v", err),
+			},
+		}
+	}
+	klog.V(4).Infof("%s/%s: defaults patch: %s",
+		request.Namespace, request.Name, string(patchBytes))
+
+	patchType := admissionv1.PatchTypeJSONPatch
+	return &admissionv1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patchBytes,
+		PatchType: &patchType,
+	}
+}
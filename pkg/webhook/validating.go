@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/practo/klog/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+	"github.com/practo/k8s-worker-pod-autoscaler/pkg/validation"
+)
+
+// NewValidateHandler returns the handler of the validating admission
+// webhook which rejects the WPAs which are not valid, e.g. with a
+// targetMessagesPerWorker which is not greater than 0
+func NewValidateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		review := admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		review.Response = validate(review.Request)
+		review.Response.UID = review.Request.UID
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			klog.Errorf("Error writing admission review response: %v", err)
+		}
+	}
+}
+
+// validate returns the response which rejects the WPA if it is not valid
+func validate(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	wpa := &v1.WorkerPodAutoScaler{}
+	if err := json.Unmarshal(request.Object.Raw, wpa); err != nil {
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("unable to decode the wpa: %v", err),
+			},
+		}
+	}
+	if wpa.Namespace == "" {
+		wpa.Namespace = request.Namespace
+	}
+
+	if errs := validation.ValidateWorkerPodAutoScaler(wpa); len(errs) > 0 {
+		klog.V(2).Infof("%s/%s: rejected: %v",
+			request.Namespace, request.Name, errs.ToAggregate())
+		return &admissionv1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInvalid,
+				Code:    http.StatusUnprocessableEntity,
+				Message: errs.ToAggregate().Error(),
+			},
+		}
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
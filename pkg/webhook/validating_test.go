@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/practo/k8s-worker-pod-autoscaler/pkg/apis/workerpodautoscaler/v1"
+)
+
+func TestValidateHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  *int32
+		allowed bool
+	}{
+		{"valid target", int32Ptr(10), true},
+		{"zero target", int32Ptr(0), false},
+		{"target not set", nil, false},
+	}
+
+	for _, test := range tests {
+		wpa := &v1.WorkerPodAutoScaler{
+			Spec: v1.WorkerPodAutoScalerSpec{
+				DeploymentName:          "otpsender",
+				MinReplicas:             int32Ptr(0),
+				MaxReplicas:             int32Ptr(10),
+				QueueURI:                "https://sqs.ap-south-1.amazonaws.com/123456789012/otpsender",
+				TargetMessagesPerWorker: test.target,
+			},
+		}
+		raw, _ := json.Marshal(wpa)
+		review := admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{
+				UID:    types.UID("1"),
+				Object: runtime.RawExtension{Raw: raw},
+			},
+		}
+		body, _ := json.Marshal(review)
+
+		recorder := httptest.NewRecorder()
+		NewValidateHandler().ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got=%v\n", test.name, recorder.Code)
+		}
+
+		response := admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: unable to decode the response: %v\n", test.name, err)
+		}
+		if response.Response.Allowed != test.allowed || response.Response.UID != "1" {
+			t.Errorf("%s: expected allowed=%v, got=%+v\n",
+				test.name, test.allowed, response.Response)
+		}
+	}
+}